@@ -197,6 +197,13 @@ func writeKeyFile(file string, content []byte) error {
 		os.Remove(f.Name())
 		return err
 	}
+	// Flush the content before the rename so a crash can't leave a truncated
+	// key file in place of the original one.
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
 	f.Close()
 	return os.Rename(f.Name(), file)
 }
@@ -17,6 +17,8 @@
 package keystore
 
 import (
+	"bytes"
+	"encoding/json"
 	"io/ioutil"
 	"math/rand"
 	"os"
@@ -68,6 +70,55 @@ func TestKeyStore(t *testing.T) {
 	}
 }
 
+func TestUpdate(t *testing.T) {
+	dir, ks := tmpKeyStore(t, true)
+	defer os.RemoveAll(dir)
+
+	a, err := ks.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	original, err := ioutil.ReadFile(a.URL.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A wrong passphrase must leave the key file untouched
+	if err := ks.Update(a, "wrong", "bar"); err != ErrDecrypt {
+		t.Fatalf("Update with wrong passphrase: have error %v, want %v", err, ErrDecrypt)
+	}
+	if content, _ := ioutil.ReadFile(a.URL.Path); !bytes.Equal(content, original) {
+		t.Fatalf("key file modified after failed update")
+	}
+	// A successful update re-encrypts the same key in place
+	if err := ks.Update(a, "foo", "bar"); err != nil {
+		t.Fatalf("Update error: %v", err)
+	}
+	updated, err := ioutil.ReadFile(a.URL.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := DecryptKey(updated, "bar")
+	if err != nil {
+		t.Fatalf("can't decrypt updated key file with the new passphrase: %v", err)
+	}
+	if key.Address != a.Address {
+		t.Errorf("address mismatch: have %x, want %x", key.Address, a.Address)
+	}
+	if _, err := DecryptKey(updated, "foo"); err != ErrDecrypt {
+		t.Errorf("old passphrase still decrypts the key file: %v", err)
+	}
+	var keyJSON encryptedKeyJSONV3
+	if err := json.Unmarshal(updated, &keyJSON); err != nil {
+		t.Fatal(err)
+	}
+	if n := int(keyJSON.Crypto.KDFParams["n"].(float64)); n != veryLightScryptN {
+		t.Errorf("scrypt N mismatch: have %d, want %d", n, veryLightScryptN)
+	}
+	if accs := ks.Accounts(); len(accs) != 1 || accs[0] != a {
+		t.Errorf("accounts mismatch after update: %v", accs)
+	}
+}
+
 func TestSign(t *testing.T) {
 	dir, ks := tmpKeyStore(t, true)
 	defer os.RemoveAll(dir)
@@ -381,7 +432,7 @@ func tmpKeyStore(t *testing.T, encrypted bool) (string, *KeyStore) {
 	}
 	factory := NewPlaintextKeyStore
 	if encrypted {
-		new = func(kd string) *KeyStore { return NewKeyStore(kd, veryLightScryptN, veryLightScryptP) }
+		factory = func(kd string) *KeyStore { return NewKeyStore(kd, veryLightScryptN, veryLightScryptP) }
 	}
 	return d, factory(d)
 }
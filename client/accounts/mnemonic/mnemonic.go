@@ -0,0 +1,129 @@
+// Package mnemonic implements BIP-39 mnemonic validation and seed generation,
+// along with the BIP-32 key derivation needed to recover accounts from them.
+package mnemonic
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/kowala-tech/kcoin/client/accounts"
+	"github.com/kowala-tech/kcoin/client/common/math"
+	"github.com/kowala-tech/kcoin/client/crypto"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+var (
+	ErrInvalidLength     = errors.New("mnemonic must have 12, 15, 18, 21 or 24 words")
+	ErrInvalidChecksum   = errors.New("invalid mnemonic checksum")
+	ErrNonASCIIPassword  = errors.New("non-ASCII mnemonic passphrases are not supported")
+	ErrInvalidDerivation = errors.New("derived key is invalid")
+)
+
+var (
+	wordlist  = strings.Fields(englishWords)
+	wordIndex = make(map[string]int, len(wordlist))
+)
+
+func init() {
+	for i, word := range wordlist {
+		wordIndex[word] = i
+	}
+}
+
+// Validate checks that the mnemonic consists of words from the English wordlist
+// and that its embedded checksum matches the encoded entropy.
+func Validate(mnemonic string) error {
+	words := strings.Fields(mnemonic)
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return ErrInvalidLength
+	}
+	// Every word encodes 11 bits, the last len(words)/3 of which are checksum
+	bits := new(big.Int)
+	for _, word := range words {
+		index, ok := wordIndex[word]
+		if !ok {
+			return fmt.Errorf("unknown mnemonic word %q", word)
+		}
+		bits.Lsh(bits, 11)
+		bits.Or(bits, big.NewInt(int64(index)))
+	}
+	checksumBits := uint(len(words) / 3)
+	checksum := new(big.Int).And(bits, big.NewInt(1<<checksumBits-1))
+	entropy := math.PaddedBigBytes(new(big.Int).Rsh(bits, checksumBits), int(checksumBits)*4)
+
+	hash := sha256.Sum256(entropy)
+	if uint64(hash[0]>>(8-checksumBits)) != checksum.Uint64() {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+// NewSeed validates the mnemonic and derives the 64 byte BIP-39 seed from it,
+// protected by the optional passphrase.
+func NewSeed(mnemonic, passphrase string) ([]byte, error) {
+	if err := Validate(mnemonic); err != nil {
+		return nil, err
+	}
+	// BIP-39 mandates NFKD normalisation, which is the identity for ASCII input
+	for _, r := range passphrase {
+		if r > 0x7f {
+			return nil, ErrNonASCIIPassword
+		}
+	}
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	return pbkdf2.Key([]byte(mnemonic), []byte("mnemonic"+passphrase), 2048, 64, sha512.New), nil
+}
+
+// DeriveKey derives the private key at the given BIP-32 path from a seed.
+func DeriveKey(seed []byte, path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	n := crypto.S256().Params().N
+
+	key, chain, err := split(hmacSHA512([]byte("Bitcoin seed"), seed), n)
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range path {
+		var data []byte
+		if index >= 0x80000000 {
+			data = append([]byte{0}, math.PaddedBigBytes(key, 32)...)
+		} else {
+			x, y := crypto.S256().ScalarBaseMult(math.PaddedBigBytes(key, 32))
+			data = crypto.CompressPubkey(&ecdsa.PublicKey{Curve: crypto.S256(), X: x, Y: y})
+		}
+		data = append(data, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(data[len(data)-4:], index)
+
+		tweak, next, err := split(hmacSHA512(chain, data), n)
+		if err != nil {
+			return nil, err
+		}
+		key = tweak.Add(tweak, key).Mod(tweak, n)
+		if key.Sign() == 0 {
+			return nil, ErrInvalidDerivation
+		}
+		chain = next
+	}
+	return crypto.ToECDSA(math.PaddedBigBytes(key, 32))
+}
+
+// split separates an HMAC output into a private key and a chain code.
+func split(sum []byte, n *big.Int) (*big.Int, []byte, error) {
+	key := new(big.Int).SetBytes(sum[:32])
+	if key.Sign() == 0 || key.Cmp(n) >= 0 {
+		return nil, nil, ErrInvalidDerivation
+	}
+	return key, sum[32:], nil
+}
+
+func hmacSHA512(key, data []byte) []byte {
+	mac := hmac.New(sha512.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,103 @@
+package mnemonic
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/kowala-tech/kcoin/client/accounts"
+	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWordlist(t *testing.T) {
+	require.Len(t, wordlist, 2048)
+	assert.Equal(t, "abandon", wordlist[0])
+	assert.Equal(t, "legal", wordlist[1019])
+	assert.Equal(t, "zoo", wordlist[2047])
+
+	prefixes := make(map[string]bool)
+	for i, word := range wordlist {
+		if i > 0 {
+			assert.True(t, wordlist[i-1] < word, word)
+		}
+		prefix := word
+		if len(prefix) > 4 {
+			prefix = prefix[:4]
+		}
+		assert.False(t, prefixes[prefix], word)
+		prefixes[prefix] = true
+	}
+}
+
+// Test vectors from https://github.com/trezor/python-mnemonic/blob/master/vectors.json
+func TestValidate(t *testing.T) {
+	for _, mnemonic := range []string{
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		"legal winner thank year wave sausage worth useful legal winner thank yellow",
+		"letter advice cage absurd amount doctor acoustic avoid letter advice cage above",
+		"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong",
+		"scheme spot photo card baby mountain device kick cradle pact join borrow",
+		"renew stay biology evidence goat welcome casual join adapt armor shuffle fault little machine walk stumble urge swap",
+	} {
+		assert.NoError(t, Validate(mnemonic), mnemonic)
+	}
+}
+
+func TestNewSeed(t *testing.T) {
+	seed, err := NewSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "TREZOR")
+	require.NoError(t, err)
+	assert.Equal(t, "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04", hex.EncodeToString(seed))
+}
+
+func TestValidateErrors(t *testing.T) {
+	assert.Equal(t, ErrInvalidLength, Validate(strings.Repeat("abandon ", 11)))
+	assert.Equal(t, ErrInvalidLength, Validate(strings.Repeat("abandon ", 13)))
+	assert.Equal(t, ErrInvalidChecksum, Validate(strings.Repeat("abandon ", 12)))
+	assert.Error(t, Validate(strings.Repeat("abandon ", 11)+"kowala"))
+
+	_, err := NewSeed(strings.Repeat("abandon ", 11)+"about", "pässword")
+	assert.Equal(t, ErrNonASCIIPassword, err)
+}
+
+// Test vector 1 from https://github.com/bitcoin/bips/blob/master/bip-0032.mediawiki
+func TestDeriveKey(t *testing.T) {
+	seed := common.FromHex("000102030405060708090a0b0c0d0e0f")
+
+	testCases := []struct {
+		path string
+		key  string
+	}{
+		{"m", "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35"},
+		{"m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+		{"m/0'/1", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"},
+		{"m/0'/1/2'", "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca"},
+		{"m/0'/1/2'/2", "0f479245fb19a38a1954c5c7c0ebab2f9bdfd96a17563ef28a6a4b1a2a764ef4"},
+		{"m/0'/1/2'/2/1000000000", "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8"},
+	}
+	for _, tc := range testCases {
+		var path accounts.DerivationPath
+		if tc.path != "m" {
+			var err error
+			path, err = accounts.ParseDerivationPath(tc.path)
+			require.NoError(t, err)
+		}
+		key, err := DeriveKey(seed, path)
+		require.NoError(t, err, tc.path)
+		assert.Equal(t, tc.key, hex.EncodeToString(crypto.FromECDSA(key)), tc.path)
+	}
+}
+
+func TestDeriveKeyFromMnemonic(t *testing.T) {
+	seed, err := NewSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "")
+	require.NoError(t, err)
+
+	path, err := accounts.ParseDerivationPath("m/44'/60'/0'/0/0")
+	require.NoError(t, err)
+
+	key, err := DeriveKey(seed, path)
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94"), crypto.PubkeyToAddress(key.PublicKey))
+}
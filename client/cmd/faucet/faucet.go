@@ -265,7 +265,7 @@ func newFaucet(genesis *core.Genesis, port int, enodes []*discv5.Node, network u
 
 	// Assemble the kcoinstats monitoring and reporting service'
 	if stats != "" {
-		utils.RegisterKowalaStatsService(stack, stats)
+		utils.RegisterKowalaStatsService(stack, stats, false)
 	}
 
 	// Boot up the client and ensure it connects to bootnodes
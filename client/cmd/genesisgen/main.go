@@ -4,17 +4,24 @@ import (
 	"flag"
 	"fmt"
 	"go/format"
+	"io"
 	"io/ioutil"
+	"math/big"
 	"os"
 	"strings"
 
 	"github.com/kowala-tech/kcoin/client/core"
 	"github.com/kowala-tech/kcoin/client/knode/genesis"
+	"github.com/kowala-tech/kcoin/client/params"
 )
 
 var (
 	packageFlag  = flag.String("package", "genesis", "Package name to include in generated output file")
 	currencyFlag = flag.String("currency", "kusd", "Currency to generate genesis for")
+	versionFlag  = flag.String("contracts.version", genesis.DefaultContractsVersion, "Version of the core contracts to deploy in the genesis ("+strings.Join(genesis.ContractsVersions(), ", ")+")")
+	supplyFlag   = flag.String("expected-supply", "", "Expected total supply of the prefunded accounts on the main network, in coins")
+	quietFlag    = flag.Bool("quiet", false, "Don't print the supply summary, only errors")
+	emptyFlag    = flag.Bool("allow-empty-prefund", false, "Allow networks without prefunded accounts, funding their genesis validators only")
 )
 
 var template = "// Auto-generated with genesisgen, do not edit!\n\npackage %s\n\nvar Generated%s = map[string][]byte { \n\"%s\": []byte(`%s`), \n\"%s\": []byte(`%s`),\n}"
@@ -23,8 +30,42 @@ func main() {
 
 	flag.Parse()
 
-	mainnetJson := mustGetGenesisJson(*currencyFlag, genesis.MainNetwork, mustFindGenesis(*currencyFlag, genesis.MainNetwork))
-	testnetJson := mustGetGenesisJson(*currencyFlag, genesis.TestNetwork, mustFindGenesis(*currencyFlag, genesis.TestNetwork))
+	if err := run(os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(-1)
+	}
+}
+
+// run generates the genesis code file of the currency, writing the supply
+// summary to w unless quiet.
+func run(w io.Writer) error {
+	if *quietFlag {
+		w = ioutil.Discard
+	}
+
+	var expectedSupply *big.Int
+	if *supplyFlag != "" {
+		coins, ok := new(big.Int).SetString(*supplyFlag, 10)
+		if !ok || coins.Sign() < 0 {
+			return fmt.Errorf("Invalid expected supply %q", *supplyFlag)
+		}
+		expectedSupply = coins.Mul(coins, big.NewInt(params.Kcoin))
+	}
+	if err := reportSupply(w, *currencyFlag, genesis.MainNetwork, expectedSupply); err != nil {
+		return fmt.Errorf("Genesis supply check for %s (%s) failed: %s", *currencyFlag, genesis.MainNetwork, err)
+	}
+	if err := reportSupply(w, *currencyFlag, genesis.TestNetwork, nil); err != nil {
+		return fmt.Errorf("Genesis supply check for %s (%s) failed: %s", *currencyFlag, genesis.TestNetwork, err)
+	}
+
+	mainnetJson, err := getGenesisJson(*currencyFlag, genesis.MainNetwork)
+	if err != nil {
+		return err
+	}
+	testnetJson, err := getGenesisJson(*currencyFlag, genesis.TestNetwork)
+	if err != nil {
+		return err
+	}
 
 	outputFile := fmt.Sprintf("%s_generated.go", *currencyFlag)
 
@@ -40,36 +81,75 @@ func main() {
 	formatted, err := format.Source([]byte(content))
 
 	if err != nil {
-		fmt.Printf("Genesis code format for %s failed: %s", *currencyFlag, err)
-		os.Exit(-1)
+		return fmt.Errorf("Genesis code format for %s failed: %s", *currencyFlag, err)
 	}
 
 	if err := ioutil.WriteFile(outputFile, formatted, 0600); err != nil {
-		fmt.Printf("Genesis code file write for %s failed: %s", *currencyFlag, err)
-		os.Exit(-1)
+		return fmt.Errorf("Genesis code file write for %s failed: %s", *currencyFlag, err)
 	}
+	return nil
 }
 
-func mustFindGenesis(currency, network string) *core.Genesis {
+func findGenesis(currency, network string) (*core.Genesis, error) {
 
-	gen, err := genesis.NetworkGenesisBlock("", currency, network)
+	var (
+		gen *core.Genesis
+		err error
+	)
+	if *versionFlag == genesis.DefaultContractsVersion && !*emptyFlag {
+		gen, err = genesis.NetworkGenesisBlock("", currency, network)
+	} else {
+		// frozen genesis blocks embed the default options, generate it again
+		opts, ok := genesis.Networks[currency][network]
+		if !ok {
+			return nil, fmt.Errorf("Genesis generation for %s (%s) failed: no network options", currency, network)
+		}
+		opts.ContractsVersion = *versionFlag
+		opts.AllowEmptyPrefund = *emptyFlag
+		gen, err = genesis.Generate(opts)
+	}
 
 	if err != nil {
-		fmt.Printf("Genesis generation for %s (%s) failed: %s", currency, network, err)
-		os.Exit(-1)
+		return nil, fmt.Errorf("Genesis generation for %s (%s) failed: %s", currency, network, err)
 	}
 
-	return gen
+	return gen, nil
 }
 
-func mustGetGenesisJson(currency, network string, gen *core.Genesis) []byte {
+func getGenesisJson(currency, network string) ([]byte, error) {
+
+	gen, err := findGenesis(currency, network)
+	if err != nil {
+		return nil, err
+	}
 
 	json, err := gen.MarshalJSON()
 
 	if err != nil {
-		fmt.Printf("Genesis marshal for %s (%s) failed: %s", currency, network, err)
-		os.Exit(-1)
+		return nil, fmt.Errorf("Genesis marshal for %s (%s) failed: %s", currency, network, err)
 	}
 
-	return json
+	return json, nil
+}
+
+// reportSupply writes the number of prefunded accounts of the network and the
+// sum of their balances to w. If expected is not nil, it returns an error if
+// the total supply in wei doesn't match it.
+func reportSupply(w io.Writer, currency, network string, expected *big.Int) error {
+	opts, ok := genesis.Networks[currency][network]
+	if !ok {
+		return fmt.Errorf("no network options")
+	}
+	supply, err := genesis.PrefundedSupply(opts.PrefundedAccounts)
+	if err != nil {
+		return err
+	}
+
+	coins := new(big.Int).Div(supply, big.NewInt(params.Kcoin))
+	fmt.Fprintf(w, "%s (%s): %d prefunded accounts, total supply %v coins (%v wei)\n", currency, network, len(opts.PrefundedAccounts), coins, supply)
+
+	if expected != nil && supply.Cmp(expected) != 0 {
+		return fmt.Errorf("total supply of %v wei doesn't match the expected %v wei", supply, expected)
+	}
+	return nil
 }
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kowala-tech/kcoin/client/knode/genesis"
+	"github.com/kowala-tech/kcoin/client/params"
+)
+
+func TestReportSupply(t *testing.T) {
+	// The main network prefunds 10000 + 3 * 10 coins
+	supply := new(big.Int).Mul(big.NewInt(10030), big.NewInt(params.Kcoin))
+
+	var out bytes.Buffer
+	if err := reportSupply(&out, "kusd", genesis.MainNetwork, supply); err != nil {
+		t.Fatalf("matching supply rejected: %v", err)
+	}
+	want := "kusd (main): 4 prefunded accounts, total supply 10030 coins (10030000000000000000000 wei)\n"
+	if out.String() != want {
+		t.Fatalf("report mismatch: have %q, want %q", out.String(), want)
+	}
+
+	out.Reset()
+	if err := reportSupply(&out, "kusd", genesis.MainNetwork, nil); err != nil {
+		t.Fatalf("report without an expected supply failed: %v", err)
+	}
+	if out.String() != want {
+		t.Fatalf("report mismatch: have %q, want %q", out.String(), want)
+	}
+}
+
+func TestReportSupplyMismatch(t *testing.T) {
+	expected := new(big.Int).Mul(big.NewInt(10031), big.NewInt(params.Kcoin))
+
+	var out bytes.Buffer
+	err := reportSupply(&out, "kusd", genesis.MainNetwork, expected)
+	if err == nil {
+		t.Fatal("mismatching supply accepted")
+	}
+	if !strings.Contains(err.Error(), "10031000000000000000000") {
+		t.Fatalf("error doesn't report the expected supply: %v", err)
+	}
+	if out.Len() == 0 {
+		t.Fatal("no report written on mismatch")
+	}
+}
+
+func TestRunQuiet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genesisgen-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(cwd)
+
+	defer func(quiet bool) { *quietFlag = quiet }(*quietFlag)
+
+	// Without -quiet the supply summary is reported
+	*quietFlag = false
+	var out bytes.Buffer
+	if err := run(&out); err != nil {
+		t.Fatalf("generation failed: %v", err)
+	}
+	if out.Len() == 0 {
+		t.Fatal("no supply summary reported")
+	}
+
+	// With -quiet nothing but the generated file is produced
+	*quietFlag = true
+	out.Reset()
+	if err := run(&out); err != nil {
+		t.Fatalf("quiet generation failed: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("quiet mode reported output: %q", out.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, *currencyFlag+"_generated.go")); err != nil {
+		t.Fatalf("genesis code file missing: %v", err)
+	}
+}
@@ -19,11 +19,13 @@ package main
 import (
 	"fmt"
 	"io/ioutil"
+	"strings"
 
 	"github.com/kowala-tech/kcoin/client/knode"
 
 	"github.com/kowala-tech/kcoin/client/accounts"
 	"github.com/kowala-tech/kcoin/client/accounts/keystore"
+	"github.com/kowala-tech/kcoin/client/accounts/mnemonic"
 	"github.com/kowala-tech/kcoin/client/cmd/utils"
 	"github.com/kowala-tech/kcoin/client/console"
 	"github.com/kowala-tech/kcoin/client/crypto"
@@ -32,6 +34,16 @@ import (
 )
 
 var (
+	hdPathFlag = cli.StringFlag{
+		Name:  "hdpath",
+		Usage: "BIP-32 derivation path of the key to import",
+		Value: accounts.DefaultBaseDerivationPath.String(),
+	}
+	mnemonicPassphraseFlag = cli.BoolFlag{
+		Name:  "mnemonic.passphrase",
+		Usage: "Prompt for the BIP-39 passphrase protecting the mnemonic",
+	}
+
 	walletCommand = cli.Command{
 		Name:      "wallet",
 		Usage:     "Manage Ethereum presale wallets",
@@ -56,6 +68,8 @@ passwordfile as argument containing the wallet password in plaintext.`,
 					utils.KeyStoreDirFlag,
 					utils.PasswordFileFlag,
 					utils.LightKDFFlag,
+					utils.KeyStoreScryptNFlag,
+					utils.KeyStoreScryptPFlag,
 				},
 				Description: `
 	kcoin wallet [options] /path/to/my/presale.wallet
@@ -112,6 +126,8 @@ Print a short summary of all accounts`,
 					utils.KeyStoreDirFlag,
 					utils.PasswordFileFlag,
 					utils.LightKDFFlag,
+					utils.KeyStoreScryptNFlag,
+					utils.KeyStoreScryptPFlag,
 				},
 				Description: `
     kcoin account new
@@ -136,7 +152,10 @@ password to file or expose in any other way.
 				Flags: []cli.Flag{
 					utils.DataDirFlag,
 					utils.KeyStoreDirFlag,
+					utils.PasswordFileFlag,
 					utils.LightKDFFlag,
+					utils.KeyStoreScryptNFlag,
+					utils.KeyStoreScryptPFlag,
 				},
 				Description: `
     kcoin account update <address>
@@ -147,14 +166,18 @@ The account is saved in the newest version in encrypted format, you are prompted
 for a passphrase to unlock the account and another to save the updated file.
 
 This same command can therefore be used to migrate an account of a deprecated
-format to the newest format or change the password for an account.
+format to the newest format or change the password for an account. The key is
+re-encrypted with the configured scrypt parameters (see --lightkdf and
+--keystore.scryptn) and the key file is replaced atomically. If the current
+passphrase is wrong, the key file is left untouched.
 
-For non-interactive use the passphrase can be specified with the --password flag:
+For non-interactive use the passphrases can be specified with the --password flag:
 
     kcoin account update [options] <address>
 
-Since only one password can be given, only format update can be performed,
-changing your password is only possible interactively.
+The first line of the password file is the current passphrase and the second
+line the new one. If only one line is given, the passphrase is kept and only
+the format is updated.
 `,
 			},
 			{
@@ -166,6 +189,8 @@ changing your password is only possible interactively.
 					utils.KeyStoreDirFlag,
 					utils.PasswordFileFlag,
 					utils.LightKDFFlag,
+					utils.KeyStoreScryptNFlag,
+					utils.KeyStoreScryptPFlag,
 				},
 				ArgsUsage: "<keyFile>",
 				Description: `
@@ -188,6 +213,39 @@ Note:
 As you can directly copy your encrypted accounts to another ethereum instance,
 this import mechanism is not needed when you transfer an account between
 nodes.
+`,
+			},
+			{
+				Name:   "import-mnemonic",
+				Usage:  "Import a key derived from a BIP-39 mnemonic into a new account",
+				Action: utils.MigrateFlags(accountImportMnemonic),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.KeyStoreDirFlag,
+					utils.PasswordFileFlag,
+					utils.LightKDFFlag,
+					utils.KeyStoreScryptNFlag,
+					utils.KeyStoreScryptPFlag,
+					hdPathFlag,
+					mnemonicPassphraseFlag,
+				},
+				ArgsUsage: "[<mnemonicFile>]",
+				Description: `
+    kcoin account import-mnemonic [<mnemonicfile>]
+
+Derives a private key from a 12 to 24 word BIP-39 mnemonic and creates a new
+account from it. Prints the address.
+
+The mnemonic is read from <mnemonicfile> if given, otherwise you are prompted
+for it. Its checksum and the --hdpath derivation path are validated before
+anything is written. Use --mnemonic.passphrase if the mnemonic is protected by
+an additional BIP-39 passphrase.
+
+The account is saved in encrypted format, you are prompted for a passphrase.
+
+For non-interactive use the passphrase can be specified with the --password flag:
+
+    kcoin account import-mnemonic [options] <mnemonicfile>
 `,
 			},
 		},
@@ -329,10 +387,11 @@ func accountUpdate(ctx *cli.Context) error {
 	}
 	stack, _ := makeConfigNode(ctx)
 	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	passwords := utils.MakePasswordList(ctx)
 
 	for _, addr := range ctx.Args() {
-		account, oldPassword := unlockAccount(ctx, ks, addr, 0, nil)
-		newPassword := getPassPhrase("Please give a new password. Do not forget this password.", true, 0, nil)
+		account, oldPassword := unlockAccount(ctx, ks, addr, 0, passwords)
+		newPassword := getPassPhrase("Please give a new password. Do not forget this password.", true, 1, passwords)
 		if err := ks.Update(account, oldPassword, newPassword); err != nil {
 			utils.Fatalf("Could not update the account: %v", err)
 		}
@@ -382,3 +441,55 @@ func accountImport(ctx *cli.Context) error {
 	fmt.Printf("Address: {%x}\n", acct.Address)
 	return nil
 }
+
+// accountImportMnemonic derives a key from a BIP-39 mnemonic and imports it into
+// the keystore.
+func accountImportMnemonic(ctx *cli.Context) error {
+	var words string
+	if file := ctx.Args().First(); file != "" {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			utils.Fatalf("Could not read mnemonic file: %v", err)
+		}
+		words = string(data)
+	} else {
+		input, err := console.Stdin.PromptPassword("Mnemonic: ")
+		if err != nil {
+			utils.Fatalf("Failed to read mnemonic: %v", err)
+		}
+		words = input
+	}
+	words = strings.ToLower(strings.TrimSpace(words))
+	if err := mnemonic.Validate(words); err != nil {
+		utils.Fatalf("Invalid mnemonic: %v", err)
+	}
+	path, err := accounts.ParseDerivationPath(ctx.String(hdPathFlag.Name))
+	if err != nil {
+		utils.Fatalf("Invalid derivation path %q: %v", ctx.String(hdPathFlag.Name), err)
+	}
+	var passphrase string
+	if ctx.Bool(mnemonicPassphraseFlag.Name) {
+		if passphrase, err = console.Stdin.PromptPassword("Mnemonic passphrase: "); err != nil {
+			utils.Fatalf("Failed to read mnemonic passphrase: %v", err)
+		}
+	}
+	seed, err := mnemonic.NewSeed(words, passphrase)
+	if err != nil {
+		utils.Fatalf("Invalid mnemonic: %v", err)
+	}
+	key, err := mnemonic.DeriveKey(seed, path)
+	if err != nil {
+		utils.Fatalf("Failed to derive key at %s: %v", path, err)
+	}
+
+	stack, _ := makeConfigNode(ctx)
+	password := getPassPhrase("Your new account is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
+
+	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	acct, err := ks.ImportECDSA(key, password)
+	if err != nil {
+		utils.Fatalf("Could not create the account: %v", err)
+	}
+	fmt.Printf("Address: {%x}\n", acct.Address)
+	return nil
+}
@@ -2,12 +2,14 @@ package main
 
 import (
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
 
 	"github.com/cespare/cp"
+	"github.com/kowala-tech/kcoin/client/accounts/keystore"
 )
 
 // These tests are 'smoke tests' for the account related
@@ -90,6 +92,37 @@ Repeat passphrase: {{.InputLine "foobar2"}}
 `)
 }
 
+func TestAccountUpdatePasswordFile(t *testing.T) {
+	datadir := tmpDatadirWithKeystore(t)
+	passwords := filepath.Join(datadir, "passwords.txt")
+	if err := ioutil.WriteFile(passwords, []byte("foobar\nfoobar2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	kcoin := runKusd(t, "account", "update",
+		"--keystore", filepath.Join(datadir, "keystore"), "--lightkdf", "--password", passwords,
+		"f466859ead1932d743d622cb74fc058882e8648a")
+	kcoin.ExpectExit()
+
+	content, err := ioutil.ReadFile(filepath.Join(datadir, "keystore", "aaa"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keystore.DecryptKey(content, "foobar2"); err != nil {
+		t.Errorf("can't decrypt the updated key with the new passphrase: %v", err)
+	}
+}
+
+func TestAccountUpdateWrongPassword(t *testing.T) {
+	datadir := tmpDatadirWithKeystore(t)
+	kcoin := runKusd(t, "account", "update",
+		"--keystore", filepath.Join(datadir, "keystore"), "--lightkdf", "--password", "testdata/wrong-passwords.txt",
+		"f466859ead1932d743d622cb74fc058882e8648a")
+	defer kcoin.ExpectExit()
+	kcoin.Expect(`
+Fatal: Failed to unlock account f466859ead1932d743d622cb74fc058882e8648a (could not decrypt key with given passphrase)
+`)
+}
+
 func TestWalletImport(t *testing.T) {
 	kcoin := runKusd(t, "wallet", "import", "--lightkdf", "testdata/guswallet.json")
 	defer kcoin.ExpectExit()
@@ -115,6 +148,42 @@ Fatal: could not decrypt key with given passphrase
 `)
 }
 
+func TestAccountImportMnemonic(t *testing.T) {
+	dir := tmpdir(t)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "mnemonic.txt")
+	words := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about\n"
+	if err := ioutil.WriteFile(file, []byte(words), 0600); err != nil {
+		t.Fatal(err)
+	}
+	kcoin := runKusd(t, "account", "import-mnemonic", "--lightkdf", "--hdpath", "m/44'/60'/0'/0/0", file)
+	defer kcoin.ExpectExit()
+	kcoin.Expect(`
+Your new account is locked with a password. Please give a password. Do not forget this password.
+!! Unsupported terminal, password will be echoed.
+Passphrase: {{.InputLine "foobar"}}
+Repeat passphrase: {{.InputLine "foobar"}}
+Address: {9858effd232b4033e47d90003d41ec34ecaeda94}
+`)
+}
+
+func TestAccountImportMnemonicBadChecksum(t *testing.T) {
+	dir := tmpdir(t)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "mnemonic.txt")
+	words := strings.Repeat("abandon ", 12)
+	if err := ioutil.WriteFile(file, []byte(words), 0600); err != nil {
+		t.Fatal(err)
+	}
+	kcoin := runKusd(t, "account", "import-mnemonic", "--lightkdf", file)
+	defer kcoin.ExpectExit()
+	kcoin.Expect(`
+Fatal: Invalid mnemonic: invalid mnemonic checksum
+`)
+}
+
 func TestUnlockFlag(t *testing.T) {
 	datadir := tmpDatadirWithKeystore(t)
 	kcoin := runKusd(t,
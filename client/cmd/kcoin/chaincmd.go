@@ -1,9 +1,14 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"reflect"
 	"runtime"
+	"sort"
 	"strconv"
 	"sync/atomic"
 	"time"
@@ -12,6 +17,7 @@ import (
 	"github.com/kowala-tech/kcoin/client/common"
 	"github.com/kowala-tech/kcoin/client/console"
 	"github.com/kowala-tech/kcoin/client/core"
+	"github.com/kowala-tech/kcoin/client/core/rawdb"
 	"github.com/kowala-tech/kcoin/client/core/state"
 	"github.com/kowala-tech/kcoin/client/core/types"
 	"github.com/kowala-tech/kcoin/client/event"
@@ -57,6 +63,7 @@ It expects the genesis file as argument.`,
 			utils.GCModeFlag,
 			utils.CacheDatabaseFlag,
 			utils.CacheGCFlag,
+			utils.NoCompactionFlag,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
 		Description: `
@@ -64,7 +71,9 @@ The import command imports blocks from an RLP-encoded form. The form can be one
 with several RLP-encoded blocks, or several files can be used.
 
 If only one file is used, import error will result in failure. If several files are used,
-processing will proceed even if an individual RLP-file import failure occurs.`,
+processing will proceed even if an individual RLP-file import failure occurs.
+
+The database is compacted once the blocks are imported, unless --nocompaction is set.`,
 	}
 	exportCommand = cli.Command{
 		Action:    utils.MigrateFlags(exportChain),
@@ -80,7 +89,8 @@ processing will proceed even if an individual RLP-file import failure occurs.`,
 		Description: `
 Requires a first argument of the file to write to.
 Optional second and third arguments control the first and
-last block to write. In this mode, the file will be appended
+last block to write, both included. The range must be within
+the local chain. In this mode, the file will be appended
 if already existing.`,
 	}
 	importPreimagesCommand = cli.Command{
@@ -110,6 +120,22 @@ if already existing.`,
 		Category: "BLOCKCHAIN COMMANDS",
 		Description: `
 The export-preimages command export hash preimages to an RLP encoded stream`,
+	}
+	dumpPreimagesCommand = cli.Command{
+		Action:    utils.MigrateFlags(dumpPreimages),
+		Name:      "dump-preimages",
+		Usage:     "Dump the preimage database as JSON",
+		ArgsUsage: "[<dumpfile>]",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.CacheFlag,
+			utils.LightModeFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The dump-preimages command writes the hash preimages recorded with --vmdebug as
+a JSON object mapping each hash to its preimage. The output goes to the given
+file, or to the standard output if none is given.`,
 	}
 	copydbCommand = cli.Command{
 		Action:    utils.MigrateFlags(copyDb),
@@ -130,14 +156,17 @@ The first argument must be the directory containing the blockchain to download f
 		Action:    utils.MigrateFlags(removeDB),
 		Name:      "removedb",
 		Usage:     "Remove blockchain and state databases",
-		ArgsUsage: " ",
+		ArgsUsage: "[full|light]...",
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
-			utils.LightModeFlag,
+			utils.ForceFlag,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
 		Description: `
-Remove blockchain and state databases`,
+Remove blockchain and state databases. The arguments select which databases
+are removed: "full" for the chain database of a full node (chaindata) and
+"light" for the one of a light node (lightchaindata). Both are removed if no
+argument is given. Each removal must be confirmed unless --force is set.`,
 	}
 	dumpCommand = cli.Command{
 		Action:    utils.MigrateFlags(dump),
@@ -154,6 +183,50 @@ Remove blockchain and state databases`,
 The arguments are interpreted as block numbers or hashes.
 Use "ethereum dump 0" to dump the genesis block.`,
 	}
+	blockCommand = cli.Command{
+		Name:     "block",
+		Usage:    "Inspect blocks in storage",
+		Category: "BLOCKCHAIN COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Action:    utils.MigrateFlags(blockVotes),
+				Name:      "votes",
+				Usage:     "Dump the consensus votes that committed a block",
+				ArgsUsage: "<blockHash> | <blockNum>",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+				},
+				Description: `
+Prints the pre-votes and pre-commits of the round that committed the block,
+along with the validators that signed them. The pre-commits are read from the
+commit recorded by the next canonical block. Pre-votes are only kept for blocks
+committed while this node was validating.`,
+			},
+		},
+	}
+	genesisCommand = cli.Command{
+		Name:     "genesis",
+		Usage:    "Inspect genesis files",
+		Category: "BLOCKCHAIN COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Action:    utils.MigrateFlags(genesisDiff),
+				Name:      "diff",
+				Usage:     "Compare a genesis file against the embedded network genesis",
+				ArgsUsage: "<genesisPath>",
+				Flags: []cli.Flag{
+					utils.TestnetFlag,
+					utils.DevModeFlag,
+					utils.CurrencyFlag,
+				},
+				Description: `
+Prints the fields of the genesis file differing from the genesis embedded for
+the currency and network, the main network unless --testnet is set. The command
+fails if any field differs.`,
+			},
+		},
+	}
 )
 
 // initGenesis will initialise the given JSON format genesis file and writes it as
@@ -272,7 +345,7 @@ func importChain(ctx *cli.Context) error {
 	fmt.Printf("Allocations:   %.3f million\n", float64(mem.Mallocs)/1000000)
 	fmt.Printf("GC pause:      %v\n\n", time.Duration(mem.PauseTotalNs))
 
-	if ctx.GlobalIsSet(utils.NoCompactionFlag.Name) {
+	if ctx.GlobalBool(utils.NoCompactionFlag.Name) {
 		return nil
 	}
 
@@ -309,19 +382,18 @@ func exportChain(ctx *cli.Context) error {
 
 	var err error
 	fp := ctx.Args().First()
-	if len(ctx.Args()) < 3 {
+	switch len(ctx.Args()) {
+	case 1:
 		err = utils.ExportChain(chain, fp)
-	} else {
-		// This can be improved to allow for numbers larger than 9223372036854775807
-		first, ferr := strconv.ParseInt(ctx.Args().Get(1), 10, 64)
-		last, lerr := strconv.ParseInt(ctx.Args().Get(2), 10, 64)
+	case 3:
+		first, ferr := strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+		last, lerr := strconv.ParseUint(ctx.Args().Get(2), 10, 64)
 		if ferr != nil || lerr != nil {
-			utils.Fatalf("Export error in parsing parameters: block number not an integer\n")
-		}
-		if first < 0 || last < 0 {
-			utils.Fatalf("Export error: block number must be greater than 0\n")
+			utils.Fatalf("Export error in parsing parameters: block number not a positive integer\n")
 		}
-		err = utils.ExportAppendChain(chain, fp, uint64(first), uint64(last))
+		err = utils.ExportAppendChain(chain, fp, first, last)
+	default:
+		utils.Fatalf("Export error: both the first and last block of the range are required\n")
 	}
 
 	if err != nil {
@@ -363,6 +435,27 @@ func exportPreimages(ctx *cli.Context) error {
 	return nil
 }
 
+// dumpPreimages writes the preimage data as JSON to the specified file or the
+// standard output.
+func dumpPreimages(ctx *cli.Context) error {
+	stack := makeFullNode(ctx)
+	diskdb := utils.MakeChainDatabase(ctx, stack).(*kcoindb.LDBDatabase)
+
+	var out io.Writer = os.Stdout
+	if fn := ctx.Args().First(); fn != "" {
+		fh, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		if err != nil {
+			utils.Fatalf("Could not create dump file: %v", err)
+		}
+		defer fh.Close()
+		out = fh
+	}
+	if err := utils.DumpPreimages(diskdb, out); err != nil {
+		utils.Fatalf("Dump error: %v", err)
+	}
+	return nil
+}
+
 func copyDb(ctx *cli.Context) error {
 	// Ensure we have a source chain directory to copy
 	if len(ctx.Args()) != 1 {
@@ -414,7 +507,21 @@ func copyDb(ctx *cli.Context) error {
 func removeDB(ctx *cli.Context) error {
 	stack, _ := makeConfigNode(ctx)
 
-	for _, name := range []string{"chaindata", "lightchaindata"} {
+	names := []string{utils.ChainDatabaseName(false), utils.ChainDatabaseName(true)}
+	if ctx.NArg() > 0 {
+		names = names[:0]
+		for _, arg := range ctx.Args() {
+			switch arg {
+			case "full":
+				names = append(names, utils.ChainDatabaseName(false))
+			case "light":
+				names = append(names, utils.ChainDatabaseName(true))
+			default:
+				utils.Fatalf("Unknown database %q, expected full or light", arg)
+			}
+		}
+	}
+	for _, name := range names {
 		// Ensure the database exists in the first place
 		logger := log.New("database", name)
 
@@ -424,18 +531,23 @@ func removeDB(ctx *cli.Context) error {
 			continue
 		}
 		// Confirm removal and execute
-		fmt.Println(dbdir)
-		confirm, err := console.Stdin.PromptConfirm("Remove this database?")
-		switch {
-		case err != nil:
-			utils.Fatalf("%v", err)
-		case !confirm:
+		confirm := ctx.Bool(utils.ForceFlag.Name)
+		if !confirm {
+			fmt.Println(dbdir)
+			var err error
+			if confirm, err = console.Stdin.PromptConfirm("Remove this database?"); err != nil {
+				utils.Fatalf("%v", err)
+			}
+		}
+		if !confirm {
 			logger.Warn("Database deletion aborted")
-		default:
-			start := time.Now()
-			os.RemoveAll(dbdir)
-			logger.Info("Database successfully deleted", "elapsed", common.PrettyDuration(time.Since(start)))
+			continue
 		}
+		start := time.Now()
+		if err := os.RemoveAll(dbdir); err != nil {
+			utils.Fatalf("Failed to remove database %s: %v", dbdir, err)
+		}
+		logger.Info("Database successfully deleted", "elapsed", common.PrettyDuration(time.Since(start)))
 	}
 	return nil
 }
@@ -466,6 +578,176 @@ func dump(ctx *cli.Context) error {
 	return nil
 }
 
+// blockVotes prints the consensus votes that committed the given block.
+func blockVotes(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires a block number or hash argument.")
+	}
+	stack := makeFullNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer chainDb.Close()
+
+	arg := ctx.Args().First()
+	var block *types.Block
+	if hashish(arg) {
+		block = chain.GetBlockByHash(common.HexToHash(arg))
+	} else {
+		num, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			utils.Fatalf("Invalid block number %q: %v", arg, err)
+		}
+		block = chain.GetBlockByNumber(num)
+	}
+	if block == nil {
+		utils.Fatalf("block not found")
+	}
+	votes, err := committedVotes(chainDb, block, chain.GetBlockByNumber(block.NumberU64()+1))
+	if err != nil {
+		utils.Fatalf("%v", err)
+	}
+	return printBlockVotes(os.Stdout, types.NewAndromedaSigner(chain.Config().ChainID), block, votes)
+}
+
+func genesisDiff(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires a genesis file argument.")
+	}
+	genesis, err := genesisgen.NetworkGenesisBlock(ctx.Args().First(), "", "")
+	if err != nil {
+		utils.Fatalf("%v", err)
+	}
+	currency, network := ctx.GlobalString(utils.CurrencyFlag.Name), extractNetworkKey(ctx)
+	reference, err := genesisgen.NetworkGenesisBlock("", currency, network)
+	if err != nil {
+		utils.Fatalf("Failed to load the embedded genesis: %v", err)
+	}
+
+	diffs, err := diffGenesis(genesis, reference)
+	if err != nil {
+		return err
+	}
+	if len(diffs) == 0 {
+		fmt.Printf("Genesis matches the embedded %s (%s) genesis\n", currency, network)
+		return nil
+	}
+	for _, diff := range diffs {
+		fmt.Println(diff)
+	}
+	return fmt.Errorf("genesis differs from the embedded %s (%s) genesis in %d fields", currency, network, len(diffs))
+}
+
+// diffGenesis compares the JSON encoding of two genesis specifications field by
+// field, returning a line per difference in the form "path: have x, want y".
+func diffGenesis(have, want *core.Genesis) ([]string, error) {
+	var encoded [2]interface{}
+	for i, genesis := range []*core.Genesis{have, want} {
+		data, err := genesis.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&encoded[i]); err != nil {
+			return nil, err
+		}
+	}
+	var diffs []string
+	diffJSON("", encoded[0], encoded[1], &diffs)
+	return diffs, nil
+}
+
+// diffJSON appends the differences between two decoded JSON values to diffs,
+// descending into objects.
+func diffJSON(path string, have, want interface{}, diffs *[]string) {
+	haveObj, haveIsObj := have.(map[string]interface{})
+	wantObj, wantIsObj := want.(map[string]interface{})
+	if !haveIsObj || !wantIsObj {
+		if !reflect.DeepEqual(have, want) {
+			*diffs = append(*diffs, fmt.Sprintf("%s: have %s, want %s", path, formatJSON(have), formatJSON(want)))
+		}
+		return
+	}
+	keys := make([]string, 0, len(haveObj)+len(wantObj))
+	for key := range haveObj {
+		keys = append(keys, key)
+	}
+	for key := range wantObj {
+		if _, ok := haveObj[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		sub := key
+		if path != "" {
+			sub = path + "." + key
+		}
+		diffJSON(sub, haveObj[key], wantObj[key], diffs)
+	}
+}
+
+// formatJSON formats a decoded JSON value for a difference, abbreviating long
+// values like contract code.
+func formatJSON(value interface{}) string {
+	if value == nil {
+		return "<none>"
+	}
+	data, _ := json.Marshal(value)
+	if len(data) > 70 {
+		return fmt.Sprintf("%s...(%d bytes)", data[:64], len(data))
+	}
+	return string(data)
+}
+
+// committedVotes returns the votes that committed block. The pre-commits are
+// recorded on chain by the child block, the only votes every node has. The
+// votes kept by a validator that took part in the election are used instead
+// when present, as they hold the pre-votes as well.
+func committedVotes(db rawdb.DatabaseReader, block, child *types.Block) (*rawdb.BlockVotes, error) {
+	if votes := rawdb.ReadBlockVotes(db, block.Hash(), block.NumberU64()); votes != nil {
+		return votes, nil
+	}
+	if child == nil || child.ParentHash() != block.Hash() || child.LastCommit() == nil {
+		return nil, fmt.Errorf("no canonical block commits block %d (%x) yet", block.NumberU64(), block.Hash())
+	}
+	commit := child.LastCommit()
+	votes := &rawdb.BlockVotes{PreCommits: commit.Commits()}
+	if first := commit.First(); first != nil {
+		votes.Round = first.Round()
+	}
+	return votes, nil
+}
+
+// printBlockVotes writes the votes that committed a block to w, along with the
+// validator that signed each of them.
+func printBlockVotes(w io.Writer, signer types.Signer, block *types.Block, votes *rawdb.BlockVotes) error {
+	fmt.Fprintf(w, "Block %d (%x) committed in round %d\n", block.NumberU64(), block.Hash(), votes.Round)
+	for _, kind := range []struct {
+		name  string
+		votes []*types.Vote
+	}{
+		{"Pre-votes", votes.PreVotes},
+		{"Pre-commits", votes.PreCommits},
+	} {
+		signers := make([]common.Address, 0, len(kind.votes))
+		for _, vote := range kind.votes {
+			addressVote, err := types.NewAddressVote(signer, vote)
+			if err != nil {
+				return fmt.Errorf("failed to recover the signer of vote %x: %v", vote.Hash(), err)
+			}
+			signers = append(signers, addressVote.Address())
+		}
+		sort.Slice(signers, func(i, j int) bool { return bytes.Compare(signers[i][:], signers[j][:]) < 0 })
+
+		fmt.Fprintf(w, "%s (%d):\n", kind.name, len(signers))
+		for _, addr := range signers {
+			fmt.Fprintf(w, "  %s\n", addr.Hex())
+		}
+	}
+	return nil
+}
+
 // hashish returns true for strings that look like hashes.
 func hashish(x string) bool {
 	_, err := strconv.Atoi(x)
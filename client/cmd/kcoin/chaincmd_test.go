@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/core"
+	"github.com/kowala-tech/kcoin/client/core/rawdb"
+	"github.com/kowala-tech/kcoin/client/core/types"
+	"github.com/kowala-tech/kcoin/client/crypto"
+	"github.com/kowala-tech/kcoin/client/kcoindb"
+	genesisgen "github.com/kowala-tech/kcoin/client/knode/genesis"
+	"github.com/kowala-tech/kcoin/client/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintBlockVotes(t *testing.T) {
+	signer := types.NewAndromedaSigner(params.TestChainConfig.ChainID)
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(12)})
+
+	keys := make([]*ecdsa.PrivateKey, 2)
+	addrs := make([]common.Address, len(keys))
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		addrs[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+	}
+	if bytes.Compare(addrs[0][:], addrs[1][:]) > 0 {
+		keys[0], keys[1] = keys[1], keys[0]
+		addrs[0], addrs[1] = addrs[1], addrs[0]
+	}
+	sign := func(key *ecdsa.PrivateKey, voteType types.VoteType) *types.Vote {
+		vote, err := types.SignVote(types.NewVote(block.Number(), block.Hash(), 1, voteType), signer, key)
+		require.NoError(t, err)
+		return vote
+	}
+
+	// the votes are dumped as stored for the committed block
+	db := kcoindb.NewMemDatabase()
+	rawdb.WriteBlockVotes(db, block.Hash(), block.NumberU64(), &rawdb.BlockVotes{
+		Round:      1,
+		PreVotes:   []*types.Vote{sign(keys[1], types.PreVote), sign(keys[0], types.PreVote)},
+		PreCommits: []*types.Vote{sign(keys[1], types.PreCommit)},
+	})
+	votes, err := committedVotes(db, block, nil)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, printBlockVotes(&out, signer, block, votes))
+
+	want := fmt.Sprintf("Block 12 (%x) committed in round 1\n", block.Hash()) +
+		"Pre-votes (2):\n" +
+		"  " + addrs[0].Hex() + "\n" +
+		"  " + addrs[1].Hex() + "\n" +
+		"Pre-commits (1):\n" +
+		"  " + addrs[1].Hex() + "\n"
+	assert.Equal(t, want, out.String())
+
+	// without them, the pre-commits come from the commit of the child block
+	db = kcoindb.NewMemDatabase()
+	_, err = committedVotes(db, block, nil)
+	assert.Error(t, err, "votes of a block not committed yet")
+
+	precommits := types.Votes{sign(keys[1], types.PreCommit), sign(keys[0], types.PreCommit)}
+	child := types.NewBlock(&types.Header{Number: big.NewInt(13), ParentHash: block.Hash()}, nil, nil, &types.Commit{
+		PreCommits:     precommits,
+		FirstPreCommit: precommits[0],
+	})
+	votes, err = committedVotes(db, block, child)
+	require.NoError(t, err)
+
+	out.Reset()
+	require.NoError(t, printBlockVotes(&out, signer, block, votes))
+
+	want = fmt.Sprintf("Block 12 (%x) committed in round 1\n", block.Hash()) +
+		"Pre-votes (0):\n" +
+		"Pre-commits (2):\n" +
+		"  " + addrs[0].Hex() + "\n" +
+		"  " + addrs[1].Hex() + "\n"
+	assert.Equal(t, want, out.String())
+
+	// a child of another block doesn't commit it
+	sibling := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(12), Extra: []byte("sibling")})
+	_, err = committedVotes(db, sibling, child)
+	assert.Error(t, err, "votes of a block committed by the child of a sibling")
+}
+
+func TestDiffGenesis(t *testing.T) {
+	dir := tmpdir(t)
+	defer os.RemoveAll(dir)
+
+	reference, err := genesisgen.NetworkGenesisBlock("", "kusd", genesisgen.MainNetwork)
+	require.NoError(t, err)
+
+	// a file holding the embedded genesis doesn't differ
+	data, err := reference.MarshalJSON()
+	require.NoError(t, err)
+	path := filepath.Join(dir, "genesis.json")
+	require.NoError(t, ioutil.WriteFile(path, data, 0644))
+	genesis, err := genesisgen.NetworkGenesisBlock(path, "", "")
+	require.NoError(t, err)
+
+	diffs, err := diffGenesis(genesis, reference)
+	require.NoError(t, err)
+	assert.Empty(t, diffs)
+
+	// every modified field is reported
+	genesis.GasLimit = 5000000
+	config := *genesis.Config
+	config.ChainID = big.NewInt(5)
+	genesis.Config = &config
+	genesis.Alloc = make(core.GenesisAlloc)
+	for addr, account := range reference.Alloc {
+		genesis.Alloc[addr] = account
+	}
+	added := common.HexToAddress("0x0c4e7a8b2d1f3e5a6b7c8d9e0f1a2b3c4d5e6f70")
+	genesis.Alloc[added] = core.GenesisAccount{Balance: big.NewInt(1)}
+
+	diffs, err = diffGenesis(genesis, reference)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"alloc.0c4e7a8b2d1f3e5a6b7c8d9e0f1a2b3c4d5e6f70: have {\"balance\":\"0x1\"}, want <none>",
+		"config.chainID: have 5, want 1",
+		fmt.Sprintf("gasLimit: have \"0x4c4b40\", want \"%#x\"", reference.GasLimit),
+	}, diffs)
+}
@@ -1,18 +1,26 @@
 package main
 
 import (
-	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"reflect"
+	"regexp"
+	"strings"
 	"unicode"
 
 	"gopkg.in/urfave/cli.v1"
 
 	"github.com/kowala-tech/kcoin/client/cmd/utils"
+	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/core"
+	"github.com/kowala-tech/kcoin/client/kcoindb"
 	"github.com/kowala-tech/kcoin/client/knode"
+	"github.com/kowala-tech/kcoin/client/knode/downloader"
+	"github.com/kowala-tech/kcoin/client/log"
 	"github.com/kowala-tech/kcoin/client/node"
 	"github.com/kowala-tech/kcoin/client/params"
 	"github.com/kowala-tech/kcoin/client/stats"
@@ -30,10 +38,44 @@ var (
 		Description: `The dumpconfig command shows configuration values.`,
 	}
 
+	configCommand = cli.Command{
+		Name:     "config",
+		Usage:    "Inspect the resolved configuration",
+		Category: "MISCELLANEOUS COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Action:    utils.MigrateFlags(explainConfig),
+				Name:      "explain",
+				Usage:     "Show where a configuration field's effective value came from",
+				ArgsUsage: "<field>",
+				Flags:     append(nodeFlags, rpcFlags...),
+				Category:  "MISCELLANEOUS COMMANDS",
+				Description: `
+    kcoin [options] config explain Kowala.TxPool.PriceLimit
+
+prints the effective value of the given configuration field (using the TOML
+key path as shown by dumpconfig) together with the layer it was resolved from.
+Layers are applied in order of precedence: the built-in defaults, then the
+--config file, then the command line flags. Environment variables referenced
+from the --config file are resolved as part of the file layer.`,
+			},
+		},
+	}
+
 	configFileFlag = cli.StringFlag{
 		Name:  "config",
 		Usage: "TOML configuration file",
 	}
+
+	configWritebackFlag = cli.StringFlag{
+		Name:  "config.writeback",
+		Usage: "Write the resolved TOML configuration to the given file on startup",
+	}
+
+	validateConfigFlag = cli.BoolFlag{
+		Name:  "validate-config",
+		Usage: "Validate the configuration and the genesis block against the chain database, then exit without starting the node",
+	}
 )
 
 // These settings ensure that TOML keys use the same names as Go struct fields.
@@ -59,19 +101,194 @@ type kcoinConfig struct {
 	Stats  stats.Config
 }
 
+// envVarPattern matches a ${NAME} or ${NAME:-default} reference in config files.
+// A leading $$ escapes the reference.
+var envVarPattern = regexp.MustCompile(`^\$(\$?)\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// envPlainValue matches the values that can be substituted outside of strings,
+// like numbers and booleans.
+var envPlainValue = regexp.MustCompile(`^[A-Za-z0-9_.:+-]*$`)
+
+// tomlContext is the kind of TOML token an environment variable reference is
+// found in.
+type tomlContext int
+
+const (
+	tomlValue              tomlContext = iota // outside of strings and comments
+	tomlComment                               // # comment
+	tomlBasicString                           // "string"
+	tomlMultiBasicString                      // """string"""
+	tomlLiteralString                         // 'string'
+	tomlMultiLiteralString                    // '''string'''
+)
+
+// expandEnv substitutes environment variable references in the raw contents of a
+// config file, leaving comments untouched. Variables that are unset or empty take
+// the default value if one is given, and references to unset variables without
+// a default are an error. The values are escaped for the strings they're put in,
+// and values that can't be, or that aren't plain values outside of strings, are
+// rejected.
+func expandEnv(data []byte, lookup func(string) (string, bool)) ([]byte, error) {
+	var (
+		out     bytes.Buffer
+		missing []string
+		context = tomlValue
+	)
+	for i := 0; i < len(data); {
+		rest := data[i:]
+		switch c := data[i]; {
+		case context == tomlComment:
+			if c == '\n' {
+				context = tomlValue
+			}
+		case c == '$':
+			match := envVarPattern.FindSubmatch(rest)
+			if match == nil {
+				break
+			}
+			i += len(match[0])
+			if len(match[1]) > 0 {
+				out.Write(match[0][1:])
+				continue
+			}
+			name := string(match[2])
+			value, ok := lookup(name)
+			if (!ok || value == "") && bytes.Contains(match[0], []byte(":-")) {
+				out.Write(match[3])
+				continue
+			}
+			if !ok {
+				if !containsString(missing, name) {
+					missing = append(missing, name)
+				}
+				continue
+			}
+			quoted, err := quoteEnvValue(value, context)
+			if err != nil {
+				return nil, fmt.Errorf("environment variable %s: %v", name, err)
+			}
+			out.WriteString(quoted)
+			continue
+		case context == tomlValue:
+			switch {
+			case c == '#':
+				context = tomlComment
+			case bytes.HasPrefix(rest, []byte(`"""`)):
+				context = tomlMultiBasicString
+				out.Write(rest[:3])
+				i += 3
+				continue
+			case c == '"':
+				context = tomlBasicString
+			case bytes.HasPrefix(rest, []byte("'''")):
+				context = tomlMultiLiteralString
+				out.Write(rest[:3])
+				i += 3
+				continue
+			case c == '\'':
+				context = tomlLiteralString
+			}
+		case c == '\\' && (context == tomlBasicString || context == tomlMultiBasicString) && len(rest) > 1:
+			// Keep escaped characters, like quotes, from ending the string
+			out.Write(rest[:2])
+			i += 2
+			continue
+		case context == tomlMultiBasicString && bytes.HasPrefix(rest, []byte(`"""`)),
+			context == tomlMultiLiteralString && bytes.HasPrefix(rest, []byte("'''")):
+			context = tomlValue
+			out.Write(rest[:3])
+			i += 3
+			continue
+		case context == tomlBasicString && (c == '"' || c == '\n'),
+			context == tomlLiteralString && (c == '\'' || c == '\n'):
+			context = tomlValue
+		}
+		out.WriteByte(data[i])
+		i++
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("environment variables not set: %s", strings.Join(missing, ", "))
+	}
+	return out.Bytes(), nil
+}
+
+// quoteEnvValue escapes an environment variable value for the TOML context it's
+// substituted in, so it can't end the string it's in or add other settings.
+func quoteEnvValue(value string, context tomlContext) (string, error) {
+	switch context {
+	case tomlBasicString, tomlMultiBasicString:
+		var b strings.Builder
+		for _, r := range value {
+			switch r {
+			case '"', '\\':
+				b.WriteByte('\\')
+				b.WriteRune(r)
+			case '\b':
+				b.WriteString(`\b`)
+			case '\t':
+				b.WriteString(`\t`)
+			case '\n':
+				b.WriteString(`\n`)
+			case '\f':
+				b.WriteString(`\f`)
+			case '\r':
+				b.WriteString(`\r`)
+			default:
+				if r < 0x20 || r == 0x7f {
+					fmt.Fprintf(&b, `\u%04X`, r)
+					continue
+				}
+				b.WriteRune(r)
+			}
+		}
+		return b.String(), nil
+	case tomlLiteralString:
+		if strings.ContainsAny(value, "'\r\n") {
+			return "", errors.New("value can't be put in a literal string, use a basic string instead")
+		}
+	case tomlMultiLiteralString:
+		if strings.Contains(value, "'''") {
+			return "", errors.New("value can't be put in a multi-line literal string, use a basic string instead")
+		}
+	default:
+		if !envPlainValue.MatchString(value) {
+			return "", errors.New("value is not a plain number or boolean, reference it in a quoted string")
+		}
+	}
+	return value, nil
+}
+
+// containsString returns whether list holds s.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
 func loadConfig(file string, cfg *kcoinConfig) error {
-	f, err := os.Open(file)
+	data, err := ioutil.ReadFile(file)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
+	if data, err = expandEnv(data, os.LookupEnv); err != nil {
+		return fmt.Errorf("%s, %v", file, err)
+	}
 
-	err = tomlSettings.NewDecoder(bufio.NewReader(f)).Decode(cfg)
+	err = tomlSettings.NewDecoder(bytes.NewReader(data)).Decode(cfg)
 	// Add file name to errors that have a line number.
 	if _, ok := err.(*toml.LineError); ok {
 		err = errors.New(file + ", " + err.Error())
 	}
-	return err
+	if err != nil {
+		return err
+	}
+	if errs := cfg.Kowala.Validate(); len(errs) > 0 {
+		return fmt.Errorf("%s, [Kowala] %v", file, knode.ConfigErrors(errs))
+	}
+	return nil
 }
 
 func defaultNodeConfig() node.Config {
@@ -84,12 +301,16 @@ func defaultNodeConfig() node.Config {
 	return cfg
 }
 
-func makeConfigNode(ctx *cli.Context) (*node.Node, kcoinConfig) {
-	// Load defaults.
-	cfg := kcoinConfig{
+func defaultKcoinConfig() kcoinConfig {
+	return kcoinConfig{
 		Kowala: knode.DefaultConfig,
 		Node:   defaultNodeConfig(),
 	}
+}
+
+func makeConfigNode(ctx *cli.Context) (*node.Node, kcoinConfig) {
+	// Load defaults.
+	cfg := defaultKcoinConfig()
 
 	// Load config file.
 	if file := ctx.GlobalString(configFileFlag.Name); file != "" {
@@ -108,6 +329,9 @@ func makeConfigNode(ctx *cli.Context) (*node.Node, kcoinConfig) {
 	if ctx.GlobalIsSet(utils.KowalaStatsURLFlag.Name) {
 		cfg.Stats.URL = ctx.GlobalString(utils.KowalaStatsURLFlag.Name)
 	}
+	if ctx.GlobalIsSet(utils.KowalaStatsInsecureFlag.Name) {
+		cfg.Stats.Insecure = ctx.GlobalBool(utils.KowalaStatsInsecureFlag.Name)
+	}
 
 	return stack, cfg
 }
@@ -115,12 +339,19 @@ func makeConfigNode(ctx *cli.Context) (*node.Node, kcoinConfig) {
 func makeFullNode(ctx *cli.Context) *node.Node {
 	stack, cfg := makeConfigNode(ctx)
 
+	if path := ctx.GlobalString(configWritebackFlag.Name); path != "" {
+		if err := writeConfigFile(path, cfg); err != nil {
+			utils.Fatalf("Failed to write back the configuration: %v", err)
+		}
+		log.Info("Wrote back the resolved configuration", "path", path)
+	}
+
 	utils.RegisterKowalaService(stack, &cfg.Kowala)
 
 	// Add the Stats daemon if requested.
 	statsURL := cfg.Stats.GetURL()
 	if statsURL != "" {
-		utils.RegisterKowalaStatsService(stack, statsURL)
+		utils.RegisterKowalaStatsService(stack, statsURL, cfg.Stats.Insecure)
 	}
 
 	return stack
@@ -129,6 +360,88 @@ func makeFullNode(ctx *cli.Context) *node.Node {
 // dumpConfig is the dumpconfig command.
 func dumpConfig(ctx *cli.Context) error {
 	_, cfg := makeConfigNode(ctx)
+	return writeConfig(os.Stdout, cfg)
+}
+
+// validateConfig is the main entry point with --validate-config. It resolves the
+// configuration like a node startup and checks the genesis block against the
+// chain database without modifying it, exiting before any service starts.
+func validateConfig(ctx *cli.Context) error {
+	stack, cfg := makeConfigNode(ctx)
+	if errs := cfg.Kowala.Validate(); len(errs) > 0 {
+		return fmt.Errorf("[Kowala] %v", knode.ConfigErrors(errs))
+	}
+
+	path := stack.ResolvePath(utils.ChainDatabaseName(cfg.Kowala.SyncMode == downloader.LightSync))
+	chainConfig, hash, err := checkGenesis(path, cfg.Kowala.Genesis, cfg.Kowala.DatabaseCache, cfg.Kowala.DatabaseHandles)
+	switch err := err.(type) {
+	case nil:
+	case *params.ConfigCompatError:
+		fmt.Printf("Chain configuration change rewinds the chain to block %d: %v\n", err.RewindTo, err)
+	default:
+		return err
+	}
+	if path == "" {
+		fmt.Println("No data directory, the genesis block is kept in memory")
+	} else if _, err := os.Stat(path); os.IsNotExist(err) {
+		fmt.Printf("No chain database at %s, the genesis block is written on startup\n", path)
+	}
+	fmt.Printf("Genesis block: %x\n", hash)
+	fmt.Printf("Chain configuration: %v\n", chainConfig)
+	fmt.Println("Configuration is valid")
+	return nil
+}
+
+// checkGenesis sets the genesis block up against the chain database at path
+// like a node startup, leaving the database untouched.
+func checkGenesis(path string, genesis *core.Genesis, cache, handles int) (*params.ChainConfig, common.Hash, error) {
+	if _, err := os.Stat(path); path == "" || os.IsNotExist(err) {
+		return core.SetupGenesisBlock(kcoindb.NewMemDatabase(), genesis)
+	}
+	db, err := kcoindb.NewReadOnlyLDBDatabase(path, cache, handles)
+	if err != nil {
+		return nil, common.Hash{}, fmt.Errorf("Could not open database: %v", err)
+	}
+	defer db.Close()
+
+	return core.SetupGenesisBlock(&overlayDatabase{Database: db, writes: kcoindb.NewMemDatabase()}, genesis)
+}
+
+// overlayDatabase keeps the writes to a database in memory, reading the keys not
+// written from the underlying database.
+type overlayDatabase struct {
+	kcoindb.Database
+	writes *kcoindb.MemDatabase
+}
+
+func (db *overlayDatabase) Put(key []byte, value []byte) error {
+	return db.writes.Put(key, value)
+}
+
+func (db *overlayDatabase) Has(key []byte) (bool, error) {
+	if ok, _ := db.writes.Has(key); ok {
+		return true, nil
+	}
+	return db.Database.Has(key)
+}
+
+func (db *overlayDatabase) Get(key []byte) ([]byte, error) {
+	if value, err := db.writes.Get(key); err == nil {
+		return value, nil
+	}
+	return db.Database.Get(key)
+}
+
+func (db *overlayDatabase) Delete(key []byte) error {
+	return errors.New("delete not supported by the overlay database")
+}
+
+func (db *overlayDatabase) NewBatch() kcoindb.Batch {
+	return db.writes.NewBatch()
+}
+
+// writeConfig encodes the configuration as TOML, leaving out the genesis block.
+func writeConfig(w io.Writer, cfg kcoinConfig) error {
 	comment := ""
 
 	if cfg.Kowala.Genesis != nil {
@@ -140,7 +453,98 @@ func dumpConfig(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	io.WriteString(os.Stdout, comment)
-	os.Stdout.Write(out)
+	if _, err := io.WriteString(w, comment); err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// writeConfigFile writes the configuration as TOML to the given file.
+func writeConfigFile(path string, cfg kcoinConfig) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if err := writeConfig(f, cfg); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// configLayer is a named stage of the configuration resolution process along
+// with the configuration as it stands after the stage has been applied.
+type configLayer struct {
+	Name   string
+	Config *kcoinConfig
+}
+
+// explainConfig is the config explain command.
+func explainConfig(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires a single configuration field as argument.")
+	}
+	defaults := defaultKcoinConfig()
+
+	file := defaultKcoinConfig()
+	if path := ctx.GlobalString(configFileFlag.Name); path != "" {
+		if err := loadConfig(path, &file); err != nil {
+			utils.Fatalf("%v", err)
+		}
+	}
+	_, flags := makeConfigNode(ctx)
+
+	source, value, err := configSource(ctx.Args().First(), []configLayer{
+		{"default", &defaults},
+		{"file", &file},
+		{"flag", &flags},
+	})
+	if err != nil {
+		utils.Fatalf("%v", err)
+	}
+	fmt.Printf("%s = %v (source: %s)\n", ctx.Args().First(), value, source)
 	return nil
 }
+
+// configSource resolves the dotted field path in each of the given layers and
+// returns the name of the last layer that changed its value, together with the
+// effective value itself.
+func configSource(field string, layers []configLayer) (string, interface{}, error) {
+	var (
+		source string
+		value  interface{}
+	)
+	for i, layer := range layers {
+		current, err := configField(layer.Config, field)
+		if err != nil {
+			return "", nil, err
+		}
+		if i == 0 || !reflect.DeepEqual(current, value) {
+			source = layer.Name
+		}
+		value = current
+	}
+	return source, value, nil
+}
+
+// configField retrieves the value of a dotted field path (e.g. Node.HTTPPort)
+// from the given configuration.
+func configField(cfg *kcoinConfig, field string) (interface{}, error) {
+	value := reflect.ValueOf(cfg).Elem()
+	for _, name := range strings.Split(field, ".") {
+		for value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				return nil, fmt.Errorf("field '%s' is unset", field)
+			}
+			value = value.Elem()
+		}
+		if value.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("field '%s' is not defined: %s is not a section", field, value.Type())
+		}
+		if value = value.FieldByName(name); !value.IsValid() || !unicode.IsUpper(rune(name[0])) {
+			return nil, fmt.Errorf("field '%s' is not defined", field)
+		}
+	}
+	return value.Interface(), nil
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/core"
+	"github.com/kowala-tech/kcoin/client/core/rawdb"
+	"github.com/kowala-tech/kcoin/client/kcoindb"
+	"github.com/kowala-tech/kcoin/client/knode/validator"
+	"github.com/kowala-tech/kcoin/client/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigSource(t *testing.T) {
+	dir := tmpdir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.toml")
+	require.NoError(t, ioutil.WriteFile(path, []byte("[Kowala.TxPool]\nPriceLimit = 5\nPriceBump = 20\n"), 0644))
+
+	defaults := defaultKcoinConfig()
+
+	file := defaultKcoinConfig()
+	require.NoError(t, loadConfig(path, &file))
+
+	flags := file
+	flags.Kowala.TxPool.PriceBump = 30
+	flags.Node.HTTPPort = 1234
+
+	layers := []configLayer{
+		{"default", &defaults},
+		{"file", &file},
+		{"flag", &flags},
+	}
+	testCases := []struct {
+		field  string
+		source string
+		value  interface{}
+	}{
+		{"Kowala.NetworkId", "default", defaults.Kowala.NetworkId},
+		{"Kowala.TxPool.PriceLimit", "file", uint64(5)},
+		{"Kowala.TxPool.PriceBump", "flag", uint64(30)},
+		{"Node.HTTPPort", "flag", 1234},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.field, func(t *testing.T) {
+			source, value, err := configSource(tc.field, layers)
+			require.NoError(t, err)
+			assert.Equal(t, tc.source, source)
+			assert.Equal(t, tc.value, value)
+		})
+	}
+}
+
+func TestConfigSourceUnknownField(t *testing.T) {
+	cfg := defaultKcoinConfig()
+
+	for _, field := range []string{"Kowala.Unknown", "Kowala.TxPool.PriceLimit.Value", "Kowala..TxPool", "kowala"} {
+		_, _, err := configSource(field, []configLayer{{"default", &cfg}})
+		assert.Error(t, err, field)
+	}
+}
+
+func TestLoadConfigReportsAllProblems(t *testing.T) {
+	dir := tmpdir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.toml")
+	require.NoError(t, ioutil.WriteFile(path, []byte("[Kowala]\nLightPeers = -5\nDatabaseCache = 0\n"), 0644))
+
+	cfg := defaultKcoinConfig()
+	err := loadConfig(path, &cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), path)
+	assert.Contains(t, err.Error(), "LightPeers")
+	assert.Contains(t, err.Error(), "DatabaseCache")
+}
+
+func TestWriteConfigFileReloads(t *testing.T) {
+	dir := tmpdir(t)
+	defer os.RemoveAll(dir)
+
+	cfg := defaultKcoinConfig()
+	cfg.Node.HTTPPort = 9000
+	cfg.Node.HTTPModules = []string{"eth", "validator"}
+	cfg.Node.ShutdownTimeout = time.Minute
+	cfg.Kowala.TxPool.PriceLimit = 5
+	cfg.Kowala.EmptyBlocks = validator.EmptyBlocksNever
+	cfg.Kowala.MaxBlockTxs = 100
+	cfg.Kowala.PriorityAddresses = []common.Address{common.HexToAddress("0x6d6b6f2e1cc1b6ed2a85c3b2e3e4cba9e7d3c7f4")}
+	cfg.Stats.URL = "node:secret@stats.kowala.tech"
+
+	path := filepath.Join(dir, "config.toml")
+	require.NoError(t, writeConfigFile(path, cfg))
+
+	reloaded := defaultKcoinConfig()
+	require.NoError(t, loadConfig(path, &reloaded))
+
+	// Empty lists decode as empty rather than nil slices, compare the encodings
+	var want, have bytes.Buffer
+	require.NoError(t, writeConfig(&want, cfg))
+	require.NoError(t, writeConfig(&have, reloaded))
+	assert.Equal(t, want.String(), have.String())
+
+	assert.Equal(t, cfg.Node.HTTPPort, reloaded.Node.HTTPPort)
+	assert.Equal(t, cfg.Kowala.EmptyBlocks, reloaded.Kowala.EmptyBlocks)
+	assert.Equal(t, cfg.Kowala.PriorityAddresses, reloaded.Kowala.PriorityAddresses)
+}
+
+func TestExpandEnv(t *testing.T) {
+	env := map[string]string{
+		"KCOIN_HOST":   "10.0.0.1",
+		"KCOIN_EMPTY":  "",
+		"KCOIN_PORT":   "8545",
+		"KCOIN_INJECT": "x\"\nHTTPHost = \"0.0.0.0",
+		"KCOIN_QUOTE":  "it's",
+	}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+	testCases := []struct {
+		input  string
+		output string
+	}{
+		{`Host = "${KCOIN_HOST}"`, `Host = "10.0.0.1"`},
+		{`Host = "${KCOIN_HOST:-127.0.0.1}"`, `Host = "10.0.0.1"`},
+		{`Host = "${KCOIN_UNSET:-127.0.0.1}"`, `Host = "127.0.0.1"`},
+		{`Host = "${KCOIN_UNSET:-}"`, `Host = ""`},
+		{`Host = "${KCOIN_EMPTY:-127.0.0.1}"`, `Host = "127.0.0.1"`},
+		{`Host = "${KCOIN_EMPTY}"`, `Host = ""`},
+		{`Host = "$${KCOIN_HOST}"`, `Host = "${KCOIN_HOST}"`},
+		{`Host = "$KCOIN_HOST"`, `Host = "$KCOIN_HOST"`},
+		{`Port = ${KCOIN_PORT}`, `Port = 8545`},
+		// Comments are left untouched, even with references to unset variables
+		{"# Host = \"${KCOIN_UNSET}\"\nHost = \"${KCOIN_HOST}\" # ${KCOIN_HOST}", "# Host = \"${KCOIN_UNSET}\"\nHost = \"10.0.0.1\" # ${KCOIN_HOST}"},
+		{`Host = "#${KCOIN_HOST}" # "${KCOIN_UNSET}"`, `Host = "#10.0.0.1" # "${KCOIN_UNSET}"`},
+		{`Host = "\"#${KCOIN_HOST}"`, `Host = "\"#10.0.0.1"`},
+		{`Host = '#${KCOIN_HOST}'`, `Host = '#10.0.0.1'`},
+		// Values are escaped for the strings they're put in
+		{`Host = "${KCOIN_INJECT}"`, `Host = "x\"\nHTTPHost = \"0.0.0.0"`},
+		{`Host = """${KCOIN_QUOTE}"""`, `Host = """it's"""`},
+		{`Host = '''${KCOIN_QUOTE}'''`, `Host = '''it's'''`},
+	}
+	for _, tc := range testCases {
+		output, err := expandEnv([]byte(tc.input), lookup)
+		require.NoError(t, err, tc.input)
+		assert.Equal(t, tc.output, string(output), tc.input)
+	}
+
+	_, err := expandEnv([]byte("A = \"${KCOIN_MISSING}\"\nB = \"${KCOIN_OTHER}\"\nC = \"${KCOIN_MISSING}\"\n"), lookup)
+	require.Error(t, err)
+	assert.Equal(t, "environment variables not set: KCOIN_MISSING, KCOIN_OTHER", err.Error())
+
+	// Values that can't be escaped where they're put are rejected
+	for _, input := range []string{`Host = '${KCOIN_QUOTE}'`, `Port = ${KCOIN_INJECT}`, `Port = ${KCOIN_HOST}x${KCOIN_QUOTE}`} {
+		_, err := expandEnv([]byte(input), lookup)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestLoadConfigExpandsEnv(t *testing.T) {
+	dir := tmpdir(t)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, os.Setenv("KCOIN_TEST_HTTP_HOST", "10.0.0.1"))
+	defer os.Unsetenv("KCOIN_TEST_HTTP_HOST")
+
+	path := filepath.Join(dir, "config.toml")
+	require.NoError(t, ioutil.WriteFile(path, []byte("[Node]\nHTTPHost = \"${KCOIN_TEST_HTTP_HOST}\"\nHTTPPort = ${KCOIN_TEST_HTTP_PORT:-8545}\n"), 0644))
+
+	cfg := defaultKcoinConfig()
+	require.NoError(t, loadConfig(path, &cfg))
+	assert.Equal(t, "10.0.0.1", cfg.Node.HTTPHost)
+	assert.Equal(t, 8545, cfg.Node.HTTPPort)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("[Node]\nHTTPHost = \"${KCOIN_TEST_UNSET_HOST}\"\n"), 0644))
+	err := loadConfig(path, &cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), path)
+	assert.Contains(t, err.Error(), "KCOIN_TEST_UNSET_HOST")
+}
+
+func TestCheckGenesisLeavesDatabaseUntouched(t *testing.T) {
+	dir := tmpdir(t)
+	defer os.RemoveAll(dir)
+
+	// Without a database the genesis block is only set up in memory
+	path := filepath.Join(dir, "chaindata")
+	_, hash, err := checkGenesis(path, core.DefaultTestnetGenesisBlock(), 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, core.DefaultTestnetGenesisBlock().ToBlock(nil).Hash(), hash)
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "database created")
+
+	db, err := kcoindb.NewLDBDatabase(path, 0, 0)
+	require.NoError(t, err)
+	block, err := core.DefaultTestnetGenesisBlock().Commit(db)
+	require.NoError(t, err)
+	db.Close()
+
+	// A compatible config change is accepted but not written
+	genesis := core.DefaultTestnetGenesisBlock()
+	config := *genesis.Config
+	config.Forks = []params.Fork{{Name: "andromeda", Block: big.NewInt(10)}}
+	genesis.Config = &config
+
+	chainConfig, hash, err := checkGenesis(path, genesis, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, block.Hash(), hash)
+	assert.Equal(t, config.Forks, chainConfig.Forks)
+
+	db, err = kcoindb.NewLDBDatabase(path, 0, 0)
+	require.NoError(t, err)
+	stored := rawdb.ReadChainConfig(db, block.Hash())
+	db.Close()
+	require.NotNil(t, stored)
+	assert.Empty(t, stored.Forks)
+
+	// Another genesis block is rejected
+	_, _, err = checkGenesis(path, core.DefaultGenesisBlock(), 0, 0)
+	assert.IsType(t, &core.GenesisMismatchError{}, err)
+}
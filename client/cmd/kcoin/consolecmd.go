@@ -17,7 +17,7 @@ import (
 )
 
 var (
-	consoleFlags = []cli.Flag{utils.JSpathFlag, utils.ExecFlag, utils.PreloadJSFlag}
+	consoleFlags = []cli.Flag{utils.JSpathFlag, utils.ExecFlag, utils.PreloadJSFlag, utils.PreloadStrictFlag}
 
 	consoleCommand = cli.Command{
 		Action:   utils.MigrateFlags(localConsole),
@@ -76,6 +76,7 @@ func localConsole(ctx *cli.Context) error {
 		DocRoot: ctx.GlobalString(utils.JSpathFlag.Name),
 		Client:  client,
 		Preload: utils.MakeConsolePreloads(ctx),
+		Strict:  ctx.GlobalBool(utils.PreloadStrictFlag.Name),
 	}
 
 	console, err := console.New(config)
@@ -123,6 +124,7 @@ func remoteConsole(ctx *cli.Context) error {
 		DocRoot: ctx.GlobalString(utils.JSpathFlag.Name),
 		Client:  client,
 		Preload: utils.MakeConsolePreloads(ctx),
+		Strict:  ctx.GlobalBool(utils.PreloadStrictFlag.Name),
 	}
 
 	console, err := console.New(config)
@@ -176,6 +178,7 @@ func ephemeralConsole(ctx *cli.Context) error {
 		DocRoot: ctx.GlobalString(utils.JSpathFlag.Name),
 		Client:  client,
 		Preload: utils.MakeConsolePreloads(ctx),
+		Strict:  ctx.GlobalBool(utils.PreloadStrictFlag.Name),
 	}
 
 	console, err := console.New(config)
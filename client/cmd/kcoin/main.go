@@ -2,14 +2,15 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"math"
+	"math/big"
 	"os"
 	"runtime"
 	godebug "runtime/debug"
 	"sort"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/elastic/gosigar"
@@ -23,9 +24,11 @@ import (
 	"github.com/kowala-tech/kcoin/client/log"
 	"github.com/kowala-tech/kcoin/client/metrics"
 	"github.com/kowala-tech/kcoin/client/node"
+	"github.com/kowala-tech/kcoin/client/stats"
 	"gopkg.in/urfave/cli.v1"
 	"github.com/kowala-tech/kcoin/client/version"
 	"github.com/kowala-tech/kcoin/client/params"
+	"github.com/mattn/go-isatty"
 )
 
 const (
@@ -43,9 +46,13 @@ var (
 		utils.BootnodesFlag,
 		utils.BootnodesV4Flag,
 		utils.BootnodesV5Flag,
+		utils.DNSDiscoveryFlag,
+		utils.StaticNodesFlag,
+		utils.TrustedNodesFlag,
 		utils.DataDirFlag,
 		utils.KeyStoreDirFlag,
 		utils.NoUSBFlag,
+		utils.ShutdownTimeoutFlag,
 		utils.TxPoolNoLocalsFlag,
 		utils.TxPoolJournalFlag,
 		utils.TxPoolRejournalFlag,
@@ -55,27 +62,41 @@ var (
 		utils.TxPoolGlobalSlotsFlag,
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
+		utils.TxPoolMaxNonceGapFlag,
 		utils.TxPoolLifetimeFlag,
+		utils.TxPoolAllowedSendersFlag,
+		utils.TxPoolBroadcastPeersFlag,
+		utils.TxPoolOrderingFlag,
 		utils.FastSyncFlag,
 		utils.LightModeFlag,
 		utils.SyncModeFlag,
 		utils.GCModeFlag,
+		utils.SnapshotVerifyFlag,
+		utils.WhitelistFlag,
 		utils.LightServFlag,
 		utils.LightPeersFlag,
 		utils.LightKDFFlag,
+		utils.KeyStoreScryptNFlag,
+		utils.KeyStoreScryptPFlag,
 		utils.VersionRepository,
 		utils.SelfUpdateEnabledFlag,
 		utils.CacheFlag,
 		utils.CacheDatabaseFlag,
 		utils.CacheGCFlag,
 		utils.TrieCacheGenFlag,
+		utils.DiskMinFreeFlag,
 		utils.ListenPortFlag,
 		utils.MaxPeersFlag,
 		utils.MaxPendingPeersFlag,
+		utils.ConnThrottleFlag,
+		utils.ConnThrottleAttemptsFlag,
 		utils.CoinbaseFlag,
 		utils.GasPriceFlag,
 		utils.ValidatorDepositFlag,
 		utils.ValidationEnabledFlag,
+		utils.ValidationConfirmFlag,
+		utils.EmptyBlocksFlag,
+		utils.EmptyBlocksTimeoutFlag,
 		utils.TargetGasLimitFlag,
 		utils.NATFlag,
 		utils.NoDiscoverFlag,
@@ -83,22 +104,42 @@ var (
 		utils.NodeKeyFileFlag,
 		utils.NodeKeyHexFlag,
 		utils.DevModeFlag,
+		utils.DevPeriodFlag,
 		utils.TestnetFlag,
 		utils.CurrencyFlag,
 		utils.VMEnableDebugFlag,
 		utils.NetworkIdFlag,
+		utils.ChainIDFlag,
 		utils.RPCCORSDomainFlag,
 		utils.RPCVirtualHostsFlag,
+		utils.RPCTLSCertFlag,
+		utils.RPCTLSKeyFlag,
+		utils.RPCAuthSecretFlag,
+		utils.RPCRateLimitFlag,
+		utils.RPCMethodRateLimitFlag,
+		utils.RPCDisableFlag,
+		utils.RPCBatchLimitFlag,
 		utils.ShipLogzioFlag,
 		utils.KowalaStatsURLFlag,
+		utils.KowalaStatsInsecureFlag,
 		utils.MetricsEnabledFlag,
 		utils.MetricsPrometheusAddressFlag,
 		utils.MetricsPrometheusSubsystemFlag,
 		utils.NoCompactionFlag,
 		utils.GpoBlocksFlag,
 		utils.GpoPercentileFlag,
+		utils.GpoCongestionFlag,
+		utils.KonsensusBlockTimeFlag,
+		utils.CommitTimeoutFlag,
+		utils.LivenessTimeoutFlag,
+		utils.ConsensusGossipFlag,
 		utils.ExtraDataFlag,
+		utils.ExtraDataRandomFlag,
+		utils.BlockMaxTxsFlag,
+		utils.BlockPriorityAddressesFlag,
 		configFileFlag,
+		configWritebackFlag,
+		validateConfigFlag,
 	}
 
 	rpcFlags = []cli.Flag{
@@ -121,6 +162,7 @@ var (
 		utils.MetricsInfluxDBDatabaseFlag,
 		utils.MetricsInfluxDBUsernameFlag,
 		utils.MetricsInfluxDBPasswordFlag,
+		utils.MetricsInfluxDBIntervalFlag,
 		utils.MetricsInfluxDBHostTagFlag,
 	}
 )
@@ -137,9 +179,12 @@ func init() {
 		exportCommand,
 		importPreimagesCommand,
 		exportPreimagesCommand,
+		dumpPreimagesCommand,
 		copydbCommand,
 		removedbCommand,
 		dumpCommand,
+		blockCommand,
+		genesisCommand,
 		// See monitorcmd.go:
 		monitorCommand,
 		// See accountcmd.go:
@@ -156,6 +201,7 @@ func init() {
 		licenseCommand,
 		// See config.go
 		dumpConfigCommand,
+		configCommand,
 		showAddressesCommand,
 	}
 	sort.Sort(cli.CommandsByName(app.Commands))
@@ -224,9 +270,8 @@ func setupLogging(ctx *cli.Context) {
 	}
 
 	// append hostname to log context from stats URL flag or hostname
-	parts := strings.Split(ctx.GlobalString(utils.KowalaStatsURLFlag.Name), ":")
-	if len(parts) > 1 {
-		log.SetContext("hostname", parts[0])
+	if url, err := stats.ParseURL(ctx.GlobalString(utils.KowalaStatsURLFlag.Name)); err == nil {
+		log.SetContext("hostname", url.Node)
 	} else if hostname, err := os.Hostname(); err == nil {
 		log.SetContext("hostname", hostname)
 	}
@@ -243,6 +288,9 @@ func main() {
 // It creates a default node based on the command line arguments and runs it in
 // blocking mode, waiting for it to be shut down.
 func kowala(ctx *cli.Context) error {
+	if ctx.GlobalBool(validateConfigFlag.Name) {
+		return validateConfig(ctx)
+	}
 	node := makeFullNode(ctx)
 	startNode(ctx, node)
 	node.Wait()
@@ -268,11 +316,8 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
 
 	passwords := utils.MakePasswordList(ctx)
-	unlocks := strings.Split(ctx.GlobalString(utils.UnlockedAccountFlag.Name), ",")
-	for i, account := range unlocks {
-		if trimmed := strings.TrimSpace(account); trimmed != "" {
-			unlockAccount(ctx, ks, trimmed, i, passwords)
-		}
+	for i, account := range utils.GlobalList(ctx, utils.UnlockedAccountFlag.Name) {
+		unlockAccount(ctx, ks, account, i, passwords)
 	}
 	// Register wallet event handlers to open and auto-derive wallets
 	events := make(chan accounts.WalletEvent, 16)
@@ -324,6 +369,25 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 			utils.Fatalf("kowala service not running: %v", err)
 		}
 
+		// Make sure the validator can sign with its coinbase before going any further
+		coinbase, err := kowala.Coinbase()
+		if err != nil {
+			utils.Fatalf("Failed to start validation: %v", err)
+		}
+		if err := utils.CheckValidatorCoinbase(ks, coinbase); err != nil {
+			utils.Fatalf("Failed to start validation: %v", err)
+		}
+
+		// Validating puts the deposit at stake, make sure the user is aware of it
+		deposit, err := kowala.Deposit()
+		if err != nil {
+			utils.Fatalf("Failed to start validation: %v", err)
+		}
+		interactive := isatty.IsTerminal(os.Stdin.Fd())
+		if err := confirmDeposit(ctx, deposit, interactive, console.Stdin.PromptConfirm); err != nil {
+			utils.Fatalf("Failed to start validation: %v", err)
+		}
+
 		// Set the gas price to the limits from the CLI and start mining
 		kowala.TxPool().SetGasPrice(utils.GlobalBig(ctx, utils.GasPriceFlag.Name))
 		if err := kowala.StartValidating(); err != nil {
@@ -337,6 +401,33 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 	}
 }
 
+// errValidationNotConfirmed is returned if the user declined to stake the deposit.
+var errValidationNotConfirmed = errors.New("deposit staking not confirmed")
+
+// confirmDeposit warns about the deposit put at risk by validating and requires
+// an explicit confirmation before going on: either the --validate.confirm flag
+// or, if attached to a terminal, an answer to a prompt. Non-interactive nodes
+// refuse to validate without the flag.
+func confirmDeposit(ctx *cli.Context, deposit *big.Int, interactive bool, confirm func(string) (bool, error)) error {
+	amount := new(big.Float).Quo(new(big.Float).SetInt(deposit), big.NewFloat(params.Kcoin))
+	log.Warn("Validation stakes the deposit, which can be slashed if the validator misbehaves", "deposit", amount.Text('f', -1)+" kcoin")
+
+	switch {
+	case ctx.GlobalBool(utils.ValidationConfirmFlag.Name):
+		return nil
+	case !interactive:
+		return fmt.Errorf("%v, use --%s to stake it in non-interactive mode", errValidationNotConfirmed, utils.ValidationConfirmFlag.Name)
+	}
+	ok, err := confirm(fmt.Sprintf("Stake %s kcoin and start validating?", amount.Text('f', -1)))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errValidationNotConfirmed
+	}
+	return nil
+}
+
 func isTestnetOrDevnet(ctx *cli.Context) bool {
 	return isTestnet(ctx) || isDevnet(ctx)
 }
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"math/big"
+	"testing"
+
+	"github.com/kowala-tech/kcoin/client/cmd/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/urfave/cli.v1"
+)
+
+func newValidateContext(t *testing.T, args ...string) *cli.Context {
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	utils.ValidationConfirmFlag.Apply(set)
+	require.NoError(t, set.Parse(args))
+	return cli.NewContext(nil, set, nil)
+}
+
+func TestConfirmDeposit(t *testing.T) {
+	deposit := big.NewInt(1e18)
+
+	testCases := []struct {
+		name        string
+		args        []string
+		interactive bool
+		answer      bool
+		prompted    bool
+		err         bool
+	}{
+		{name: "non-interactive without confirmation", err: true},
+		{name: "non-interactive with confirmation", args: []string{"--validate.confirm"}},
+		{name: "interactive with confirmation", args: []string{"--validate.confirm"}, interactive: true},
+		{name: "interactive accepted", interactive: true, answer: true, prompted: true},
+		{name: "interactive declined", interactive: true, prompted: true, err: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prompted := false
+			confirm := func(string) (bool, error) {
+				prompted = true
+				return tc.answer, nil
+			}
+			err := confirmDeposit(newValidateContext(t, tc.args...), deposit, tc.interactive, confirm)
+			if tc.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.prompted, prompted)
+		})
+	}
+}
+
+func TestConfirmDepositPromptError(t *testing.T) {
+	failure := errors.New("prompt failed")
+	confirm := func(string) (bool, error) { return true, failure }
+
+	assert.Equal(t, failure, confirmDeposit(newValidateContext(t), big.NewInt(0), true, confirm))
+}
@@ -51,19 +51,29 @@ var AppHelpFlagGroups = []flagGroup{
 		Name: "KOWALA",
 		Flags: []cli.Flag{
 			configFileFlag,
+			configWritebackFlag,
+			validateConfigFlag,
 			utils.DataDirFlag,
 			utils.KeyStoreDirFlag,
 			utils.NoUSBFlag,
+			utils.ShutdownTimeoutFlag,
 			utils.NetworkIdFlag,
+			utils.ChainIDFlag,
 			utils.TestnetFlag,
 			utils.DevModeFlag,
+			utils.DevPeriodFlag,
 			utils.SyncModeFlag,
 			utils.GCModeFlag,
+			utils.SnapshotVerifyFlag,
+			utils.WhitelistFlag,
 			utils.KowalaStatsURLFlag,
+			utils.KowalaStatsInsecureFlag,
 			utils.IdentityFlag,
 			utils.LightServFlag,
 			utils.LightPeersFlag,
 			utils.LightKDFFlag,
+			utils.KeyStoreScryptNFlag,
+			utils.KeyStoreScryptPFlag,
 			utils.VersionRepository,
 		},
 	},
@@ -79,7 +89,11 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TxPoolGlobalSlotsFlag,
 			utils.TxPoolAccountQueueFlag,
 			utils.TxPoolGlobalQueueFlag,
+			utils.TxPoolMaxNonceGapFlag,
 			utils.TxPoolLifetimeFlag,
+			utils.TxPoolAllowedSendersFlag,
+			utils.TxPoolBroadcastPeersFlag,
+			utils.TxPoolOrderingFlag,
 		},
 	},
 	{
@@ -89,6 +103,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.CacheDatabaseFlag,
 			utils.CacheGCFlag,
 			utils.TrieCacheGenFlag,
+			utils.DiskMinFreeFlag,
 		},
 	},
 	{
@@ -114,9 +129,17 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.IPCPathFlag,
 			utils.RPCCORSDomainFlag,
 			utils.RPCVirtualHostsFlag,
+			utils.RPCTLSCertFlag,
+			utils.RPCTLSKeyFlag,
+			utils.RPCAuthSecretFlag,
+			utils.RPCRateLimitFlag,
+			utils.RPCMethodRateLimitFlag,
+			utils.RPCDisableFlag,
+			utils.RPCBatchLimitFlag,
 			utils.JSpathFlag,
 			utils.ExecFlag,
 			utils.PreloadJSFlag,
+			utils.PreloadStrictFlag,
 		},
 	},
 	{
@@ -125,9 +148,14 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.BootnodesFlag,
 			utils.BootnodesV4Flag,
 			utils.BootnodesV5Flag,
+			utils.DNSDiscoveryFlag,
+			utils.StaticNodesFlag,
+			utils.TrustedNodesFlag,
 			utils.ListenPortFlag,
 			utils.MaxPeersFlag,
 			utils.MaxPendingPeersFlag,
+			utils.ConnThrottleFlag,
+			utils.ConnThrottleAttemptsFlag,
 			utils.NATFlag,
 			utils.NoDiscoverFlag,
 			utils.NetrestrictFlag,
@@ -140,10 +168,16 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.ValidationEnabledFlag,
 			utils.ValidatorDepositFlag,
+			utils.ValidationConfirmFlag,
+			utils.EmptyBlocksFlag,
+			utils.EmptyBlocksTimeoutFlag,
 			utils.CoinbaseFlag,
 			utils.TargetGasLimitFlag,
 			utils.GasPriceFlag,
 			utils.ExtraDataFlag,
+			utils.ExtraDataRandomFlag,
+			utils.BlockMaxTxsFlag,
+			utils.BlockPriorityAddressesFlag,
 		},
 	},
 	{
@@ -151,6 +185,16 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.GpoBlocksFlag,
 			utils.GpoPercentileFlag,
+			utils.GpoCongestionFlag,
+		},
+	},
+	{
+		Name: "CONSENSUS ENGINE",
+		Flags: []cli.Flag{
+			utils.KonsensusBlockTimeFlag,
+			utils.CommitTimeoutFlag,
+			utils.LivenessTimeoutFlag,
+			utils.ConsensusGossipFlag,
 		},
 	},
 	{
@@ -175,6 +219,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.MetricsInfluxDBDatabaseFlag,
 			utils.MetricsInfluxDBUsernameFlag,
 			utils.MetricsInfluxDBPasswordFlag,
+			utils.MetricsInfluxDBIntervalFlag,
 			utils.MetricsInfluxDBHostTagFlag,
 		},
 	},
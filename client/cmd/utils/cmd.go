@@ -28,6 +28,7 @@ import (
 	"syscall"
 
 	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/common/hexutil"
 	"github.com/kowala-tech/kcoin/client/core"
 	"github.com/kowala-tech/kcoin/client/core/rawdb"
 	"github.com/kowala-tech/kcoin/client/core/types"
@@ -212,10 +213,16 @@ func ExportChain(blockchain *core.BlockChain, fn string) error {
 	return nil
 }
 
-// ExportAppendChain exports a blockchain into the specified file, appending to
-// the file if data already exists in it.
+// ExportAppendChain exports the blocks of the inclusive range first..last into
+// the specified file, appending to the file if data already exists in it.
 func ExportAppendChain(blockchain *core.BlockChain, fn string, first uint64, last uint64) error {
-	log.Info("Exporting blockchain", "file", fn)
+	if first > last {
+		return fmt.Errorf("first block #%d is greater than last block #%d", first, last)
+	}
+	if head := blockchain.CurrentBlock().NumberU64(); last > head {
+		return fmt.Errorf("last block #%d is beyond the chain head #%d", last, head)
+	}
+	log.Info("Exporting blockchain", "file", fn, "first", first, "last", last)
 
 	// Open the file handle and potentially wrap with a gzip stream
 	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_APPEND|os.O_WRONLY, os.ModePerm)
@@ -310,3 +317,26 @@ func ExportPreimages(db *kcoindb.LDBDatabase, fn string) error {
 	log.Info("Exported preimages", "file", fn)
 	return nil
 }
+
+// DumpPreimages writes all known hash preimages to w as a JSON object mapping
+// each hash to its preimage.
+func DumpPreimages(db *kcoindb.LDBDatabase, w io.Writer) error {
+	prefix := []byte("secure-key-")
+	it := db.NewIteratorWithPrefix(prefix)
+	defer it.Release()
+
+	if _, err := io.WriteString(w, "{"); err != nil {
+		return err
+	}
+	for sep := ""; it.Next(); sep = "," {
+		hash := common.BytesToHash(it.Key()[len(prefix):])
+		if _, err := fmt.Fprintf(w, "%s\n  %q: %q", sep, hash.Hex(), hexutil.Encode(it.Value())); err != nil {
+			return err
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n}\n")
+	return err
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/common/hexutil"
+	"github.com/kowala-tech/kcoin/client/consensus/konsensus"
+	"github.com/kowala-tech/kcoin/client/core"
+	"github.com/kowala-tech/kcoin/client/core/rawdb"
+	"github.com/kowala-tech/kcoin/client/core/vm"
+	"github.com/kowala-tech/kcoin/client/crypto"
+	"github.com/kowala-tech/kcoin/client/kcoindb"
+	"github.com/kowala-tech/kcoin/client/params"
+)
+
+func TestDumpPreimages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kcoin-preimages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := kcoindb.NewLDBDatabase(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	dump := func() map[common.Hash]hexutil.Bytes {
+		var buf bytes.Buffer
+		if err := DumpPreimages(db, &buf); err != nil {
+			t.Fatalf("dump failed: %v", err)
+		}
+		preimages := make(map[common.Hash]hexutil.Bytes)
+		if err := json.Unmarshal(buf.Bytes(), &preimages); err != nil {
+			t.Fatalf("invalid dump %q: %v", buf.String(), err)
+		}
+		return preimages
+	}
+	if preimages := dump(); len(preimages) != 0 {
+		t.Fatalf("empty database dumped %d preimages", len(preimages))
+	}
+
+	want := make(map[common.Hash]hexutil.Bytes)
+	stored := make(map[common.Hash][]byte)
+	for _, blob := range [][]byte{{0x01}, []byte("slot"), common.LeftPadBytes([]byte{0x2a}, 32)} {
+		hash := crypto.Keccak256Hash(blob)
+		want[hash], stored[hash] = blob, blob
+	}
+	rawdb.WritePreimages(db, 0, stored)
+	// Entries outside of the preimage space aren't dumped
+	db.Put([]byte("secure"), []byte{0xff})
+
+	if have := dump(); !reflect.DeepEqual(have, want) {
+		t.Fatalf("preimages mismatch: have %v, want %v", have, want)
+	}
+}
+
+// newTestChain returns a chain of the given number of blocks on top of the
+// test genesis.
+func newTestChain(t *testing.T, blocks int) *core.BlockChain {
+	db := kcoindb.NewMemDatabase()
+	genesis := (&core.Genesis{Config: params.TestChainConfig}).MustCommit(db)
+	chain, err := core.NewBlockChain(db, nil, params.TestChainConfig, konsensus.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, _ := core.GenerateChain(params.TestChainConfig, genesis, konsensus.NewFaker(), db, blocks, nil)
+	if _, err := chain.InsertChain(generated); err != nil {
+		t.Fatalf("failed to insert the chain: %v", err)
+	}
+	return chain
+}
+
+func TestExportChainRange(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kcoin-export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	source := newTestChain(t, 5)
+	defer source.Stop()
+
+	fn := filepath.Join(dir, "range.rlp")
+	for _, rng := range [][2]uint64{{3, 2}, {4, 6}} {
+		if err := ExportAppendChain(source, fn, rng[0], rng[1]); err == nil {
+			t.Errorf("range %d-%d exported", rng[0], rng[1])
+		}
+	}
+	if _, err := os.Stat(fn); !os.IsNotExist(err) {
+		t.Fatalf("file written for invalid ranges: %v", err)
+	}
+
+	if err := ExportAppendChain(source, fn, 1, 3); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	target := newTestChain(t, 0)
+	defer target.Stop()
+	if err := ImportChain(target, fn); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if head := target.CurrentBlock(); head.NumberU64() != 3 || head.Hash() != source.GetBlockByNumber(3).Hash() {
+		t.Fatalf("imported head mismatch: have #%d %x, want #3 %x", head.NumberU64(), head.Hash(), source.GetBlockByNumber(3).Hash())
+	}
+}
@@ -185,6 +185,52 @@ func GlobalBig(ctx *cli.Context, name string) *big.Int {
 	return (*big.Int)(val.(*bigValue))
 }
 
+// ListFlag is a command line flag that accepts a comma separated list of values.
+// The flag may be repeated, accumulating the values of all occurrences.
+type ListFlag struct {
+	Name  string
+	Usage string
+}
+
+// listValue turns a list of strings into a flag.Value
+type listValue []string
+
+func (l *listValue) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listValue) Set(s string) error {
+	for _, value := range strings.Split(s, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			*l = append(*l, value)
+		}
+	}
+	return nil
+}
+
+func (f ListFlag) GetName() string {
+	return f.Name
+}
+
+func (f ListFlag) String() string {
+	return fmt.Sprintf("%s value\t%v", prefixedNames(f.Name), f.Usage)
+}
+
+func (f ListFlag) Apply(set *flag.FlagSet) {
+	eachName(f.Name, func(name string) {
+		set.Var(new(listValue), f.Name, f.Usage)
+	})
+}
+
+// GlobalList returns the values of a ListFlag from the global flag set.
+func GlobalList(ctx *cli.Context, name string) []string {
+	val := ctx.GlobalGeneric(name)
+	if val == nil {
+		return nil
+	}
+	return *val.(*listValue)
+}
+
 func prefixFor(name string) (prefix string) {
 	if len(name) == 1 {
 		prefix = "-"
@@ -19,7 +19,10 @@ package utils
 import (
 	"os"
 	"os/user"
+	"reflect"
 	"testing"
+
+	"gopkg.in/urfave/cli.v1"
 )
 
 func TestPathExpansion(t *testing.T) {
@@ -39,3 +42,23 @@ func TestPathExpansion(t *testing.T) {
 		}
 	}
 }
+
+func TestListFlag(t *testing.T) {
+	flag := ListFlag{Name: "list"}
+	if have := GlobalList(newTestContext(t, []cli.Flag{flag}), flag.Name); len(have) != 0 {
+		t.Fatalf("list set without the flag: %q", have)
+	}
+
+	ctx := newTestContext(t, []cli.Flag{flag}, "--list", "a, b,", "--list", "c", "--list", "")
+	want := []string{"a", "b", "c"}
+	if have := GlobalList(ctx, flag.Name); !reflect.DeepEqual(have, want) {
+		t.Fatalf("list mismatch: have %q, want %q", have, want)
+	}
+
+	// Migrated flags are set again from their string form
+	ctx = newTestContext(t, []cli.Flag{flag}, "--list", "d", "--list", ctx.GlobalGeneric(flag.Name).(*listValue).String())
+	want = []string{"d", "a", "b", "c"}
+	if have := GlobalList(ctx, flag.Name); !reflect.DeepEqual(have, want) {
+		t.Fatalf("migrated list mismatch: have %q, want %q", have, want)
+	}
+}
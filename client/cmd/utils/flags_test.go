@@ -0,0 +1,387 @@
+package utils
+
+import (
+	"errors"
+	"flag"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kowala-tech/kcoin/client/accounts/keystore"
+	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/crypto"
+	"github.com/kowala-tech/kcoin/client/knode"
+	"github.com/kowala-tech/kcoin/client/knode/validator"
+	"github.com/kowala-tech/kcoin/client/node"
+	"github.com/kowala-tech/kcoin/client/p2p"
+	"github.com/kowala-tech/kcoin/client/p2p/discover"
+	"github.com/kowala-tech/kcoin/client/p2p/dnsdisc"
+	"github.com/kowala-tech/kcoin/client/params"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// newTestContext creates a cli context with the given flags parsed from args.
+func newTestContext(t *testing.T, flags []cli.Flag, args ...string) *cli.Context {
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	for _, f := range flags {
+		f.Apply(set)
+	}
+	if err := set.Parse(args); err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+	return cli.NewContext(nil, set, nil)
+}
+
+func TestSetPersistentNodes(t *testing.T) {
+	valid := "enode://dd38c33eff2ba2fbf152bc698d86fa5baa18b30973e45700c48cdcc8555f2d437160731138960bc46f42b26e363ee5f8f1daa592cafa852669f91ef201ea569d@35.178.226.105:32233"
+
+	testCases := []struct {
+		flag  cli.Flag
+		set   func(*cli.Context, *p2p.Config)
+		nodes func(*p2p.Config) []*discover.Node
+	}{
+		{StaticNodesFlag, setStaticNodes, func(cfg *p2p.Config) []*discover.Node { return cfg.StaticNodes }},
+		{TrustedNodesFlag, setTrustedNodes, func(cfg *p2p.Config) []*discover.Node { return cfg.TrustedNodes }},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.flag.GetName(), func(t *testing.T) {
+			ctx := newTestContext(t, []cli.Flag{tc.flag}, "--"+tc.flag.GetName(), valid+", enode://invalid,")
+			cfg := new(p2p.Config)
+			tc.set(ctx, cfg)
+
+			nodes := tc.nodes(cfg)
+			if len(nodes) != 1 {
+				t.Fatalf("nodes mismatch: have %d, want %d", len(nodes), 1)
+			}
+			if have := nodes[0].String(); have != valid {
+				t.Errorf("node mismatch: have %s, want %s", have, valid)
+			}
+
+			// Without the flag, the nodes are left for the datadir file
+			ctx = newTestContext(t, []cli.Flag{tc.flag})
+			cfg = new(p2p.Config)
+			tc.set(ctx, cfg)
+
+			if nodes := tc.nodes(cfg); nodes != nil {
+				t.Errorf("nodes set without flag: %v", nodes)
+			}
+		})
+	}
+}
+
+func TestCheckValidatorCoinbase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kcoin-keystore-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+
+	// An empty keystore resolves no coinbase at all
+	if err := CheckValidatorCoinbase(ks, common.Address{}); err != errNoValidatorCoinbase {
+		t.Fatalf("empty coinbase: error mismatch: have %v, want %v", err, errNoValidatorCoinbase)
+	}
+	if err := CheckValidatorCoinbase(ks, common.HexToAddress("0x01")); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("unknown coinbase: unexpected error: %v", err)
+	}
+	account, err := ks.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckValidatorCoinbase(ks, account.Address); err == nil || !strings.Contains(err.Error(), "--"+UnlockedAccountFlag.Name) {
+		t.Fatalf("locked coinbase: unexpected error: %v", err)
+	}
+	if err := ks.Unlock(account, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckValidatorCoinbase(ks, account.Address); err != nil {
+		t.Fatalf("unlocked coinbase: unexpected error: %v", err)
+	}
+}
+
+func TestSetKonsensusBlockTime(t *testing.T) {
+	cfg := new(params.KonsensusConfig)
+	setKonsensus(newTestContext(t, []cli.Flag{KonsensusBlockTimeFlag}), cfg)
+	if cfg.BlockTime != 0 {
+		t.Fatalf("block time set without the flag: %d", cfg.BlockTime)
+	}
+
+	setKonsensus(newTestContext(t, []cli.Flag{KonsensusBlockTimeFlag}, "--"+KonsensusBlockTimeFlag.Name, "250ms"), cfg)
+	if have, want := cfg.BlockDuration(), 250*time.Millisecond; have != want {
+		t.Fatalf("block time mismatch: have %v, want %v", have, want)
+	}
+}
+
+func TestSetKonsensusCommitTimeout(t *testing.T) {
+	cfg := new(params.KonsensusConfig)
+	setKonsensus(newTestContext(t, []cli.Flag{CommitTimeoutFlag}), cfg)
+	if cfg.CommitTimeout != 0 {
+		t.Fatalf("commit timeout set without the flag: %d", cfg.CommitTimeout)
+	}
+
+	setKonsensus(newTestContext(t, []cli.Flag{CommitTimeoutFlag}, "--"+CommitTimeoutFlag.Name, "3s"), cfg)
+	if have, want := cfg.CommitDuration(), 3*time.Second; have != want {
+		t.Fatalf("commit timeout mismatch: have %v, want %v", have, want)
+	}
+}
+
+func TestSetDevPeriod(t *testing.T) {
+	flags := []cli.Flag{DevModeFlag, DevPeriodFlag, KonsensusBlockTimeFlag, EmptyBlocksFlag}
+
+	cfg := knode.DefaultConfig
+	if err := setDevPeriod(newTestContext(t, flags, "--"+DevModeFlag.Name), &cfg); err != nil {
+		t.Fatalf("unexpected error without the flag: %v", err)
+	}
+	if cfg.EmptyBlocks != knode.DefaultConfig.EmptyBlocks || cfg.Konsensus.BlockTime != 0 {
+		t.Fatalf("dev period applied without the flag: %v, %dms", cfg.EmptyBlocks, cfg.Konsensus.BlockTime)
+	}
+
+	cfg = knode.DefaultConfig
+	if err := setDevPeriod(newTestContext(t, flags, "--"+DevModeFlag.Name, "--"+DevPeriodFlag.Name, "0"), &cfg); err != nil {
+		t.Fatalf("on-demand period: unexpected error: %v", err)
+	}
+	if cfg.EmptyBlocks != validator.EmptyBlocksNever {
+		t.Fatalf("on-demand period: empty blocks policy mismatch: have %v, want %v", cfg.EmptyBlocks, validator.EmptyBlocksNever)
+	}
+
+	cfg = knode.DefaultConfig
+	cfg.EmptyBlocks = validator.EmptyBlocksNever
+	if err := setDevPeriod(newTestContext(t, flags, "--"+DevModeFlag.Name, "--"+DevPeriodFlag.Name, "5s"), &cfg); err != nil {
+		t.Fatalf("fixed period: unexpected error: %v", err)
+	}
+	if cfg.EmptyBlocks != validator.EmptyBlocksAlways {
+		t.Fatalf("fixed period: empty blocks policy mismatch: have %v, want %v", cfg.EmptyBlocks, validator.EmptyBlocksAlways)
+	}
+	if have, want := cfg.Konsensus.BlockDuration(), 5*time.Second; have != want {
+		t.Fatalf("fixed period: block time mismatch: have %v, want %v", have, want)
+	}
+
+	cfg = knode.DefaultConfig
+	if err := setDevPeriod(newTestContext(t, flags, "--"+DevPeriodFlag.Name, "5s"), &cfg); err == nil {
+		t.Fatal("no error outside developer mode")
+	}
+	if err := setDevPeriod(newTestContext(t, flags, "--"+DevModeFlag.Name, "--"+DevPeriodFlag.Name, "1us"), &cfg); err == nil {
+		t.Fatal("no error for sub-millisecond period")
+	}
+
+	// Explicit block time and empty block settings conflict with the period
+	for _, conflict := range [][]string{
+		{"--" + KonsensusBlockTimeFlag.Name, "2s"},
+		{"--" + EmptyBlocksFlag.Name, knode.DefaultConfig.EmptyBlocks.String()},
+	} {
+		cfg = knode.DefaultConfig
+		args := append([]string{"--" + DevModeFlag.Name, "--" + DevPeriodFlag.Name, "5s"}, conflict...)
+		if err := setDevPeriod(newTestContext(t, flags, args...), &cfg); err == nil {
+			t.Fatalf("no error combined with %s", conflict[0])
+		}
+		if cfg.Konsensus.BlockTime != 0 || cfg.EmptyBlocks != knode.DefaultConfig.EmptyBlocks {
+			t.Fatalf("dev period applied despite %s", conflict[0])
+		}
+	}
+}
+
+func TestSetDNSDiscovery(t *testing.T) {
+	cfg := new(p2p.Config)
+	setDNSDiscovery(newTestContext(t, []cli.Flag{DNSDiscoveryFlag}), cfg)
+	if cfg.DNSDiscovery != nil {
+		t.Fatalf("DNS discovery set without the flag: %v", cfg.DNSDiscovery)
+	}
+
+	key, _ := crypto.GenerateKey()
+	url1, url2 := dnsdisc.URL(&key.PublicKey, "a.nodes.example.org"), dnsdisc.URL(&key.PublicKey, "b.nodes.example.org")
+	setDNSDiscovery(newTestContext(t, []cli.Flag{DNSDiscoveryFlag}, "--"+DNSDiscoveryFlag.Name, url1+", "+url2), cfg)
+	if !reflect.DeepEqual(cfg.DNSDiscovery, []string{url1, url2}) {
+		t.Fatalf("DNS discovery mismatch: have %v, want %v", cfg.DNSDiscovery, []string{url1, url2})
+	}
+}
+
+func TestSetWhitelist(t *testing.T) {
+	cfg := new(knode.Config)
+	setWhitelist(newTestContext(t, []cli.Flag{WhitelistFlag}), cfg)
+	if cfg.Whitelist != nil {
+		t.Fatalf("whitelist set without the flag: %v", cfg.Whitelist)
+	}
+
+	hash1 := common.HexToHash("0x9b9e3bfe1b5a8d9a3b2c52d0e7c0a1d3d2ac4ee06b0b3b0df39b5fd2b8f3a001")
+	hash2 := common.HexToHash("0x9b9e3bfe1b5a8d9a3b2c52d0e7c0a1d3d2ac4ee06b0b3b0df39b5fd2b8f3a002")
+	setWhitelist(newTestContext(t, []cli.Flag{WhitelistFlag}, "--"+WhitelistFlag.Name, "100="+hash1.Hex()+", 0x3e8="+hash2.Hex()), cfg)
+
+	want := map[uint64]common.Hash{100: hash1, 1000: hash2}
+	if len(cfg.Whitelist) != len(want) {
+		t.Fatalf("whitelist mismatch: have %v, want %v", cfg.Whitelist, want)
+	}
+	for number, hash := range want {
+		if cfg.Whitelist[number] != hash {
+			t.Errorf("whitelist entry %d mismatch: have %x, want %x", number, cfg.Whitelist[number], hash)
+		}
+	}
+}
+
+func TestMakeAddressErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kcoin-keystore-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+	if _, err := ks.NewAccount("secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := MakeAddress(ks, "0"); err != nil {
+		t.Fatalf("valid index rejected: %v", err)
+	}
+	testCases := []struct {
+		account string
+		err     error
+		msg     string
+	}{
+		{"nonsense", ErrInvalidAccountSpec, `invalid account address or index "nonsense"`},
+		{"-1", ErrInvalidAccountSpec, `invalid account address or index "-1"`},
+		{"1", ErrAccountIndexOutOfRange, "index 1 higher than number of accounts 1"},
+	}
+	for _, tc := range testCases {
+		_, err := MakeAddress(ks, tc.account)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("account %q: error mismatch: have %v, want %v", tc.account, err, tc.err)
+		}
+		if err != nil && err.Error() != tc.msg {
+			t.Errorf("account %q: message mismatch: have %q, want %q", tc.account, err, tc.msg)
+		}
+	}
+}
+
+func TestSetRPCDisabledModules(t *testing.T) {
+	flags := []cli.Flag{RPCApiFlag, RPCDisableFlag}
+
+	cfg := &node.Config{HTTPModules: []string{"kcoin", "personal"}}
+	setRPCDisabled(newTestContext(t, flags), cfg)
+	if cfg.RPCDisabledModules != nil {
+		t.Fatalf("disabled modules set without the flag: %v", cfg.RPCDisabledModules)
+	}
+
+	ctx := newTestContext(t, flags,
+		"--"+RPCApiFlag.Name, "personal,debug",
+		"--"+RPCDisableFlag.Name, "personal, debug,")
+	setHTTP(ctx, cfg)
+	setRPCDisabled(ctx, cfg)
+	if want := []string{"personal", "debug"}; !reflect.DeepEqual(cfg.RPCDisabledModules, want) {
+		t.Fatalf("disabled modules mismatch: have %v, want %v", cfg.RPCDisabledModules, want)
+	}
+	// the modules are filtered by the node, leaving the list in place so
+	// disabling all of them doesn't fall back to every public module
+	if want := []string{"personal", "debug"}; !reflect.DeepEqual(cfg.HTTPModules, want) {
+		t.Fatalf("HTTP modules mismatch: have %v, want %v", cfg.HTTPModules, want)
+	}
+}
+
+func TestSetDepositKeepsConfigFile(t *testing.T) {
+	flags := []cli.Flag{ValidatorDepositFlag}
+	second := common.HexToAddress("0x6d6b6f2e1cc1b6ed2a85c3b2e3e4cba9e7d3c7f4")
+	third := common.HexToAddress("0x259be75d96876f2ada3d202722523e9cd4dd917d")
+	newConfig := func() *knode.Config {
+		return &knode.Config{
+			Deposit:         big.NewInt(500),
+			ExtraValidators: []knode.ValidatorIdentity{{Coinbase: second, Deposit: big.NewInt(700)}, {Coinbase: third}},
+		}
+	}
+
+	cfg := newConfig()
+	setDeposit(newTestContext(t, flags), cfg)
+	want := []knode.ValidatorIdentity{{Deposit: big.NewInt(500)}, {Coinbase: second, Deposit: big.NewInt(700)}, {Coinbase: third, Deposit: big.NewInt(0)}}
+	if have := cfg.ValidatorIdentities(); !reflect.DeepEqual(have, want) {
+		t.Errorf("identities without the flag mismatch: have %v, want %v", have, want)
+	}
+
+	cfg = newConfig()
+	setDeposit(newTestContext(t, flags, "--"+ValidatorDepositFlag.Name, "100"), cfg)
+	want = []knode.ValidatorIdentity{{Deposit: big.NewInt(100)}, {Coinbase: second, Deposit: big.NewInt(100)}, {Coinbase: third, Deposit: big.NewInt(100)}}
+	if have := cfg.ValidatorIdentities(); !reflect.DeepEqual(have, want) {
+		t.Errorf("identities with the flag mismatch: have %v, want %v", have, want)
+	}
+}
+
+func TestCheckValidatorIdentities(t *testing.T) {
+	flags := []cli.Flag{CoinbaseFlag}
+	coinbase := "0x259be75d96876f2ada3d202722523e9cd4dd917d"
+	multi := &knode.Config{ExtraValidators: []knode.ValidatorIdentity{{Coinbase: common.HexToAddress("0x6d6b6f2e1cc1b6ed2a85c3b2e3e4cba9e7d3c7f4")}}}
+
+	if err := checkValidatorIdentities(newTestContext(t, flags, "--coinbase", coinbase), new(knode.Config)); err != nil {
+		t.Errorf("single validator config rejected: %v", err)
+	}
+	if err := checkValidatorIdentities(newTestContext(t, flags), multi); err != nil {
+		t.Errorf("multi validator config without the flag rejected: %v", err)
+	}
+	if err := checkValidatorIdentities(newTestContext(t, flags, "--coinbase", coinbase), multi); err == nil || !strings.Contains(err.Error(), CoinbaseFlag.Name) {
+		t.Errorf("conflicting validator identities accepted or not reported: %v", err)
+	}
+}
+
+func TestMakePasswordList(t *testing.T) {
+	want := []string{"first", "second", ""}
+
+	file, err := ioutil.TempFile("", "kcoin-password-")
+	if err != nil {
+		t.Fatalf("failed to create password file: %v", err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("first\r\nsecond\n")
+	file.Close()
+
+	stdin := passwordStdin
+	defer func() { passwordStdin = stdin }()
+	passwordStdin = strings.NewReader("first\nsecond\r\n")
+
+	for _, path := range []string{file.Name(), "-"} {
+		have := MakePasswordList(newTestContext(t, []cli.Flag{PasswordFileFlag}, "--"+PasswordFileFlag.Name, path))
+		if !reflect.DeepEqual(have, want) {
+			t.Errorf("password list from %q mismatch: have %q, want %q", path, have, want)
+		}
+	}
+	if have := MakePasswordList(newTestContext(t, []cli.Flag{PasswordFileFlag})); have != nil {
+		t.Errorf("password list set without the flag: %q", have)
+	}
+}
+
+func TestSetValidatorIdentities(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kcoin-keystore-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+	flags := []cli.Flag{CoinbaseFlag, ValidatorDepositFlag}
+
+	first := common.HexToAddress("0x259be75d96876f2ada3d202722523e9cd4dd917d")
+
+	testCases := []struct {
+		args       []string
+		identities []knode.ValidatorIdentity
+	}{
+		{
+			[]string{"--coinbase", first.Hex(), "--deposit", "100"},
+			[]knode.ValidatorIdentity{{Coinbase: first, Deposit: big.NewInt(100)}},
+		},
+		{
+			[]string{"--coinbase", first.Hex()},
+			[]knode.ValidatorIdentity{{Coinbase: first, Deposit: big.NewInt(0)}},
+		},
+	}
+	for i, tc := range testCases {
+		ctx := newTestContext(t, flags, tc.args...)
+		cfg := new(knode.Config)
+		setCoinbase(ctx, ks, cfg)
+		setDeposit(ctx, cfg)
+
+		if have := cfg.ValidatorIdentities(); !reflect.DeepEqual(have, tc.identities) {
+			t.Errorf("test %d: identities mismatch: have %v, want %v", i, have, tc.identities)
+		}
+	}
+}
@@ -2,11 +2,13 @@ package konsensus
 
 import (
 	"math/big"
+	"time"
 
 	"github.com/kowala-tech/kcoin/client/common"
 	"github.com/kowala-tech/kcoin/client/consensus"
 	"github.com/kowala-tech/kcoin/client/core/state"
 	"github.com/kowala-tech/kcoin/client/core/types"
+	"github.com/kowala-tech/kcoin/client/log"
 	"github.com/kowala-tech/kcoin/client/params"
 	"github.com/kowala-tech/kcoin/client/rpc"
 )
@@ -20,6 +22,9 @@ type Konsensus struct {
 }
 
 func New(config *params.KonsensusConfig) *Konsensus {
+	if config.BlockTime != 0 && config.BlockTime < params.MinimumSafeBlockTime {
+		log.Warn("Block time below the safety floor, rounds may time out before reaching a majority", "blocktime", config.BlockDuration(), "floor", time.Duration(params.MinimumSafeBlockTime)*time.Millisecond)
+	}
 	return &Konsensus{config: config}
 }
 
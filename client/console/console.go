@@ -42,6 +42,7 @@ type Config struct {
 	Prompter UserPrompter // Input prompter to allow interactive user feedback (defaults to TerminalPrompter)
 	Printer  io.Writer    // Output writer to serialize any display strings to (defaults to os.Stdout)
 	Preload  []string     // Absolute paths to JavaScript files to preload
+	Strict   bool         // Whether a failing preload script aborts console startup
 }
 
 // Console is a JavaScript interpreted runtime environment. It is a fully fledged
@@ -82,15 +83,16 @@ func New(config Config) (*Console, error) {
 	if err := os.MkdirAll(config.DataDir, 0700); err != nil {
 		return nil, err
 	}
-	if err := console.init(config.Preload); err != nil {
+	if err := console.init(config.Preload, config.Strict); err != nil {
 		return nil, err
 	}
 	return console, nil
 }
 
 // init retrieves the available APIs from the remote RPC provider and initializes
-// the console's JavaScript namespaces based on the exposed modules.
-func (c *Console) init(preload []string) error {
+// the console's JavaScript namespaces based on the exposed modules. Preload
+// failures are reported to the printer, or returned as an error if strict.
+func (c *Console) init(preload []string, strict bool) error {
 	// Initialize the JavaScript <-> Go RPC bridge
 	bridge := newBridge(c.client, c.prompter, c.printer)
 	c.jsre.Set("jeth", struct{}{})
@@ -184,14 +186,17 @@ func (c *Console) init(preload []string) error {
 		obj.Set("sleep", bridge.Sleep)
 		obj.Set("clearHistory", c.clearHistory)
 	}
-	// Preload any JavaScript files before starting the console
+	// Preload any JavaScript files before starting the console, in the given order
 	for _, path := range preload {
 		if err := c.jsre.Exec(path); err != nil {
 			failure := err.Error()
 			if ottoErr, ok := err.(*otto.Error); ok {
-				failure = ottoErr.String()
+				failure = ottoErr.String() // includes the failing file and line
 			}
-			return fmt.Errorf("%s: %v", path, failure)
+			if strict {
+				return fmt.Errorf("preload %s: %v", path, failure)
+			}
+			fmt.Fprintf(c.printer, "Failed to preload %s: %v\n", path, failure)
 		}
 	}
 	// Configure the console's input prompter for scrollback and tab completion
@@ -215,6 +215,48 @@ func TestPreload(t *testing.T) {
 	}
 }
 
+// Tests that a failing preload script is reported with its filename and line,
+// and only aborts the console startup in strict mode.
+func TestPreloadFailure(t *testing.T) {
+	tester := newTester(t, nil)
+	defer tester.Close(t)
+
+	client, err := tester.stack.Attach()
+	if err != nil {
+		t.Fatalf("failed to attach to node: %v", err)
+	}
+	config := Config{
+		DataDir:  tester.stack.DataDir(),
+		DocRoot:  "testdata",
+		Client:   client,
+		Prompter: tester.input,
+		Printer:  new(bytes.Buffer),
+		Preload:  []string{"preload-throws.js", "preload.js"},
+	}
+	// Non-strict mode should report the failure and carry on preloading
+	console, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to create JavaScript console: %v", err)
+	}
+	console.Stop(false)
+
+	output := config.Printer.(*bytes.Buffer).String()
+	if want := "preload-throws.js:2"; !strings.Contains(output, want) {
+		t.Fatalf("preload failure location missing: have %s, want %s", output, want)
+	}
+	if want := "preload failure"; !strings.Contains(output, want) {
+		t.Fatalf("preload failure message missing: have %s, want %s", output, want)
+	}
+	// Strict mode should abort with the failing script in the error
+	config.Strict = true
+	config.Printer = new(bytes.Buffer)
+	if _, err := New(config); err == nil {
+		t.Fatalf("strict preload failure not reported")
+	} else if want := "preload-throws.js:2"; !strings.Contains(err.Error(), want) {
+		t.Fatalf("preload failure location missing: have %v, want %s", err, want)
+	}
+}
+
 // Tests that JavaScript scripts can be executes from the configured asset path.
 func TestExecute(t *testing.T) {
 	tester := newTester(t, nil)
@@ -0,0 +1,2 @@
+var beforeFailure = true;
+throw new Error("preload failure");
@@ -191,21 +191,56 @@ func (css *Consensus) ValidatorsChecksum() (types.VotersChecksum, error) {
 	return css.manager.ValidatorsChecksum(&bind.CallOpts{})
 }
 
+// Validators returns the registered validators, starting their proposer
+// weights at zero.
 func (css *Consensus) Validators() (types.Voters, error) {
-	count, err := css.manager.GetValidatorCount(&bind.CallOpts{})
+	return NewValidatorSet(css.manager, &bind.CallOpts{}, ZeroWeight)
+}
+
+// ValidatorsReader lists the validators registered in the validator manager
+// contract. The contract bindings satisfy it.
+type ValidatorsReader interface {
+	GetValidatorCount(opts *bind.CallOpts) (*big.Int, error)
+	GetValidatorAtIndex(opts *bind.CallOpts, index *big.Int) (struct {
+		Code    common.Address
+		Deposit *big.Int
+	}, error)
+}
+
+// WeightPolicy derives the starting proposer weight of a validator from its
+// deposit.
+type WeightPolicy func(deposit *big.Int) *big.Int
+
+// ZeroWeight starts every validator at zero. The proposer order depends on the
+// starting weights, so every node must elect proposers with this policy until
+// another one is activated by a fork.
+func ZeroWeight(*big.Int) *big.Int {
+	return new(big.Int)
+}
+
+// DepositWeight starts every validator with its deposit.
+func DepositWeight(deposit *big.Int) *big.Int {
+	return new(big.Int).Set(deposit)
+}
+
+// NewValidatorSet reads the validators registered in the validator manager
+// contract and returns them as a voters set, starting each voter with the
+// weight given by the policy. As with types.NewVoters, it returns
+// types.ErrInvalidParams if there are no validators.
+func NewValidatorSet(reader ValidatorsReader, opts *bind.CallOpts, weight WeightPolicy) (types.Voters, error) {
+	count, err := reader.GetValidatorCount(opts)
 	if err != nil {
 		return nil, err
 	}
 
 	voters := make([]*types.Voter, count.Uint64())
 	for i := int64(0); i < count.Int64(); i++ {
-		validator, err := css.manager.GetValidatorAtIndex(&bind.CallOpts{}, big.NewInt(i))
+		validator, err := reader.GetValidatorAtIndex(opts, big.NewInt(i))
 		if err != nil {
 			return nil, err
 		}
 
-		weight := big.NewInt(0)
-		voters[i] = types.NewVoter(validator.Code, validator.Deposit, weight)
+		voters[i] = types.NewVoter(validator.Code, validator.Deposit, weight(validator.Deposit))
 	}
 
 	return types.NewVoters(voters)
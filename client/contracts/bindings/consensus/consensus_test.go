@@ -0,0 +1,73 @@
+package consensus_test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/kowala-tech/kcoin/client/accounts/abi/bind"
+	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/contracts/bindings/consensus"
+	"github.com/kowala-tech/kcoin/client/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type validatorEntry struct {
+	Code    common.Address
+	Deposit *big.Int
+}
+
+type fakeValidatorsReader struct {
+	validators []validatorEntry
+	err        error
+}
+
+func (r *fakeValidatorsReader) GetValidatorCount(opts *bind.CallOpts) (*big.Int, error) {
+	return big.NewInt(int64(len(r.validators))), nil
+}
+
+func (r *fakeValidatorsReader) GetValidatorAtIndex(opts *bind.CallOpts, index *big.Int) (struct {
+	Code    common.Address
+	Deposit *big.Int
+}, error) {
+	return r.validators[index.Int64()], r.err
+}
+
+func TestNewValidatorSet(t *testing.T) {
+	reader := &fakeValidatorsReader{validators: []validatorEntry{
+		{Code: common.Address{1}, Deposit: big.NewInt(100)},
+		{Code: common.Address{2}, Deposit: big.NewInt(300)},
+	}}
+
+	voters, err := consensus.NewValidatorSet(reader, &bind.CallOpts{}, consensus.ZeroWeight)
+	require.NoError(t, err)
+	require.Equal(t, 2, voters.Len())
+	assert.Equal(t, common.Address{1}, voters.At(0).Address())
+	assert.Equal(t, big.NewInt(300), voters.At(1).Deposit())
+	assert.Equal(t, []*big.Int{big.NewInt(0), big.NewInt(0)}, voters.Weights())
+
+	voters, err = consensus.NewValidatorSet(reader, &bind.CallOpts{}, consensus.DepositWeight)
+	require.NoError(t, err)
+	assert.Equal(t, []*big.Int{big.NewInt(100), big.NewInt(300)}, voters.Weights())
+
+	// the weights don't share memory with the deposits
+	voters.NextProposer()
+	assert.Equal(t, big.NewInt(100), voters.At(0).Deposit())
+	assert.Equal(t, big.NewInt(300), voters.At(1).Deposit())
+}
+
+func TestNewValidatorSet_EmptyReturnsError(t *testing.T) {
+	_, err := consensus.NewValidatorSet(&fakeValidatorsReader{}, &bind.CallOpts{}, consensus.ZeroWeight)
+	assert.Equal(t, types.ErrInvalidParams, err)
+}
+
+func TestNewValidatorSet_ReaderError(t *testing.T) {
+	reader := &fakeValidatorsReader{
+		validators: []validatorEntry{{Code: common.Address{1}, Deposit: big.NewInt(100)}},
+		err:        errors.New("no contract code"),
+	}
+
+	_, err := consensus.NewValidatorSet(reader, &bind.CallOpts{}, consensus.ZeroWeight)
+	assert.EqualError(t, err, "no contract code")
+}
@@ -4,6 +4,7 @@ import (
 	"math/big"
 
 	"github.com/kowala-tech/kcoin/client/accounts/abi/bind"
+	"github.com/kowala-tech/kcoin/client/common"
 	"github.com/kowala-tech/kcoin/client/common/kns"
 	"github.com/kowala-tech/kcoin/client/contracts/bindings"
 	"github.com/kowala-tech/kcoin/client/log"
@@ -24,6 +25,20 @@ func (mgr *Manager) Domain() string {
 	return ""
 }
 
+// LatestPrice returns the last price submitted by the oracles along with the
+// number of prices submitted so far. The price is nil if there is none.
+func (mgr *Manager) LatestPrice() (*big.Int, uint64, error) {
+	count, err := mgr.GetPriceCount()
+	if err != nil || count.Sign() == 0 {
+		return nil, 0, err
+	}
+	last, err := mgr.GetPriceAtIndex(new(big.Int).Sub(count, common.Big1))
+	if err != nil {
+		return nil, 0, err
+	}
+	return last.Price, count.Uint64(), nil
+}
+
 // Bind returns a binding to the current oracle mgr
 func Bind(contractBackend bind.ContractBackend, chainID *big.Int) (bindings.Binding, error) {
 	addr, err := kns.GetAddressFromDomain(
@@ -84,6 +84,8 @@ type BlockChain struct {
 	chainFeed     event.Feed
 	chainSideFeed event.Feed
 	chainHeadFeed event.Feed
+	reorgFeed     event.Feed
+	finalizedFeed event.Feed
 	logsFeed      event.Feed
 	scope         event.SubscriptionScope
 	genesisBlock  *types.Block
@@ -412,6 +414,48 @@ func (bc *BlockChain) repair(head **types.Block) error {
 	}
 }
 
+// VerifyState walks the entire state of the current head block, including all
+// contract storage and code, and checks that every node is available and
+// hashes to the key it is stored under.
+func (bc *BlockChain) VerifyState() (err error) {
+	head := bc.CurrentBlock()
+
+	// The trie decoder panics on malformed nodes, report those as corruption
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("corrupt state at block #%d [%x…]: %v", head.NumberU64(), head.Hash().Bytes()[:4], r)
+		}
+	}()
+
+	statedb, err := state.New(head.Root(), bc.stateCache)
+	if err != nil {
+		return fmt.Errorf("missing state at block #%d [%x…]: %v", head.NumberU64(), head.Hash().Bytes()[:4], err)
+	}
+	var (
+		start = time.Now()
+		nodes int
+	)
+	it := state.NewNodeIterator(statedb)
+	for it.Next() {
+		if it.Hash == (common.Hash{}) {
+			continue
+		}
+		blob, err := bc.stateCache.TrieDB().Node(it.Hash)
+		if err != nil {
+			return fmt.Errorf("missing state entry %x at block #%d: %v", it.Hash, head.NumberU64(), err)
+		}
+		if hash := crypto.Keccak256Hash(blob); hash != it.Hash {
+			return fmt.Errorf("state entry %x at block #%d hashes to %x", it.Hash, head.NumberU64(), hash)
+		}
+		nodes++
+	}
+	if it.Error != nil {
+		return fmt.Errorf("corrupt state at block #%d [%x…]: %v", head.NumberU64(), head.Hash().Bytes()[:4], it.Error)
+	}
+	log.Info("Verified head state", "number", head.Number(), "hash", head.Hash(), "root", head.Root(), "nodes", nodes, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
 // Export writes the active chain to the given writer.
 func (bc *BlockChain) Export(w io.Writer) error {
 	return bc.ExportN(w, uint64(0), bc.CurrentBlock().NumberU64())
@@ -1110,6 +1154,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*ty
 			coalescedLogs = append(coalescedLogs, logs...)
 			blockInsertTimer.UpdateSince(bstart)
 			events = append(events, ChainEvent{block, block.Hash(), logs})
+			events = append(events, ChainFinalizedEvent{block})
 			lastCanon = block
 
 			// Only count canonical blocks for GC processing time
@@ -1308,11 +1353,25 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 				bc.chainSideFeed.Send(ChainSideEvent{Block: block})
 			}
 		}()
+		go bc.reorgFeed.Send(ChainReorgEvent{
+			Ancestor: commonBlock,
+			Removed:  reverseBlocks(oldChain),
+			Added:    reverseBlocks(newChain),
+		})
 	}
 
 	return nil
 }
 
+// reverseBlocks returns a copy of the given blocks in reverse order.
+func reverseBlocks(blocks types.Blocks) types.Blocks {
+	reversed := make(types.Blocks, len(blocks))
+	for i, block := range blocks {
+		reversed[len(blocks)-1-i] = block
+	}
+	return reversed
+}
+
 // PostChainEvents iterates over the events generated by a chain insertion and
 // posts them into the event feed.
 // TODO: Should not expose PostChainEvents. The chain events should be posted in WriteBlock.
@@ -1331,6 +1390,9 @@ func (bc *BlockChain) PostChainEvents(events []interface{}, logs []*types.Log) {
 
 		case ChainSideEvent:
 			bc.chainSideFeed.Send(ev)
+
+		case ChainFinalizedEvent:
+			bc.finalizedFeed.Send(ev)
 		}
 	}
 }
@@ -1516,6 +1578,16 @@ func (bc *BlockChain) SubscribeChainSideEvent(ch chan<- ChainSideEvent) event.Su
 	return bc.scope.Track(bc.chainSideFeed.Subscribe(ch))
 }
 
+// SubscribeChainReorgEvent registers a subscription of ChainReorgEvent.
+func (bc *BlockChain) SubscribeChainReorgEvent(ch chan<- ChainReorgEvent) event.Subscription {
+	return bc.scope.Track(bc.reorgFeed.Subscribe(ch))
+}
+
+// SubscribeChainFinalizedEvent registers a subscription of ChainFinalizedEvent.
+func (bc *BlockChain) SubscribeChainFinalizedEvent(ch chan<- ChainFinalizedEvent) event.Subscription {
+	return bc.scope.Track(bc.finalizedFeed.Subscribe(ch))
+}
+
 // SubscribeLogsEvent registers a subscription of []*types.Log.
 func (bc *BlockChain) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription {
 	return bc.scope.Track(bc.logsFeed.Subscribe(ch))
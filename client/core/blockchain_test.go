@@ -0,0 +1,154 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/consensus/konsensus"
+	"github.com/kowala-tech/kcoin/client/core/state"
+	"github.com/kowala-tech/kcoin/client/core/vm"
+	"github.com/kowala-tech/kcoin/client/kcoindb"
+	"github.com/kowala-tech/kcoin/client/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newVerifyStateDB returns a database holding a genesis state with a few
+// accounts and a contract, along with the hashes of the state entries other
+// than the root.
+func newVerifyStateDB(t *testing.T) (*kcoindb.MemDatabase, []common.Hash) {
+	alloc := GenesisAlloc{
+		common.Address{0xaa}: {
+			Balance: big.NewInt(1),
+			Code:    []byte{0x60, 0x00, 0x60, 0x00, 0xf3},
+			Storage: map[common.Hash]common.Hash{{0x01}: {0x02}, {0x03}: {0x04}},
+		},
+	}
+	for i := byte(1); i <= 16; i++ {
+		alloc[common.Address{i}] = GenesisAccount{Balance: big.NewInt(int64(i))}
+	}
+	db := kcoindb.NewMemDatabase()
+	genesis := (&Genesis{Config: params.TestChainConfig, Alloc: alloc}).MustCommit(db)
+
+	statedb, err := state.New(genesis.Root(), state.NewDatabase(db))
+	require.NoError(t, err)
+
+	var entries []common.Hash
+	it := state.NewNodeIterator(statedb)
+	for it.Next() {
+		if it.Hash != (common.Hash{}) && it.Hash != genesis.Root() {
+			entries = append(entries, it.Hash)
+		}
+	}
+	require.NoError(t, it.Error)
+	require.True(t, len(entries) > 2)
+
+	return db, entries
+}
+
+func verifyState(t *testing.T, db kcoindb.Database) error {
+	chain, err := NewBlockChain(db, nil, params.TestChainConfig, konsensus.NewFaker(), vm.Config{})
+	require.NoError(t, err)
+	defer chain.Stop()
+
+	return chain.VerifyState()
+}
+
+func TestBlockChainVerifyState(t *testing.T) {
+	db, _ := newVerifyStateDB(t)
+	assert.NoError(t, verifyState(t, db))
+}
+
+func TestBlockChainVerifyStateMissingEntry(t *testing.T) {
+	db, entries := newVerifyStateDB(t)
+	for _, hash := range entries {
+		db.Delete(hash[:])
+	}
+	assert.Error(t, verifyState(t, db))
+}
+
+func TestBlockChainVerifyStateMismatchedEntry(t *testing.T) {
+	db, entries := newVerifyStateDB(t)
+
+	// Store a valid entry under the key of another one
+	blob, err := db.Get(entries[1][:])
+	require.NoError(t, err)
+	require.NoError(t, db.Put(entries[0][:], blob))
+
+	assert.Error(t, verifyState(t, db))
+}
+
+func TestBlockChainVerifyStateMalformedEntry(t *testing.T) {
+	db, entries := newVerifyStateDB(t)
+	for _, hash := range entries {
+		require.NoError(t, db.Put(hash[:], []byte{0xde, 0xad}))
+	}
+	assert.Error(t, verifyState(t, db))
+}
+
+func TestBlockChainInsertAnnouncesFinality(t *testing.T) {
+	db := kcoindb.NewMemDatabase()
+	genesis := (&Genesis{Config: params.TestChainConfig}).MustCommit(db)
+	chain, err := NewBlockChain(db, nil, params.TestChainConfig, konsensus.NewFaker(), vm.Config{})
+	require.NoError(t, err)
+	defer chain.Stop()
+
+	finalized := make(chan ChainFinalizedEvent, 3)
+	sub := chain.SubscribeChainFinalizedEvent(finalized)
+	defer sub.Unsubscribe()
+
+	// Blocks imported by a node that isn't validating are announced as well
+	blocks := makeBlockChain(genesis, 3, konsensus.NewFaker(), db, 1)
+	_, err = chain.InsertChain(blocks)
+	require.NoError(t, err)
+
+	for i, block := range blocks {
+		select {
+		case ev := <-finalized:
+			assert.Equal(t, block.Hash(), ev.Block.Hash(), "block %d", i)
+		case <-time.After(time.Second):
+			t.Fatalf("no finalized event for block %d", i)
+		}
+	}
+}
+
+func TestBlockChainReorgEvent(t *testing.T) {
+	db := kcoindb.NewMemDatabase()
+	genesis := (&Genesis{Config: params.TestChainConfig}).MustCommit(db)
+	chain, err := NewBlockChain(db, nil, params.TestChainConfig, konsensus.NewFaker(), vm.Config{})
+	require.NoError(t, err)
+	defer chain.Stop()
+
+	oldBlocks := makeBlockChain(genesis, 3, konsensus.NewFaker(), db, 1)
+	newBlocks := makeBlockChain(genesis, 5, konsensus.NewFaker(), db, 2)
+
+	_, err = chain.InsertChain(oldBlocks)
+	require.NoError(t, err)
+
+	reorgs := make(chan ChainReorgEvent, 1)
+	sub := chain.SubscribeChainReorgEvent(reorgs)
+	defer sub.Unsubscribe()
+
+	_, err = chain.InsertChain(newBlocks)
+	require.NoError(t, err)
+	require.Equal(t, newBlocks[len(newBlocks)-1].Hash(), chain.CurrentBlock().Hash())
+
+	select {
+	case ev := <-reorgs:
+		assert.Equal(t, genesis.Hash(), ev.Ancestor.Hash())
+		require.Len(t, ev.Removed, len(oldBlocks))
+		for i, block := range oldBlocks {
+			assert.Equal(t, block.Hash(), ev.Removed[i].Hash(), "removed block %d", i)
+		}
+		// Equal height forks are split at random, so the reorg happens when
+		// either the third or the fourth block of the new chain is imported.
+		require.True(t, len(ev.Added) == 3 || len(ev.Added) == 4, "added %d blocks", len(ev.Added))
+		for i, block := range ev.Added {
+			assert.Equal(t, newBlocks[i].Hash(), block.Hash(), "added block %d", i)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no reorg event received")
+	}
+}
@@ -10,6 +10,13 @@ import (
 // NewTxsEvent is posted when a batch of transactions enter the transaction pool.
 type NewTxsEvent struct{ Txs []*types.Transaction }
 
+// ReplacedTxEvent is posted when a transaction in the pool is superseded by a
+// higher priced one with the same nonce.
+type ReplacedTxEvent struct {
+	Old common.Hash // Hash of the dropped transaction
+	New common.Hash // Hash of the replacing transaction
+}
+
 // NewVoteEvent is posted when a consensus validator votes.
 type NewVoteEvent struct{ Vote *types.Vote }
 
@@ -28,6 +35,18 @@ type NewMajorityEvent struct {
 	Winner common.Hash
 }
 
+// DoubleSignEvent is posted when a validator is caught signing two conflicting
+// votes for the same block number and round.
+type DoubleSignEvent struct{ Evidence *types.DuplicateVoteEvidence }
+
+// ValidatorSetChangedEvent is posted when an applied block changes the set of
+// validators or their deposits.
+type ValidatorSetChangedEvent struct {
+	Block *types.Block
+	Old   types.Voters
+	New   types.Voters
+}
+
 // PendingLogsEvent is posted pre mining and notifies of pending logs.
 type PendingLogsEvent struct {
 	Logs []*types.Log
@@ -53,3 +72,17 @@ type ChainSideEvent struct {
 }
 
 type ChainHeadEvent struct{ Block *types.Block }
+
+// ChainFinalizedEvent is posted when a block committed by the consensus, which
+// makes it irreversible, joins the canonical chain. That is when the local
+// validator commits it or when it's imported from the network.
+type ChainFinalizedEvent struct{ Block *types.Block }
+
+// ChainReorgEvent is posted when the canonical chain is reorganised onto a
+// competing branch. Removed and Added hold the blocks dropped from and spliced
+// into the canonical chain above Ancestor, in ascending number order.
+type ChainReorgEvent struct {
+	Ancestor *types.Block
+	Removed  types.Blocks
+	Added    types.Blocks
+}
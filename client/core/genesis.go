@@ -294,6 +294,17 @@ func DefaultTestnetGenesisBlock() *Genesis {
 	}
 }
 
+// IsPublicGenesisHash reports whether the hash is the one of the genesis block
+// of the Kowala main or test network.
+func IsPublicGenesisHash(hash common.Hash) bool {
+	for _, genesis := range []*Genesis{DefaultGenesisBlock(), DefaultTestnetGenesisBlock()} {
+		if genesis.ToBlock(nil).Hash() == hash {
+			return true
+		}
+	}
+	return false
+}
+
 // DevGenesisBlock returns the 'kcoin --dev' genesis block.
 func DevGenesisBlock() *Genesis {
 	return &Genesis{
@@ -0,0 +1,20 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/kowala-tech/kcoin/client/kcoindb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPublicGenesisHash(t *testing.T) {
+	assert.True(t, IsPublicGenesisHash(DefaultGenesisBlock().ToBlock(nil).Hash()), "main network")
+	assert.True(t, IsPublicGenesisHash(DefaultTestnetGenesisBlock().ToBlock(nil).Hash()), "test network")
+
+	// The main network genesis is the one set up without a genesis spec
+	_, hash, err := SetupGenesisBlock(kcoindb.NewMemDatabase(), nil)
+	assert.NoError(t, err)
+	assert.True(t, IsPublicGenesisHash(hash), "default genesis")
+
+	assert.False(t, IsPublicGenesisHash(DevGenesisBlock().ToBlock(nil).Hash()), "dev network")
+}
@@ -126,6 +126,94 @@ func WriteFastTrieProgress(db DatabaseWriter, count uint64) {
 	}
 }
 
+// StateSyncCheckpoint is the resumable progress of a fast sync state download,
+// tied to the pivot block whose state is being retrieved.
+type StateSyncCheckpoint struct {
+	Number uint64      // Number of the pivot block
+	Hash   common.Hash // Hash of the pivot block
+	Root   common.Hash // State root of the pivot block
+	Nodes  [][]byte    // Downloaded trie nodes not yet committed to the database
+}
+
+// ReadStateSyncCheckpoint retrieves the fast sync state checkpoint, if any.
+func ReadStateSyncCheckpoint(db DatabaseReader) *StateSyncCheckpoint {
+	data, _ := db.Get(stateSyncCheckpointKey)
+	if len(data) == 0 {
+		return nil
+	}
+	checkpoint := new(StateSyncCheckpoint)
+	if err := rlp.DecodeBytes(data, checkpoint); err != nil {
+		log.Error("Invalid state sync checkpoint RLP", "err", err)
+		return nil
+	}
+	return checkpoint
+}
+
+// WriteStateSyncCheckpoint stores the fast sync state checkpoint.
+func WriteStateSyncCheckpoint(db DatabaseWriter, checkpoint *StateSyncCheckpoint) {
+	data, err := rlp.EncodeToBytes(checkpoint)
+	if err != nil {
+		log.Crit("Failed to RLP encode state sync checkpoint", "err", err)
+	}
+	if err := db.Put(stateSyncCheckpointKey, data); err != nil {
+		log.Crit("Failed to store state sync checkpoint", "err", err)
+	}
+}
+
+// DeleteStateSyncCheckpoint removes the fast sync state checkpoint.
+func DeleteStateSyncCheckpoint(db DatabaseDeleter) {
+	if err := db.Delete(stateSyncCheckpointKey); err != nil {
+		log.Crit("Failed to delete state sync checkpoint", "err", err)
+	}
+}
+
+// ValidatorSet is the validator set in force after a block, along with the
+// proposer weights accumulated by the election so far.
+type ValidatorSet struct {
+	Checksum [32]byte            // Checksum of the set in the consensus contract
+	Rounds   uint64              // Election rounds since the set was formed
+	Voters   []ValidatorSetEntry // Validators, in set order
+}
+
+// ValidatorSetEntry is a single validator of a stored validator set.
+type ValidatorSetEntry struct {
+	Address common.Address
+	Deposit *big.Int
+	Weight  *big.Int
+}
+
+// ReadValidatorSet retrieves the validator set stored for a block number.
+func ReadValidatorSet(db DatabaseReader, number uint64) *ValidatorSet {
+	data, _ := db.Get(validatorSetKey(number))
+	if len(data) == 0 {
+		return nil
+	}
+	set := new(ValidatorSet)
+	if err := rlp.DecodeBytes(data, set); err != nil {
+		log.Error("Invalid validator set RLP", "number", number, "err", err)
+		return nil
+	}
+	return set
+}
+
+// WriteValidatorSet stores the validator set in force after a block number.
+func WriteValidatorSet(db DatabaseWriter, number uint64, set *ValidatorSet) {
+	data, err := rlp.EncodeToBytes(set)
+	if err != nil {
+		log.Crit("Failed to RLP encode validator set", "err", err)
+	}
+	if err := db.Put(validatorSetKey(number), data); err != nil {
+		log.Crit("Failed to store validator set", "err", err)
+	}
+}
+
+// DeleteValidatorSet removes the validator set stored for a block number.
+func DeleteValidatorSet(db DatabaseDeleter, number uint64) {
+	if err := db.Delete(validatorSetKey(number)); err != nil {
+		log.Crit("Failed to delete validator set", "err", err)
+	}
+}
+
 // ReadHeaderRLP retrieves a block header in its raw RLP database encoding.
 func ReadHeaderRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
 	data, _ := db.Get(headerKey(number, hash))
@@ -283,6 +371,46 @@ func DeleteReceipts(db DatabaseDeleter, hash common.Hash, number uint64) {
 	}
 }
 
+// BlockVotes are the consensus votes for a block cast in the round that
+// committed it.
+type BlockVotes struct {
+	Round      uint64        // Election round the block was committed in
+	PreVotes   []*types.Vote // Pre-votes for the block
+	PreCommits []*types.Vote // Pre-commits for the block
+}
+
+// ReadBlockVotes retrieves the votes that committed a block, if they were kept.
+func ReadBlockVotes(db DatabaseReader, hash common.Hash, number uint64) *BlockVotes {
+	data, _ := db.Get(blockVotesKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	votes := new(BlockVotes)
+	if err := rlp.DecodeBytes(data, votes); err != nil {
+		log.Error("Invalid block votes RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return votes
+}
+
+// WriteBlockVotes stores the votes that committed a block.
+func WriteBlockVotes(db DatabaseWriter, hash common.Hash, number uint64, votes *BlockVotes) {
+	data, err := rlp.EncodeToBytes(votes)
+	if err != nil {
+		log.Crit("Failed to RLP encode block votes", "err", err)
+	}
+	if err := db.Put(blockVotesKey(number, hash), data); err != nil {
+		log.Crit("Failed to store block votes", "err", err)
+	}
+}
+
+// DeleteBlockVotes removes the votes associated with a block hash.
+func DeleteBlockVotes(db DatabaseDeleter, hash common.Hash, number uint64) {
+	if err := db.Delete(blockVotesKey(number, hash)); err != nil {
+		log.Crit("Failed to delete block votes", "err", err)
+	}
+}
+
 // ReadBlock retrieves an entire block corresponding to the hash, assembling it
 // back from the stored header and body. If either the header or body could not
 // be retrieved nil is returned.
@@ -312,6 +440,7 @@ func DeleteBlock(db DatabaseDeleter, hash common.Hash, number uint64) {
 	DeleteReceipts(db, hash, number)
 	DeleteHeader(db, hash, number)
 	DeleteBody(db, hash, number)
+	DeleteBlockVotes(db, hash, number)
 }
 
 // FindCommonAncestor returns the last common ancestor of two block headers
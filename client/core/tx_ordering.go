@@ -0,0 +1,109 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/core/types"
+)
+
+// TxOrdering represents the order in which pending transactions are included
+// into the blocks assembled by the local validator.
+type TxOrdering int
+
+const (
+	PriceOrdering TxOrdering = iota // Highest gas price first, honouring account nonces
+	FIFOOrdering                    // Strict arrival order, honouring account nonces
+)
+
+func (ordering TxOrdering) IsValid() bool {
+	return ordering >= PriceOrdering && ordering <= FIFOOrdering
+}
+
+// String implements the stringer interface.
+func (ordering TxOrdering) String() string {
+	switch ordering {
+	case PriceOrdering:
+		return "price"
+	case FIFOOrdering:
+		return "fifo"
+	default:
+		return "unknown"
+	}
+}
+
+func (ordering TxOrdering) MarshalText() ([]byte, error) {
+	if !ordering.IsValid() {
+		return nil, fmt.Errorf("unknown transaction ordering %d", ordering)
+	}
+	return []byte(ordering.String()), nil
+}
+
+func (ordering *TxOrdering) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "price":
+		*ordering = PriceOrdering
+	case "fifo":
+		*ordering = FIFOOrdering
+	default:
+		return fmt.Errorf(`unknown transaction ordering %q, want "price" or "fifo"`, text)
+	}
+	return nil
+}
+
+// OrderedTransactions is a nonce-honouring set of transactions to pick from
+// when assembling a block.
+type OrderedTransactions interface {
+	Peek() *types.Transaction
+	Shift()
+	Pop()
+}
+
+// Order arranges the given transactions, grouped by account and sorted by nonce,
+// according to the ordering. The input map is reowned by the returned set.
+func (ordering TxOrdering) Order(signer types.Signer, txs map[common.Address]types.Transactions) OrderedTransactions {
+	if ordering == FIFOOrdering {
+		return types.NewTransactionsByArrivalAndNonce(signer, txs)
+	}
+	return types.NewTransactionsByPriceAndNonce(signer, txs)
+}
+
+// Prioritize arranges the given transactions like Order, but yields every
+// transaction from an account accepted by priority before any other one. Unlike
+// Order, the input map is left untouched.
+func (ordering TxOrdering) Prioritize(signer types.Signer, txs map[common.Address]types.Transactions, priority func(common.Address) bool) OrderedTransactions {
+	first := make(map[common.Address]types.Transactions)
+	rest := make(map[common.Address]types.Transactions, len(txs))
+	for addr, list := range txs {
+		if priority(addr) {
+			first[addr] = list
+		} else {
+			rest[addr] = list
+		}
+	}
+	if len(first) == 0 {
+		return ordering.Order(signer, rest)
+	}
+	return &prioritizedTransactions{
+		first: ordering.Order(signer, first),
+		rest:  ordering.Order(signer, rest),
+	}
+}
+
+// prioritizedTransactions drains a set of prioritized transactions before
+// moving on to the rest.
+type prioritizedTransactions struct {
+	first OrderedTransactions
+	rest  OrderedTransactions
+}
+
+func (p *prioritizedTransactions) current() OrderedTransactions {
+	if p.first.Peek() != nil {
+		return p.first
+	}
+	return p.rest
+}
+
+func (p *prioritizedTransactions) Peek() *types.Transaction { return p.current().Peek() }
+func (p *prioritizedTransactions) Shift()                   { p.current().Shift() }
+func (p *prioritizedTransactions) Pop()                     { p.current().Pop() }
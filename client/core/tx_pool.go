@@ -76,6 +76,10 @@ var (
 	// than some meaningful limit a user might use. This is not a consensus error
 	// making the transaction invalid, rather a DOS protection.
 	ErrOversizedData = errors.New("oversized data")
+
+	// ErrSenderNotAllowed is returned if the pool is restricted to a set of
+	// allowed senders and the transaction's sender is not among them.
+	ErrSenderNotAllowed = errors.New("sender not allowed")
 )
 
 var (
@@ -95,12 +99,27 @@ var (
 	queuedReplaceCounter   = metrics.NewRegisteredCounter("txpool/queued/replace", nil)
 	queuedRateLimitCounter = metrics.NewRegisteredCounter("txpool/queued/ratelimit", nil) // Dropped due to rate limiting
 	queuedNofundsCounter   = metrics.NewRegisteredCounter("txpool/queued/nofunds", nil)   // Dropped due to out-of-funds
+	queuedNonceGapCounter  = metrics.NewRegisteredCounter("txpool/queued/noncegap", nil)  // Held back due to a nonce gap
 
 	// General tx metrics
 	invalidTxCounter     = metrics.NewRegisteredCounter("txpool/invalid", nil)
 	underpricedTxCounter = metrics.NewRegisteredCounter("txpool/underpriced", nil)
+
+	// Metrics for the pool contents, only registered if metrics are enabled since
+	// refreshing them requires walking the pool
+	pendingGauge metrics.Gauge = metrics.NilGauge{}
+	queuedGauge  metrics.Gauge = metrics.NilGauge{}
+	localGauge   metrics.Gauge = metrics.NilGauge{}
 )
 
+func init() {
+	if metrics.Enabled {
+		pendingGauge = metrics.NewRegisteredGauge("txpool/pending", nil)
+		queuedGauge = metrics.NewRegisteredGauge("txpool/queued", nil)
+		localGauge = metrics.NewRegisteredGauge("txpool/local", nil)
+	}
+}
+
 // TxStatus is the current status of a transaction as seen by the pool.
 type TxStatus uint
 
@@ -134,8 +153,13 @@ type TxPoolConfig struct {
 	GlobalSlots  uint64 // Maximum number of executable transaction slots for all accounts
 	AccountQueue uint64 // Maximum number of non-executable transaction slots permitted per account
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
+	MaxNonceGap  uint64 // Maximum distance of a transaction's nonce ahead of the account nonce (0 = unlimited)
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+
+	AllowedSenders []common.Address // Senders permitted to submit transactions (empty = everyone)
+
+	Ordering TxOrdering // Order of the pending transactions in locally assembled blocks
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -171,6 +195,10 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool price bump", "provided", conf.PriceBump, "updated", DefaultTxPoolConfig.PriceBump)
 		conf.PriceBump = DefaultTxPoolConfig.PriceBump
 	}
+	if !conf.Ordering.IsValid() {
+		log.Warn("Sanitizing invalid txpool ordering", "provided", conf.Ordering, "updated", DefaultTxPoolConfig.Ordering)
+		conf.Ordering = DefaultTxPoolConfig.Ordering
+	}
 	return conf
 }
 
@@ -187,6 +215,7 @@ type TxPool struct {
 	chain        blockChain
 	gasPrice     *big.Int
 	txFeed       event.Feed
+	replaceFeed  event.Feed
 	scope        event.SubscriptionScope
 	chainHeadCh  chan ChainHeadEvent
 	chainHeadSub event.Subscription
@@ -198,6 +227,7 @@ type TxPool struct {
 	currentMaxGas uint64              // Current gas limit for transaction caps
 
 	locals  *accountSet // Set of local transaction to exempt from eviction rules
+	allowed *accountSet // Set of senders permitted into the pool (nil = everyone)
 	journal *txJournal  // Journal of local transaction to back up to disk
 
 	pending map[common.Address]*txList   // All currently processable transactions
@@ -229,6 +259,13 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		gasPrice:    new(big.Int).SetUint64(config.PriceLimit),
 	}
 	pool.locals = newAccountSet(pool.signer)
+	if len(config.AllowedSenders) > 0 {
+		pool.allowed = newAccountSet(pool.signer)
+		for _, addr := range config.AllowedSenders {
+			log.Info("Setting new allowed sender", "address", addr)
+			pool.allowed.add(addr)
+		}
+	}
 	pool.priced = newTxPricedList(pool.all)
 	pool.reset(nil, chain.CurrentBlock().Header())
 
@@ -317,6 +354,7 @@ func (pool *TxPool) loop() {
 					}
 				}
 			}
+			pool.updateGauges()
 			pool.mu.Unlock()
 
 		// Handle local transaction journal rotation
@@ -446,6 +484,12 @@ func (pool *TxPool) SubscribeNewTxsEvent(ch chan<- NewTxsEvent) event.Subscripti
 	return pool.scope.Track(pool.txFeed.Subscribe(ch))
 }
 
+// SubscribeReplacedTxEvent registers a subscription of ReplacedTxEvent and
+// starts sending event to the given channel.
+func (pool *TxPool) SubscribeReplacedTxEvent(ch chan<- ReplacedTxEvent) event.Subscription {
+	return pool.scope.Track(pool.replaceFeed.Subscribe(ch))
+}
+
 // GasPrice returns the current gas price enforced by the transaction pool.
 func (pool *TxPool) GasPrice() *big.Int {
 	pool.mu.RLock()
@@ -498,6 +542,29 @@ func (pool *TxPool) stats() (int, int) {
 	return pending, queued
 }
 
+// updateGauges refreshes the pool content metrics.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) updateGauges() {
+	if !metrics.Enabled {
+		return
+	}
+	pending, queued := pool.stats()
+	pendingGauge.Update(int64(pending))
+	queuedGauge.Update(int64(queued))
+
+	local := 0
+	for addr := range pool.locals.accounts {
+		if list := pool.pending[addr]; list != nil {
+			local += list.Len()
+		}
+		if list := pool.queue[addr]; list != nil {
+			local += list.Len()
+		}
+	}
+	localGauge.Update(int64(local))
+}
+
 // Content retrieves the data content of the transaction pool, returning all the
 // pending as well as queued transactions, grouped by account and sorted by nonce.
 func (pool *TxPool) Content() (map[common.Address]types.Transactions, map[common.Address]types.Transactions) {
@@ -515,6 +582,12 @@ func (pool *TxPool) Content() (map[common.Address]types.Transactions, map[common
 	return pending, queued
 }
 
+// Ordering returns the order in which the pending transactions should be
+// included into locally assembled blocks.
+func (pool *TxPool) Ordering() TxOrdering {
+	return pool.config.Ordering
+}
+
 // Pending retrieves all currently processable transactions, groupped by origin
 // account and sorted by nonce. The returned transaction set is a copy and can be
 // freely modified by calling code.
@@ -545,6 +618,15 @@ func (pool *TxPool) local() map[common.Address]types.Transactions {
 	return txs
 }
 
+// IsLocal reports whether the transaction was sent by an account the pool
+// treats as local.
+func (pool *TxPool) IsLocal(tx *types.Transaction) bool {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return pool.locals.containsTx(tx)
+}
+
 // validateTx checks whether a transaction is valid according to the consensus
 // rules and adheres to some heuristic limits of the local node (price and size).
 func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
@@ -566,15 +648,23 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if err != nil {
 		return ErrInvalidSender
 	}
+	// Drop transactions from senders outside of the allowed set, if any
+	if pool.allowed != nil && !pool.allowed.contains(from) {
+		return ErrSenderNotAllowed
+	}
 	// Drop non-local transactions under our own minimal accepted gas price
 	local = local || pool.locals.contains(from) // account may be local even if the transaction arrived from the network
 	if !local && pool.gasPrice.Cmp(tx.GasPrice()) > 0 {
 		return ErrUnderpriced
 	}
 	// Ensure the transaction adheres to nonce ordering
-	if pool.currentState.GetNonce(from) > tx.Nonce() {
+	nonce := pool.currentState.GetNonce(from)
+	if nonce > tx.Nonce() {
 		return ErrNonceTooLow
 	}
+	if pool.config.MaxNonceGap > 0 && tx.Nonce()-nonce > pool.config.MaxNonceGap {
+		return ErrNonceTooHigh
+	}
 	// Transactor should have enough funds to cover the costs
 	// cost == V + GP * GL
 	if pool.currentState.GetBalance(from).Cmp(tx.Cost()) < 0 {
@@ -641,6 +731,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 			pool.all.Remove(old.Hash())
 			pool.priced.Removed()
 			pendingReplaceCounter.Inc(1)
+			pool.notifyReplaced(old, tx)
 		}
 		pool.all.Add(tx)
 		pool.priced.Put(tx)
@@ -658,6 +749,9 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 	if err != nil {
 		return false, err
 	}
+	if tx.Nonce() > pool.pendingState.GetNonce(from) {
+		queuedNonceGapCounter.Inc(1)
+	}
 	// Mark local addresses and journal local transactions
 	if local {
 		pool.locals.add(from)
@@ -688,6 +782,7 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed()
 		queuedReplaceCounter.Inc(1)
+		pool.notifyReplaced(old, tx)
 	}
 	if pool.all.Get(hash) == nil {
 		pool.all.Add(tx)
@@ -696,6 +791,13 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 	return old != nil, nil
 }
 
+// notifyReplaced informs any subsystems that the old transaction was evicted in
+// favor of a higher priced one with the same nonce.
+func (pool *TxPool) notifyReplaced(old, tx *types.Transaction) {
+	log.Trace("Replaced pooled transaction", "old", old.Hash(), "new", tx.Hash())
+	go pool.replaceFeed.Send(ReplacedTxEvent{Old: old.Hash(), New: tx.Hash()})
+}
+
 // journalTx adds the specified transaction to the local disk journal if it is
 // deemed to have been sent from a local account.
 func (pool *TxPool) journalTx(from common.Address, tx *types.Transaction) {
@@ -734,6 +836,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 		pool.priced.Removed()
 
 		pendingReplaceCounter.Inc(1)
+		pool.notifyReplaced(old, tx)
 	}
 	// Failsafe to work around direct pending inserts (tests)
 	if pool.all.Get(hash) == nil {
@@ -852,6 +955,34 @@ func (pool *TxPool) Get(hash common.Hash) *types.Transaction {
 	return pool.all.Get(hash)
 }
 
+// RemoveAccount drops every pending and queued transaction sent by the given
+// account, resetting its pool nonce, and returns the number of transactions
+// removed.
+func (pool *TxPool) RemoveAccount(addr common.Address) int {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	removed := 0
+	if list := pool.pending[addr]; list != nil {
+		// Remove the highest nonces first so nothing gets demoted to the queue
+		txs := list.Flatten()
+		for i := len(txs) - 1; i >= 0; i-- {
+			pool.removeTx(txs[i].Hash(), true)
+			removed++
+		}
+	}
+	if list := pool.queue[addr]; list != nil {
+		for _, tx := range list.Flatten() {
+			pool.removeTx(tx.Hash(), true)
+			removed++
+		}
+	}
+	if removed > 0 {
+		log.Info("Removed account transactions from the pool", "account", addr, "count", removed)
+	}
+	return removed
+}
+
 // removeTx removes a single transaction from the queue, moving all subsequent
 // transactions back to the future queue.
 func (pool *TxPool) removeTx(hash common.Hash, outofbound bool) {
@@ -1069,6 +1200,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			}
 		}
 	}
+	pool.updateGauges()
 }
 
 // demoteUnexecutables removes invalid and processed transactions from the pools
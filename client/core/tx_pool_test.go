@@ -0,0 +1,427 @@
+package core
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/core/state"
+	"github.com/kowala-tech/kcoin/client/core/types"
+	"github.com/kowala-tech/kcoin/client/crypto"
+	"github.com/kowala-tech/kcoin/client/event"
+	"github.com/kowala-tech/kcoin/client/kcoindb"
+	"github.com/kowala-tech/kcoin/client/metrics"
+	"github.com/kowala-tech/kcoin/client/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTxPoolConfig is a transaction pool configuration without stateful disk
+// sideeffects used during testing.
+var testTxPoolConfig TxPoolConfig
+
+func init() {
+	testTxPoolConfig = DefaultTxPoolConfig
+	testTxPoolConfig.Journal = ""
+}
+
+type testBlockChain struct {
+	statedb       *state.StateDB
+	gasLimit      uint64
+	chainHeadFeed *event.Feed
+}
+
+func (bc *testBlockChain) CurrentBlock() *types.Block {
+	return types.NewBlock(&types.Header{
+		GasLimit: bc.gasLimit,
+	}, nil, nil, nil)
+}
+
+func (bc *testBlockChain) GetBlock(hash common.Hash, number uint64) *types.Block {
+	return bc.CurrentBlock()
+}
+
+func (bc *testBlockChain) StateAt(common.Hash) (*state.StateDB, error) {
+	return bc.statedb, nil
+}
+
+func (bc *testBlockChain) SubscribeChainHeadEvent(ch chan<- ChainHeadEvent) event.Subscription {
+	return bc.chainHeadFeed.Subscribe(ch)
+}
+
+func transaction(nonce uint64, gaslimit uint64, key *ecdsa.PrivateKey) *types.Transaction {
+	return pricedTransaction(nonce, gaslimit, big.NewInt(1), key)
+}
+
+func pricedTransaction(nonce uint64, gaslimit uint64, gasprice *big.Int, key *ecdsa.PrivateKey) *types.Transaction {
+	tx, _ := types.SignTx(types.NewTransaction(nonce, common.Address{}, big.NewInt(100), gaslimit, gasprice, nil), types.NewAndromedaSigner(params.TestChainConfig.ChainID), key)
+	return tx
+}
+
+func setupTxPoolWithConfig(config TxPoolConfig) (*TxPool, *state.StateDB) {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(kcoindb.NewMemDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	return NewTxPool(config, params.TestChainConfig, blockchain), statedb
+}
+
+func TestTxPoolAllowedSenders(t *testing.T) {
+	allowedKey, _ := crypto.GenerateKey()
+	otherKey, _ := crypto.GenerateKey()
+	allowed := crypto.PubkeyToAddress(allowedKey.PublicKey)
+	other := crypto.PubkeyToAddress(otherKey.PublicKey)
+
+	config := testTxPoolConfig
+	config.AllowedSenders = []common.Address{allowed}
+
+	pool, statedb := setupTxPoolWithConfig(config)
+	defer pool.Stop()
+
+	statedb.AddBalance(allowed, big.NewInt(1000000))
+	statedb.AddBalance(other, big.NewInt(1000000))
+
+	require.NoError(t, pool.AddRemote(transaction(0, 100000, allowedKey)))
+	assert.Equal(t, ErrSenderNotAllowed, pool.AddRemote(transaction(0, 100000, otherKey)))
+	assert.Equal(t, ErrSenderNotAllowed, pool.AddLocal(transaction(0, 100000, otherKey)))
+
+	pending, queued := pool.Stats()
+	assert.Equal(t, 1, pending)
+	assert.Equal(t, 0, queued)
+}
+
+func TestTxPoolMaxNonceGap(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	config := testTxPoolConfig
+	config.MaxNonceGap = 5
+
+	pool, statedb := setupTxPoolWithConfig(config)
+	defer pool.Stop()
+
+	statedb.AddBalance(addr, big.NewInt(1000000))
+	statedb.SetNonce(addr, 10)
+	pool.lockedReset(nil, nil)
+
+	// Nonces up to the gap ahead of the account nonce are accepted
+	require.NoError(t, pool.AddRemote(transaction(10, 100000, key)))
+	require.NoError(t, pool.AddRemote(transaction(15, 100000, key)))
+
+	// Anything beyond is rejected
+	assert.Equal(t, ErrNonceTooHigh, pool.AddRemote(transaction(16, 100000, key)))
+	assert.Equal(t, ErrNonceTooHigh, pool.AddLocal(transaction(1000, 100000, key)))
+
+	pending, queued := pool.Stats()
+	assert.Equal(t, 1, pending)
+	assert.Equal(t, 1, queued)
+}
+
+func TestTxPoolAllowedSendersEmptyAcceptsAll(t *testing.T) {
+	pool, statedb := setupTxPoolWithConfig(testTxPoolConfig)
+	defer pool.Stop()
+
+	for i := 0; i < 3; i++ {
+		key, _ := crypto.GenerateKey()
+		statedb.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000))
+
+		require.NoError(t, pool.AddRemote(transaction(0, 100000, key)))
+	}
+	pending, _ := pool.Stats()
+	assert.Equal(t, 3, pending)
+}
+
+func TestTxPoolReplacementEvent(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+
+	pool, statedb := setupTxPoolWithConfig(testTxPoolConfig)
+	defer pool.Stop()
+
+	statedb.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(100000000))
+
+	replaced := make(chan ReplacedTxEvent, 1)
+	sub := pool.SubscribeReplacedTxEvent(replaced)
+	defer sub.Unsubscribe()
+
+	old := pricedTransaction(0, 100000, big.NewInt(1), key)
+	require.NoError(t, pool.AddRemote(old))
+
+	// A replacement without the required price bump must not fire an event
+	assert.Equal(t, ErrReplaceUnderpriced, pool.AddRemote(pricedTransaction(0, 100001, big.NewInt(1), key)))
+
+	tx := pricedTransaction(0, 100000, big.NewInt(2), key)
+	require.NoError(t, pool.AddRemote(tx))
+
+	select {
+	case ev := <-replaced:
+		assert.Equal(t, old.Hash(), ev.Old)
+		assert.Equal(t, tx.Hash(), ev.New)
+	case <-time.After(time.Second):
+		t.Fatal("replacement event not fired")
+	}
+}
+
+func TestTxPoolRemoveAccount(t *testing.T) {
+	pool, statedb := setupTxPoolWithConfig(testTxPoolConfig)
+	defer pool.Stop()
+
+	target, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	targetAddr := crypto.PubkeyToAddress(target.PublicKey)
+	otherAddr := crypto.PubkeyToAddress(other.PublicKey)
+	statedb.AddBalance(targetAddr, big.NewInt(1000000))
+	statedb.AddBalance(otherAddr, big.NewInt(1000000))
+
+	// Both accounts get two executable transactions and one gapped one
+	for _, key := range []*ecdsa.PrivateKey{target, other} {
+		for _, nonce := range []uint64{0, 1, 3} {
+			require.NoError(t, pool.AddRemote(transaction(nonce, 100000, key)))
+		}
+	}
+	pending, queued := pool.Stats()
+	require.Equal(t, 4, pending)
+	require.Equal(t, 2, queued)
+
+	assert.Equal(t, 3, pool.RemoveAccount(targetAddr))
+
+	pendingTxs, queuedTxs := pool.Content()
+	assert.NotContains(t, pendingTxs, targetAddr)
+	assert.NotContains(t, queuedTxs, targetAddr)
+	assert.Len(t, pendingTxs[otherAddr], 2)
+	assert.Len(t, queuedTxs[otherAddr], 1)
+	assert.Equal(t, 3, pool.all.Count())
+	assert.Equal(t, uint64(0), pool.State().GetNonce(targetAddr))
+	assert.Equal(t, uint64(2), pool.State().GetNonce(otherAddr))
+
+	// The account can start over from its confirmed nonce, and clearing it
+	// again removes nothing else
+	require.NoError(t, pool.AddRemote(transaction(0, 100000, target)))
+	pending, _ = pool.Stats()
+	assert.Equal(t, 3, pending)
+	assert.Equal(t, 0, pool.RemoveAccount(common.Address{1}))
+}
+
+func TestTxPoolGauges(t *testing.T) {
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = false }()
+
+	oldPending, oldQueued, oldLocal := pendingGauge, queuedGauge, localGauge
+	defer func() { pendingGauge, queuedGauge, localGauge = oldPending, oldQueued, oldLocal }()
+	pendingGauge, queuedGauge, localGauge = metrics.NewGauge(), metrics.NewGauge(), metrics.NewGauge()
+
+	pool, statedb := setupTxPoolWithConfig(testTxPoolConfig)
+	defer pool.Stop()
+
+	localKey, _ := crypto.GenerateKey()
+	remoteKey, _ := crypto.GenerateKey()
+	statedb.AddBalance(crypto.PubkeyToAddress(localKey.PublicKey), big.NewInt(1000000000))
+	statedb.AddBalance(crypto.PubkeyToAddress(remoteKey.PublicKey), big.NewInt(1000000000))
+
+	require.NoError(t, pool.AddLocal(transaction(0, 100000, localKey)))
+	require.NoError(t, pool.AddRemote(transaction(0, 100000, remoteKey)))
+	require.NoError(t, pool.AddRemote(transaction(2, 100000, remoteKey)))
+
+	assert.Equal(t, int64(2), pendingGauge.Value())
+	assert.Equal(t, int64(1), queuedGauge.Value())
+	assert.Equal(t, int64(1), localGauge.Value())
+}
+
+func TestTxPoolSnapshotRoundTrip(t *testing.T) {
+	source, sourceState := setupTxPoolWithConfig(testTxPoolConfig)
+	defer source.Stop()
+
+	keys := make([]*ecdsa.PrivateKey, 2)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		sourceState.AddBalance(crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000000))
+	}
+	require.NoError(t, source.AddLocal(transaction(0, 100000, keys[0])))
+	require.NoError(t, source.AddRemote(transaction(1, 100000, keys[0])))
+	require.NoError(t, source.AddRemote(transaction(0, 100000, keys[1])))
+	require.NoError(t, source.AddRemote(transaction(2, 100000, keys[1])))
+
+	var snapshot bytes.Buffer
+	exported, err := source.ExportSnapshot(&snapshot)
+	require.NoError(t, err)
+	assert.Equal(t, 4, exported)
+
+	target, targetState := setupTxPoolWithConfig(testTxPoolConfig)
+	defer target.Stop()
+	for _, key := range keys {
+		targetState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	}
+	imported, dropped, err := target.ImportSnapshot(&snapshot)
+	require.NoError(t, err)
+	assert.Equal(t, 4, imported)
+	assert.Equal(t, 0, dropped)
+
+	sourcePending, sourceQueued := source.Content()
+	targetPending, targetQueued := target.Content()
+	assert.Equal(t, txHashes(sourcePending), txHashes(targetPending))
+	assert.Equal(t, txHashes(sourceQueued), txHashes(targetQueued))
+
+	// imported transactions are remote ones, whatever they were on the source
+	assert.Empty(t, target.local())
+}
+
+func TestTxPoolSnapshotImportAppliesAdmissionRules(t *testing.T) {
+	source, sourceState := setupTxPoolWithConfig(testTxPoolConfig)
+	defer source.Stop()
+
+	funded, _ := crypto.GenerateKey()
+	unfunded, _ := crypto.GenerateKey()
+	sourceState.AddBalance(crypto.PubkeyToAddress(funded.PublicKey), big.NewInt(1000000000))
+	sourceState.AddBalance(crypto.PubkeyToAddress(unfunded.PublicKey), big.NewInt(1000000000))
+
+	require.NoError(t, source.AddRemote(pricedTransaction(0, 100000, big.NewInt(1), funded)))
+	require.NoError(t, source.AddRemote(pricedTransaction(1, 100000, big.NewInt(5), funded)))
+	require.NoError(t, source.AddRemote(pricedTransaction(0, 100000, big.NewInt(5), unfunded)))
+
+	var snapshot bytes.Buffer
+	_, err := source.ExportSnapshot(&snapshot)
+	require.NoError(t, err)
+
+	config := testTxPoolConfig
+	config.PriceLimit = 2
+	target, targetState := setupTxPoolWithConfig(config)
+	defer target.Stop()
+	targetState.AddBalance(crypto.PubkeyToAddress(funded.PublicKey), big.NewInt(1000000000))
+
+	imported, dropped, err := target.ImportSnapshot(&snapshot)
+	require.NoError(t, err)
+	assert.Equal(t, 1, imported)
+	assert.Equal(t, 2, dropped)
+
+	pending, queued := target.Stats()
+	assert.Equal(t, 0, pending)
+	assert.Equal(t, 1, queued)
+}
+
+func TestTxPoolSnapshotImportCorrupted(t *testing.T) {
+	pool, _ := setupTxPoolWithConfig(testTxPoolConfig)
+	defer pool.Stop()
+
+	_, _, err := pool.ImportSnapshot(bytes.NewReader([]byte{0xff, 0x01}))
+	assert.Error(t, err)
+}
+
+// txHashes flattens a pool content listing into the transaction hashes of
+// every account.
+func txHashes(content map[common.Address]types.Transactions) map[common.Address][]common.Hash {
+	hashes := make(map[common.Address][]common.Hash)
+	for addr, txs := range content {
+		for _, tx := range txs {
+			hashes[addr] = append(hashes[addr], tx.Hash())
+		}
+	}
+	return hashes
+}
+
+func TestTxPoolOrdering(t *testing.T) {
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	// Transactions in arrival order, deliberately not sorted by price
+	arrivals := []*types.Transaction{
+		pricedTransaction(0, 100000, big.NewInt(1), keys[1]),
+		pricedTransaction(0, 100000, big.NewInt(10), keys[0]),
+		pricedTransaction(0, 100000, big.NewInt(5), keys[2]),
+		pricedTransaction(1, 100000, big.NewInt(20), keys[1]),
+	}
+	testCases := []struct {
+		ordering TxOrdering
+		want     []int // indexes into arrivals
+	}{
+		{FIFOOrdering, []int{0, 1, 2, 3}},
+		{PriceOrdering, []int{1, 2, 0, 3}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.ordering.String(), func(t *testing.T) {
+			config := testTxPoolConfig
+			config.Ordering = tc.ordering
+			pool, statedb := setupTxPoolWithConfig(config)
+			defer pool.Stop()
+
+			for _, key := range keys {
+				statedb.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+			}
+			for _, tx := range arrivals {
+				require.NoError(t, pool.AddRemote(tx))
+			}
+			pending, err := pool.Pending()
+			require.NoError(t, err)
+
+			var have []common.Hash
+			txs := pool.Ordering().Order(pool.signer, pending)
+			for tx := txs.Peek(); tx != nil; tx = txs.Peek() {
+				have = append(have, tx.Hash())
+				txs.Shift()
+			}
+			want := make([]common.Hash, len(tc.want))
+			for i, index := range tc.want {
+				want[i] = arrivals[index].Hash()
+			}
+			assert.Equal(t, want, have)
+		})
+	}
+}
+
+func TestTxOrderingPrioritize(t *testing.T) {
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	signer := types.NewAndromedaSigner(params.TestChainConfig.ChainID)
+	priority := crypto.PubkeyToAddress(keys[2].PublicKey)
+
+	txs := []*types.Transaction{
+		pricedTransaction(0, 100000, big.NewInt(10), keys[0]),
+		pricedTransaction(0, 100000, big.NewInt(5), keys[1]),
+		pricedTransaction(0, 100000, big.NewInt(1), keys[2]),
+		pricedTransaction(1, 100000, big.NewInt(1), keys[2]),
+	}
+	for _, ordering := range []TxOrdering{PriceOrdering, FIFOOrdering} {
+		t.Run(ordering.String(), func(t *testing.T) {
+			pending := make(map[common.Address]types.Transactions)
+			for _, tx := range txs {
+				from, _ := types.TxSender(signer, tx)
+				pending[from] = append(pending[from], tx)
+			}
+			input := make(map[common.Address]types.Transactions, len(pending))
+			for addr, list := range pending {
+				input[addr] = list
+			}
+			ordered := ordering.Prioritize(signer, pending, func(addr common.Address) bool { return addr == priority })
+			assert.Equal(t, input, pending, "input map modified")
+
+			var have []common.Hash
+			for tx := ordered.Peek(); tx != nil; tx = ordered.Peek() {
+				have = append(have, tx.Hash())
+				ordered.Shift()
+			}
+			require.Len(t, have, len(txs))
+			assert.Equal(t, []common.Hash{txs[2].Hash(), txs[3].Hash()}, have[:2])
+			assert.Equal(t, input, pending, "input map modified while draining")
+		})
+	}
+}
+
+func TestTxPoolOrderingText(t *testing.T) {
+	for _, ordering := range []TxOrdering{PriceOrdering, FIFOOrdering} {
+		text, err := ordering.MarshalText()
+		require.NoError(t, err)
+
+		var decoded TxOrdering
+		require.NoError(t, decoded.UnmarshalText(text))
+		assert.Equal(t, ordering, decoded)
+	}
+	var ordering TxOrdering
+	assert.Error(t, ordering.UnmarshalText([]byte("random")))
+
+	config := testTxPoolConfig
+	config.Ordering = TxOrdering(7)
+	assert.Equal(t, PriceOrdering, config.sanitize().Ordering)
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"sort"
+
+	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/core/types"
+	"github.com/kowala-tech/kcoin/client/log"
+	"github.com/kowala-tech/kcoin/client/rlp"
+)
+
+// ExportSnapshot writes every pending and queued transaction of the pool into
+// the given writer as a stream of RLP encoded transactions, grouped by sender
+// and sorted by nonce. It returns the number of exported transactions.
+func (pool *TxPool) ExportSnapshot(w io.Writer) (int, error) {
+	pending, queued := pool.Content()
+
+	senders := make([]common.Address, 0, len(pending)+len(queued))
+	for addr := range pending {
+		senders = append(senders, addr)
+	}
+	for addr := range queued {
+		if _, ok := pending[addr]; !ok {
+			senders = append(senders, addr)
+		}
+	}
+	sort.Slice(senders, func(i, j int) bool {
+		return bytes.Compare(senders[i][:], senders[j][:]) < 0
+	})
+
+	exported := 0
+	for _, addr := range senders {
+		txs := append(pending[addr], queued[addr]...)
+		for _, tx := range txs {
+			if err := rlp.Encode(w, tx); err != nil {
+				return exported, err
+			}
+			exported++
+		}
+	}
+	return exported, nil
+}
+
+// ImportSnapshot reads a stream of RLP encoded transactions, as produced by
+// ExportSnapshot, and adds them to the pool as remote transactions, so the
+// regular admission rules apply. It returns the number of imported and dropped
+// transactions.
+func (pool *TxPool) ImportSnapshot(r io.Reader) (int, int, error) {
+	stream := rlp.NewStream(r, 0)
+	imported, dropped := 0, 0
+
+	addBatch := func(txs types.Transactions) {
+		for i, err := range pool.AddRemotes(txs) {
+			if err != nil {
+				log.Debug("Failed to import transaction", "hash", txs[i].Hash(), "err", err)
+				dropped++
+				continue
+			}
+			imported++
+		}
+	}
+	var batch types.Transactions
+	for {
+		tx := new(types.Transaction)
+		if err := stream.Decode(tx); err != nil {
+			if batch.Len() > 0 {
+				addBatch(batch)
+			}
+			if err == io.EOF {
+				break
+			}
+			return imported, dropped, err
+		}
+		if batch = append(batch, tx); batch.Len() >= 1024 {
+			addBatch(batch)
+			batch = batch[:0]
+		}
+	}
+	log.Info("Imported transaction pool snapshot", "transactions", imported, "dropped", dropped)
+
+	return imported, dropped, nil
+}
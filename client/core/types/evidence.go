@@ -0,0 +1,66 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/kowala-tech/kcoin/client/common"
+)
+
+var (
+	errNotConflicting = errors.New("votes are not conflicting")
+	errWrongSigner    = errors.New("vote not signed by the accused validator")
+)
+
+// DuplicateVoteEvidence holds two conflicting votes signed by the same
+// validator for the same block number, round and vote type.
+type DuplicateVoteEvidence struct {
+	Address common.Address
+	VoteA   *Vote
+	VoteB   *Vote
+}
+
+// NewDuplicateVoteEvidence returns the evidence of a validator double signing.
+// It returns an error if the votes do not conflict.
+func NewDuplicateVoteEvidence(address common.Address, voteA, voteB *Vote) (*DuplicateVoteEvidence, error) {
+	if voteA.BlockNumber().Cmp(voteB.BlockNumber()) != 0 ||
+		voteA.Round() != voteB.Round() ||
+		voteA.Type() != voteB.Type() ||
+		voteA.BlockHash() == voteB.BlockHash() {
+		return nil, errNotConflicting
+	}
+	return &DuplicateVoteEvidence{
+		Address: address,
+		VoteA:   voteA,
+		VoteB:   voteB,
+	}, nil
+}
+
+// Verify checks that the votes conflict and that both of them were signed by
+// the accused validator, so the evidence can be trusted regardless of where it
+// came from.
+func (ev *DuplicateVoteEvidence) Verify(signer Signer) error {
+	if _, err := NewDuplicateVoteEvidence(ev.Address, ev.VoteA, ev.VoteB); err != nil {
+		return err
+	}
+	for _, vote := range []*Vote{ev.VoteA, ev.VoteB} {
+		address, err := VoteSender(signer, vote)
+		if err != nil {
+			return err
+		}
+		if address != ev.Address {
+			return errWrongSigner
+		}
+	}
+	return nil
+}
+
+// Hash returns the hash identifying the evidence. It doesn't depend on the
+// order of the votes, so the same offence reported twice hashes the same.
+func (ev *DuplicateVoteEvidence) Hash() common.Hash {
+	hashA, hashB := ev.VoteA.Hash(), ev.VoteB.Hash()
+	if bytes.Compare(hashA[:], hashB[:]) > 0 {
+		hashA, hashB = hashB, hashA
+	}
+	return rlpHash([]interface{}{ev.Address, hashA, hashB})
+}
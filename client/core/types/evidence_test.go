@@ -0,0 +1,75 @@
+package types
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDuplicateVoteEvidence(t *testing.T) {
+	addr := common.HexToAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed")
+	vote := NewVote(big.NewInt(1), common.HexToHash("123"), 0, PreVote)
+
+	tests := []struct {
+		name        string
+		other       *Vote
+		conflicting bool
+	}{
+		{"different block", NewVote(big.NewInt(1), common.HexToHash("456"), 0, PreVote), true},
+		{"same block", NewVote(big.NewInt(1), common.HexToHash("123"), 0, PreVote), false},
+		{"different number", NewVote(big.NewInt(2), common.HexToHash("456"), 0, PreVote), false},
+		{"different round", NewVote(big.NewInt(1), common.HexToHash("456"), 1, PreVote), false},
+		{"different type", NewVote(big.NewInt(1), common.HexToHash("456"), 0, PreCommit), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evidence, err := NewDuplicateVoteEvidence(addr, vote, tt.other)
+			if !tt.conflicting {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, addr, evidence.Address)
+			assert.Equal(t, vote, evidence.VoteA)
+			assert.Equal(t, tt.other, evidence.VoteB)
+		})
+	}
+}
+
+func TestDuplicateVoteEvidenceVerify(t *testing.T) {
+	signer := NewAndromedaSigner(big.NewInt(1))
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	sign := func(key *ecdsa.PrivateKey, hash common.Hash) *Vote {
+		vote, err := SignVote(NewVote(big.NewInt(1), hash, 0, PreCommit), signer, key)
+		require.NoError(t, err)
+		return vote
+	}
+	voteA, voteB := sign(key, common.HexToHash("123")), sign(key, common.HexToHash("456"))
+
+	evidence, err := NewDuplicateVoteEvidence(addr, voteA, voteB)
+	require.NoError(t, err)
+	assert.NoError(t, evidence.Verify(signer))
+
+	swapped, err := NewDuplicateVoteEvidence(addr, voteB, voteA)
+	require.NoError(t, err)
+	assert.Equal(t, evidence.Hash(), swapped.Hash(), "hash depends on vote order")
+
+	forged := &DuplicateVoteEvidence{Address: addr, VoteA: voteA, VoteB: sign(other, common.HexToHash("456"))}
+	assert.Equal(t, errWrongSigner, forged.Verify(signer))
+
+	accused := &DuplicateVoteEvidence{Address: common.Address{1}, VoteA: voteA, VoteB: voteB}
+	assert.Equal(t, errWrongSigner, accused.Verify(signer))
+
+	same := &DuplicateVoteEvidence{Address: addr, VoteA: voteA, VoteB: sign(key, common.HexToHash("123"))}
+	assert.Equal(t, errNotConflicting, same.Verify(signer))
+}
@@ -1,12 +1,14 @@
 package types
 
 import (
+	"bytes"
 	"container/heap"
 	"errors"
 	"fmt"
 	"io"
 	"math/big"
 	"sync/atomic"
+	"time"
 
 	"github.com/kowala-tech/kcoin/client/common"
 	"github.com/kowala-tech/kcoin/client/common/hexutil"
@@ -23,6 +25,7 @@ func deriveSigner(V *big.Int) Signer {
 
 type Transaction struct {
 	data txdata
+	time time.Time // Time first seen locally, used for arrival ordering
 	// caches
 	hash atomic.Value
 	size atomic.Value
@@ -87,7 +90,7 @@ func newTransaction(nonce uint64, to *common.Address, amount *big.Int, gasLimit
 		d.Price.Set(gasPrice)
 	}
 
-	return &Transaction{data: d}
+	return &Transaction{data: d, time: time.Now()}
 }
 
 // ChainID returns which chain id this transaction was signed for (if at all)
@@ -110,6 +113,7 @@ func (tx *Transaction) DecodeRLP(s *rlp.Stream) error {
 	err := s.Decode(&tx.data)
 	if err == nil {
 		tx.size.Store(common.StorageSize(rlp.ListSize(size)))
+		tx.time = time.Now()
 	}
 
 	return err
@@ -139,7 +143,7 @@ func (tx *Transaction) UnmarshalJSON(input []byte) error {
 	if !crypto.ValidateSignatureValues(V, dec.R, dec.S, false) {
 		return ErrInvalidSig
 	}
-	*tx = Transaction{data: dec}
+	*tx = Transaction{data: dec, time: time.Now()}
 	return nil
 }
 
@@ -261,7 +265,7 @@ func (tx *Transaction) WithSignature(signer Signer, sig []byte) (*Transaction, e
 	if err != nil {
 		return nil, err
 	}
-	cpy := &Transaction{data: tx.data}
+	cpy := &Transaction{data: tx.data, time: tx.time}
 	cpy.data.R, cpy.data.S, cpy.data.V = r, s, v
 	return cpy, nil
 }
@@ -391,6 +395,94 @@ func (s *TxByPrice) Pop() interface{} {
 	return x
 }
 
+// TxByArrival implements the heap interface, sorting transactions by the time
+// they were first seen locally. Transactions seen at the same time are ordered
+// by nonce and then by hash, so that the order doesn't depend on map iteration.
+type TxByArrival Transactions
+
+func (s TxByArrival) Len() int { return len(s) }
+func (s TxByArrival) Less(i, j int) bool {
+	if !s[i].time.Equal(s[j].time) {
+		return s[i].time.Before(s[j].time)
+	}
+	if s[i].Nonce() != s[j].Nonce() {
+		return s[i].Nonce() < s[j].Nonce()
+	}
+	return bytes.Compare(s[i].Hash().Bytes(), s[j].Hash().Bytes()) < 0
+}
+func (s TxByArrival) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+func (s *TxByArrival) Push(x interface{}) {
+	*s = append(*s, x.(*Transaction))
+}
+
+func (s *TxByArrival) Pop() interface{} {
+	old := *s
+	n := len(old)
+	x := old[n-1]
+	*s = old[0 : n-1]
+	return x
+}
+
+// TransactionsByArrivalAndNonce represents a set of transactions that can return
+// transactions in the order they were first seen, while honouring the nonce
+// order of each account and supporting removing entire batches of transactions
+// for non-executable accounts.
+type TransactionsByArrivalAndNonce struct {
+	txs    map[common.Address]Transactions // Per account nonce-sorted list of transactions
+	heads  TxByArrival                     // Next transaction for each unique account (arrival heap)
+	signer Signer                          // Signer for the set of transactions
+}
+
+// NewTransactionsByArrivalAndNonce creates a transaction set that can retrieve
+// arrival sorted transactions in a nonce-honouring way.
+//
+// Note, the input map is reowned so the caller should not interact any more with
+// if after providing it to the constructor.
+func NewTransactionsByArrivalAndNonce(signer Signer, txs map[common.Address]Transactions) *TransactionsByArrivalAndNonce {
+	heads := make(TxByArrival, 0, len(txs))
+	for from, accTxs := range txs {
+		heads = append(heads, accTxs[0])
+		acc, _ := TxSender(signer, accTxs[0])
+		txs[acc] = accTxs[1:]
+		if from != acc {
+			delete(txs, from)
+		}
+	}
+	heap.Init(&heads)
+
+	return &TransactionsByArrivalAndNonce{
+		txs:    txs,
+		heads:  heads,
+		signer: signer,
+	}
+}
+
+// Peek returns the earliest seen transaction.
+func (t *TransactionsByArrivalAndNonce) Peek() *Transaction {
+	if len(t.heads) == 0 {
+		return nil
+	}
+	return t.heads[0]
+}
+
+// Shift replaces the current head with the next one from the same account.
+func (t *TransactionsByArrivalAndNonce) Shift() {
+	acc, _ := TxSender(t.signer, t.heads[0])
+	if txs, ok := t.txs[acc]; ok && len(txs) > 0 {
+		t.heads[0], t.txs[acc] = txs[0], txs[1:]
+		heap.Fix(&t.heads, 0)
+	} else {
+		heap.Pop(&t.heads)
+	}
+}
+
+// Pop removes the current head, *not* replacing it with the next one from the
+// same account.
+func (t *TransactionsByArrivalAndNonce) Pop() {
+	heap.Pop(&t.heads)
+}
+
 // TransactionsByPriceAndNonce represents a set of transactions that can return
 // transactions in a profit-maximizing sorted order, while supporting removing
 // entire batches of transactions for non-executable accounts.
@@ -0,0 +1,44 @@
+package types
+
+import (
+	"bytes"
+	"container/heap"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxByArrival_TieBreak(t *testing.T) {
+	seen := time.Now()
+	newTx := func(nonce uint64, amount int64) *Transaction {
+		tx := NewTransaction(nonce, common.Address{}, big.NewInt(amount), 21000, big.NewInt(1), nil)
+		tx.time = seen
+		return tx
+	}
+	early := newTx(5, 0)
+	early.time = seen.Add(-time.Second)
+	low := newTx(1, 0)
+	sameA, sameB := newTx(2, 1), newTx(2, 2)
+	if bytes.Compare(sameA.Hash().Bytes(), sameB.Hash().Bytes()) > 0 {
+		sameA, sameB = sameB, sameA
+	}
+	want := Transactions{early, low, sameA, sameB}
+
+	// Every insertion order yields the same sequence
+	for _, order := range [][]int{{0, 1, 2, 3}, {3, 2, 1, 0}, {2, 0, 3, 1}, {1, 3, 0, 2}} {
+		heads := make(TxByArrival, 0, len(want))
+		for _, i := range order {
+			heads = append(heads, want[i])
+		}
+		heap.Init(&heads)
+
+		var have Transactions
+		for heads.Len() > 0 {
+			have = append(have, heap.Pop(&heads).(*Transaction))
+		}
+		assert.Equal(t, want, have, "insertion order %v", order)
+	}
+}
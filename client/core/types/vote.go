@@ -32,6 +32,18 @@ func (typ VoteType) IsValid() bool {
 	return typ >= PreVote && typ <= PreCommit
 }
 
+// String implements the stringer interface.
+func (typ VoteType) String() string {
+	switch typ {
+	case PreVote:
+		return "prevote"
+	case PreCommit:
+		return "precommit"
+	default:
+		return "unknown"
+	}
+}
+
 type AddressVote interface {
 	Address() common.Address
 	Vote() *Vote
@@ -295,6 +307,21 @@ func (v *VotesSet) Leader() common.Hash {
 	return res
 }
 
+// List returns every vote of the set, including the nil ones.
+func (v *VotesSet) List() []*Vote {
+	v.l.RLock()
+	defer v.l.RUnlock()
+
+	votes := make([]*Vote, 0, len(v.m)+len(v.nilVotes))
+	for _, vote := range v.m {
+		votes = append(votes, vote)
+	}
+	for _, vote := range v.nilVotes {
+		votes = append(votes, vote)
+	}
+	return votes
+}
+
 func (v *VotesSet) Get(h common.Hash) (*Vote, bool) {
 	v.l.RLock()
 	vote, ok := v.m[h]
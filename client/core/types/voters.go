@@ -2,6 +2,7 @@ package types
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"math/big"
 
@@ -46,6 +47,7 @@ type Voters interface {
 	Len() int
 	Contains(addr common.Address) bool
 	Hash() common.Hash
+	Weights() []*big.Int
 }
 
 // NewVoter validates that a list of voters is valid returning a new type if so
@@ -57,6 +59,46 @@ func NewVoters(voterList []*Voter) (voters, error) {
 	return voters(voterList), nil
 }
 
+// NewValidatorSetWithWeights restores a voters set along with the proposer
+// weights accumulated by its voters, in set order, so the election carries on
+// where it left off instead of starting over.
+func NewValidatorSetWithWeights(voterList []*Voter, weights []*big.Int) (voters, error) {
+	if len(voterList) == 0 {
+		return nil, ErrInvalidParams
+	}
+	if len(weights) != len(voterList) {
+		return nil, fmt.Errorf("weights count mismatch: have %d, want %d", len(weights), len(voterList))
+	}
+	set := make(voters, len(voterList))
+	for i, voter := range voterList {
+		set[i] = NewVoter(voter.Address(), new(big.Int).Set(voter.Deposit()), new(big.Int).Set(weights[i]))
+	}
+	return set, nil
+}
+
+// NewNormalizedVoters returns a voters set whose weights stay bounded across
+// elections. The elected proposer gives up the total deposit of the set rather
+// than only its own, and the weights are then centered around zero and scaled
+// down whenever they spread over more than twice the total deposit. Shifting
+// and scaling every weight alike keeps their relative order, and each voter is
+// elected in proportion to its share of the total deposit.
+func NewNormalizedVoters(voterList []*Voter) (Voters, error) {
+	set, err := NewVoters(voterList)
+	if err != nil {
+		return nil, err
+	}
+	return normalizedVoters{set}, nil
+}
+
+// NormalizeVoters returns a voters set electing proposers like the ones of
+// NewNormalizedVoters, sharing the voters and weights of the given set.
+func NormalizeVoters(set Voters) Voters {
+	if list, ok := set.(voters); ok {
+		return normalizedVoters{list}
+	}
+	return set
+}
+
 // voters is a list of Voter
 type voters []*Voter
 
@@ -122,6 +164,71 @@ func (voters voters) Contains(addr common.Address) bool {
 	return voter != nil
 }
 
+// Weights returns a copy of the proposer weight of each voter, in set order
+func (voters voters) Weights() []*big.Int {
+	weights := make([]*big.Int, len(voters))
+	for i, voter := range voters {
+		weights[i] = new(big.Int).Set(voter.weight)
+	}
+	return weights
+}
+
+// normalizedVoters is a list of Voter whose weights are normalized after each
+// proposer election
+type normalizedVoters struct {
+	voters
+}
+
+// NextProposer returns the next proposer based on the weight of each voter and
+// normalizes the weights afterwards
+func (set normalizedVoters) NextProposer() *Voter {
+	total := new(big.Int)
+	proposer := set.voters[0]
+	for _, voter := range set.voters {
+		total.Add(total, voter.deposit)
+		voter.weight.Add(voter.weight, voter.deposit)
+		if voter.weight.Cmp(proposer.weight) > 0 {
+			proposer = voter
+		}
+	}
+	proposer.weight.Sub(proposer.weight, total)
+
+	set.normalize(total)
+
+	return proposer
+}
+
+// normalize scales the weights down to spread over at most twice the total
+// deposit and centers them around zero
+func (set normalizedVoters) normalize(total *big.Int) {
+	min, max := set.voters[0].weight, set.voters[0].weight
+	for _, voter := range set.voters[1:] {
+		if voter.weight.Cmp(min) < 0 {
+			min = voter.weight
+		}
+		if voter.weight.Cmp(max) > 0 {
+			max = voter.weight
+		}
+	}
+	limit := new(big.Int).Lsh(total, 1)
+	if spread := new(big.Int).Sub(max, min); limit.Sign() > 0 && spread.Cmp(limit) > 0 {
+		// round the divisor up so the spread ends within the limit
+		divisor := spread.Div(spread.Add(spread, limit).Sub(spread, big.NewInt(1)), limit)
+		for _, voter := range set.voters {
+			voter.weight.Div(voter.weight, divisor)
+		}
+	}
+
+	sum := new(big.Int)
+	for _, voter := range set.voters {
+		sum.Add(sum, voter.weight)
+	}
+	avg := sum.Div(sum, big.NewInt(int64(len(set.voters))))
+	for _, voter := range set.voters {
+		voter.weight.Sub(voter.weight, avg)
+	}
+}
+
 // VotersChecksum lets a voter know if there are changes in the voters set
 type VotersChecksum [32]byte
 
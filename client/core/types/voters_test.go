@@ -108,6 +108,96 @@ func TestVoters_UpdateWeightChangesProposerElections(t *testing.T) {
 	}
 }
 
+func TestNormalizedVoters_KeepWeightsBounded(t *testing.T) {
+	deposits := []uint64{100, 200, 300, 400}
+	list := make([]*Voter, len(deposits))
+	total := new(big.Int)
+	for i, deposit := range deposits {
+		list[i] = makeVoter(fmt.Sprintf("0x%d000000000000000000000000000000000000000", i+1), deposit, deposit)
+		total.Add(total, new(big.Int).SetUint64(deposit))
+	}
+	voters, err := NewNormalizedVoters(list)
+	require.NoError(t, err)
+
+	const rounds = 10000
+	elected := make(map[common.Address]int)
+	for round := 0; round < rounds; round++ {
+		elected[voters.NextProposer().Address()]++
+
+		for _, weight := range voters.Weights() {
+			require.True(t, new(big.Int).Abs(weight).Cmp(total) <= 0, "round %d: weight %v out of bounds", round, weight)
+		}
+	}
+
+	for i, deposit := range deposits {
+		want := rounds * deposit / total.Uint64()
+		assert.InDelta(t, want, elected[voters.At(i).Address()], 1, "voter %d", i)
+	}
+}
+
+func TestNormalizedVoters_ScaleDownRestoredWeights(t *testing.T) {
+	voters, err := NewNormalizedVoters([]*Voter{
+		makeVoter("0x1000000000000000000000000000000000000000", 100, 1000000),
+		makeVoter("0x2000000000000000000000000000000000000000", 100, 3000000),
+		makeVoter("0x3000000000000000000000000000000000000000", 100, 2000000),
+	})
+	require.NoError(t, err)
+
+	proposer := voters.NextProposer()
+	assert.Equal(t, voters.At(1), proposer)
+
+	// the order of the weights before the election is kept
+	weights := voters.Weights()
+	assert.True(t, weights[0].Cmp(weights[2]) < 0)
+	assert.True(t, weights[2].Cmp(weights[1]) < 0)
+	assert.True(t, new(big.Int).Sub(weights[1], weights[0]).Cmp(big.NewInt(600)) <= 0)
+}
+
+func TestNormalizeVoters(t *testing.T) {
+	set, err := NewVoters([]*Voter{makeVoter("0x1000000000000000000000000000000000000000", 100, 0), makeVoter("0x2000000000000000000000000000000000000000", 200, 0)})
+	require.NoError(t, err)
+
+	normalized := NormalizeVoters(set)
+	assert.Equal(t, normalized, NormalizeVoters(normalized))
+
+	// the proposer gives up the total deposit and the weights are shared
+	assert.Equal(t, set.At(1), normalized.NextProposer())
+	assert.Equal(t, []*big.Int{big.NewInt(100), big.NewInt(-100)}, set.Weights())
+}
+
+func TestVoters_Weights(t *testing.T) {
+	voters, err := NewVoters([]*Voter{makeVoter("0x1000000000000000000000000000000000000000", 100, 10), makeVoter("0x2000000000000000000000000000000000000000", 50, 20)})
+	require.NoError(t, err)
+
+	weights := voters.Weights()
+	assert.Equal(t, []*big.Int{big.NewInt(10), big.NewInt(20)}, weights)
+
+	// the weights are a copy of the internal state
+	weights[0].SetInt64(0)
+	assert.Equal(t, big.NewInt(10), voters.At(0).Weight())
+}
+
+func TestNewValidatorSetWithWeights(t *testing.T) {
+	_, err := NewValidatorSetWithWeights(nil, nil)
+	assert.Equal(t, ErrInvalidParams, err)
+
+	list := []*Voter{voterSet[0], voterSet[1]}
+	weight := new(big.Int).Set(voterSet[1].Weight())
+	_, err = NewValidatorSetWithWeights(list, []*big.Int{big.NewInt(1)})
+	assert.Error(t, err)
+
+	voters, err := NewValidatorSetWithWeights(list, []*big.Int{big.NewInt(7), big.NewInt(9)})
+	require.NoError(t, err)
+	assert.Equal(t, []*big.Int{big.NewInt(7), big.NewInt(9)}, voters.Weights())
+	assert.Equal(t, voterSet[1].Address(), voters.At(1).Address())
+	assert.Equal(t, voterSet[1].Deposit(), voters.At(1).Deposit())
+
+	// the restored election goes on from the given weights, leaving the
+	// original voters untouched
+	assert.Equal(t, voterSet[1].Address(), voters.NextProposer().Address())
+	assert.Equal(t, weight, voterSet[1].Weight())
+}
+
 func TestVoters_IsHashable(t *testing.T) {
 	voters1, err := NewVoters([]*Voter{voterSet[0], voterSet[1], voterSet[2]})
 	require.NoError(t, err)
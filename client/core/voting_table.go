@@ -9,30 +9,39 @@ import (
 	"github.com/kowala-tech/kcoin/client/log"
 )
 
+// ErrConflictingVote is returned when a voter has already voted for a different
+// block in the same voting table.
+var ErrConflictingVote = errors.New("conflicting vote")
+
 type VotingTable interface {
 	Add(vote types.AddressVote) error
 	Leader() common.Hash
+	Votes() []*types.Vote
 }
 
 type votingTable struct {
-	voteType types.VoteType
-	voters   types.Voters
-	votes    *types.VotesSet
-	quorum   QuorumFunc
-	majority QuorumReachedFunc
+	voteType   types.VoteType
+	voters     types.Voters
+	votes      *types.VotesSet
+	byVoter    map[common.Address]*types.Vote
+	quorum     QuorumFunc
+	majority   QuorumReachedFunc
+	doubleSign DoubleSignFunc
 }
 
-func NewVotingTable(voteType types.VoteType, voters types.Voters, majority QuorumReachedFunc) (*votingTable, error) {
+func NewVotingTable(voteType types.VoteType, voters types.Voters, majority QuorumReachedFunc, doubleSign DoubleSignFunc) (*votingTable, error) {
 	if voters == nil {
 		return nil, errors.New("cant create a voting table with nil voters")
 	}
 
 	return &votingTable{
-		voteType: voteType,
-		voters:   voters,
-		votes:    types.NewVotesSet(),
-		quorum:   TwoThirdsPlusOneVoteQuorum,
-		majority: majority,
+		voteType:   voteType,
+		voters:     voters,
+		votes:      types.NewVotesSet(),
+		byVoter:    make(map[common.Address]*types.Vote),
+		quorum:     TwoThirdsPlusOneVoteQuorum,
+		majority:   majority,
+		doubleSign: doubleSign,
 	}, nil
 }
 
@@ -44,8 +53,13 @@ func (table *votingTable) Add(voteAddressed types.AddressVote) error {
 		return err
 	}
 
+	if err := table.isConflicting(voteAddressed); err != nil {
+		return err
+	}
+
 	vote := voteAddressed.Vote()
 	table.votes.Add(vote)
+	table.byVoter[voteAddressed.Address()] = vote
 
 	if table.hasQuorum() {
 		log.Debug("voting. Quorum has been achieved. majority", "votes", table.votes.Len(), "voters", table.voters.Len())
@@ -59,6 +73,11 @@ func (table *votingTable) Leader() common.Hash {
 	return table.votes.Leader()
 }
 
+// Votes returns the votes received so far.
+func (table *votingTable) Votes() []*types.Vote {
+	return table.votes.List()
+}
+
 func (table *votingTable) isDuplicate(voteAddressed types.AddressVote) error {
 	vote := voteAddressed.Vote()
 	err := table.votes.Contains(vote.Hash())
@@ -69,6 +88,31 @@ func (table *votingTable) isDuplicate(voteAddressed types.AddressVote) error {
 	return err
 }
 
+// isConflicting reports the voter as an equivocator if it already voted for a
+// different block in this table.
+func (table *votingTable) isConflicting(voteAddressed types.AddressVote) error {
+	prev, ok := table.byVoter[voteAddressed.Address()]
+	if !ok {
+		return nil
+	}
+	evidence, err := types.NewDuplicateVoteEvidence(voteAddressed.Address(), prev, voteAddressed.Vote())
+	if err != nil {
+		return nil
+	}
+
+	vA, rA, sA := evidence.VoteA.RawSignatureValues()
+	vB, rB, sB := evidence.VoteB.RawSignatureValues()
+	log.Error("Validator signed conflicting votes", "validator", evidence.Address, "type", table.voteType,
+		"number", evidence.VoteA.BlockNumber(), "round", evidence.VoteA.Round(),
+		"blockA", evidence.VoteA.BlockHash(), "sigA", fmt.Sprintf("%x/%x/%x", rA, sA, vA),
+		"blockB", evidence.VoteB.BlockHash(), "sigB", fmt.Sprintf("%x/%x/%x", rB, sB, vB))
+
+	if table.doubleSign != nil {
+		table.doubleSign(evidence)
+	}
+	return ErrConflictingVote
+}
+
 func (table *votingTable) isVoter(address common.Address) bool {
 	return table.voters.Contains(address)
 }
@@ -80,8 +124,14 @@ func (table *votingTable) hasQuorum() bool {
 
 type QuorumReachedFunc func(winner common.Hash)
 
+type DoubleSignFunc func(evidence *types.DuplicateVoteEvidence)
+
 type QuorumFunc func(votes, voters int) bool
 
+// TwoThirdsPlusOneVoteQuorum reports whether the votes are more than two thirds
+// of the voters. A set of a single voter is the degenerate case: it has no fault
+// tolerance and reaches the quorum with its own vote, so a single validator
+// chain commits without waiting on the step timeouts.
 func TwoThirdsPlusOneVoteQuorum(votes, voters int) bool {
 	return votes >= voters*2/3+1
 }
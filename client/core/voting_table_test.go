@@ -39,7 +39,7 @@ func TestTwoThirdsPlusOneVoteQuorum(t *testing.T) {
 }
 
 func TestNewVotingTable_ReturnsErrorsOnNilVoters(t *testing.T) {
-	votingTable, err := NewVotingTable(types.PreVote, nil, nil)
+	votingTable, err := NewVotingTable(types.PreVote, nil, nil, nil)
 
 	assert.Error(t, err, "cant create a voting table with nil voters")
 	assert.Nil(t, votingTable)
@@ -59,6 +59,7 @@ func TestVotingTable_Add_CheckIsVoterAndVoteNotSeen_CallsQuorum(t *testing.T) {
 		func(winner common.Hash) {
 			quorum = true
 		},
+		nil,
 	)
 	assert.NoError(t, err)
 
@@ -85,6 +86,7 @@ func TestVotingTable_Add_DoubleVoteFromAddressReturnsError(t *testing.T) {
 		types.PreVote,
 		voters,
 		func(winner common.Hash) {},
+		nil,
 	)
 	assert.NoError(t, err)
 
@@ -116,6 +118,7 @@ func TestVotingTable_Add_VoteFromNonVoterReturnsError(t *testing.T) {
 		func(winner common.Hash) {
 			assert.Fail(t, "unexpected Quorum reached call")
 		},
+		nil,
 	)
 	assert.NoError(t, err)
 
@@ -129,3 +132,44 @@ func TestVotingTable_Add_VoteFromNonVoterReturnsError(t *testing.T) {
 	assert.Equal(t, voters, votingTable.voters)
 	assert.Equal(t, 0, votingTable.votes.Len())
 }
+
+func TestVotingTable_Add_ConflictingVoteReportsDoubleSign(t *testing.T) {
+	voterAddress := common.HexToAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed")
+
+	voter := types.NewVoter(voterAddress, common.Big0, big.NewInt(1))
+	voters, err := types.NewVoters([]*types.Voter{voter})
+	require.NoError(t, err)
+
+	var evidence *types.DuplicateVoteEvidence
+	votingTable, err := NewVotingTable(
+		types.PreVote,
+		voters,
+		func(winner common.Hash) {},
+		func(ev *types.DuplicateVoteEvidence) {
+			evidence = ev
+		},
+	)
+	assert.NoError(t, err)
+
+	voteA := types.NewVote(big.NewInt(1), common.HexToHash("123"), 0, types.PreVote)
+	signedVoteA := &mocks.AddressVote{}
+	signedVoteA.On("Address").Return(voterAddress)
+	signedVoteA.On("Vote").Return(voteA)
+
+	voteB := types.NewVote(big.NewInt(1), common.HexToHash("456"), 0, types.PreVote)
+	signedVoteB := &mocks.AddressVote{}
+	signedVoteB.On("Address").Return(voterAddress)
+	signedVoteB.On("Vote").Return(voteB)
+
+	err = votingTable.Add(signedVoteA)
+	assert.NoError(t, err)
+
+	err = votingTable.Add(signedVoteB)
+
+	assert.Equal(t, ErrConflictingVote, err)
+	assert.Equal(t, 1, votingTable.votes.Len())
+	require.NotNil(t, evidence)
+	assert.Equal(t, voterAddress, evidence.Address)
+	assert.Equal(t, voteA, evidence.VoteA)
+	assert.Equal(t, voteB, evidence.VoteB)
+}
@@ -25,6 +25,11 @@ var (
 		Usage: "Logging verbosity: 0=silent, 1=error, 2=warn, 3=info, 4=debug, 5=detail",
 		Value: 2,
 	}
+	logFormatFlag = cli.StringFlag{
+		Name:  "log.format",
+		Usage: "Log output format: text or json (one JSON object per record)",
+		Value: "text",
+	}
 	vmoduleFlag = cli.StringFlag{
 		Name:  "vmodule",
 		Usage: "Per-module verbosity: comma-separated list of <pattern>=<level> (e.g. eth/*=5,p2p=4)",
@@ -74,7 +79,7 @@ var (
 
 // Flags holds all command-line flags required for debugging.
 var Flags = []cli.Flag{
-	verbosityFlag, vmoduleFlag, backtraceAtFlag, debugFlag,
+	verbosityFlag, logFormatFlag, vmoduleFlag, backtraceAtFlag, debugFlag,
 	pprofFlag, pprofAddrFlag, pprofPortFlag,
 	memprofilerateFlag, blockprofilerateFlag, cpuprofileFlag, traceFlag,
 }
@@ -82,18 +87,42 @@ var Flags = []cli.Flag{
 var glogger *log.GlogHandler
 
 func init() {
+	glogger = log.NewGlogHandler(newLogHandler(log.TerminalFormat))
+}
+
+// newLogHandler creates a stderr log handler with the given format, enabling
+// colors if the output is a capable terminal.
+func newLogHandler(format func(usecolor bool) log.Format) log.Handler {
 	usecolor := term.IsTty(os.Stderr.Fd()) && os.Getenv("TERM") != "dumb"
 	output := io.Writer(os.Stderr)
 	if usecolor {
 		output = colorable.NewColorableStderr()
 	}
-	glogger = log.NewGlogHandler(log.StreamHandler(output, log.TerminalFormat(usecolor)))
+	return log.StreamHandler(output, format(usecolor))
+}
+
+// logFormat returns the log record format with the given name.
+func logFormat(name string) (func(usecolor bool) log.Format, error) {
+	switch name {
+	case "text":
+		return log.TerminalFormat, nil
+	case "json":
+		return func(bool) log.Format { return log.JSONFormat() }, nil
+	}
+	return nil, fmt.Errorf("unknown log format %q, want text or json", name)
 }
 
 // Setup initializes profiling and logging based on the CLI flags.
 // It should be called as early as possible in the program.
 func Setup(ctx *cli.Context) error {
 	// logging
+	if ctx.GlobalIsSet(logFormatFlag.Name) {
+		format, err := logFormat(ctx.GlobalString(logFormatFlag.Name))
+		if err != nil {
+			return err
+		}
+		glogger.SetHandler(newLogHandler(format))
+	}
 	log.PrintOrigins(ctx.GlobalBool(debugFlag.Name))
 	glogger.Verbosity(log.Lvl(ctx.GlobalInt(verbosityFlag.Name)))
 	glogger.Vmodule(ctx.GlobalString(vmoduleFlag.Name))
@@ -0,0 +1,41 @@
+package debug
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kowala-tech/kcoin/client/log"
+)
+
+func TestLogFormatJSON(t *testing.T) {
+	format, err := logFormat("json")
+	if err != nil {
+		t.Fatalf("json format rejected: %v", err)
+	}
+	logger := log.New()
+	var out []byte
+	logger.SetHandler(log.FuncHandler(func(r *log.Record) error {
+		out = format(false).Format(r)
+		return nil
+	}))
+	logger.Info("imported block", "number", 42)
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(out, &record); err != nil {
+		t.Fatalf("record %q is not JSON: %v", out, err)
+	}
+	for _, key := range []string{"t", "lvl", "msg", "number"} {
+		if _, ok := record[key]; !ok {
+			t.Errorf("record %q misses key %q", out, key)
+		}
+	}
+	if record["msg"] != "imported block" {
+		t.Errorf("message mismatch: have %v, want %v", record["msg"], "imported block")
+	}
+}
+
+func TestLogFormatUnknown(t *testing.T) {
+	if _, err := logFormat("xml"); err == nil {
+		t.Fatal("unknown format accepted")
+	}
+}
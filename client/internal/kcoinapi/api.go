@@ -161,6 +161,23 @@ func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
 	return content
 }
 
+// PrivateTxPoolAPI offers the transaction pool operations that modify its
+// content. It is not exposed publicly.
+type PrivateTxPoolAPI struct {
+	b Backend
+}
+
+// NewPrivateTxPoolAPI creates a new tx pool service for managing the transaction pool.
+func NewPrivateTxPoolAPI(b Backend) *PrivateTxPoolAPI {
+	return &PrivateTxPoolAPI{b}
+}
+
+// Clear removes all the pending and queued transactions sent by the given
+// account, returning the number of transactions removed.
+func (s *PrivateTxPoolAPI) Clear(addr common.Address) hexutil.Uint {
+	return hexutil.Uint(s.b.TxPoolRemoveAccount(addr))
+}
+
 // PublicAccountAPI provides an API to access accounts managed by this node.
 // It offers only methods that can retrieve accounts.
 type PublicAccountAPI struct {
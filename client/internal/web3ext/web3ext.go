@@ -5,11 +5,14 @@ var Modules = map[string]string{
 	"admin":      Admin_JS,
 	"chequebook": Chequebook_JS,
 	"clique":     Clique_JS,
+	"consensus":  Consensus_JS,
+	"currency":   Currency_JS,
 	"debug":      Debug_JS,
 	"eth":        Eth_JS,
 	"mtoken":     MToken_JS,
 	"validator":  Validator_JS,
 	"net":        Net_JS,
+	"oracle":     Oracle_JS,
 	"personal":   Personal_JS,
 	"rpc":        RPC_JS,
 	"shh":        Shh_JS,
@@ -123,6 +126,16 @@ web3._extend({
 			call: 'admin_importChain',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'exportTxPool',
+			call: 'admin_exportTxPool',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'importTxPool',
+			call: 'admin_importTxPool',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'sleepBlocks',
 			call: 'admin_sleepBlocks',
@@ -415,6 +428,10 @@ web3._extend({
 			call: 'eth_getRawTransactionByHash',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'chainConfig',
+			call: 'eth_chainConfig'
+		}),
 		new web3._extend.Method({
 			name: 'getRawTransactionFromBlock',
 			call: function(args) {
@@ -496,6 +513,57 @@ web3._extend({
 });
 `
 
+const Consensus_JS = `
+web3._extend({
+	property: 'consensus',
+	methods: [],
+	properties: [
+		new web3._extend.Property({
+			name: 'roundState',
+			getter: 'consensus_roundState'
+		}),
+	]
+});
+`
+
+const Currency_JS = `
+web3._extend({
+	property: 'currency',
+	methods:
+	[
+		new web3._extend.Method({
+			name: 'toCurrency',
+			call: 'currency_toCurrency',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal],
+			outputFormatter: web3._extend.formatters.outputBigNumberFormatter
+		}),
+		new web3._extend.Method({
+			name: 'fromCurrency',
+			call: 'currency_fromCurrency',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal],
+			outputFormatter: web3._extend.formatters.outputBigNumberFormatter
+		}),
+	]
+});
+`
+
+const Oracle_JS = `
+web3._extend({
+	property: 'oracle',
+	methods:
+	[
+		new web3._extend.Method({
+			name: 'submitPrice',
+			call: 'oracle_submitPrice',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal]
+		}),
+	]
+});
+`
+
 const Validator_JS = `
 web3._extend({
 	property: 'validator',
@@ -552,6 +620,40 @@ web3._extend({
 			name: 'redeemDeposits',
 			call: 'validator_redeemDeposits'
 		}),
+		new web3._extend.Method({
+			name: 'exit',
+			call: 'validator_exit'
+		}),
+		new web3._extend.Method({
+			name: 'getWeights',
+			call: 'validator_getWeights'
+		}),
+		new web3._extend.Method({
+			name: 'simulate',
+			call: 'validator_simulate',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getValidators',
+			call: 'validator_getValidators'
+		}),
+		new web3._extend.Method({
+			name: 'getProposer',
+			call: 'validator_getProposer'
+		}),
+		new web3._extend.Method({
+			name: 'getUptime',
+			call: 'validator_getUptime'
+		}),
+		new web3._extend.Method({
+			name: 'getEvidence',
+			call: 'validator_getEvidence'
+		}),
+		new web3._extend.Method({
+			name: 'submitEvidence',
+			call: 'validator_submitEvidence',
+			params: 2
+		}),
 	],
 	properties: []
 });
@@ -680,7 +782,15 @@ web3._extend({
 const TxPool_JS = `
 web3._extend({
 	property: 'txpool',
-	methods: [],
+	methods: [
+		new web3._extend.Method({
+			name: 'clear',
+			call: 'txpool_clear',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter],
+			outputFormatter: web3._extend.utils.toDecimal
+		}),
+	],
 	properties:
 	[
 		new web3._extend.Property({
@@ -172,3 +172,37 @@ func testParallelPutGet(db kcoindb.Database, t *testing.T) {
 	}
 	pending.Wait()
 }
+
+func TestLDB_ReadOnly(t *testing.T) {
+	dirname, err := ioutil.TempDir(os.TempDir(), "kcoindb_test_")
+	if err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	defer os.RemoveAll(dirname)
+
+	db, err := kcoindb.NewLDBDatabase(dirname, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+	if err := db.Put([]byte("key"), []byte("value")); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+	db.Close()
+
+	db, err = kcoindb.NewReadOnlyLDBDatabase(dirname, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to open test database read-only: %v", err)
+	}
+	defer db.Close()
+
+	data, err := db.Get([]byte("key"))
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	if !bytes.Equal(data, []byte("value")) {
+		t.Fatalf("get returned wrong result, got %q expected %q", string(data), "value")
+	}
+	if err := db.Put([]byte("key"), []byte("other")); err == nil {
+		t.Fatal("put succeeded on a read-only database")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"io"
 	"math/big"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -17,6 +18,7 @@ import (
 	"github.com/kowala-tech/kcoin/client/common"
 	"github.com/kowala-tech/kcoin/client/common/hexutil"
 	"github.com/kowala-tech/kcoin/client/contracts/bindings/consensus"
+	"github.com/kowala-tech/kcoin/client/contracts/bindings/oracle"
 	"github.com/kowala-tech/kcoin/client/core"
 	"github.com/kowala-tech/kcoin/client/core/rawdb"
 	"github.com/kowala-tech/kcoin/client/core/state"
@@ -46,6 +48,149 @@ func (api *PublicKowalaAPI) Coinbase() (common.Address, error) {
 	return api.kcoin.Coinbase()
 }
 
+// ChainConfig returns the configuration of the chain the node is running.
+func (api *PublicKowalaAPI) ChainConfig() *params.ChainConfig {
+	return api.kcoin.ChainConfig()
+}
+
+// errNoValidators is returned if the validator set couldn't be loaded.
+var errNoValidators = errors.New("validator set not available")
+
+// PublicValidatorAPI provides an API to read the validator set of the chain and
+// the state of the local consensus validator.
+type PublicValidatorAPI struct {
+	kcoin *Kowala
+}
+
+// NewPublicValidatorAPI creates a new RPC service to read the validator set.
+func NewPublicValidatorAPI(kcoin *Kowala) *PublicValidatorAPI {
+	return &PublicValidatorAPI{kcoin: kcoin}
+}
+
+// GetValidators returns the active validators along with their deposits. The
+// proposer weights are only known, and returned, while the local validator is
+// running.
+func (api *PublicValidatorAPI) GetValidators() ([]weightEntry, error) {
+	if voters, _, err := api.kcoin.Validator().Weights(); err == nil {
+		validators := make([]weightEntry, len(voters))
+		for i, voter := range voters {
+			validators[i] = weightEntry{
+				Address: voter.Address(),
+				Deposit: voter.Deposit(),
+				Weight:  voter.Weight(),
+			}
+		}
+		return validators, nil
+	}
+
+	// Not validating, fall back to the validator set at the head of the chain
+	if api.kcoin.voters == nil {
+		return nil, errNoValidators
+	}
+	voters := api.kcoin.voters.Voters()
+	if voters == nil {
+		return nil, errNoValidators
+	}
+	validators := make([]weightEntry, voters.Len())
+	for i := range validators {
+		voter := voters.At(i)
+		validators[i] = weightEntry{Address: voter.Address(), Deposit: voter.Deposit()}
+	}
+	return validators, nil
+}
+
+// GetProposer returns the proposer of the current consensus round while the
+// local validator takes part in it, or otherwise the one of the latest block.
+func (api *PublicValidatorAPI) GetProposer() (common.Address, error) {
+	if state, err := api.kcoin.Validator().RoundState(); err == nil {
+		return state.Proposer, nil
+	}
+	return api.kcoin.BlockChain().CurrentBlock().Coinbase(), nil
+}
+
+// GetUptime returns the share of the recent blocks each validator pre-committed
+// out of the ones it was expected to, as recorded by the commits on chain.
+func (api *PublicValidatorAPI) GetUptime() []validatorUptime {
+	return api.kcoin.uptime.uptime()
+}
+
+// evidenceEntry describes a double signing offence. The votes are RLP encoded,
+// the same way SubmitEvidence expects them.
+type evidenceEntry struct {
+	Hash      common.Hash    `json:"hash"`
+	Validator common.Address `json:"validator"`
+	Number    *big.Int       `json:"number"`
+	Round     uint64         `json:"round"`
+	Type      string         `json:"type"`
+	BlockA    common.Hash    `json:"blockA"`
+	BlockB    common.Hash    `json:"blockB"`
+	VoteA     hexutil.Bytes  `json:"voteA"`
+	VoteB     hexutil.Bytes  `json:"voteB"`
+}
+
+func newEvidenceEntry(evidence *types.DuplicateVoteEvidence) (evidenceEntry, error) {
+	voteA, err := rlp.EncodeToBytes(evidence.VoteA)
+	if err != nil {
+		return evidenceEntry{}, err
+	}
+	voteB, err := rlp.EncodeToBytes(evidence.VoteB)
+	if err != nil {
+		return evidenceEntry{}, err
+	}
+	return evidenceEntry{
+		Hash:      evidence.Hash(),
+		Validator: evidence.Address,
+		Number:    evidence.VoteA.BlockNumber(),
+		Round:     evidence.VoteA.Round(),
+		Type:      evidence.VoteA.Type().String(),
+		BlockA:    evidence.VoteA.BlockHash(),
+		BlockB:    evidence.VoteB.BlockHash(),
+		VoteA:     voteA,
+		VoteB:     voteB,
+	}, nil
+}
+
+// GetEvidence returns the double signing offences detected locally or
+// submitted by other nodes, oldest first.
+func (api *PublicValidatorAPI) GetEvidence() ([]evidenceEntry, error) {
+	list := api.kcoin.evidence.list()
+
+	entries := make([]evidenceEntry, len(list))
+	for i, evidence := range list {
+		entry, err := newEvidenceEntry(evidence)
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = entry
+	}
+	return entries, nil
+}
+
+// SubmitEvidence records the evidence of a validator signing the two given
+// RLP encoded votes, which must conflict. The validator is derived from the
+// vote signatures and must belong to the current validator set. It returns the hash identifying the evidence.
+func (api *PublicValidatorAPI) SubmitEvidence(voteA, voteB hexutil.Bytes) (common.Hash, error) {
+	var a, b types.Vote
+	if err := rlp.DecodeBytes(voteA, &a); err != nil {
+		return common.Hash{}, fmt.Errorf("invalid first vote: %v", err)
+	}
+	if err := rlp.DecodeBytes(voteB, &b); err != nil {
+		return common.Hash{}, fmt.Errorf("invalid second vote: %v", err)
+	}
+	address, err := types.VoteSender(api.kcoin.evidence.signer, &a)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	evidence, err := types.NewDuplicateVoteEvidence(address, &a, &b)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if _, err := api.kcoin.evidence.add(evidence, false); err != nil {
+		return common.Hash{}, err
+	}
+	return evidence.Hash(), nil
+}
+
 // PrivateValidatorAPI provides private RPC methods to control the validator.
 // These methods can be abused by external users and must be considered insecure for use by untrusted users.
 type PrivateValidatorAPI struct {
@@ -171,6 +316,107 @@ func (api *PrivateValidatorAPI) RedeemDeposits() error {
 	return api.kcoin.Validator().RedeemDeposits()
 }
 
+// Exit deregisters the validator from the election, waits for the unbonding
+// period of its deposits to be acknowledged and stops validating
+func (api *PrivateValidatorAPI) Exit() (GetDepositsResult, error) {
+	deposits, err := api.kcoin.Validator().Exit()
+	if err != nil {
+		return GetDepositsResult{}, err
+	}
+
+	return depositsToResponse(deposits), nil
+}
+
+// GetWeightsResult is the result of a validator_getWeights API call.
+type GetWeightsResult struct {
+	Rounds uint64        `json:"rounds"`
+	Voters []weightEntry `json:"voters"`
+}
+
+type weightEntry struct {
+	Address common.Address `json:"address"`
+	Deposit *big.Int       `json:"deposit"`
+	Weight  *big.Int       `json:"weight,omitempty"`
+}
+
+// GetWeights returns the proposer weights of the current voters along with the
+// number of rounds since the voters set was formed
+func (api *PrivateValidatorAPI) GetWeights() (GetWeightsResult, error) {
+	voters, rounds, err := api.kcoin.Validator().Weights()
+	if err != nil {
+		return GetWeightsResult{}, err
+	}
+
+	weights := make([]weightEntry, len(voters))
+	for i, voter := range voters {
+		weights[i] = weightEntry{
+			Address: voter.Address(),
+			Deposit: voter.Deposit(),
+			Weight:  voter.Weight(),
+		}
+	}
+
+	return GetWeightsResult{Rounds: rounds, Voters: weights}, nil
+}
+
+// SimulateResult is the result of a validator_simulate API call.
+type SimulateResult struct {
+	Rounds    uint64            `json:"rounds"`
+	Proposers []simulationEntry `json:"proposers"`
+}
+
+type simulationEntry struct {
+	Address       common.Address `json:"address"`
+	Deposit       *big.Int       `json:"deposit"`
+	Proposals     uint64         `json:"proposals"`
+	Share         float64        `json:"share"`
+	ExpectedShare float64        `json:"expectedShare"`
+}
+
+// maxSimulateRounds bounds the work a single simulation can put on the node.
+const maxSimulateRounds = 1000000
+
+// Simulate runs the proposer election over the current voters for the given
+// number of rounds and reports how many times each validator would propose,
+// along with the share it would get proportionally to its deposit
+func (api *PrivateValidatorAPI) Simulate(rounds uint64) (SimulateResult, error) {
+	if rounds == 0 {
+		return SimulateResult{}, errors.New("a number of rounds should be specified")
+	}
+	if rounds > maxSimulateRounds {
+		return SimulateResult{}, fmt.Errorf("too many rounds: have %d, max %d", rounds, maxSimulateRounds)
+	}
+	voters, _, err := api.kcoin.Validator().Weights()
+	if err != nil {
+		return SimulateResult{}, err
+	}
+	proposals, err := validator.SimulateProposers(voters, rounds)
+	if err != nil {
+		return SimulateResult{}, err
+	}
+
+	total := new(big.Int)
+	for _, voter := range voters {
+		total.Add(total, voter.Deposit())
+	}
+	entries := make([]simulationEntry, len(voters))
+	for i, voter := range voters {
+		var expected float64
+		if total.Sign() > 0 {
+			expected, _ = new(big.Rat).SetFrac(voter.Deposit(), total).Float64()
+		}
+		entries[i] = simulationEntry{
+			Address:       voter.Address(),
+			Deposit:       voter.Deposit(),
+			Proposals:     proposals[i],
+			Share:         float64(proposals[i]) / float64(rounds),
+			ExpectedShare: expected,
+		}
+	}
+
+	return SimulateResult{Rounds: rounds, Proposers: entries}, nil
+}
+
 // TransferArgs represents the arguments to transfer tokens.
 type TransferArgs struct {
 	From           common.Address  `json:"from"`
@@ -336,6 +582,195 @@ func (api *PublicTokenAPI) getWallet(addr common.Address) (*accounts.Account, ac
 	return nil, nil, errors.New("account not found in any wallet")
 }
 
+// PublicConsensusAPI provides an API to inspect the consensus election the
+// local validator takes part in.
+type PublicConsensusAPI struct {
+	kcoin *Kowala
+}
+
+// NewPublicConsensusAPI creates a new RPC service to inspect the consensus state.
+func NewPublicConsensusAPI(kcoin *Kowala) *PublicConsensusAPI {
+	return &PublicConsensusAPI{kcoin: kcoin}
+}
+
+// RoundStateResult is the result of a consensus_roundState API call.
+type RoundStateResult struct {
+	Height   *big.Int       `json:"height"`
+	Round    uint64         `json:"round"`
+	Step     string         `json:"step"`
+	Proposer common.Address `json:"proposer"`
+	Votes    []voteEntry    `json:"votes"`
+}
+
+type voteEntry struct {
+	Type      string         `json:"type"`
+	Round     uint64         `json:"round"`
+	BlockHash common.Hash    `json:"blockHash"`
+	Voter     common.Address `json:"voter"`
+}
+
+// RoundState returns the height, round and step the local validator is in, the
+// expected proposer of the round and the votes received during the election.
+func (api *PublicConsensusAPI) RoundState() (RoundStateResult, error) {
+	state, err := api.kcoin.Validator().RoundState()
+	if err != nil {
+		return RoundStateResult{}, err
+	}
+
+	sort.Slice(state.Votes, func(i, j int) bool {
+		vi, vj := state.Votes[i].Vote(), state.Votes[j].Vote()
+		if vi.Round() != vj.Round() {
+			return vi.Round() < vj.Round()
+		}
+		if vi.Type() != vj.Type() {
+			return vi.Type() < vj.Type()
+		}
+		return bytes.Compare(state.Votes[i].Address().Bytes(), state.Votes[j].Address().Bytes()) < 0
+	})
+	votes := make([]voteEntry, len(state.Votes))
+	for i, addressVote := range state.Votes {
+		vote := addressVote.Vote()
+		votes[i] = voteEntry{
+			Type:      vote.Type().String(),
+			Round:     vote.Round(),
+			BlockHash: vote.BlockHash(),
+			Voter:     addressVote.Address(),
+		}
+	}
+
+	return RoundStateResult{
+		Height:   state.BlockNumber,
+		Round:    state.Round,
+		Step:     state.Step,
+		Proposer: state.Proposer,
+		Votes:    votes,
+	}, nil
+}
+
+// PrivateOracleAPI provides an API for the oracles of the price feed to report
+// prices with the coinbase account.
+type PrivateOracleAPI struct {
+	kcoin *Kowala
+}
+
+// NewPrivateOracleAPI creates a new RPC service to report prices to the oracle manager.
+func NewPrivateOracleAPI(kcoin *Kowala) *PrivateOracleAPI {
+	return &PrivateOracleAPI{kcoin: kcoin}
+}
+
+// SubmitPrice submits a price to the oracle manager on behalf of the coinbase,
+// which must be unlocked and registered as an oracle. It returns the hash of
+// the submitted transaction.
+func (api *PrivateOracleAPI) SubmitPrice(price *hexutil.Big) (common.Hash, error) {
+	if price == nil || price.ToInt().Sign() <= 0 {
+		return common.Hash{}, errors.New("price must be positive")
+	}
+
+	var mgr *oracle.Manager
+	if err := api.kcoin.Contract(&mgr); err != nil {
+		return common.Hash{}, err
+	}
+
+	coinbase, err := api.kcoin.Coinbase()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	isOracle, err := mgr.IsOracle(coinbase)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if !isOracle {
+		return common.Hash{}, fmt.Errorf("coinbase %s is not an authorized oracle", coinbase.Hex())
+	}
+
+	account := accounts.Account{Address: coinbase}
+	wallet, err := api.kcoin.AccountManager().Find(account)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	chainID := api.kcoin.chainConfig.ChainID
+	opts := &bind.TransactOpts{
+		From: coinbase,
+		Signer: func(signer types.Signer, address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			return wallet.SignTx(account, tx, chainID)
+		},
+	}
+
+	tx, err := mgr.Contract.SubmitPrice(opts, price.ToInt())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
+
+// PublicCurrencyAPI provides an API to convert amounts between the native coin
+// and the currency of the node using the price submitted by the oracles.
+type PublicCurrencyAPI struct {
+	kcoin *Kowala
+}
+
+// NewPublicCurrencyAPI creates a new RPC service to convert currency amounts.
+func NewPublicCurrencyAPI(kcoin *Kowala) *PublicCurrencyAPI {
+	return &PublicCurrencyAPI{kcoin: kcoin}
+}
+
+// ToCurrency converts an amount of the native coin, in wei, to the currency
+// of the node, in its smallest unit.
+func (api *PublicCurrencyAPI) ToCurrency(amount *hexutil.Big) (*hexutil.Big, error) {
+	if err := checkAmount(amount); err != nil {
+		return nil, err
+	}
+	price, err := api.price()
+	if err != nil {
+		return nil, err
+	}
+	result := new(big.Int).Mul(amount.ToInt(), price)
+	return (*hexutil.Big)(result.Div(result, big.NewInt(params.Kcoin))), nil
+}
+
+// FromCurrency converts an amount of the currency of the node, in its smallest
+// unit, to the native coin, in wei.
+func (api *PublicCurrencyAPI) FromCurrency(amount *hexutil.Big) (*hexutil.Big, error) {
+	if err := checkAmount(amount); err != nil {
+		return nil, err
+	}
+	price, err := api.price()
+	if err != nil {
+		return nil, err
+	}
+	result := new(big.Int).Mul(amount.ToInt(), big.NewInt(params.Kcoin))
+	return (*hexutil.Big)(result.Div(result, price)), nil
+}
+
+// checkAmount returns an error if an amount to convert is missing or negative.
+func checkAmount(amount *hexutil.Big) error {
+	if amount == nil || amount.ToInt().Sign() < 0 {
+		return errors.New("amount must not be negative")
+	}
+	return nil
+}
+
+// price returns the price of a coin in the currency of the node, or an error
+// if the oracles did not update it within the configured maximum age.
+func (api *PublicCurrencyAPI) price() (*big.Int, error) {
+	price, updated, err := api.kcoin.prices.Latest()
+	if err != nil {
+		return nil, err
+	}
+	if price.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid %s price %v", api.kcoin.config.Currency, price)
+	}
+	if maxAge := api.kcoin.config.PriceMaxAge; maxAge > 0 {
+		if updated.IsZero() {
+			return nil, fmt.Errorf("%s price is stale, not updated since the node started", api.kcoin.config.Currency)
+		}
+		if age := time.Since(updated); age > maxAge {
+			return nil, fmt.Errorf("%s price is stale, last updated %v ago", api.kcoin.config.Currency, common.PrettyDuration(age))
+		}
+	}
+	return price, nil
+}
+
 // PrivateAdminAPI is the collection of Kowala full node-related APIs
 // exposed over the private admin endpoint.
 type PrivateAdminAPI struct {
@@ -429,6 +864,48 @@ func (api *PrivateAdminAPI) ImportChain(file string) (bool, error) {
 	return true, nil
 }
 
+// ExportTxPool exports the pending and queued transactions of the pool into a
+// local file and returns the number of exported transactions.
+func (api *PrivateAdminAPI) ExportTxPool(file string) (int, error) {
+	out, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return 0, err
+	}
+	defer out.Close()
+
+	var writer io.Writer = out
+	if strings.HasSuffix(file, ".gz") {
+		writer = gzip.NewWriter(writer)
+		defer writer.(*gzip.Writer).Close()
+	}
+	return api.kcoin.TxPool().ExportSnapshot(writer)
+}
+
+// ImportTxPoolResult is the outcome of a transaction pool snapshot import.
+type ImportTxPoolResult struct {
+	Imported int `json:"imported"`
+	Dropped  int `json:"dropped"`
+}
+
+// ImportTxPool imports the transactions of a local file into the pool. The
+// transactions are treated as remote ones, so the pool admission rules apply.
+func (api *PrivateAdminAPI) ImportTxPool(file string) (ImportTxPoolResult, error) {
+	in, err := os.Open(file)
+	if err != nil {
+		return ImportTxPoolResult{}, err
+	}
+	defer in.Close()
+
+	var reader io.Reader = in
+	if strings.HasSuffix(file, ".gz") {
+		if reader, err = gzip.NewReader(reader); err != nil {
+			return ImportTxPoolResult{}, err
+		}
+	}
+	imported, dropped, err := api.kcoin.TxPool().ImportSnapshot(reader)
+	return ImportTxPoolResult{Imported: imported, Dropped: dropped}, err
+}
+
 // PublicDebugAPI is the collection of Kowala full node APIs exposed
 // over the public debugging endpoint.
 type PublicDebugAPI struct {
@@ -135,11 +135,22 @@ func (b *KowalaAPIBackend) SubscribeChainSideEvent(ch chan<- core.ChainSideEvent
 	return b.kcoin.BlockChain().SubscribeChainSideEvent(ch)
 }
 
+func (b *KowalaAPIBackend) SubscribeChainReorgEvent(ch chan<- core.ChainReorgEvent) event.Subscription {
+	return b.kcoin.BlockChain().SubscribeChainReorgEvent(ch)
+}
+
+func (b *KowalaAPIBackend) SubscribeChainFinalizedEvent(ch chan<- core.ChainFinalizedEvent) event.Subscription {
+	return b.kcoin.BlockChain().SubscribeChainFinalizedEvent(ch)
+}
+
 func (b *KowalaAPIBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription {
 	return b.kcoin.BlockChain().SubscribeLogsEvent(ch)
 }
 
 func (b *KowalaAPIBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
+	if b.kcoin.disk.Full() {
+		return errDiskFull
+	}
 	return b.kcoin.txPool.AddLocal(signedTx)
 }
 
@@ -171,6 +182,10 @@ func (b *KowalaAPIBackend) TxPoolContent() (map[common.Address]types.Transaction
 	return b.kcoin.TxPool().Content()
 }
 
+func (b *KowalaAPIBackend) TxPoolRemoveAccount(addr common.Address) int {
+	return b.kcoin.TxPool().RemoveAccount(addr)
+}
+
 func (b *KowalaAPIBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
 	return b.kcoin.TxPool().SubscribeNewTxsEvent(ch)
 }
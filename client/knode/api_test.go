@@ -1,13 +1,37 @@
 package knode
 
 import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/davecgh/go-spew/spew"
+	kcoin "github.com/kowala-tech/kcoin/client"
+	"github.com/kowala-tech/kcoin/client/accounts"
+	"github.com/kowala-tech/kcoin/client/accounts/abi"
+	"github.com/kowala-tech/kcoin/client/accounts/abi/bind"
+	"github.com/kowala-tech/kcoin/client/accounts/keystore"
 	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/common/hexutil"
+	"github.com/kowala-tech/kcoin/client/consensus/konsensus"
+	"github.com/kowala-tech/kcoin/client/contracts/bindings"
+	"github.com/kowala-tech/kcoin/client/contracts/bindings/oracle"
+	"github.com/kowala-tech/kcoin/client/core"
 	"github.com/kowala-tech/kcoin/client/core/state"
+	"github.com/kowala-tech/kcoin/client/core/types"
+	"github.com/kowala-tech/kcoin/client/core/vm"
+	"github.com/kowala-tech/kcoin/client/crypto"
 	"github.com/kowala-tech/kcoin/client/kcoindb"
+	"github.com/kowala-tech/kcoin/client/knode/validator"
+	"github.com/kowala-tech/kcoin/client/params"
+	"github.com/kowala-tech/kcoin/client/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var dumper = spew.ConfigState{Indent: "    "}
@@ -72,3 +96,278 @@ func TestStorageRangeAt(t *testing.T) {
 		}
 	}
 }
+
+type testValidator struct {
+	validator.Validator
+	voters []*types.Voter
+	state  *validator.RoundState
+	err    error
+}
+
+func (v *testValidator) Weights() ([]*types.Voter, uint64, error) {
+	return v.voters, 0, v.err
+}
+
+func (v *testValidator) RoundState() (*validator.RoundState, error) {
+	return v.state, v.err
+}
+
+func TestPublicValidatorAPI(t *testing.T) {
+	val := &testValidator{
+		voters: []*types.Voter{
+			types.NewVoter(common.Address{1}, big.NewInt(100), big.NewInt(20)),
+			types.NewVoter(common.Address{2}, big.NewInt(50), big.NewInt(-20)),
+		},
+		state: &validator.RoundState{Proposer: common.Address{2}},
+	}
+	api := NewPublicValidatorAPI(&Kowala{validator: val})
+
+	validators, err := api.GetValidators()
+	require.NoError(t, err)
+	assert.Equal(t, []weightEntry{
+		{Address: common.Address{1}, Deposit: big.NewInt(100), Weight: big.NewInt(20)},
+		{Address: common.Address{2}, Deposit: big.NewInt(50), Weight: big.NewInt(-20)},
+	}, validators)
+
+	proposer, err := api.GetProposer()
+	require.NoError(t, err)
+	assert.Equal(t, common.Address{2}, proposer)
+
+	val.err = validator.ErrNotValidating
+	_, err = api.GetValidators()
+	assert.Equal(t, errNoValidators, err)
+}
+
+func TestPrivateValidatorAPISimulate(t *testing.T) {
+	val := &testValidator{
+		voters: []*types.Voter{
+			types.NewVoter(common.Address{1}, big.NewInt(100), big.NewInt(0)),
+			types.NewVoter(common.Address{2}, big.NewInt(100), big.NewInt(0)),
+		},
+	}
+	api := NewPrivateValidatorAPI(&Kowala{validator: val})
+
+	result, err := api.Simulate(10)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10), result.Rounds)
+	require.Len(t, result.Proposers, 2)
+	assert.Equal(t, uint64(10), result.Proposers[0].Proposals+result.Proposers[1].Proposals)
+	assert.Equal(t, 0.5, result.Proposers[0].ExpectedShare)
+
+	_, err = api.Simulate(0)
+	assert.Error(t, err)
+
+	result, err = api.Simulate(maxSimulateRounds + 1)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "too many rounds")
+	assert.Empty(t, result.Proposers)
+}
+
+func TestPublicValidatorAPINotValidating(t *testing.T) {
+	db := kcoindb.NewMemDatabase()
+	genesis := (&core.Genesis{Config: params.TestChainConfig}).MustCommit(db)
+	chain, err := core.NewBlockChain(db, nil, params.TestChainConfig, konsensus.NewFaker(), vm.Config{})
+	require.NoError(t, err)
+	defer chain.Stop()
+	blocks, _ := core.GenerateChain(params.TestChainConfig, genesis, konsensus.NewFaker(), db, 1, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(common.Address{2})
+	})
+	_, err = chain.InsertChain(blocks)
+	require.NoError(t, err)
+
+	source := &testVotersSource{voters: make(chan types.Voters, 1)}
+	source.voters <- newTestVoters(t, map[byte]int64{1: 100, 2: 50})
+	voters := newVotersTracker(new(testChainHeads), source)
+	voters.start()
+	defer voters.stop()
+
+	api := NewPublicValidatorAPI(&Kowala{
+		validator:  &testValidator{err: validator.ErrNotValidating},
+		voters:     voters,
+		blockchain: chain,
+	})
+
+	validators, err := api.GetValidators()
+	require.NoError(t, err)
+	assert.Equal(t, []weightEntry{
+		{Address: common.Address{1}, Deposit: big.NewInt(100)},
+		{Address: common.Address{2}, Deposit: big.NewInt(50)},
+	}, validators)
+
+	// the proposer of the latest block
+	proposer, err := api.GetProposer()
+	require.NoError(t, err)
+	assert.Equal(t, common.Address{2}, proposer)
+}
+
+func TestPublicKowalaAPIChainConfig(t *testing.T) {
+	config := &params.ChainConfig{
+		ChainID: big.NewInt(519374298533),
+		Konsensus: &params.KonsensusConfig{
+			BlockTime:      1000,
+			ProposeTimeout: 500,
+		},
+	}
+	server := rpc.NewServer()
+	defer server.Stop()
+	require.NoError(t, server.RegisterName("eth", NewPublicKowalaAPI(&Kowala{chainConfig: config})))
+
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	var have params.ChainConfig
+	require.NoError(t, client.Call(&have, "eth_chainConfig"))
+	assert.Equal(t, config, &have)
+}
+
+// testOracleBackend mocks the oracle manager contract: it answers isOracle calls
+// from the registered oracles and records the submitted transactions.
+type testOracleBackend struct {
+	bind.ContractBackend
+	abi     abi.ABI
+	oracles map[common.Address]bool
+	sent    []*types.Transaction
+}
+
+func (b *testOracleBackend) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return []byte{1}, nil
+}
+
+func (b *testOracleBackend) PendingCodeAt(ctx context.Context, contract common.Address) ([]byte, error) {
+	return []byte{1}, nil
+}
+
+func (b *testOracleBackend) CallContract(ctx context.Context, call kcoin.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	var identity common.Address
+	if err := b.abi.Methods["isOracle"].Inputs.Unpack(&identity, call.Data[4:]); err != nil {
+		return nil, err
+	}
+	if b.oracles[identity] {
+		return common.LeftPadBytes([]byte{1}, 32), nil
+	}
+	return make([]byte, 32), nil
+}
+
+func (b *testOracleBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return 0, nil
+}
+
+func (b *testOracleBackend) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(1), nil
+}
+
+func (b *testOracleBackend) EstimateGas(ctx context.Context, call kcoin.CallMsg) (uint64, error) {
+	return 100000, nil
+}
+
+func (b *testOracleBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	b.sent = append(b.sent, tx)
+	return nil
+}
+
+func TestPrivateOracleAPISubmitPrice(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oracle-api-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	account, err := ks.ImportECDSA(key, "")
+	require.NoError(t, err)
+	require.NoError(t, ks.Unlock(account, ""))
+
+	parsed, err := abi.JSON(strings.NewReader(oracle.OracleMgrABI))
+	require.NoError(t, err)
+	backend := &testOracleBackend{abi: parsed, oracles: make(map[common.Address]bool)}
+	contractAddr := common.HexToAddress("0x0a")
+	contract, err := oracle.NewOracleMgr(contractAddr, backend)
+	require.NoError(t, err)
+	mgr := &oracle.Manager{OracleMgrSession: &oracle.OracleMgrSession{Contract: contract}}
+
+	chainConfig := params.TestChainConfig
+	api := NewPrivateOracleAPI(&Kowala{
+		accountManager: accounts.NewManager(ks),
+		coinbase:       account.Address,
+		chainConfig:    chainConfig,
+		contracts:      map[reflect.Type]bindings.Binding{reflect.TypeOf(mgr): mgr},
+	})
+	price := big.NewInt(1500)
+
+	_, err = api.SubmitPrice((*hexutil.Big)(price))
+	assert.EqualError(t, err, "coinbase "+account.Address.Hex()+" is not an authorized oracle")
+	assert.Empty(t, backend.sent)
+
+	_, err = api.SubmitPrice((*hexutil.Big)(big.NewInt(0)))
+	assert.Error(t, err)
+
+	backend.oracles[account.Address] = true
+	hash, err := api.SubmitPrice((*hexutil.Big)(price))
+	require.NoError(t, err)
+	require.Len(t, backend.sent, 1)
+
+	tx := backend.sent[0]
+	assert.Equal(t, tx.Hash(), hash)
+	assert.Equal(t, &contractAddr, tx.To())
+	data, err := parsed.Pack("submitPrice", price)
+	require.NoError(t, err)
+	assert.Equal(t, data, tx.Data())
+	sender, err := types.TxSender(types.NewAndromedaSigner(chainConfig.ChainID), tx)
+	require.NoError(t, err)
+	assert.Equal(t, account.Address, sender)
+}
+
+func TestPublicCurrencyAPI(t *testing.T) {
+	chain := &testPriceChain{head: newTestPriceBlock(1, time.Now().Unix())}
+	source := &testPriceSource{price: big.NewInt(params.Kcoin / 2), count: 1}
+	prices := newPriceTracker(chain, source, 0)
+	prices.update(chain.head)
+
+	config := DefaultConfig
+	api := NewPublicCurrencyAPI(&Kowala{config: &config, prices: prices})
+
+	// The age of the price found at startup is unknown, so it's stale until
+	// the oracles submit a new one
+	_, err := api.ToCurrency((*hexutil.Big)(big.NewInt(params.Kcoin)))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "stale")
+
+	source.count++
+	prices.update(newTestPriceBlock(2, time.Now().Unix()))
+
+	amount, err := api.ToCurrency((*hexutil.Big)(big.NewInt(3 * params.Kcoin)))
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(3*params.Kcoin/2), amount.ToInt())
+
+	amount, err = api.FromCurrency((*hexutil.Big)(big.NewInt(3 * params.Kcoin)))
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(6*params.Kcoin), amount.ToInt())
+
+	// A price included two hours ago is stale with the default maximum age
+	source.count++
+	prices.update(newTestPriceBlock(3, time.Now().Add(-2*time.Hour).Unix()))
+	_, err = api.ToCurrency((*hexutil.Big)(big.NewInt(params.Kcoin)))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "stale")
+	_, err = api.FromCurrency((*hexutil.Big)(big.NewInt(params.Kcoin)))
+	assert.Error(t, err)
+
+	config.PriceMaxAge = 0
+	_, err = api.ToCurrency((*hexutil.Big)(big.NewInt(params.Kcoin)))
+	assert.NoError(t, err)
+
+	// Missing and negative amounts are rejected
+	_, err = api.ToCurrency(nil)
+	assert.Error(t, err)
+	_, err = api.FromCurrency(nil)
+	assert.Error(t, err)
+	_, err = api.ToCurrency((*hexutil.Big)(big.NewInt(-1)))
+	assert.Error(t, err)
+	_, err = api.FromCurrency((*hexutil.Big)(big.NewInt(-1)))
+	assert.Error(t, err)
+
+	// No price was submitted yet
+	api = NewPublicCurrencyAPI(&Kowala{config: &config, prices: newPriceTracker(chain, new(testPriceSource), 0)})
+	_, err = api.ToCurrency((*hexutil.Big)(big.NewInt(params.Kcoin)))
+	assert.Equal(t, errNoPrice, err)
+}
@@ -1,7 +1,9 @@
 package knode
 
 import (
+	"fmt"
 	"math/big"
+	"strings"
 	"time"
 
 	"github.com/kowala-tech/kcoin/client/common"
@@ -10,6 +12,8 @@ import (
 	"github.com/kowala-tech/kcoin/client/knode/currency"
 	"github.com/kowala-tech/kcoin/client/knode/downloader"
 	"github.com/kowala-tech/kcoin/client/knode/gasprice"
+	"github.com/kowala-tech/kcoin/client/knode/validator"
+	"github.com/kowala-tech/kcoin/client/p2p/discover"
 	"github.com/kowala-tech/kcoin/client/params"
 )
 
@@ -21,17 +25,22 @@ var DefaultConfig = Config{
 	DatabaseCache: 128,
 	TrieCache:     256,
 	TrieTimeout:   60 * time.Minute,
+	DiskMinFree:   256,
 	GasPrice:      big.NewInt(1),
 
+	EmptyBlocks:        validator.EmptyBlocksAlways,
+	EmptyBlocksTimeout: 30 * time.Second,
+
 	TxPool: core.DefaultTxPoolConfig,
 	GPO: gasprice.Config{
 		Blocks:     20,
 		Percentile: 60,
 	},
-	Currency: currency.KUSD,
+	Currency:    currency.KUSD,
+	PriceMaxAge: time.Hour,
 }
 
-//go:generate gencodec -type Config -field-override configMarshaling -formats toml -out gen_config.go
+//go:generate gencodec -type Config -field-override configMarshaling -formats json,toml -out gen_config.go
 
 type Config struct {
 	// The genesis block, which is inserted if the database is empty.
@@ -39,42 +48,129 @@ type Config struct {
 	Genesis *core.Genesis `toml:",omitempty"`
 
 	// Protocol options
-	NetworkId uint64 // Network ID to use for selecting peers to connect to
+	NetworkId uint64   // Network ID to use for selecting peers to connect to
+	ChainID   *big.Int `toml:",omitempty"` // Overrides the chain ID of the genesis, private networks only
 	SyncMode  downloader.SyncMode
 	NoPruning bool
 
+	// Whitelist of required block number -> hash values to accept
+	Whitelist map[uint64]common.Hash `toml:"-" json:"-"`
+
 	// Light client options
 	LightServ  int `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
 	LightPeers int `toml:",omitempty"` // Maximum number of LES client peers
 
 	// Database options
-	SkipBcVersionCheck bool `toml:"-"`
-	DatabaseHandles    int  `toml:"-"`
+	SkipBcVersionCheck bool `toml:"-" json:"-"`
+	DatabaseHandles    int  `toml:"-" json:"-"`
 	DatabaseCache      int
 	TrieCache          int
 	TrieTimeout        time.Duration
+	VerifyState        bool   `toml:",omitempty"` // Verify the integrity of the head state on startup
+	DiskMinFree        uint64 `toml:",omitempty"` // Megabytes of free disk space below which imports are paused (0 = disabled)
 
 	// consensus validation-related options
-	Coinbase  common.Address `toml:",omitempty"`
-	Deposit   *big.Int       `toml:",omitempty"`
-	ExtraData []byte         `toml:",omitempty"`
-	GasPrice  *big.Int
+	Coinbase           common.Address             `toml:",omitempty"`
+	Deposit            *big.Int                   `toml:",omitempty"`
+	ExtraValidators    []ValidatorIdentity        `toml:",omitempty"` // Validator identities beyond Coinbase and Deposit
+	ExtraData          []byte                     `toml:",omitempty"`
+	RandomExtraData    bool                       `toml:",omitempty"` // Fill the extra data of every proposed block with random bytes
+	EmptyBlocks        validator.EmptyBlockPolicy // When to propose blocks without transactions
+	EmptyBlocksTimeout time.Duration              // Period without transactions before proposing an empty block (timeout policy)
+	MaxBlockTxs        uint64                     `toml:",omitempty"` // Maximum number of transactions per proposed block (0 = unlimited)
+	PriorityAddresses  []common.Address           `toml:",omitempty"` // Accounts whose transactions are included first, regardless of gas price
+	GasPrice           *big.Int
 
 	// Transaction pool options
-	TxPool core.TxPoolConfig
+	TxPool           core.TxPoolConfig
+	TxBroadcastPeers []discover.NodeID `toml:",omitempty"` // Peers local transactions are exclusively broadcast to (empty = all peers)
 
 	// Gas Price Oracle options
 	GPO gasprice.Config
 
+	// Consensus engine block time and step timeouts
+	Konsensus params.KonsensusConfig
+
+	// Period without new blocks after which block production is reported as
+	// stalled (0 = disabled)
+	LivenessTimeout time.Duration `toml:",omitempty"`
+
+	// Relay consensus votes to peers while not validating
+	ConsensusGossip bool `toml:",omitempty"`
+
 	// Enables tracking of SHA3 preimages in the VM
 	EnablePreimageRecording bool
 
 	// Miscellaneous options
-	DocRoot string `toml:"-"`
+	DocRoot string `toml:"-" json:"-"`
 
 	Currency string
+
+	// Maximum age of the oracle price used to convert amounts to and from the
+	// currency (0 = unlimited)
+	PriceMaxAge time.Duration `toml:",omitempty"`
 }
 
 type configMarshaling struct {
 	ExtraData hexutil.Bytes
 }
+
+// ValidatorIdentity is an account the node validates with, along with the
+// deposit it stakes.
+type ValidatorIdentity struct {
+	Coinbase common.Address
+	Deposit  *big.Int `toml:",omitempty"`
+}
+
+// ValidatorIdentities returns every validator identity configured, starting
+// with the one given by Coinbase and Deposit.
+func (c *Config) ValidatorIdentities() []ValidatorIdentity {
+	identities := []ValidatorIdentity{{Coinbase: c.Coinbase, Deposit: c.Deposit}}
+	return append(identities, c.ExtraValidators...)
+}
+
+// Validate checks the invariants of the configuration fields and returns every
+// problem found, or nil if the configuration is usable.
+func (c *Config) Validate() []error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	check(c.SyncMode.IsValid(), "SyncMode: unknown sync mode %d", c.SyncMode)
+	check(c.LightServ >= 0 && c.LightServ <= 100, "LightServ: must be a percentage between 0 and 100, have %d", c.LightServ)
+	check(c.LightPeers >= 0, "LightPeers: must not be negative, have %d", c.LightPeers)
+	check(c.SyncMode != downloader.LightSync || c.LightServ == 0, "LightServ: light clients can't serve other light clients, use a full or fast SyncMode")
+	check(c.DatabaseCache > 0, "DatabaseCache: must be positive, have %d", c.DatabaseCache)
+	check(c.TrieCache >= 0, "TrieCache: must not be negative, have %d", c.TrieCache)
+	check(c.TrieTimeout > 0, "TrieTimeout: must be positive, have %v", c.TrieTimeout)
+	check(c.LivenessTimeout >= 0, "LivenessTimeout: must not be negative, have %v", c.LivenessTimeout)
+	check(c.PriceMaxAge >= 0, "PriceMaxAge: must not be negative, have %v", c.PriceMaxAge)
+	check(c.ChainID == nil || c.ChainID.Sign() > 0, "ChainID: must be positive, have %v", c.ChainID)
+	check(c.ChainID == nil || !params.IsPublicChainID(c.ChainID), "ChainID: %v belongs to a public network", c.ChainID)
+	check(c.Deposit == nil || c.Deposit.Sign() >= 0, "Deposit: must not be negative, have %v", c.Deposit)
+	// The validator only takes part in consensus with a single identity, extra
+	// ones would be silently ignored.
+	check(len(c.ExtraValidators) == 0, "ExtraValidators: a node validates with a single identity, have %d extra", len(c.ExtraValidators))
+	check(uint64(len(c.ExtraData)) <= params.MaximumExtraDataSize, "ExtraData: exceeds the %d bytes limit, have %d", params.MaximumExtraDataSize, len(c.ExtraData))
+	check(c.EmptyBlocks.IsValid(), "EmptyBlocks: unknown empty block policy %d", c.EmptyBlocks)
+	check(c.EmptyBlocks != validator.EmptyBlocksTimeout || c.EmptyBlocksTimeout > 0, "EmptyBlocksTimeout: must be positive with the timeout policy, have %v", c.EmptyBlocksTimeout)
+	check(c.GasPrice != nil && c.GasPrice.Sign() >= 0, "GasPrice: must be set and not negative, have %v", c.GasPrice)
+	check(c.GPO.Blocks > 0, "GPO.Blocks: must be positive, have %d", c.GPO.Blocks)
+	check(c.GPO.Percentile >= 0 && c.GPO.Percentile <= 100, "GPO.Percentile: must be between 0 and 100, have %d", c.GPO.Percentile)
+	check(c.GPO.Congestion >= 0, "GPO.Congestion: must not be negative, have %d", c.GPO.Congestion)
+	check(c.Currency != "", "Currency: must not be empty")
+	return errs
+}
+
+// ConfigErrors aggregates the problems reported by Config.Validate.
+type ConfigErrors []error
+
+func (errs ConfigErrors) Error() string {
+	lines := make([]string, len(errs))
+	for i, err := range errs {
+		lines[i] = "  - " + err.Error()
+	}
+	return fmt.Sprintf("invalid configuration (%d problems):\n%s", len(errs), strings.Join(lines, "\n"))
+}
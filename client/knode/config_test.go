@@ -0,0 +1,163 @@
+package knode
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/knode/downloader"
+	"github.com/kowala-tech/kcoin/client/knode/validator"
+	"github.com/naoina/toml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPopulatedConfig() Config {
+	cfg := DefaultConfig
+	cfg.NetworkId = 42
+	cfg.ChainID = big.NewInt(42)
+	cfg.SyncMode = downloader.FullSync
+	cfg.NoPruning = true
+	cfg.LightServ = 50
+	cfg.LightPeers = 10
+	cfg.DatabaseCache = 1024
+	cfg.TrieCache = 512
+	cfg.TrieTimeout = 30 * time.Minute
+	cfg.Coinbase = common.HexToAddress("0x259be75d96876f2ada3d202722523e9cd4dd917d")
+	cfg.Deposit = big.NewInt(5000)
+	cfg.ExtraData = []byte("kcoin")
+	cfg.RandomExtraData = true
+	cfg.EmptyBlocks = validator.EmptyBlocksTimeout
+	cfg.EmptyBlocksTimeout = time.Minute
+	cfg.GasPrice = big.NewInt(3)
+	cfg.TxPool.PriceLimit = 7
+	cfg.TxPool.AllowedSenders = []common.Address{common.HexToAddress("0x6d6b6f2e1cc1b6ed2a85c3b2e3e4cba9e7d3c7f4")}
+	cfg.TxPool.Lifetime = time.Hour
+	cfg.GPO.Blocks = 15
+	cfg.GPO.Default = big.NewInt(2)
+	cfg.GPO.Congestion = 35
+	cfg.EnablePreimageRecording = true
+	cfg.Currency = "kusd"
+	cfg.PriceMaxAge = 10 * time.Minute
+	return cfg
+}
+
+func TestConfigJSONRoundTrip(t *testing.T) {
+	cfg := newPopulatedConfig()
+
+	enc, err := json.Marshal(cfg)
+	require.NoError(t, err)
+
+	var dec Config
+	require.NoError(t, json.Unmarshal(enc, &dec))
+	assert.Equal(t, cfg, dec)
+}
+
+func TestConfigJSONMatchesTOML(t *testing.T) {
+	cfg := newPopulatedConfig()
+	// The default TOML settings encode byte slices as arrays, which the hex
+	// decoder of the extra data rejects, so it's left out of the comparison.
+	cfg.ExtraData = nil
+
+	jsonEnc, err := json.Marshal(cfg)
+	require.NoError(t, err)
+	var fromJSON Config
+	require.NoError(t, json.Unmarshal(jsonEnc, &fromJSON))
+
+	tomlEnc, err := toml.Marshal(cfg)
+	require.NoError(t, err)
+	var fromTOML Config
+	require.NoError(t, toml.Unmarshal(tomlEnc, &fromTOML))
+
+	fromJSON.ExtraData = nil
+	assert.Equal(t, fromTOML, fromJSON)
+}
+
+func TestConfigJSONSkipsRuntimeFields(t *testing.T) {
+	cfg := newPopulatedConfig()
+	cfg.SkipBcVersionCheck = true
+	cfg.DatabaseHandles = 256
+	cfg.DocRoot = "/tmp/docroot"
+	cfg.Whitelist = map[uint64]common.Hash{1: common.HexToHash("0x01")}
+
+	enc, err := json.Marshal(cfg)
+	require.NoError(t, err)
+
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(enc, &fields))
+	for _, name := range []string{"SkipBcVersionCheck", "DatabaseHandles", "DocRoot", "Whitelist"} {
+		assert.NotContains(t, fields, name)
+	}
+	assert.Contains(t, fields, "NetworkId")
+
+	// Runtime-only fields are not picked up from the input either
+	dec := DefaultConfig
+	require.NoError(t, json.Unmarshal([]byte(`{"DatabaseHandles": 64, "DocRoot": "/srv", "Whitelist": {"1": "0x01"}}`), &dec))
+	assert.Zero(t, dec.DatabaseHandles)
+	assert.Empty(t, dec.DocRoot)
+	assert.Empty(t, dec.Whitelist)
+}
+
+func TestConfigJSONMergesOverDefaults(t *testing.T) {
+	cfg := DefaultConfig
+	require.NoError(t, json.Unmarshal([]byte(`{"NetworkId": 7, "SyncMode": "full", "TxPool": {"PriceLimit": 9}}`), &cfg))
+
+	assert.Equal(t, uint64(7), cfg.NetworkId)
+	assert.Equal(t, downloader.FullSync, cfg.SyncMode)
+	assert.Equal(t, uint64(9), cfg.TxPool.PriceLimit)
+	assert.Equal(t, DefaultConfig.DatabaseCache, cfg.DatabaseCache)
+	assert.Equal(t, DefaultConfig.GasPrice, cfg.GasPrice)
+	assert.Equal(t, DefaultConfig.Currency, cfg.Currency)
+}
+
+func TestConfigValidate(t *testing.T) {
+	assert.Nil(t, DefaultConfig.Validate())
+
+	cfg := newPopulatedConfig()
+	assert.Nil(t, cfg.Validate())
+
+	cfg = DefaultConfig
+	cfg.LightPeers = -1
+	cfg.SyncMode = downloader.LightSync
+	cfg.LightServ = 50
+	cfg.DatabaseCache = 0
+	cfg.EmptyBlocks = validator.EmptyBlocksTimeout
+	cfg.EmptyBlocksTimeout = 0
+
+	errs := cfg.Validate()
+	require.Len(t, errs, 4)
+	for i, field := range []string{"LightPeers", "LightServ", "DatabaseCache", "EmptyBlocksTimeout"} {
+		assert.Contains(t, errs[i].Error(), field)
+	}
+	assert.Contains(t, ConfigErrors(errs).Error(), "4 problems")
+}
+
+func TestConfigValidateChainID(t *testing.T) {
+	cfg := DefaultConfig
+	for _, chainID := range []int64{0, 1, 2} {
+		cfg.ChainID = big.NewInt(chainID)
+		errs := cfg.Validate()
+		require.Len(t, errs, 1, "chain ID %d", chainID)
+		assert.Contains(t, errs[0].Error(), "ChainID")
+	}
+	cfg.ChainID = big.NewInt(1337)
+	assert.Nil(t, cfg.Validate())
+}
+
+func TestConfigValidateExtraValidators(t *testing.T) {
+	cfg := DefaultConfig
+	cfg.Coinbase = common.HexToAddress("0x259be75d96876f2ada3d202722523e9cd4dd917d")
+	cfg.Deposit = big.NewInt(100)
+	cfg.ExtraValidators = []ValidatorIdentity{
+		{Coinbase: common.HexToAddress("0x6d6b6f2e1cc1b6ed2a85c3b2e3e4cba9e7d3c7f4"), Deposit: big.NewInt(200)},
+	}
+	errs := cfg.Validate()
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "ExtraValidators: a node validates with a single identity")
+
+	cfg.ExtraValidators = nil
+	assert.Nil(t, cfg.Validate())
+	assert.Equal(t, []ValidatorIdentity{{Coinbase: cfg.Coinbase, Deposit: cfg.Deposit}}, cfg.ValidatorIdentities())
+}
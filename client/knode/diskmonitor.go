@@ -0,0 +1,120 @@
+package knode
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/knode/validator"
+	"github.com/kowala-tech/kcoin/client/log"
+)
+
+// diskCheckInterval is the period between two checks of the free disk space.
+const diskCheckInterval = 10 * time.Second
+
+var (
+	errDiskFull              = errors.New("not enough free disk space")
+	errDiskSpaceNotSupported = errors.New("free disk space not supported on this platform")
+)
+
+// diskMonitor checks the free space left on the disk holding the datadir and
+// flags the disk as full while it stays below the minimum, so that the node
+// stops importing and committing blocks and transactions instead of crashing
+// mid-write.
+type diskMonitor struct {
+	path      string
+	minFree   uint64                            // Minimum free space in bytes
+	freeSpace func(path string) (uint64, error) // Source of the free space left on the disk
+	interval  time.Duration
+	full      int32  // Flag whether the free space is below the minimum (atomic)
+	onFull    func() // Called whenever the free space drops below the minimum (optional)
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newDiskMonitor(path string, minFree uint64, freeSpace func(path string) (uint64, error), interval time.Duration) *diskMonitor {
+	return &diskMonitor{
+		path:      path,
+		minFree:   minFree,
+		freeSpace: freeSpace,
+		interval:  interval,
+		quit:      make(chan struct{}),
+	}
+}
+
+// attach pauses the block and transaction imports of the protocol manager and
+// the block commits of the local validator while the disk is full. Ongoing
+// downloads are cancelled as soon as the disk fills up.
+func (m *diskMonitor) attach(pm *ProtocolManager, val validator.Validator) {
+	pm.disk = m
+	m.onFull = pm.downloader.Cancel
+	val.SetPaused(m.Full)
+}
+
+// start checks the free disk space right away and then periodically. If the
+// free space can't be retrieved the monitor stays disabled.
+func (m *diskMonitor) start() {
+	if err := m.check(); err != nil {
+		log.Warn("Free disk space monitor disabled", "path", m.path, "err", err)
+		return
+	}
+	m.wg.Add(1)
+	go m.loop()
+}
+
+// stop terminates the monitor.
+func (m *diskMonitor) stop() {
+	close(m.quit)
+	m.wg.Wait()
+}
+
+// Full reports whether the free disk space is currently below the minimum. A
+// nil monitor never reports a full disk.
+func (m *diskMonitor) Full() bool {
+	return m != nil && atomic.LoadInt32(&m.full) == 1
+}
+
+func (m *diskMonitor) loop() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := m.check(); err != nil {
+				log.Debug("Failed to check the free disk space", "path", m.path, "err", err)
+			}
+		case <-m.quit:
+			return
+		}
+	}
+}
+
+// check retrieves the free disk space and updates the full flag accordingly.
+// While the disk is full the error is repeated at every check.
+func (m *diskMonitor) check() error {
+	free, err := m.freeSpace(m.path)
+	if err != nil {
+		return err
+	}
+	if free < m.minFree {
+		if atomic.CompareAndSwapInt32(&m.full, 0, 1) {
+			diskFullGauge.Update(1)
+			if m.onFull != nil {
+				m.onFull()
+			}
+		}
+		log.Error("Free disk space below the minimum, block and transaction imports paused", "path", m.path, "free", common.StorageSize(free), "min", common.StorageSize(m.minFree))
+		return nil
+	}
+	if atomic.CompareAndSwapInt32(&m.full, 1, 0) {
+		diskFullGauge.Update(0)
+		log.Info("Free disk space recovered, block and transaction imports resumed", "path", m.path, "free", common.StorageSize(free))
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package knode
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kowala-tech/kcoin/client/knode/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDiskUsage is a free disk space source whose value can be changed.
+type testDiskUsage struct {
+	free uint64 // atomic
+}
+
+func (u *testDiskUsage) set(free uint64) { atomic.StoreUint64(&u.free, free) }
+
+func (u *testDiskUsage) freeSpace(path string) (uint64, error) {
+	return atomic.LoadUint64(&u.free), nil
+}
+
+func TestDiskMonitor(t *testing.T) {
+	usage := &testDiskUsage{free: 2048}
+	monitor := newDiskMonitor("chaindata", 1024, usage.freeSpace, 10*time.Millisecond)
+
+	var engaged int32
+	monitor.onFull = func() { atomic.AddInt32(&engaged, 1) }
+	monitor.start()
+	defer monitor.stop()
+
+	assert.False(t, monitor.Full())
+
+	// Crossing the threshold engages the protective mode once
+	usage.set(1023)
+	waitDiskFull(t, monitor, true)
+	time.Sleep(50 * time.Millisecond)
+	assert.True(t, monitor.Full())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&engaged))
+
+	// Freeing space resumes the imports
+	usage.set(1024)
+	waitDiskFull(t, monitor, false)
+}
+
+func TestDiskMonitor_FullOnStart(t *testing.T) {
+	usage := &testDiskUsage{free: 10}
+	monitor := newDiskMonitor("chaindata", 1024, usage.freeSpace, time.Hour)
+	monitor.start()
+	defer monitor.stop()
+
+	assert.True(t, monitor.Full())
+}
+
+func TestDiskMonitor_DisabledOnError(t *testing.T) {
+	monitor := newDiskMonitor("chaindata", 1024, func(string) (uint64, error) {
+		return 0, errors.New("no disk")
+	}, 10*time.Millisecond)
+	monitor.start()
+	defer monitor.stop()
+
+	assert.False(t, monitor.Full())
+
+	var nilMonitor *diskMonitor
+	assert.False(t, nilMonitor.Full())
+}
+
+func TestSendTxRejectedWhileDiskFull(t *testing.T) {
+	usage := &testDiskUsage{free: 0}
+	monitor := newDiskMonitor("chaindata", 1024, usage.freeSpace, time.Hour)
+	monitor.start()
+	defer monitor.stop()
+	require.True(t, monitor.Full())
+
+	backend := &KowalaAPIBackend{kcoin: &Kowala{disk: monitor}}
+	assert.Equal(t, errDiskFull, backend.SendTx(nil, nil))
+}
+
+// pausedValidator is a validator that only records its pause check.
+type pausedValidator struct {
+	validator.Validator
+	paused func() bool
+}
+
+func (v *pausedValidator) SetPaused(paused func() bool) { v.paused = paused }
+
+func TestDiskMonitorPausesValidator(t *testing.T) {
+	usage := &testDiskUsage{free: 2048}
+	monitor := newDiskMonitor("chaindata", 1024, usage.freeSpace, 10*time.Millisecond)
+
+	pm, val := new(ProtocolManager), new(pausedValidator)
+	monitor.attach(pm, val)
+	require.NotNil(t, val.paused)
+	assert.Equal(t, monitor, pm.disk)
+	assert.NotNil(t, monitor.onFull)
+	monitor.onFull = nil // no downloader to cancel
+
+	monitor.start()
+	defer monitor.stop()
+	assert.False(t, val.paused())
+
+	// Block commits pause and resume along with the imports
+	usage.set(0)
+	waitDiskFull(t, monitor, true)
+	assert.True(t, val.paused())
+
+	usage.set(2048)
+	waitDiskFull(t, monitor, false)
+	assert.False(t, val.paused())
+}
+
+// waitDiskFull waits for the monitor to report the given disk state.
+func waitDiskFull(t *testing.T, monitor *diskMonitor, full bool) {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if monitor.Full() == full {
+			return
+		}
+	}
+	t.Fatalf("disk full state mismatch: have %v, want %v", !full, full)
+}
@@ -0,0 +1,9 @@
+// +build !linux,!darwin
+
+package knode
+
+// freeDiskSpace is not supported on this platform, leaving the free disk space
+// monitor disabled.
+func freeDiskSpace(path string) (uint64, error) {
+	return 0, errDiskSpaceNotSupported
+}
@@ -3,8 +3,10 @@ package downloader
 import (
 	"context"
 	"sync"
+	"time"
 
 	kcoin "github.com/kowala-tech/kcoin/client"
+	"github.com/kowala-tech/kcoin/client/common/hexutil"
 	"github.com/kowala-tech/kcoin/client/event"
 	"github.com/kowala-tech/kcoin/client/rpc"
 )
@@ -103,6 +105,47 @@ func (api *PublicDownloaderAPI) Syncing(ctx context.Context) (*rpc.Subscription,
 	return rpcSub, nil
 }
 
+// SyncProgress streams the synchronisation progress every few seconds while
+// this node is synchronising with the Kowala network.
+func (api *PublicDownloaderAPI) SyncProgress(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		progress := make(chan ProgressEvent)
+		sub := api.d.SubscribeProgress(progress)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-progress:
+				notifier.Notify(rpcSub.ID, &SyncProgressResult{
+					Status:    ev.SyncProgress,
+					Remaining: hexutil.Uint64(ev.Remaining / time.Second),
+				})
+			case <-sub.Err():
+				return
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// SyncProgressResult is a periodic synchronisation progress notification.
+type SyncProgressResult struct {
+	Status    kcoin.SyncProgress `json:"status"`
+	Remaining hexutil.Uint64     `json:"remaining"` // Estimated seconds left, zero if unknown
+}
+
 // SyncingResult provides information about the current synchronisation status for this node.
 type SyncingResult struct {
 	Syncing bool                `json:"syncing"`
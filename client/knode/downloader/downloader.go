@@ -93,6 +93,9 @@ type Downloader struct {
 	syncStatsState       stateSyncStats
 	syncStatsLock        sync.RWMutex // Lock protecting the sync stats fields
 
+	progressFeed  event.Feed              // Feed of the periodic sync progress events
+	progressScope event.SubscriptionScope // Tracks the progress subscriptions to close them on termination
+
 	lightchain LightChain
 	blockchain BlockChain
 
@@ -416,6 +419,11 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, blockNumb
 	d.syncStatsChainHeight = height
 	d.syncStatsLock.Unlock()
 
+	// Report the sync progress periodically until the sync terminates
+	progressQuit := make(chan struct{})
+	defer close(progressQuit)
+	go d.reportProgress(progressQuit)
+
 	// Ensure our origin point is below any fast sync pivot point
 	pivot := uint64(0)
 	if d.mode == FastSync {
@@ -423,6 +431,9 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, blockNumb
 			origin = 0
 		} else {
 			pivot = height - uint64(fsMinFullBlocks)
+			if header := d.checkpointPivot(height); header != nil {
+				pivot = header.Number.Uint64()
+			}
 			if pivot <= origin {
 				origin = pivot - 1
 			}
@@ -517,6 +528,7 @@ func (d *Downloader) Terminate() {
 
 	// Cancel any pending download requests
 	d.Cancel()
+	d.progressScope.Close()
 }
 
 // fetchHeight retrieves the head header of the remote peer to aid in estimating
@@ -1350,8 +1362,13 @@ func (d *Downloader) importBlockResults(results []*fetchResult) error {
 // database. It also controls the synchronisation of state nodes of the pivot block.
 func (d *Downloader) processFastSyncContent(latest *types.Header) error {
 	// Start syncing state of the reported head block. This should get us most of
-	// the state of the pivot block.
-	stateSync := d.syncState(latest.Root)
+	// the state of the pivot block. If a previous sync was interrupted with a
+	// still usable pivot, resume that instead.
+	target := latest
+	if header := d.checkpointPivot(latest.Number.Uint64()); header != nil {
+		target = header
+	}
+	stateSync := d.syncState(target)
 	defer stateSync.Cancel()
 	go func() {
 		if err := stateSync.Wait(); err != nil && err != errCancelStateFetch {
@@ -1364,6 +1381,9 @@ func (d *Downloader) processFastSyncContent(latest *types.Header) error {
 	if height := latest.Number.Uint64(); height > uint64(fsMinFullBlocks) {
 		pivot = height - uint64(fsMinFullBlocks)
 	}
+	if target != latest {
+		pivot = target.Number.Uint64()
+	}
 	// To cater for moving pivot points, track the pivot block and subsequently
 	// accumulated download results separately.
 	var (
@@ -1409,7 +1429,7 @@ func (d *Downloader) processFastSyncContent(latest *types.Header) error {
 			if oldPivot != P {
 				stateSync.Cancel()
 
-				stateSync = d.syncState(P.Header.Root)
+				stateSync = d.syncState(P.Header)
 				defer stateSync.Cancel()
 				go func() {
 					if err := stateSync.Wait(); err != nil && err != errCancelStateFetch {
@@ -1441,6 +1461,21 @@ func (d *Downloader) processFastSyncContent(latest *types.Header) error {
 	}
 }
 
+// checkpointPivot returns the pivot block of a previously interrupted state sync
+// if it is known locally and would not yet be considered stale at the given
+// chain height.
+func (d *Downloader) checkpointPivot(height uint64) *types.Header {
+	checkpoint := rawdb.ReadStateSyncCheckpoint(d.stateDB)
+	if checkpoint == nil || checkpoint.Number >= height || checkpoint.Number+2*uint64(fsMinFullBlocks) < height {
+		return nil
+	}
+	header := d.lightchain.GetHeaderByHash(checkpoint.Hash)
+	if header == nil || header.Number.Uint64() != checkpoint.Number || header.Root != checkpoint.Root {
+		return nil
+	}
+	return header
+}
+
 func splitAroundPivot(pivot uint64, results []*fetchResult) (p *fetchResult, before, after []*fetchResult) {
 	for _, result := range results {
 		num := result.Header.Number.Uint64()
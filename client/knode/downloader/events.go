@@ -1,5 +1,17 @@
 package downloader
 
+import (
+	"time"
+
+	kcoin "github.com/kowala-tech/kcoin/client"
+)
+
 type DoneEvent struct{}
 type StartEvent struct{}
 type FailedEvent struct{ Err error }
+
+// ProgressEvent is posted periodically while a sync is running.
+type ProgressEvent struct {
+	kcoin.SyncProgress
+	Remaining time.Duration // Estimated time until the sync target is reached, zero if unknown
+}
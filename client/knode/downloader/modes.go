@@ -1,6 +1,14 @@
 package downloader
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
+
+// errSnapSyncUnsupported is returned when snapshot sync is requested. The
+// protocol has no messages for serving account ranges, so there is nothing a
+// snapshot mode could download from.
+var errSnapSyncUnsupported = errors.New(`snapshot sync is not supported by the kcoin protocol, use "fast" instead`)
 
 // SyncMode represents the synchronisation mode of the downloader.
 type SyncMode int
@@ -50,6 +58,8 @@ func (mode *SyncMode) UnmarshalText(text []byte) error {
 		*mode = FastSync
 	case "light":
 		*mode = LightSync
+	case "snap":
+		return errSnapSyncUnsupported
 	default:
 		return fmt.Errorf(`unknown sync mode %q, want "full", "fast" or "light"`, text)
 	}
@@ -0,0 +1,46 @@
+package downloader
+
+import "testing"
+
+func TestSyncModeTextRoundTrip(t *testing.T) {
+	for _, mode := range []SyncMode{FullSync, FastSync, LightSync} {
+		if !mode.IsValid() {
+			t.Fatalf("mode %v: not valid", mode)
+		}
+		text, err := mode.MarshalText()
+		if err != nil {
+			t.Fatalf("mode %v: failed to marshal: %v", mode, err)
+		}
+		if string(text) != mode.String() {
+			t.Fatalf("mode %v: text mismatch: have %q, want %q", mode, text, mode.String())
+		}
+		var dec SyncMode
+		if err := dec.UnmarshalText(text); err != nil {
+			t.Fatalf("mode %v: failed to unmarshal %q: %v", mode, text, err)
+		}
+		if dec != mode {
+			t.Fatalf("mode %v: round trip mismatch: have %v", mode, dec)
+		}
+	}
+}
+
+func TestSyncModeInvalid(t *testing.T) {
+	var mode SyncMode
+	if err := mode.UnmarshalText([]byte("warp")); err == nil {
+		t.Fatalf("unknown mode accepted as %v", mode)
+	}
+	invalid := LightSync + 1
+	if invalid.IsValid() {
+		t.Fatalf("mode %d reported valid", invalid)
+	}
+	if _, err := invalid.MarshalText(); err == nil {
+		t.Fatalf("mode %d marshaled", invalid)
+	}
+}
+
+func TestSyncModeSnapRejected(t *testing.T) {
+	var mode SyncMode
+	if err := mode.UnmarshalText([]byte("snap")); err != errSnapSyncUnsupported {
+		t.Fatalf("snap mode: error mismatch: have %v, want %v", err, errSnapSyncUnsupported)
+	}
+}
@@ -0,0 +1,54 @@
+package downloader
+
+import (
+	"time"
+
+	kcoin "github.com/kowala-tech/kcoin/client"
+	"github.com/kowala-tech/kcoin/client/event"
+)
+
+// progressInterval is the time between two sync progress events.
+var progressInterval = 8 * time.Second
+
+// SubscribeProgress registers a subscription of ProgressEvent, posted every
+// few seconds while a sync is running.
+func (d *Downloader) SubscribeProgress(ch chan<- ProgressEvent) event.Subscription {
+	return d.progressScope.Track(d.progressFeed.Subscribe(ch))
+}
+
+// reportProgress periodically posts the sync progress until quit is closed or
+// the downloader terminates.
+func (d *Downloader) reportProgress(quit chan struct{}) {
+	var (
+		start = time.Now()
+		first = d.Progress()
+	)
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			progress := d.Progress()
+			d.progressFeed.Send(ProgressEvent{
+				SyncProgress: progress,
+				Remaining:    estimateRemaining(first, progress, time.Since(start)),
+			})
+		case <-quit:
+			return
+		case <-d.quitCh:
+			return
+		}
+	}
+}
+
+// estimateRemaining extrapolates the time needed to reach the highest known
+// block from the rate at which blocks were synced since the first progress.
+func estimateRemaining(first, current kcoin.SyncProgress, elapsed time.Duration) time.Duration {
+	if current.CurrentBlock <= first.CurrentBlock || current.HighestBlock <= current.CurrentBlock {
+		return 0
+	}
+	synced := current.CurrentBlock - first.CurrentBlock
+	left := current.HighestBlock - current.CurrentBlock
+	return time.Duration(float64(elapsed) * float64(left) / float64(synced))
+}
@@ -0,0 +1,103 @@
+package downloader
+
+import (
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	kcoin "github.com/kowala-tech/kcoin/client"
+	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/core/types"
+)
+
+// progressTestChain is a light chain whose head advances on demand.
+type progressTestChain struct {
+	head uint64 // atomic
+}
+
+func (c *progressTestChain) HasHeader(common.Hash, uint64) bool        { return false }
+func (c *progressTestChain) GetHeaderByHash(common.Hash) *types.Header { return nil }
+func (c *progressTestChain) CurrentHeader() *types.Header {
+	return &types.Header{Number: new(big.Int).SetUint64(atomic.LoadUint64(&c.head))}
+}
+func (c *progressTestChain) InsertHeaderChain([]*types.Header, int) (int, error) { return 0, nil }
+func (c *progressTestChain) Rollback([]common.Hash)                              {}
+
+func TestReportProgress(t *testing.T) {
+	defer func(interval time.Duration) { progressInterval = interval }(progressInterval)
+	progressInterval = 10 * time.Millisecond
+
+	chain := &progressTestChain{head: 100}
+	d := &Downloader{
+		mode:                 LightSync,
+		lightchain:           chain,
+		syncStatsChainOrigin: 100,
+		syncStatsChainHeight: 1000,
+		quitCh:               make(chan struct{}),
+	}
+	events := make(chan ProgressEvent, 16)
+	sub := d.SubscribeProgress(events)
+	defer sub.Unsubscribe()
+
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		d.reportProgress(quit)
+		close(done)
+	}()
+
+	// Advance the chain once the reporter took its starting point
+	select {
+	case ev := <-events:
+		if ev.CurrentBlock != 100 || ev.Remaining != 0 {
+			t.Fatalf("initial progress mismatch: have %+v, remaining %v", ev.SyncProgress, ev.Remaining)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no progress event received")
+	}
+	atomic.StoreUint64(&chain.head, 400)
+	for {
+		select {
+		case ev := <-events:
+			if ev.CurrentBlock != 400 {
+				continue
+			}
+			if ev.StartingBlock != 100 || ev.HighestBlock != 1000 {
+				t.Fatalf("progress mismatch: have %+v", ev.SyncProgress)
+			}
+			if ev.Remaining <= 0 {
+				t.Fatalf("remaining time not estimated: %v", ev.Remaining)
+			}
+			close(quit)
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("progress reporter did not stop")
+			}
+			return
+		case <-time.After(time.Second):
+			t.Fatal("no progress event received")
+		}
+	}
+}
+
+func TestEstimateRemaining(t *testing.T) {
+	tests := []struct {
+		first, current uint64
+		highest        uint64
+		elapsed        time.Duration
+		remaining      time.Duration
+	}{
+		{100, 100, 1000, time.Minute, 0},               // no blocks synced yet
+		{100, 400, 1000, time.Minute, 2 * time.Minute}, // 300 blocks per minute, 600 left
+		{100, 1000, 1000, time.Minute, 0},              // caught up
+	}
+	for i, tt := range tests {
+		first := kcoin.SyncProgress{CurrentBlock: tt.first, HighestBlock: tt.highest}
+		current := kcoin.SyncProgress{CurrentBlock: tt.current, HighestBlock: tt.highest}
+		if have := estimateRemaining(first, current, tt.elapsed); have != tt.remaining {
+			t.Errorf("test %d: remaining mismatch: have %v, want %v", i, have, tt.remaining)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/kowala-tech/kcoin/client/common"
 	"github.com/kowala-tech/kcoin/client/core/rawdb"
 	"github.com/kowala-tech/kcoin/client/core/state"
+	"github.com/kowala-tech/kcoin/client/core/types"
 	"github.com/kowala-tech/kcoin/client/crypto/sha3"
 	"github.com/kowala-tech/kcoin/client/kcoindb"
 	"github.com/kowala-tech/kcoin/client/log"
@@ -41,9 +42,9 @@ type stateSyncStats struct {
 	pending    uint64 // Number of still pending state entries
 }
 
-// syncState starts downloading state with the given root hash.
-func (d *Downloader) syncState(root common.Hash) *stateSync {
-	s := newStateSync(d, root)
+// syncState starts downloading the state of the given pivot block.
+func (d *Downloader) syncState(pivot *types.Header) *stateSync {
+	s := newStateSync(d, pivot)
 	select {
 	case d.stateSyncStart <- s:
 	case <-d.quitCh:
@@ -196,7 +197,8 @@ func (d *Downloader) runStateSync(s *stateSync) *stateSync {
 // stateSync schedules requests for downloading a particular state trie defined
 // by a given state root.
 type stateSync struct {
-	d *Downloader // Downloader instance to access and manage current peerset
+	d     *Downloader   // Downloader instance to access and manage current peerset
+	pivot *types.Header // Block whose state is being retrieved, used to key checkpoints
 
 	sched  *trie.Sync                 // State trie sync scheduler defining the tasks
 	keccak hash.Hash                  // Keccak256 hasher to verify deliveries with
@@ -220,16 +222,42 @@ type stateTask struct {
 
 // newStateSync creates a new state trie download scheduler. This method does not
 // yet start the sync. The user needs to call run to initiate.
-func newStateSync(d *Downloader, root common.Hash) *stateSync {
-	return &stateSync{
+func newStateSync(d *Downloader, pivot *types.Header) *stateSync {
+	s := &stateSync{
 		d:       d,
-		sched:   state.NewStateSync(root, d.stateDB),
+		pivot:   pivot,
+		sched:   state.NewStateSync(pivot.Root, d.stateDB),
 		keccak:  sha3.NewKeccak256(),
 		tasks:   make(map[common.Hash]*stateTask),
 		deliver: make(chan *stateReq),
 		cancel:  make(chan struct{}),
 		done:    make(chan struct{}),
 	}
+	s.restore()
+	return s
+}
+
+// restore resumes the sync from the checkpoint left behind by a previous, not
+// completed sync of the same pivot block. Checkpoints of any other pivot are
+// stale and get discarded.
+func (s *stateSync) restore() {
+	checkpoint := rawdb.ReadStateSyncCheckpoint(s.d.stateDB)
+	if checkpoint == nil {
+		return
+	}
+	if checkpoint.Hash != s.pivot.Hash() || checkpoint.Root != s.pivot.Root {
+		log.Debug("Discarding stale state sync checkpoint", "number", checkpoint.Number, "hash", checkpoint.Hash, "pivot", s.pivot.Number)
+		rawdb.DeleteStateSyncCheckpoint(s.d.stateDB)
+		return
+	}
+	restored, err := s.sched.Restore(checkpoint.Nodes)
+	if err != nil {
+		log.Warn("Failed to restore state sync checkpoint", "number", checkpoint.Number, "hash", checkpoint.Hash, "err", err)
+		s.sched = state.NewStateSync(s.pivot.Root, s.d.stateDB)
+		rawdb.DeleteStateSyncCheckpoint(s.d.stateDB)
+		return
+	}
+	log.Info("Resuming state sync from checkpoint", "number", checkpoint.Number, "hash", checkpoint.Hash, "restored", restored)
 }
 
 // run starts the task assignment and response processing loop, blocking until
@@ -313,9 +341,11 @@ func (s *stateSync) commit(force bool) error {
 	}
 	start := time.Now()
 	b := s.d.stateDB.NewBatch()
-	if written, err := s.sched.Commit(b); written == 0 || err != nil {
+	written, err := s.sched.Commit(b)
+	if err != nil || (written == 0 && !force) {
 		return err
 	}
+	s.checkpoint(b)
 	if err := b.Write(); err != nil {
 		return fmt.Errorf("DB write error: %v", err)
 	}
@@ -325,6 +355,22 @@ func (s *stateSync) commit(force bool) error {
 	return nil
 }
 
+// checkpoint adds the nodes retrieved but not yet committed to the batch, so an
+// interrupted sync of the same pivot can pick up from there. The checkpoint is
+// dropped once the sync completes.
+func (s *stateSync) checkpoint(b kcoindb.Batch) {
+	if s.sched.Pending() == 0 {
+		rawdb.DeleteStateSyncCheckpoint(b)
+		return
+	}
+	rawdb.WriteStateSyncCheckpoint(b, &rawdb.StateSyncCheckpoint{
+		Number: s.pivot.Number.Uint64(),
+		Hash:   s.pivot.Hash(),
+		Root:   s.pivot.Root,
+		Nodes:  s.sched.Retrieved(),
+	})
+}
+
 // assignTasks attempts to assign new tasks to all idle peers, either from the
 // batch currently being retried, or fetching new data from the trie sync itself.
 func (s *stateSync) assignTasks() {
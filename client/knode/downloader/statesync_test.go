@@ -0,0 +1,135 @@
+package downloader
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/core/rawdb"
+	"github.com/kowala-tech/kcoin/client/core/state"
+	"github.com/kowala-tech/kcoin/client/core/types"
+	"github.com/kowala-tech/kcoin/client/kcoindb"
+)
+
+// makeStateSyncSource creates a state large enough to need many retrieval rounds
+// to sync, returning the database holding it and a pivot header referencing it.
+func makeStateSyncSource(t *testing.T) (kcoindb.Database, *types.Header) {
+	db := kcoindb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	for i := 0; i < 256; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i + 1)))
+		statedb.SetBalance(addr, big.NewInt(int64(i+1)))
+		statedb.SetNonce(addr, uint64(i))
+		if i%8 == 0 {
+			statedb.SetCode(addr, []byte{byte(i), 0x60, 0x00})
+			for j := 0; j < 16; j++ {
+				statedb.SetState(addr, common.BigToHash(big.NewInt(int64(j))), common.BigToHash(big.NewInt(int64(i*j+1))))
+			}
+		}
+	}
+	root, err := statedb.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit source state: %v", err)
+	}
+	if err := statedb.Database().TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to write source state: %v", err)
+	}
+	return db, &types.Header{Number: big.NewInt(100), Root: root}
+}
+
+// fetchState serves up to rounds batches of state retrievals from the source
+// database, returning the number of nodes fetched. A negative rounds limit runs
+// the sync to completion.
+func fetchState(t *testing.T, s *stateSync, src kcoindb.Database, rounds int) int {
+	fetched := 0
+	for round := 0; s.sched.Pending() > 0 && round != rounds; round++ {
+		for _, hash := range s.sched.Missing(16) {
+			blob, err := src.Get(hash[:])
+			if err != nil {
+				t.Fatalf("failed to retrieve node %x: %v", hash, err)
+			}
+			if _, _, err := s.processNodeData(blob); err != nil {
+				t.Fatalf("failed to process node %x: %v", hash, err)
+			}
+			s.bytesUncommitted += len(blob)
+			fetched++
+		}
+		if err := s.commit(false); err != nil {
+			t.Fatalf("failed to commit state: %v", err)
+		}
+	}
+	return fetched
+}
+
+// interruptedStateSync runs a state sync until it is killed midway, losing the
+// requests in flight, and then resumes it from a fresh scheduler. It returns the
+// number of nodes fetched after the restart.
+func interruptedStateSync(t *testing.T, checkpoint bool) int {
+	src, pivot := makeStateSyncSource(t)
+
+	d := &Downloader{stateDB: kcoindb.NewMemDatabase()}
+	s := newStateSync(d, pivot)
+	fetchState(t, s, src, 10)
+	s.sched.Missing(16)
+	if err := s.commit(true); err != nil {
+		t.Fatalf("failed to commit state on shutdown: %v", err)
+	}
+	if rawdb.ReadStateSyncCheckpoint(d.stateDB) == nil {
+		t.Fatal("no checkpoint written on shutdown")
+	}
+	if !checkpoint {
+		rawdb.DeleteStateSyncCheckpoint(d.stateDB)
+	}
+	s = newStateSync(d, pivot)
+	fetched := fetchState(t, s, src, -1)
+	if err := s.commit(true); err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if rawdb.ReadStateSyncCheckpoint(d.stateDB) != nil {
+		t.Fatal("checkpoint retained after sync completed")
+	}
+	// Cross check that the full state was reconstructed
+	statedb, err := state.New(pivot.Root, state.NewDatabase(d.stateDB))
+	if err != nil {
+		t.Fatalf("failed to open synced state: %v", err)
+	}
+	for i := 0; i < 256; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i + 1)))
+		if balance := statedb.GetBalance(addr); balance.Cmp(big.NewInt(int64(i+1))) != 0 {
+			t.Fatalf("account %d: balance mismatch: have %v, want %d", i, balance, i+1)
+		}
+		if i%8 == 0 {
+			if code := statedb.GetCode(addr); len(code) != 3 || code[0] != byte(i) {
+				t.Fatalf("account %d: code mismatch: have %x", i, code)
+			}
+		}
+	}
+	return fetched
+}
+
+// Tests that an interrupted state sync resumes from its checkpoint, retrieving
+// fewer nodes than a restart without one.
+func TestStateSyncCheckpointResume(t *testing.T) {
+	resumed := interruptedStateSync(t, true)
+	restarted := interruptedStateSync(t, false)
+	if resumed >= restarted {
+		t.Fatalf("resumed sync fetched %d nodes, not fewer than the %d of a restarted one", resumed, restarted)
+	}
+}
+
+// Tests that the checkpoint of a different pivot is discarded.
+func TestStateSyncCheckpointPivotMoved(t *testing.T) {
+	src, pivot := makeStateSyncSource(t)
+
+	d := &Downloader{stateDB: kcoindb.NewMemDatabase()}
+	s := newStateSync(d, pivot)
+	fetchState(t, s, src, 10)
+	if err := s.commit(true); err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	moved := &types.Header{Number: big.NewInt(200), Root: pivot.Root}
+	newStateSync(d, moved)
+	if rawdb.ReadStateSyncCheckpoint(d.stateDB) != nil {
+		t.Fatal("checkpoint of a moved pivot was not discarded")
+	}
+}
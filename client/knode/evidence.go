@@ -0,0 +1,143 @@
+package knode
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/core"
+	"github.com/kowala-tech/kcoin/client/core/types"
+	"github.com/kowala-tech/kcoin/client/event"
+	"github.com/kowala-tech/kcoin/client/log"
+)
+
+// maxEvidence is the number of double signing offences kept in memory. The
+// oldest submitted ones are dropped first, the ones detected locally are only
+// dropped for newer ones detected locally.
+const maxEvidence = 256
+
+var (
+	errNotValidator     = errors.New("accused signer is not a validator")
+	errEvidencePoolFull = errors.New("evidence pool full")
+)
+
+// evidencePool collects the evidence of validators signing conflicting votes,
+// both detected by the local voting system and submitted by other nodes.
+type evidencePool struct {
+	signer types.Signer
+	voters votersReader // validator set submitted evidence is checked against
+
+	mu       sync.RWMutex
+	evidence map[common.Hash]*types.DuplicateVoteEvidence
+	local    map[common.Hash]bool // whether the evidence was detected locally
+	order    []common.Hash        // insertion order, oldest first
+
+	sub *event.TypeMuxSubscription
+	wg  sync.WaitGroup
+}
+
+func newEvidencePool(signer types.Signer, voters votersReader) *evidencePool {
+	return &evidencePool{
+		signer:   signer,
+		voters:   voters,
+		evidence: make(map[common.Hash]*types.DuplicateVoteEvidence),
+		local:    make(map[common.Hash]bool),
+	}
+}
+
+// start begins collecting the double signing offences posted to mux.
+func (pool *evidencePool) start(mux *event.TypeMux) {
+	pool.sub = mux.Subscribe(core.DoubleSignEvent{})
+
+	pool.wg.Add(1)
+	go pool.loop()
+}
+
+// stop terminates the collection of offences.
+func (pool *evidencePool) stop() {
+	pool.sub.Unsubscribe()
+	pool.wg.Wait()
+}
+
+func (pool *evidencePool) loop() {
+	defer pool.wg.Done()
+
+	for obj := range pool.sub.Chan() {
+		if ev, ok := obj.Data.(core.DoubleSignEvent); ok {
+			if _, err := pool.add(ev.Evidence, true); err != nil {
+				log.Warn("Discarding invalid double signing evidence", "validator", ev.Evidence.Address, "err", err)
+			}
+		}
+	}
+}
+
+// add verifies the evidence and stores it, reporting whether it's new. The
+// evidence is either detected by the local voting system or submitted, in which
+// case the accused signer must belong to the current validator set.
+func (pool *evidencePool) add(evidence *types.DuplicateVoteEvidence, local bool) (bool, error) {
+	if err := evidence.Verify(pool.signer); err != nil {
+		return false, err
+	}
+	if !local {
+		voters := pool.voters.Voters()
+		if voters == nil {
+			return false, errNoValidators
+		}
+		if !voters.Contains(evidence.Address) {
+			return false, errNotValidator
+		}
+	}
+	hash := evidence.Hash()
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if _, ok := pool.evidence[hash]; ok {
+		return false, nil
+	}
+	if len(pool.order) >= maxEvidence && !pool.evict(local) {
+		return false, errEvidencePoolFull
+	}
+	pool.evidence[hash] = evidence
+	pool.local[hash] = local
+	pool.order = append(pool.order, hash)
+
+	log.Info("Recorded double signing evidence", "validator", evidence.Address, "number", evidence.VoteA.BlockNumber(), "round", evidence.VoteA.Round(), "hash", hash)
+	return true, nil
+}
+
+// evict drops the oldest submitted evidence to make room for new evidence,
+// falling back to the oldest evidence detected locally if the new one was
+// detected locally too. It reports whether any evidence was dropped.
+func (pool *evidencePool) evict(local bool) bool {
+	index := -1
+	for i, hash := range pool.order {
+		if !pool.local[hash] {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		if !local {
+			return false
+		}
+		index = 0
+	}
+	hash := pool.order[index]
+	delete(pool.evidence, hash)
+	delete(pool.local, hash)
+	pool.order = append(pool.order[:index], pool.order[index+1:]...)
+	return true
+}
+
+// list returns the collected evidence, oldest first.
+func (pool *evidencePool) list() []*types.DuplicateVoteEvidence {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	list := make([]*types.DuplicateVoteEvidence, len(pool.order))
+	for i, hash := range pool.order {
+		list[i] = pool.evidence[hash]
+	}
+	return list
+}
@@ -0,0 +1,189 @@
+package knode
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/common/hexutil"
+	"github.com/kowala-tech/kcoin/client/core/types"
+	"github.com/kowala-tech/kcoin/client/crypto"
+	"github.com/kowala-tech/kcoin/client/event"
+	"github.com/kowala-tech/kcoin/client/knode/validator"
+	"github.com/kowala-tech/kcoin/client/rlp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testVoteSigner = types.NewAndromedaSigner(big.NewInt(1))
+
+func signTestVote(t *testing.T, key *ecdsa.PrivateKey, block common.Hash) *types.Vote {
+	vote, err := types.SignVote(types.NewVote(big.NewInt(5), block, 2, types.PreCommit), testVoteSigner, key)
+	require.NoError(t, err)
+	return vote
+}
+
+func encodeTestVote(t *testing.T, vote *types.Vote) hexutil.Bytes {
+	enc, err := rlp.EncodeToBytes(vote)
+	require.NoError(t, err)
+	return enc
+}
+
+func TestEvidencePoolDetectsDoubleSign(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	voters, err := types.NewVoters([]*types.Voter{types.NewVoter(addr, big.NewInt(1), big.NewInt(0))})
+	require.NoError(t, err)
+
+	mux := new(event.TypeMux)
+	defer mux.Stop()
+	pool := newEvidencePool(testVoteSigner, &testVoters{voters: voters})
+	pool.start(mux)
+	defer pool.stop()
+
+	tables, err := validator.NewVotingTables(mux, voters)
+	require.NoError(t, err)
+	voteA, voteB := signTestVote(t, key, common.Hash{1}), signTestVote(t, key, common.Hash{2})
+	for _, vote := range []*types.Vote{voteA, voteB} {
+		addressVote, err := types.NewAddressVote(testVoteSigner, vote)
+		require.NoError(t, err)
+		tables[types.PreCommit].Add(addressVote)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for len(pool.list()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	list := pool.list()
+	require.Len(t, list, 1)
+	assert.Equal(t, addr, list[0].Address)
+	assert.Equal(t, voteA.Hash(), list[0].VoteA.Hash())
+	assert.Equal(t, voteB.Hash(), list[0].VoteB.Hash())
+}
+
+func TestPublicValidatorAPIEvidence(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	voters, err := types.NewVoters([]*types.Voter{types.NewVoter(addr, big.NewInt(1), big.NewInt(0))})
+	require.NoError(t, err)
+	api := NewPublicValidatorAPI(&Kowala{evidence: newEvidencePool(testVoteSigner, &testVoters{voters: voters})})
+	voteA, voteB := signTestVote(t, key, common.Hash{1}), signTestVote(t, key, common.Hash{2})
+
+	hash, err := api.SubmitEvidence(encodeTestVote(t, voteA), encodeTestVote(t, voteB))
+	require.NoError(t, err)
+
+	// The same offence reported with the votes swapped isn't recorded twice
+	swapped, err := api.SubmitEvidence(encodeTestVote(t, voteB), encodeTestVote(t, voteA))
+	require.NoError(t, err)
+	assert.Equal(t, hash, swapped)
+
+	_, err = api.SubmitEvidence(encodeTestVote(t, voteA), encodeTestVote(t, signTestVote(t, other, common.Hash{2})))
+	assert.Error(t, err, "votes of different validators")
+	_, err = api.SubmitEvidence(encodeTestVote(t, voteA), encodeTestVote(t, signTestVote(t, key, common.Hash{1})))
+	assert.Error(t, err, "votes not conflicting")
+	_, err = api.SubmitEvidence(hexutil.Bytes{0x01}, encodeTestVote(t, voteB))
+	assert.Error(t, err, "invalid encoding")
+	_, err = api.SubmitEvidence(encodeTestVote(t, signTestVote(t, other, common.Hash{1})), encodeTestVote(t, signTestVote(t, other, common.Hash{2})))
+	assert.Equal(t, errNotValidator, err, "not a validator")
+
+	entries, err := api.GetEvidence()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, hash, entry.Hash)
+	assert.Equal(t, addr, entry.Validator)
+	assert.Equal(t, big.NewInt(5), entry.Number)
+	assert.Equal(t, uint64(2), entry.Round)
+	assert.Equal(t, types.PreCommit.String(), entry.Type)
+	assert.Equal(t, common.Hash{1}, entry.BlockA)
+	assert.Equal(t, common.Hash{2}, entry.BlockB)
+	assert.Equal(t, encodeTestVote(t, voteA), entry.VoteA)
+	assert.Equal(t, encodeTestVote(t, voteB), entry.VoteB)
+}
+
+// newTestEvidence returns the i-th double signing offence of the given key.
+func newTestEvidence(t *testing.T, key *ecdsa.PrivateKey, i int) *types.DuplicateVoteEvidence {
+	evidence, err := types.NewDuplicateVoteEvidence(crypto.PubkeyToAddress(key.PublicKey), signTestVote(t, key, common.Hash{}), signTestVote(t, key, common.BigToHash(big.NewInt(int64(i+1)))))
+	require.NoError(t, err)
+	return evidence
+}
+
+// newTestEvidencePool returns an evidence pool whose validator set only holds
+// the given key.
+func newTestEvidencePool(t *testing.T, key *ecdsa.PrivateKey) *evidencePool {
+	voters, err := types.NewVoters([]*types.Voter{types.NewVoter(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1), big.NewInt(0))})
+	require.NoError(t, err)
+	return newEvidencePool(testVoteSigner, &testVoters{voters: voters})
+}
+
+func TestEvidencePoolLimit(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	for _, local := range []bool{false, true} {
+		pool := newTestEvidencePool(t, key)
+		for i := 0; i <= maxEvidence; i++ {
+			added, err := pool.add(newTestEvidence(t, key, i), local)
+			require.NoError(t, err, "local %v", local)
+			require.True(t, added, "local %v", local)
+		}
+		list := pool.list()
+		assert.Len(t, list, maxEvidence, "local %v", local)
+		assert.Equal(t, newTestEvidence(t, key, 1).Hash(), list[0].Hash(), "local %v: oldest evidence not dropped", local)
+	}
+}
+
+func TestEvidencePoolKeepsLocalEvidence(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	pool := newTestEvidencePool(t, key)
+	for i := 0; i < maxEvidence-1; i++ {
+		_, err := pool.add(newTestEvidence(t, key, i), true)
+		require.NoError(t, err)
+	}
+	// Submitted evidence only evicts older submitted evidence
+	_, err = pool.add(newTestEvidence(t, key, maxEvidence), false)
+	require.NoError(t, err)
+	submitted := newTestEvidence(t, key, maxEvidence+1)
+	_, err = pool.add(submitted, false)
+	require.NoError(t, err)
+
+	// Evidence detected locally evicts the submitted evidence first
+	_, err = pool.add(newTestEvidence(t, key, maxEvidence+2), true)
+	require.NoError(t, err)
+	list := pool.list()
+	require.Len(t, list, maxEvidence)
+	assert.Equal(t, newTestEvidence(t, key, 0).Hash(), list[0].Hash())
+	for _, evidence := range list {
+		assert.NotEqual(t, submitted.Hash(), evidence.Hash())
+	}
+
+	// Submitted evidence doesn't evict the evidence detected locally
+	_, err = pool.add(newTestEvidence(t, key, maxEvidence+3), false)
+	assert.Equal(t, errEvidencePoolFull, err)
+}
+
+func TestEvidencePoolRejectsNonValidators(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	pool := newTestEvidencePool(t, key)
+	_, err = pool.add(newTestEvidence(t, other, 0), false)
+	assert.Equal(t, errNotValidator, err)
+
+	pool = newEvidencePool(testVoteSigner, &testVoters{})
+	_, err = pool.add(newTestEvidence(t, key, 0), false)
+	assert.Equal(t, errNoValidators, err, "validator set not loaded")
+	assert.Empty(t, pool.list())
+}
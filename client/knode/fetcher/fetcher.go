@@ -4,6 +4,7 @@ package fetcher
 import (
 	"errors"
 	"math/rand"
+	"sort"
 	"time"
 
 	"github.com/kowala-tech/kcoin/client/common"
@@ -21,6 +22,9 @@ const (
 	maxQueueDist  = 32                     // Maximum allowed distance from the chain head to queue
 	hashLimit     = 256                    // Maximum number of unique blocks a peer may have announced
 	blockLimit    = 64                     // Maximum number of unique blocks a peer may have delivered
+
+	headerBatchSize   = 8 // Maximum number of consecutive headers requested at once
+	maxHeaderRequests = 4 // Maximum number of header requests in flight per peer
 )
 
 var (
@@ -33,6 +37,10 @@ type blockRetrievalFn func(common.Hash) *types.Block
 // headerRequesterFn is a callback type for sending a header retrieval request.
 type headerRequesterFn func(common.Hash) error
 
+// headerRangeRequesterFn is a callback type for sending a retrieval request of
+// a range of consecutive headers.
+type headerRangeRequesterFn func(origin uint64, amount int) error
+
 // bodyRequesterFn is a callback type for sending a body retrieval request.
 type bodyRequesterFn func([]common.Hash) error
 
@@ -61,8 +69,9 @@ type announce struct {
 
 	origin string // Identifier of the peer originating the notification
 
-	fetchHeader headerRequesterFn // Fetcher function to retrieve the header of an announced block
-	fetchBodies bodyRequesterFn   // Fetcher function to retrieve the body of an announced block
+	fetchHeader  headerRequesterFn      // Fetcher function to retrieve the header of an announced block
+	fetchHeaders headerRangeRequesterFn // Fetcher function to retrieve the headers of consecutive announced blocks
+	fetchBodies  bodyRequesterFn        // Fetcher function to retrieve the body of an announced block
 }
 
 // headerFilterTask represents a batch of headers needing fetcher filtering.
@@ -171,14 +180,15 @@ func (f *Fetcher) Stop() {
 // Notify announces the fetcher of the potential availability of a new block in
 // the network.
 func (f *Fetcher) Notify(peer string, hash common.Hash, number uint64, time time.Time,
-	headerFetcher headerRequesterFn, bodyFetcher bodyRequesterFn) error {
+	headerFetcher headerRequesterFn, headerRangeFetcher headerRangeRequesterFn, bodyFetcher bodyRequesterFn) error {
 	block := &announce{
-		hash:        hash,
-		number:      number,
-		time:        time,
-		origin:      peer,
-		fetchHeader: headerFetcher,
-		fetchBodies: bodyFetcher,
+		hash:         hash,
+		number:       number,
+		time:         time,
+		origin:       peer,
+		fetchHeader:  headerFetcher,
+		fetchHeaders: headerRangeFetcher,
+		fetchBodies:  bodyFetcher,
 	}
 	select {
 	case f.notify <- block:
@@ -367,16 +377,17 @@ func (f *Fetcher) loop() {
 			for peer, hashes := range request {
 				log.Trace("Fetching scheduled headers", "peer", peer, "list", hashes)
 
+				announces := make([]*announce, len(hashes))
+				for i, hash := range hashes {
+					announces[i] = f.fetching[hash]
+				}
 				// Create a closure of the fetch and schedule in on a new thread
-				fetchHeader, hashes := f.fetching[hashes[0]].fetchHeader, hashes
+				batches, hashes := batchHeaders(announces), hashes
 				go func() {
 					if f.fetchingHook != nil {
 						f.fetchingHook(hashes)
 					}
-					for _, hash := range hashes {
-						headerFetchMeter.Mark(1)
-						fetchHeader(hash) // Suboptimal, but protocol doesn't allow batch header retrievals
-					}
+					fetchHeaderBatches(batches)
 				}()
 			}
 			// Schedule the next fetch if blocks are still pending
@@ -423,6 +434,18 @@ func (f *Fetcher) loop() {
 			}
 			headerFilterInMeter.Mark(int64(len(task.headers)))
 
+			// Batches of headers are only taken over if they answer one of our
+			// range requests, so other synchronisation algorithms never get
+			// partial responses.
+			if len(task.headers) > 1 && !f.requestedAll(task) {
+				headerFilterOutMeter.Mark(int64(len(task.headers)))
+				select {
+				case filter <- &headerFilterTask{headers: task.headers, time: task.time}:
+				case <-f.quit:
+					return
+				}
+				break
+			}
 			// Split the batch of headers into unknown ones (to return to the caller),
 			// known incomplete ones (requiring body retrievals) and completed blocks.
 			unknown, incomplete, complete := []*types.Header{}, []*announce{}, []*types.Block{}
@@ -550,6 +573,66 @@ func (f *Fetcher) loop() {
 	}
 }
 
+// headerBatch is a run of announced blocks with consecutive numbers, the
+// headers of which are retrieved with a single request.
+type headerBatch []*announce
+
+// batchHeaders groups the announces of a peer into runs of consecutive block
+// numbers, so that catching up with several new blocks doesn't take a round
+// trip per block. Announces without a number are retrieved on their own.
+func batchHeaders(announces []*announce) []headerBatch {
+	sorted := make([]*announce, len(announces))
+	copy(sorted, announces)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].number < sorted[j].number })
+
+	var batches []headerBatch
+	for _, announce := range sorted {
+		if n := len(batches); n > 0 && announce.number > 0 && announce.fetchHeaders != nil {
+			last := batches[n-1]
+			if tail := last[len(last)-1]; tail.number > 0 && tail.number+1 == announce.number && tail.fetchHeaders != nil && len(last) < headerBatchSize {
+				batches[n-1] = append(last, announce)
+				continue
+			}
+		}
+		batches = append(batches, headerBatch{announce})
+	}
+	return batches
+}
+
+// fetchHeaderBatches requests the headers of all the batches, keeping at most
+// maxHeaderRequests requests in flight.
+func fetchHeaderBatches(batches []headerBatch) {
+	slots := make(chan struct{}, maxHeaderRequests)
+	for _, batch := range batches {
+		slots <- struct{}{}
+		go func(batch headerBatch) {
+			defer func() { <-slots }()
+
+			headerFetchMeter.Mark(int64(len(batch)))
+			if len(batch) == 1 {
+				batch[0].fetchHeader(batch[0].hash)
+			} else {
+				batch[0].fetchHeaders(batch[0].number, len(batch))
+			}
+		}(batch)
+	}
+	// Wait for the last requests to be sent out
+	for i := 0; i < cap(slots); i++ {
+		slots <- struct{}{}
+	}
+}
+
+// requestedAll reports whether all the headers of the task are being fetched
+// from the peer that delivered them.
+func (f *Fetcher) requestedAll(task *headerFilterTask) bool {
+	for _, header := range task.headers {
+		if announce := f.fetching[header.Hash()]; announce == nil || announce.origin != task.peer {
+			return false
+		}
+	}
+	return true
+}
+
 // rescheduleFetch resets the specified fetch timer to the next announce timeout.
 func (f *Fetcher) rescheduleFetch(fetch *time.Timer) {
 	// Short circuit if no blocks are announced
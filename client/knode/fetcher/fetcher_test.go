@@ -0,0 +1,154 @@
+package fetcher
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// headerRequests records the header requests sent out by the fetcher.
+type headerRequests struct {
+	mu      sync.Mutex
+	trips   int             // number of requests sent
+	numbers map[uint64]bool // block numbers requested by range
+	hashes  map[common.Hash]bool
+}
+
+func newHeaderRequests() *headerRequests {
+	return &headerRequests{numbers: make(map[uint64]bool), hashes: make(map[common.Hash]bool)}
+}
+
+func (r *headerRequests) fetchHeader(hash common.Hash) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.trips++
+	r.hashes[hash] = true
+	return nil
+}
+
+func (r *headerRequests) fetchHeaders(origin uint64, amount int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.trips++
+	for i := 0; i < amount; i++ {
+		r.numbers[origin+uint64(i)] = true
+	}
+	return nil
+}
+
+func (r *headerRequests) count() (int, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.trips, len(r.numbers) + len(r.hashes)
+}
+
+func newTestFetcher() *Fetcher {
+	return New(
+		func(common.Hash) *types.Block { return nil },
+		func(*types.Header) error { return nil },
+		func(*types.Block, bool) {},
+		func() uint64 { return 0 },
+		func(types.Blocks) (int, error) { return 0, nil },
+		func(string) {},
+	)
+}
+
+func testAnnounce(number uint64, requests *headerRequests) *announce {
+	return &announce{
+		hash:         common.Hash{byte(number)},
+		number:       number,
+		fetchHeader:  requests.fetchHeader,
+		fetchHeaders: requests.fetchHeaders,
+	}
+}
+
+func TestBatchHeaders(t *testing.T) {
+	requests := newHeaderRequests()
+	var announces []*announce
+	for _, number := range []uint64{12, 3, 4, 5, 1, 6, 7, 8, 9, 10, 11, 20, 0} {
+		announces = append(announces, testAnnounce(number, requests))
+	}
+	batches := batchHeaders(announces)
+
+	var numbers [][]uint64
+	for _, batch := range batches {
+		var run []uint64
+		for _, announce := range batch {
+			run = append(run, announce.number)
+		}
+		numbers = append(numbers, run)
+	}
+	assert.Equal(t, [][]uint64{{0}, {1}, {3, 4, 5, 6, 7, 8, 9, 10}, {11, 12}, {20}}, numbers)
+}
+
+// fetchWindow announces a window of consecutive blocks from a single peer and
+// waits for the fetcher to request all of their headers, returning the number
+// of requests it took.
+func fetchWindow(t *testing.T, size uint64, batched bool) int {
+	requests := newHeaderRequests()
+	fetcher := newTestFetcher()
+	fetcher.Start()
+	defer fetcher.Stop()
+
+	for number := uint64(1); number <= size; number++ {
+		var fetchHeaders headerRangeRequesterFn
+		if batched {
+			fetchHeaders = requests.fetchHeaders
+		}
+		err := fetcher.Notify("peer", common.Hash{byte(number)}, number, time.Now(), requests.fetchHeader, fetchHeaders, nil)
+		require.NoError(t, err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		trips, fetched := requests.count()
+		if fetched == int(size) {
+			return trips
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("fetched %d of %d headers", fetched, size)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestFetcherBatchesCatchUp(t *testing.T) {
+	const window = 20
+
+	assert.Equal(t, window, fetchWindow(t, window, false), "round trips without range requests")
+	assert.Equal(t, (window+headerBatchSize-1)/headerBatchSize, fetchWindow(t, window, true), "round trips with range requests")
+}
+
+func TestFetcherKeepsRequestedRange(t *testing.T) {
+	requests := newHeaderRequests()
+	fetcher := newTestFetcher()
+	fetcher.Start()
+	defer fetcher.Stop()
+
+	headers := make([]*types.Header, 3)
+	for i := range headers {
+		headers[i] = &types.Header{Number: big.NewInt(int64(i + 1))}
+		err := fetcher.Notify("peer", headers[i].Hash(), uint64(i+1), time.Now(), requests.fetchHeader, requests.fetchHeaders, func([]common.Hash) error { return nil })
+		require.NoError(t, err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for _, fetched := requests.count(); fetched < len(headers); _, fetched = requests.count() {
+		if time.Now().After(deadline) {
+			t.Fatal("headers not requested")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// A range from another peer or not matching the request is passed on
+	assert.Len(t, fetcher.FilterHeaders("other", headers, time.Now()), len(headers))
+	assert.Len(t, fetcher.FilterHeaders("peer", append(headers[:2:2], &types.Header{Number: headers[2].Number, Extra: []byte("fork")}), time.Now()), len(headers))
+
+	// The requested range is kept by the fetcher
+	assert.Empty(t, fetcher.FilterHeaders("peer", headers, time.Now()))
+}
@@ -12,6 +12,7 @@ import (
 	kcoin "github.com/kowala-tech/kcoin/client"
 	"github.com/kowala-tech/kcoin/client/common"
 	"github.com/kowala-tech/kcoin/client/common/hexutil"
+	"github.com/kowala-tech/kcoin/client/core"
 	"github.com/kowala-tech/kcoin/client/core/types"
 	"github.com/kowala-tech/kcoin/client/event"
 	"github.com/kowala-tech/kcoin/client/kcoindb"
@@ -217,6 +218,121 @@ func (api *PublicFilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, er
 	return rpcSub, nil
 }
 
+// FinalizedBlock is the notification sent for a block committed by the
+// consensus, which can no longer be reverted.
+type FinalizedBlock struct {
+	Hash       common.Hash    `json:"hash"`
+	Number     hexutil.Uint64 `json:"number"`
+	ParentHash common.Hash    `json:"parentHash"`
+}
+
+// Finalized sends a notification each time the consensus commits a block.
+// Committed blocks are final, so dapps can treat them as irreversible without
+// waiting for confirmations.
+func (api *PublicFilterAPI) Finalized(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	var (
+		rpcSub     = notifier.CreateSubscription()
+		headers    = make(chan *types.Header)
+		headersSub = api.events.SubscribeFinalizedHeads(headers)
+	)
+
+	go func() {
+		for {
+			select {
+			case h := <-headers:
+				notifier.Notify(rpcSub.ID, &FinalizedBlock{
+					Hash:       h.Hash(),
+					Number:     hexutil.Uint64(h.Number.Uint64()),
+					ParentHash: h.ParentHash,
+				})
+			case <-rpcSub.Err():
+				headersSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				headersSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// ReorgCriteria configures which chain reorganisations are reported to a
+// reorg subscription.
+type ReorgCriteria struct {
+	MinDepth hexutil.Uint64 `json:"minDepth"` // Minimum number of dropped blocks
+}
+
+// Reorg is the notification sent for a reorganisation of the canonical chain.
+// Removed and Added list the dropped and newly canonical blocks above the
+// common ancestor, in ascending number order.
+type Reorg struct {
+	Ancestor       common.Hash    `json:"ancestor"`
+	AncestorNumber hexutil.Uint64 `json:"ancestorNumber"`
+	Removed        []common.Hash  `json:"removed"`
+	Added          []common.Hash  `json:"added"`
+}
+
+// newReorg converts a chain reorg event into its RPC representation.
+func newReorg(ev core.ChainReorgEvent) *Reorg {
+	reorg := &Reorg{
+		Ancestor:       ev.Ancestor.Hash(),
+		AncestorNumber: hexutil.Uint64(ev.Ancestor.NumberU64()),
+		Removed:        make([]common.Hash, len(ev.Removed)),
+		Added:          make([]common.Hash, len(ev.Added)),
+	}
+	for i, block := range ev.Removed {
+		reorg.Removed[i] = block.Hash()
+	}
+	for i, block := range ev.Added {
+		reorg.Added[i] = block.Hash()
+	}
+	return reorg
+}
+
+// Reorgs sends a notification each time the canonical chain is reorganised onto
+// a competing branch. The optional criteria limits the notifications to reorgs
+// dropping at least the given number of blocks.
+func (api *PublicFilterAPI) Reorgs(ctx context.Context, crit *ReorgCriteria) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	var minDepth uint64
+	if crit != nil {
+		minDepth = uint64(crit.MinDepth)
+	}
+	var (
+		rpcSub    = notifier.CreateSubscription()
+		reorgs    = make(chan core.ChainReorgEvent)
+		reorgsSub = api.events.SubscribeReorgs(reorgs, minDepth)
+	)
+
+	go func() {
+		for {
+			select {
+			case ev := <-reorgs:
+				notifier.Notify(rpcSub.ID, newReorg(ev))
+			case <-rpcSub.Err():
+				reorgsSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				reorgsSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
 func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
@@ -1,14 +1,151 @@
 package filters
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"testing"
+	"time"
 
 	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/core"
+	"github.com/kowala-tech/kcoin/client/core/bloombits"
+	"github.com/kowala-tech/kcoin/client/core/types"
+	"github.com/kowala-tech/kcoin/client/event"
+	"github.com/kowala-tech/kcoin/client/kcoindb"
 	"github.com/kowala-tech/kcoin/client/rpc"
 )
 
+type testBackend struct {
+	mux        *event.TypeMux
+	db         kcoindb.Database
+	txFeed     event.Feed
+	chainFeed  event.Feed
+	rmLogsFeed event.Feed
+	reorgFeed  event.Feed
+	finalFeed  event.Feed
+	logsFeed   event.Feed
+}
+
+func (b *testBackend) ChainDb() kcoindb.Database { return b.db }
+func (b *testBackend) EventMux() *event.TypeMux  { return b.mux }
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error) {
+	return nil, nil
+}
+func (b *testBackend) GetReceipts(ctx context.Context, blockHash common.Hash) (types.Receipts, error) {
+	return nil, nil
+}
+func (b *testBackend) GetLogs(ctx context.Context, blockHash common.Hash) ([][]*types.Log, error) {
+	return nil, nil
+}
+
+func (b *testBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
+	return b.txFeed.Subscribe(ch)
+}
+func (b *testBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription {
+	return b.chainFeed.Subscribe(ch)
+}
+func (b *testBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription {
+	return b.rmLogsFeed.Subscribe(ch)
+}
+func (b *testBackend) SubscribeChainReorgEvent(ch chan<- core.ChainReorgEvent) event.Subscription {
+	return b.reorgFeed.Subscribe(ch)
+}
+func (b *testBackend) SubscribeChainFinalizedEvent(ch chan<- core.ChainFinalizedEvent) event.Subscription {
+	return b.finalFeed.Subscribe(ch)
+}
+func (b *testBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription {
+	return b.logsFeed.Subscribe(ch)
+}
+
+func (b *testBackend) BloomStatus() (uint64, uint64)                                   { return 4096, 0 }
+func (b *testBackend) ServiceFilter(ctx context.Context, ms *bloombits.MatcherSession) {}
+
+// makeReorg creates a reorg event replacing removed blocks with added ones on
+// top of a common ancestor at the given height.
+func makeReorg(ancestor uint64, removed, added int) core.ChainReorgEvent {
+	makeBlocks := func(n int, seed byte) types.Blocks {
+		blocks := make(types.Blocks, n)
+		for i := range blocks {
+			blocks[i] = types.NewBlockWithHeader(&types.Header{
+				Number: new(big.Int).SetUint64(ancestor + uint64(i) + 1),
+				Extra:  []byte{seed},
+			})
+		}
+		return blocks
+	}
+	return core.ChainReorgEvent{
+		Ancestor: types.NewBlockWithHeader(&types.Header{Number: new(big.Int).SetUint64(ancestor)}),
+		Removed:  makeBlocks(removed, 1),
+		Added:    makeBlocks(added, 2),
+	}
+}
+
+func checkReorg(t *testing.T, have *Reorg, want core.ChainReorgEvent) {
+	if have.Ancestor != want.Ancestor.Hash() || uint64(have.AncestorNumber) != want.Ancestor.NumberU64() {
+		t.Fatalf("ancestor mismatch: have %x (%d), want %x (%d)", have.Ancestor, have.AncestorNumber, want.Ancestor.Hash(), want.Ancestor.NumberU64())
+	}
+	if len(have.Removed) != len(want.Removed) {
+		t.Fatalf("removed count mismatch: have %d, want %d", len(have.Removed), len(want.Removed))
+	}
+	for i, block := range want.Removed {
+		if have.Removed[i] != block.Hash() {
+			t.Errorf("removed block %d mismatch: have %x, want %x", i, have.Removed[i], block.Hash())
+		}
+	}
+	if len(have.Added) != len(want.Added) {
+		t.Fatalf("added count mismatch: have %d, want %d", len(have.Added), len(want.Added))
+	}
+	for i, block := range want.Added {
+		if have.Added[i] != block.Hash() {
+			t.Errorf("added block %d mismatch: have %x, want %x", i, have.Added[i], block.Hash())
+		}
+	}
+}
+
+// Tests that reorg subscriptions are notified of the ancestor, removed and added
+// blocks of the reorgs reaching their configured depth.
+func TestReorgSubscription(t *testing.T) {
+	backend := &testBackend{mux: new(event.TypeMux), db: kcoindb.NewMemDatabase()}
+	defer backend.mux.Stop()
+	es := NewEventSystem(backend.mux, backend, false)
+
+	var (
+		all     = make(chan core.ChainReorgEvent, 2)
+		deep    = make(chan core.ChainReorgEvent, 2)
+		allSub  = es.SubscribeReorgs(all, 0)
+		deepSub = es.SubscribeReorgs(deep, 2)
+	)
+	defer allSub.Unsubscribe()
+	defer deepSub.Unsubscribe()
+
+	shallowReorg, deepReorg := makeReorg(10, 1, 2), makeReorg(20, 3, 4)
+	backend.reorgFeed.Send(shallowReorg)
+	backend.reorgFeed.Send(deepReorg)
+
+	for i, want := range []core.ChainReorgEvent{shallowReorg, deepReorg} {
+		select {
+		case have := <-all:
+			checkReorg(t, newReorg(have), want)
+		case <-time.After(time.Second):
+			t.Fatalf("reorg %d not delivered", i)
+		}
+	}
+	select {
+	case have := <-deep:
+		checkReorg(t, newReorg(have), deepReorg)
+	case <-time.After(time.Second):
+		t.Fatal("deep reorg not delivered")
+	}
+	select {
+	case have := <-deep:
+		t.Fatalf("unexpected reorg delivered: %x", have.Ancestor.Hash())
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
 func TestUnmarshalJSONNewFilterArgs(t *testing.T) {
 	var (
 		fromBlock rpc.BlockNumber = 0x123435
@@ -168,3 +305,30 @@ func TestUnmarshalJSONNewFilterArgs(t *testing.T) {
 	}
 
 }
+
+// Tests that finalized block subscriptions are notified of committed blocks.
+func TestFinalizedSubscription(t *testing.T) {
+	backend := &testBackend{mux: new(event.TypeMux), db: kcoindb.NewMemDatabase()}
+	defer backend.mux.Stop()
+	es := NewEventSystem(backend.mux, backend, false)
+
+	headers := make(chan *types.Header, 1)
+	sub := es.SubscribeFinalizedHeads(headers)
+	defer sub.Unsubscribe()
+
+	block := types.NewBlockWithHeader(&types.Header{
+		ParentHash: common.HexToHash("0x01"),
+		Number:     big.NewInt(7),
+	})
+	backend.chainFeed.Send(core.ChainEvent{Block: types.NewBlockWithHeader(&types.Header{Number: big.NewInt(8)})})
+	backend.finalFeed.Send(core.ChainFinalizedEvent{Block: block})
+
+	select {
+	case have := <-headers:
+		if have.Hash() != block.Hash() {
+			t.Fatalf("finalized hash mismatch: have %x, want %x", have.Hash(), block.Hash())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("finalized block not delivered")
+	}
+}
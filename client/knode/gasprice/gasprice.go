@@ -6,6 +6,7 @@ import (
 	"sort"
 	"sync"
 
+	"github.com/hashicorp/golang-lru"
 	"github.com/kowala-tech/kcoin/client/common"
 	"github.com/kowala-tech/kcoin/client/core/types"
 	"github.com/kowala-tech/kcoin/client/internal/kcoinapi"
@@ -19,41 +20,62 @@ type Config struct {
 	Blocks     int
 	Percentile int
 	Default    *big.Int `toml:",omitempty"`
+
+	// Percentage the price sampled from a block using the whole target gas
+	// limit is raised by, scaled down linearly to nothing for a block using
+	// half of it (0 = disabled)
+	Congestion int `toml:",omitempty"`
 }
 
 // Oracle recommends gas prices based on the content of recent
 // blocks. Suitable for both light and full clients.
+//
+// The suggestion is memoized until a new head arrives, and the price sampled
+// from each block is cached by block hash so that a new head only requires
+// to scan the blocks not seen yet.
 type Oracle struct {
-	backend   kcoinapi.Backend
-	lastHead  common.Hash
-	lastPrice *big.Int
-	cacheLock sync.RWMutex
-	fetchLock sync.Mutex
+	backend     kcoinapi.Backend
+	lastHead    common.Hash
+	lastPrice   *big.Int
+	cacheLock   sync.RWMutex
+	fetchLock   sync.Mutex
+	blockPrices *lru.Cache // price sampled from each recent block, by block hash
 
 	checkBlocks, maxEmpty, maxBlocks int
 	percentile                       int
+	congestion                       int
+	targetGasLimit                   uint64 // gas limit the block fullness is measured against
 }
 
-// NewOracle returns a new oracle.
-func NewOracle(backend kcoinapi.Backend, params Config) *Oracle {
-	blocks := params.Blocks
+// NewOracle returns a new oracle. The block fullness is measured against the
+// target gas limit set when it's created.
+func NewOracle(backend kcoinapi.Backend, config Config) *Oracle {
+	blocks := config.Blocks
 	if blocks < 1 {
 		blocks = 1
 	}
-	percent := params.Percentile
+	percent := config.Percentile
 	if percent < 0 {
 		percent = 0
 	}
 	if percent > 100 {
 		percent = 100
 	}
+	congestion := config.Congestion
+	if congestion < 0 {
+		congestion = 0
+	}
+	blockPrices, _ := lru.New(blocks * 5)
 	return &Oracle{
-		backend:     backend,
-		lastPrice:   params.Default,
-		checkBlocks: blocks,
-		maxEmpty:    blocks / 2,
-		maxBlocks:   blocks * 5,
-		percentile:  percent,
+		backend:        backend,
+		blockPrices:    blockPrices,
+		lastPrice:      config.Default,
+		checkBlocks:    blocks,
+		maxEmpty:       blocks / 2,
+		maxBlocks:      blocks * 5,
+		percentile:     percent,
+		congestion:     congestion,
+		targetGasLimit: params.TargetGasLimit,
 	}
 }
 
@@ -142,15 +164,35 @@ func (t transactionsByGasPrice) Len() int           { return len(t) }
 func (t transactionsByGasPrice) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
 func (t transactionsByGasPrice) Less(i, j int) bool { return t[i].GasPrice().Cmp(t[j].GasPrice()) < 0 }
 
-// getBlockPrices calculates the lowest transaction gas price in a given block
-// and sends it to the result channel. If the block is empty, price is nil.
+// getBlockPrices calculates the lowest transaction gas price in a given block,
+// raised by the congestion premium if the block is more than half full, and
+// sends it to the result channel. If the block is empty, price is nil.
 func (gpo *Oracle) getBlockPrices(ctx context.Context, signer types.Signer, blockNum uint64, ch chan getBlockPricesResult) {
+	header, err := gpo.backend.HeaderByNumber(ctx, rpc.BlockNumber(blockNum))
+	if header == nil {
+		ch <- getBlockPricesResult{nil, err}
+		return
+	}
+	hash := header.Hash()
+	if price, ok := gpo.blockPrices.Get(hash); ok {
+		ch <- getBlockPricesResult{price.(*big.Int), nil}
+		return
+	}
+
 	block, err := gpo.backend.BlockByNumber(ctx, rpc.BlockNumber(blockNum))
 	if block == nil {
 		ch <- getBlockPricesResult{nil, err}
 		return
 	}
+	price := gpo.lowestPrice(signer, block)
+	gpo.blockPrices.Add(hash, price)
+	ch <- getBlockPricesResult{price, nil}
+}
 
+// lowestPrice returns the lowest gas price of the transactions in the block
+// not sent by its coinbase, raised by the congestion premium. If there is no
+// such transaction, the price is nil.
+func (gpo *Oracle) lowestPrice(signer types.Signer, block *types.Block) *big.Int {
 	blockTxs := block.Transactions()
 	txs := make([]*types.Transaction, len(blockTxs))
 	copy(txs, blockTxs)
@@ -159,11 +201,30 @@ func (gpo *Oracle) getBlockPrices(ctx context.Context, signer types.Signer, bloc
 	for _, tx := range txs {
 		sender, err := types.TxSender(signer, tx)
 		if err == nil && sender != block.Coinbase() {
-			ch <- getBlockPricesResult{tx.GasPrice(), nil}
-			return
+			return gpo.congestionPrice(tx.GasPrice(), block.GasUsed())
 		}
 	}
-	ch <- getBlockPricesResult{nil, nil}
+	return nil
+}
+
+// congestionPrice raises the price sampled from a block by the part of the
+// congestion premium matching the gas used beyond half of the target gas limit,
+// so that blocks consistently near the target push the suggestion up even if
+// they are full of cheap transactions. The target is used rather than the gas
+// limit of the block, which validators can move away from it.
+func (gpo *Oracle) congestionPrice(price *big.Int, gasUsed uint64) *big.Int {
+	gasLimit := gpo.targetGasLimit
+	if gpo.congestion == 0 || gasLimit == 0 || 2*gasUsed <= gasLimit {
+		return price
+	}
+	excess := 2*gasUsed - gasLimit
+	if excess > gasLimit {
+		excess = gasLimit
+	}
+	premium := new(big.Int).Mul(price, big.NewInt(int64(gpo.congestion)))
+	premium.Mul(premium, new(big.Int).SetUint64(excess))
+	premium.Div(premium, new(big.Int).SetUint64(100*gasLimit))
+	return premium.Add(premium, price)
 }
 
 type bigIntArray []*big.Int
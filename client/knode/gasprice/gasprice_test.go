@@ -0,0 +1,131 @@
+package gasprice
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/core/types"
+	"github.com/kowala-tech/kcoin/client/crypto"
+	"github.com/kowala-tech/kcoin/client/internal/kcoinapi"
+	"github.com/kowala-tech/kcoin/client/params"
+	"github.com/kowala-tech/kcoin/client/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testGasLimit = 1000000
+
+// testBackend serves a chain whose blocks each hold a single transaction.
+type testBackend struct {
+	kcoinapi.Backend
+	key    *ecdsa.PrivateKey
+	blocks []*types.Block
+	loads  int32 // number of blocks loaded (atomic)
+}
+
+// newTestBackend creates a chain of blocks using the same amount of gas and
+// holding transactions with the same gas price.
+func newTestBackend(t *testing.T, blocks int, gasUsed uint64, gasPrice *big.Int) *testBackend {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	backend := &testBackend{key: key, blocks: []*types.Block{types.NewBlockWithHeader(&types.Header{Number: new(big.Int)})}}
+	for i := 0; i < blocks; i++ {
+		backend.addBlock(t, gasUsed, gasPrice)
+	}
+	return backend
+}
+
+// addBlock appends a new head to the chain.
+func (b *testBackend) addBlock(t *testing.T, gasUsed uint64, gasPrice *big.Int) {
+	number := len(b.blocks)
+	signer := types.NewAndromedaSigner(params.TestChainConfig.ChainID)
+	tx, err := types.SignTx(types.NewTransaction(uint64(number-1), common.Address{1}, new(big.Int), 21000, gasPrice, nil), signer, b.key)
+	require.NoError(t, err)
+	header := &types.Header{
+		Number:   big.NewInt(int64(number)),
+		Coinbase: common.Address{2},
+		GasLimit: testGasLimit,
+		GasUsed:  gasUsed,
+	}
+	b.blocks = append(b.blocks, types.NewBlock(header, []*types.Transaction{tx}, nil, nil))
+}
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	if number == rpc.LatestBlockNumber {
+		number = rpc.BlockNumber(len(b.blocks) - 1)
+	}
+	return b.blocks[number].Header(), nil
+}
+
+func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	atomic.AddInt32(&b.loads, 1)
+	return b.blocks[number], nil
+}
+
+func (b *testBackend) ChainConfig() *params.ChainConfig {
+	return params.TestChainConfig
+}
+
+func TestSuggestPriceCongestion(t *testing.T) {
+	defer func(target uint64) { params.TargetGasLimit = target }(params.TargetGasLimit)
+	params.TargetGasLimit = testGasLimit
+
+	gasPrice := big.NewInt(params.Shannon)
+	config := Config{Blocks: 5, Percentile: 60, Congestion: 20}
+
+	// Sparse blocks don't raise the price
+	sparse, err := NewOracle(newTestBackend(t, 10, testGasLimit/10, gasPrice), config).SuggestPrice(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, gasPrice, sparse)
+
+	// Full blocks of the same transactions raise it by the whole premium
+	congested, err := NewOracle(newTestBackend(t, 10, testGasLimit, gasPrice), config).SuggestPrice(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(params.Shannon*12/10), congested)
+	assert.True(t, congested.Cmp(sparse) > 0)
+
+	// Three quarter full blocks raise it by half of the premium
+	busy, err := NewOracle(newTestBackend(t, 10, testGasLimit*3/4, gasPrice), config).SuggestPrice(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(params.Shannon*11/10), busy)
+
+	// The fullness is measured against the target gas limit, not the block one
+	params.TargetGasLimit = 2 * testGasLimit
+	halfTarget, err := NewOracle(newTestBackend(t, 10, testGasLimit, gasPrice), config).SuggestPrice(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, gasPrice, halfTarget)
+	params.TargetGasLimit = testGasLimit
+
+	// Without a premium the fullness is ignored
+	config.Congestion = 0
+	plain, err := NewOracle(newTestBackend(t, 10, testGasLimit, gasPrice), config).SuggestPrice(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, gasPrice, plain)
+}
+
+func TestSuggestPriceCaching(t *testing.T) {
+	backend := newTestBackend(t, 10, testGasLimit/10, big.NewInt(params.Shannon))
+	gpo := NewOracle(backend, Config{Blocks: 5, Percentile: 100})
+
+	// Repeated suggestions on the same head scan the blocks only once
+	for i := 0; i < 100; i++ {
+		price, err := gpo.SuggestPrice(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(params.Shannon), price)
+	}
+	assert.Equal(t, int32(5), atomic.LoadInt32(&backend.loads))
+
+	// A new head invalidates the suggestion, but only the new block is scanned
+	backend.addBlock(t, testGasLimit/10, big.NewInt(2*params.Shannon))
+	for i := 0; i < 100; i++ {
+		price, err := gpo.SuggestPrice(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(2*params.Shannon), price)
+	}
+	assert.Equal(t, int32(6), atomic.LoadInt32(&backend.loads))
+}
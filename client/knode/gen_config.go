@@ -3,6 +3,7 @@
 package knode
 
 import (
+	"encoding/json"
 	"math/big"
 	"time"
 
@@ -11,39 +12,288 @@ import (
 	"github.com/kowala-tech/kcoin/client/core"
 	"github.com/kowala-tech/kcoin/client/knode/downloader"
 	"github.com/kowala-tech/kcoin/client/knode/gasprice"
+	"github.com/kowala-tech/kcoin/client/knode/validator"
+	"github.com/kowala-tech/kcoin/client/p2p/discover"
+	"github.com/kowala-tech/kcoin/client/params"
 )
 
 var _ = (*configMarshaling)(nil)
 
+// MarshalJSON marshals as JSON.
+func (c Config) MarshalJSON() ([]byte, error) {
+	type Config struct {
+		Genesis                 *core.Genesis `toml:",omitempty"`
+		NetworkId               uint64
+		ChainID                 *big.Int `toml:",omitempty"`
+		SyncMode                downloader.SyncMode
+		NoPruning               bool
+		Whitelist               map[uint64]common.Hash `toml:"-" json:"-"`
+		LightServ               int                    `toml:",omitempty"`
+		LightPeers              int                    `toml:",omitempty"`
+		SkipBcVersionCheck      bool                   `toml:"-" json:"-"`
+		DatabaseHandles         int                    `toml:"-" json:"-"`
+		DatabaseCache           int
+		TrieCache               int
+		TrieTimeout             time.Duration
+		VerifyState             bool                `toml:",omitempty"`
+		DiskMinFree             uint64              `toml:",omitempty"`
+		Coinbase                common.Address      `toml:",omitempty"`
+		Deposit                 *big.Int            `toml:",omitempty"`
+		ExtraValidators         []ValidatorIdentity `toml:",omitempty"`
+		ExtraData               hexutil.Bytes       `toml:",omitempty"`
+		RandomExtraData         bool                `toml:",omitempty"`
+		EmptyBlocks             validator.EmptyBlockPolicy
+		EmptyBlocksTimeout      time.Duration
+		MaxBlockTxs             uint64           `toml:",omitempty"`
+		PriorityAddresses       []common.Address `toml:",omitempty"`
+		GasPrice                *big.Int
+		TxPool                  core.TxPoolConfig
+		TxBroadcastPeers        []discover.NodeID `toml:",omitempty"`
+		GPO                     gasprice.Config
+		Konsensus               params.KonsensusConfig
+		LivenessTimeout         time.Duration `toml:",omitempty"`
+		ConsensusGossip         bool          `toml:",omitempty"`
+		EnablePreimageRecording bool
+		DocRoot                 string `toml:"-" json:"-"`
+		Currency                string
+		PriceMaxAge             time.Duration `toml:",omitempty"`
+	}
+	var enc Config
+	enc.Genesis = c.Genesis
+	enc.NetworkId = c.NetworkId
+	enc.ChainID = c.ChainID
+	enc.SyncMode = c.SyncMode
+	enc.NoPruning = c.NoPruning
+	enc.Whitelist = c.Whitelist
+	enc.LightServ = c.LightServ
+	enc.LightPeers = c.LightPeers
+	enc.SkipBcVersionCheck = c.SkipBcVersionCheck
+	enc.DatabaseHandles = c.DatabaseHandles
+	enc.DatabaseCache = c.DatabaseCache
+	enc.TrieCache = c.TrieCache
+	enc.TrieTimeout = c.TrieTimeout
+	enc.VerifyState = c.VerifyState
+	enc.DiskMinFree = c.DiskMinFree
+	enc.Coinbase = c.Coinbase
+	enc.Deposit = c.Deposit
+	enc.ExtraValidators = c.ExtraValidators
+	enc.ExtraData = c.ExtraData
+	enc.RandomExtraData = c.RandomExtraData
+	enc.EmptyBlocks = c.EmptyBlocks
+	enc.EmptyBlocksTimeout = c.EmptyBlocksTimeout
+	enc.MaxBlockTxs = c.MaxBlockTxs
+	enc.PriorityAddresses = c.PriorityAddresses
+	enc.GasPrice = c.GasPrice
+	enc.TxPool = c.TxPool
+	enc.TxBroadcastPeers = c.TxBroadcastPeers
+	enc.GPO = c.GPO
+	enc.Konsensus = c.Konsensus
+	enc.LivenessTimeout = c.LivenessTimeout
+	enc.ConsensusGossip = c.ConsensusGossip
+	enc.EnablePreimageRecording = c.EnablePreimageRecording
+	enc.DocRoot = c.DocRoot
+	enc.Currency = c.Currency
+	enc.PriceMaxAge = c.PriceMaxAge
+	return json.Marshal(&enc)
+}
+
+// UnmarshalJSON unmarshals from JSON.
+func (c *Config) UnmarshalJSON(input []byte) error {
+	type Config struct {
+		Genesis                 *core.Genesis `toml:",omitempty"`
+		NetworkId               *uint64
+		ChainID                 *big.Int `toml:",omitempty"`
+		SyncMode                *downloader.SyncMode
+		NoPruning               *bool
+		Whitelist               map[uint64]common.Hash `toml:"-" json:"-"`
+		LightServ               *int                   `toml:",omitempty"`
+		LightPeers              *int                   `toml:",omitempty"`
+		SkipBcVersionCheck      *bool                  `toml:"-" json:"-"`
+		DatabaseHandles         *int                   `toml:"-" json:"-"`
+		DatabaseCache           *int
+		TrieCache               *int
+		TrieTimeout             *time.Duration
+		VerifyState             *bool               `toml:",omitempty"`
+		DiskMinFree             *uint64             `toml:",omitempty"`
+		Coinbase                *common.Address     `toml:",omitempty"`
+		Deposit                 *big.Int            `toml:",omitempty"`
+		ExtraValidators         []ValidatorIdentity `toml:",omitempty"`
+		ExtraData               *hexutil.Bytes      `toml:",omitempty"`
+		RandomExtraData         *bool               `toml:",omitempty"`
+		EmptyBlocks             *validator.EmptyBlockPolicy
+		EmptyBlocksTimeout      *time.Duration
+		MaxBlockTxs             *uint64          `toml:",omitempty"`
+		PriorityAddresses       []common.Address `toml:",omitempty"`
+		GasPrice                *big.Int
+		TxPool                  *core.TxPoolConfig
+		TxBroadcastPeers        []discover.NodeID `toml:",omitempty"`
+		GPO                     *gasprice.Config
+		Konsensus               *params.KonsensusConfig
+		LivenessTimeout         *time.Duration `toml:",omitempty"`
+		ConsensusGossip         *bool          `toml:",omitempty"`
+		EnablePreimageRecording *bool
+		DocRoot                 *string `toml:"-" json:"-"`
+		Currency                *string
+		PriceMaxAge             *time.Duration `toml:",omitempty"`
+	}
+	var dec Config
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	if dec.Genesis != nil {
+		c.Genesis = dec.Genesis
+	}
+	if dec.NetworkId != nil {
+		c.NetworkId = *dec.NetworkId
+	}
+	if dec.ChainID != nil {
+		c.ChainID = dec.ChainID
+	}
+	if dec.SyncMode != nil {
+		c.SyncMode = *dec.SyncMode
+	}
+	if dec.NoPruning != nil {
+		c.NoPruning = *dec.NoPruning
+	}
+	if dec.Whitelist != nil {
+		c.Whitelist = dec.Whitelist
+	}
+	if dec.LightServ != nil {
+		c.LightServ = *dec.LightServ
+	}
+	if dec.LightPeers != nil {
+		c.LightPeers = *dec.LightPeers
+	}
+	if dec.SkipBcVersionCheck != nil {
+		c.SkipBcVersionCheck = *dec.SkipBcVersionCheck
+	}
+	if dec.DatabaseHandles != nil {
+		c.DatabaseHandles = *dec.DatabaseHandles
+	}
+	if dec.DatabaseCache != nil {
+		c.DatabaseCache = *dec.DatabaseCache
+	}
+	if dec.TrieCache != nil {
+		c.TrieCache = *dec.TrieCache
+	}
+	if dec.TrieTimeout != nil {
+		c.TrieTimeout = *dec.TrieTimeout
+	}
+	if dec.VerifyState != nil {
+		c.VerifyState = *dec.VerifyState
+	}
+	if dec.DiskMinFree != nil {
+		c.DiskMinFree = *dec.DiskMinFree
+	}
+	if dec.Coinbase != nil {
+		c.Coinbase = *dec.Coinbase
+	}
+	if dec.Deposit != nil {
+		c.Deposit = dec.Deposit
+	}
+	if dec.ExtraValidators != nil {
+		c.ExtraValidators = dec.ExtraValidators
+	}
+	if dec.ExtraData != nil {
+		c.ExtraData = *dec.ExtraData
+	}
+	if dec.RandomExtraData != nil {
+		c.RandomExtraData = *dec.RandomExtraData
+	}
+	if dec.EmptyBlocks != nil {
+		c.EmptyBlocks = *dec.EmptyBlocks
+	}
+	if dec.EmptyBlocksTimeout != nil {
+		c.EmptyBlocksTimeout = *dec.EmptyBlocksTimeout
+	}
+	if dec.MaxBlockTxs != nil {
+		c.MaxBlockTxs = *dec.MaxBlockTxs
+	}
+	if dec.PriorityAddresses != nil {
+		c.PriorityAddresses = dec.PriorityAddresses
+	}
+	if dec.GasPrice != nil {
+		c.GasPrice = dec.GasPrice
+	}
+	if dec.TxPool != nil {
+		c.TxPool = *dec.TxPool
+	}
+	if dec.TxBroadcastPeers != nil {
+		c.TxBroadcastPeers = dec.TxBroadcastPeers
+	}
+	if dec.GPO != nil {
+		c.GPO = *dec.GPO
+	}
+	if dec.Konsensus != nil {
+		c.Konsensus = *dec.Konsensus
+	}
+	if dec.LivenessTimeout != nil {
+		c.LivenessTimeout = *dec.LivenessTimeout
+	}
+	if dec.ConsensusGossip != nil {
+		c.ConsensusGossip = *dec.ConsensusGossip
+	}
+	if dec.EnablePreimageRecording != nil {
+		c.EnablePreimageRecording = *dec.EnablePreimageRecording
+	}
+	if dec.DocRoot != nil {
+		c.DocRoot = *dec.DocRoot
+	}
+	if dec.Currency != nil {
+		c.Currency = *dec.Currency
+	}
+	if dec.PriceMaxAge != nil {
+		c.PriceMaxAge = *dec.PriceMaxAge
+	}
+	return nil
+}
+
 // MarshalTOML marshals as TOML.
 func (c Config) MarshalTOML() (interface{}, error) {
 	type Config struct {
 		Genesis                 *core.Genesis `toml:",omitempty"`
 		NetworkId               uint64
+		ChainID                 *big.Int `toml:",omitempty"`
 		SyncMode                downloader.SyncMode
 		NoPruning               bool
-		LightServ               int  `toml:",omitempty"`
-		LightPeers              int  `toml:",omitempty"`
-		SkipBcVersionCheck      bool `toml:"-"`
-		DatabaseHandles         int  `toml:"-"`
+		Whitelist               map[uint64]common.Hash `toml:"-" json:"-"`
+		LightServ               int                    `toml:",omitempty"`
+		LightPeers              int                    `toml:",omitempty"`
+		SkipBcVersionCheck      bool                   `toml:"-" json:"-"`
+		DatabaseHandles         int                    `toml:"-" json:"-"`
 		DatabaseCache           int
 		TrieCache               int
 		TrieTimeout             time.Duration
-		Coinbase                common.Address `toml:",omitempty"`
-		Deposit                 *big.Int       `toml:",omitempty"`
-		ExtraData               hexutil.Bytes  `toml:",omitempty"`
+		VerifyState             bool                `toml:",omitempty"`
+		DiskMinFree             uint64              `toml:",omitempty"`
+		Coinbase                common.Address      `toml:",omitempty"`
+		Deposit                 *big.Int            `toml:",omitempty"`
+		ExtraValidators         []ValidatorIdentity `toml:",omitempty"`
+		ExtraData               hexutil.Bytes       `toml:",omitempty"`
+		RandomExtraData         bool                `toml:",omitempty"`
+		EmptyBlocks             validator.EmptyBlockPolicy
+		EmptyBlocksTimeout      time.Duration
+		MaxBlockTxs             uint64           `toml:",omitempty"`
+		PriorityAddresses       []common.Address `toml:",omitempty"`
 		GasPrice                *big.Int
 		TxPool                  core.TxPoolConfig
+		TxBroadcastPeers        []discover.NodeID `toml:",omitempty"`
 		GPO                     gasprice.Config
+		Konsensus               params.KonsensusConfig
+		LivenessTimeout         time.Duration `toml:",omitempty"`
+		ConsensusGossip         bool          `toml:",omitempty"`
 		EnablePreimageRecording bool
-		DocRoot                 string `toml:"-"`
+		DocRoot                 string `toml:"-" json:"-"`
 		Currency                string
+		PriceMaxAge             time.Duration `toml:",omitempty"`
 	}
 	var enc Config
 	enc.Genesis = c.Genesis
 	enc.NetworkId = c.NetworkId
+	enc.ChainID = c.ChainID
 	enc.SyncMode = c.SyncMode
 	enc.NoPruning = c.NoPruning
+	enc.Whitelist = c.Whitelist
 	enc.LightServ = c.LightServ
 	enc.LightPeers = c.LightPeers
 	enc.SkipBcVersionCheck = c.SkipBcVersionCheck
@@ -51,15 +301,28 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.DatabaseCache = c.DatabaseCache
 	enc.TrieCache = c.TrieCache
 	enc.TrieTimeout = c.TrieTimeout
+	enc.VerifyState = c.VerifyState
+	enc.DiskMinFree = c.DiskMinFree
 	enc.Coinbase = c.Coinbase
 	enc.Deposit = c.Deposit
+	enc.ExtraValidators = c.ExtraValidators
 	enc.ExtraData = c.ExtraData
+	enc.RandomExtraData = c.RandomExtraData
+	enc.EmptyBlocks = c.EmptyBlocks
+	enc.EmptyBlocksTimeout = c.EmptyBlocksTimeout
+	enc.MaxBlockTxs = c.MaxBlockTxs
+	enc.PriorityAddresses = c.PriorityAddresses
 	enc.GasPrice = c.GasPrice
 	enc.TxPool = c.TxPool
+	enc.TxBroadcastPeers = c.TxBroadcastPeers
 	enc.GPO = c.GPO
+	enc.Konsensus = c.Konsensus
+	enc.LivenessTimeout = c.LivenessTimeout
+	enc.ConsensusGossip = c.ConsensusGossip
 	enc.EnablePreimageRecording = c.EnablePreimageRecording
 	enc.DocRoot = c.DocRoot
 	enc.Currency = c.Currency
+	enc.PriceMaxAge = c.PriceMaxAge
 	return &enc, nil
 }
 
@@ -68,24 +331,39 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	type Config struct {
 		Genesis                 *core.Genesis `toml:",omitempty"`
 		NetworkId               *uint64
+		ChainID                 *big.Int `toml:",omitempty"`
 		SyncMode                *downloader.SyncMode
 		NoPruning               *bool
-		LightServ               *int  `toml:",omitempty"`
-		LightPeers              *int  `toml:",omitempty"`
-		SkipBcVersionCheck      *bool `toml:"-"`
-		DatabaseHandles         *int  `toml:"-"`
+		Whitelist               map[uint64]common.Hash `toml:"-" json:"-"`
+		LightServ               *int                   `toml:",omitempty"`
+		LightPeers              *int                   `toml:",omitempty"`
+		SkipBcVersionCheck      *bool                  `toml:"-" json:"-"`
+		DatabaseHandles         *int                   `toml:"-" json:"-"`
 		DatabaseCache           *int
 		TrieCache               *int
 		TrieTimeout             *time.Duration
-		Coinbase                *common.Address `toml:",omitempty"`
-		Deposit                 *big.Int        `toml:",omitempty"`
-		ExtraData               *hexutil.Bytes  `toml:",omitempty"`
+		VerifyState             *bool               `toml:",omitempty"`
+		DiskMinFree             *uint64             `toml:",omitempty"`
+		Coinbase                *common.Address     `toml:",omitempty"`
+		Deposit                 *big.Int            `toml:",omitempty"`
+		ExtraValidators         []ValidatorIdentity `toml:",omitempty"`
+		ExtraData               *hexutil.Bytes      `toml:",omitempty"`
+		RandomExtraData         *bool               `toml:",omitempty"`
+		EmptyBlocks             *validator.EmptyBlockPolicy
+		EmptyBlocksTimeout      *time.Duration
+		MaxBlockTxs             *uint64          `toml:",omitempty"`
+		PriorityAddresses       []common.Address `toml:",omitempty"`
 		GasPrice                *big.Int
 		TxPool                  *core.TxPoolConfig
+		TxBroadcastPeers        []discover.NodeID `toml:",omitempty"`
 		GPO                     *gasprice.Config
+		Konsensus               *params.KonsensusConfig
+		LivenessTimeout         *time.Duration `toml:",omitempty"`
+		ConsensusGossip         *bool          `toml:",omitempty"`
 		EnablePreimageRecording *bool
-		DocRoot                 *string `toml:"-"`
+		DocRoot                 *string `toml:"-" json:"-"`
 		Currency                *string
+		PriceMaxAge             *time.Duration `toml:",omitempty"`
 	}
 	var dec Config
 	if err := unmarshal(&dec); err != nil {
@@ -97,12 +375,18 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.NetworkId != nil {
 		c.NetworkId = *dec.NetworkId
 	}
+	if dec.ChainID != nil {
+		c.ChainID = dec.ChainID
+	}
 	if dec.SyncMode != nil {
 		c.SyncMode = *dec.SyncMode
 	}
 	if dec.NoPruning != nil {
 		c.NoPruning = *dec.NoPruning
 	}
+	if dec.Whitelist != nil {
+		c.Whitelist = dec.Whitelist
+	}
 	if dec.LightServ != nil {
 		c.LightServ = *dec.LightServ
 	}
@@ -124,24 +408,60 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.TrieTimeout != nil {
 		c.TrieTimeout = *dec.TrieTimeout
 	}
+	if dec.VerifyState != nil {
+		c.VerifyState = *dec.VerifyState
+	}
+	if dec.DiskMinFree != nil {
+		c.DiskMinFree = *dec.DiskMinFree
+	}
 	if dec.Coinbase != nil {
 		c.Coinbase = *dec.Coinbase
 	}
 	if dec.Deposit != nil {
 		c.Deposit = dec.Deposit
 	}
+	if dec.ExtraValidators != nil {
+		c.ExtraValidators = dec.ExtraValidators
+	}
 	if dec.ExtraData != nil {
 		c.ExtraData = *dec.ExtraData
 	}
+	if dec.RandomExtraData != nil {
+		c.RandomExtraData = *dec.RandomExtraData
+	}
+	if dec.EmptyBlocks != nil {
+		c.EmptyBlocks = *dec.EmptyBlocks
+	}
+	if dec.EmptyBlocksTimeout != nil {
+		c.EmptyBlocksTimeout = *dec.EmptyBlocksTimeout
+	}
+	if dec.MaxBlockTxs != nil {
+		c.MaxBlockTxs = *dec.MaxBlockTxs
+	}
+	if dec.PriorityAddresses != nil {
+		c.PriorityAddresses = dec.PriorityAddresses
+	}
 	if dec.GasPrice != nil {
 		c.GasPrice = dec.GasPrice
 	}
 	if dec.TxPool != nil {
 		c.TxPool = *dec.TxPool
 	}
+	if dec.TxBroadcastPeers != nil {
+		c.TxBroadcastPeers = dec.TxBroadcastPeers
+	}
 	if dec.GPO != nil {
 		c.GPO = *dec.GPO
 	}
+	if dec.Konsensus != nil {
+		c.Konsensus = *dec.Konsensus
+	}
+	if dec.LivenessTimeout != nil {
+		c.LivenessTimeout = *dec.LivenessTimeout
+	}
+	if dec.ConsensusGossip != nil {
+		c.ConsensusGossip = *dec.ConsensusGossip
+	}
 	if dec.EnablePreimageRecording != nil {
 		c.EnablePreimageRecording = *dec.EnablePreimageRecording
 	}
@@ -151,5 +471,8 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.Currency != nil {
 		c.Currency = *dec.Currency
 	}
+	if dec.PriceMaxAge != nil {
+		c.PriceMaxAge = *dec.PriceMaxAge
+	}
 	return nil
 }
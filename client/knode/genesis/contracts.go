@@ -3,6 +3,7 @@ package genesis
 import (
 	"fmt"
 	"math/big"
+	"sort"
 	"strings"
 
 	"github.com/kowala-tech/kcoin/client/accounts/abi"
@@ -33,6 +34,20 @@ type contract struct {
 	postDeploy func(contract *contract, opts *validGenesisOptions) error
 }
 
+// setStorage writes a storage slot of the contract outside of the EVM, both in
+// the state shared with the other contracts and in the storage of the genesis.
+func (contract *contract) setStorage(key, value common.Hash) {
+	contract.runtimeCfg.State.SetState(contract.address, key, value)
+
+	tracer := contract.runtimeCfg.EVMConfig.Tracer.(*vmTracer)
+	storage, ok := tracer.getAddrStorage(contract.address)
+	if !ok {
+		storage = make(map[common.Hash]common.Hash, defaultSize)
+		tracer.setAddrStorage(contract.address, storage)
+	}
+	storage[key] = value
+}
+
 func (contract *contract) AsGenesisAccount() core.GenesisAccount {
 	return core.GenesisAccount{
 		Code:    contract.code,
@@ -725,10 +740,44 @@ var ProxiedOracleMgr = &contract{
 		return nil
 	},
 	postDeploy: func(contract *contract, opts *validGenesisOptions) error {
-		return registerAddressToDomain(contract, opts, params.KNSDomains[params.OracleMgrDomain].Node())
+		if err := registerAddressToDomain(contract, opts, params.KNSDomains[params.OracleMgrDomain].Node()); err != nil {
+			return err
+		}
+		registerOracles(contract, opts)
+		return nil
 	},
 }
 
+// storage layout of the oracle manager contract
+const (
+	oracleRegistrySlot = 7 // mapping (address => Oracle)
+	oraclePoolSlot     = 8 // address[]
+)
+
+// registerOracles authorizes the genesis oracles in the oracle manager. The
+// contract only accepts the registration of super nodes from the oracles
+// themselves, so the oracles are written straight to its storage instead.
+func registerOracles(contract *contract, opts *validGenesisOptions) {
+	oracles := opts.oracleMgr.oracles
+	if len(oracles) == 0 {
+		return
+	}
+
+	poolSlot := common.BigToHash(big.NewInt(oraclePoolSlot))
+	poolData := new(big.Int).SetBytes(crypto.Keccak256(poolSlot.Bytes()))
+	contract.setStorage(poolSlot, common.BigToHash(big.NewInt(int64(len(oracles)))))
+
+	for i, oracle := range oracles {
+		index := big.NewInt(int64(i))
+		contract.setStorage(common.BigToHash(new(big.Int).Add(poolData, index)), oracle.Hash())
+
+		// Oracle{index uint, isOracle bool, hasSubmittedPrice bool}
+		entry := new(big.Int).SetBytes(crypto.Keccak256(oracle.Hash().Bytes(), common.BigToHash(big.NewInt(oracleRegistrySlot)).Bytes()))
+		contract.setStorage(common.BigToHash(entry), common.BigToHash(index))
+		contract.setStorage(common.BigToHash(entry.Add(entry, common.Big1)), common.BigToHash(common.Big1))
+	}
+}
+
 func registerAddressToDomain(contract *contract, opts *validGenesisOptions, domain string) error {
 	validatorAddr := opts.prefundedAccounts[0].accountAddress
 
@@ -807,6 +856,27 @@ func registerAddressToDomain(contract *contract, opts *validGenesisOptions, doma
 	return nil
 }
 
+// DefaultContractsVersion is the version of the core contracts deployed in the
+// genesis unless another one is selected.
+const DefaultContractsVersion = "1.0.0"
+
+// validatorMgrCode holds the bytecode of the validator manager contract for
+// each version of the core contracts embedded in the client.
+var validatorMgrCode = map[string]string{
+	"1.0.0": consensus.ValidatorMgrBin,
+}
+
+// ContractsVersions returns the versions of the core contracts available to
+// the genesis, sorted.
+func ContractsVersions() []string {
+	versions := make([]string, 0, len(validatorMgrCode))
+	for version := range validatorMgrCode {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
 var ValidatorMgrContract = &contract{
 	name: "Validator Manager",
 	deploy: func(contract *contract, opts *validGenesisOptions) error {
@@ -831,7 +901,7 @@ var ValidatorMgrContract = &contract{
 
 		runtimeCfg := contract.runtimeCfg
 		runtimeCfg.Origin = args.owner
-		contractCode, contractAddr, _, err := runtime.Create(append(common.FromHex(consensus.ValidatorMgrBin), managerParams...), runtimeCfg)
+		contractCode, contractAddr, _, err := runtime.Create(append(common.FromHex(validatorMgrCode[opts.contractsVersion]), managerParams...), runtimeCfg)
 		if err != nil {
 			return err
 		}
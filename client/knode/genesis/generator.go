@@ -89,6 +89,7 @@ func (gen *generator) Generate(opts Options) (*core.Genesis, error) {
 		Config: &params.ChainConfig{
 			ChainID:   getNetwork(validOptions.network),
 			Konsensus: getConsensusEngine(validOptions.consensusEngine),
+			Forks:     validOptions.forks,
 		},
 		ExtraData: getExtraData(opts.ExtraData),
 	}
@@ -145,16 +146,13 @@ func (gen *generator) getDefaultRuntimeConfig() *runtime.Config {
 	}
 }
 
+// extraDataLength is the length the extra data of the genesis block is padded to.
+const extraDataLength = 32
+
 func getExtraData(extraData string) []byte {
-	extra := ""
-	if extraData != "" {
-		extra = extraData
-	}
-	extraSlice := make([]byte, 32)
-	if len(extra) > 32 {
-		extra = extra[:32]
-	}
-	return append([]byte(extra), extraSlice[len(extra):]...)
+	extra := make([]byte, extraDataLength)
+	copy(extra, extraData)
+	return extra
 }
 
 func getConsensusEngine(consensusEngine string) *params.KonsensusConfig {
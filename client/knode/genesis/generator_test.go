@@ -1,9 +1,21 @@
 package genesis
 
 import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/kowala-tech/kcoin/client/accounts/abi"
+	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/contracts/bindings/oracle"
+	"github.com/kowala-tech/kcoin/client/core"
+	"github.com/kowala-tech/kcoin/client/core/state"
+	"github.com/kowala-tech/kcoin/client/core/vm/runtime"
+	"github.com/kowala-tech/kcoin/client/kcoindb"
+	"github.com/kowala-tech/kcoin/client/params"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"testing"
 )
 
 func TestGenerateIsDeterministic(t *testing.T) {
@@ -23,9 +35,232 @@ func TestGenerateIsDeterministicHasDifferentHash(t *testing.T) {
 	generatedGenesis, err := Generate(options)
 	require.NoError(t, err)
 
-	options.ExtraData = "Something different in this config"
+	options.ExtraData = "Something different"
 	generatedGenesisTwo, err := Generate(options)
 	require.NoError(t, err)
 
 	assert.NotEqual(t, getHashFromGenesisBlock(generatedGenesis), getHashFromGenesisBlock(generatedGenesisTwo))
 }
+
+func TestGenerateExtraData(t *testing.T) {
+	options := Networks["kusd"][MainNetwork]
+	options.ExtraData = "short"
+	generatedGenesis, err := Generate(options)
+	require.NoError(t, err)
+	assert.Equal(t, append([]byte("short"), make([]byte, 27)...), generatedGenesis.ExtraData)
+
+	options.ExtraData = strings.Repeat("x", 32)
+	generatedGenesis, err = Generate(options)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(options.ExtraData), generatedGenesis.ExtraData)
+
+	options.ExtraData = strings.Repeat("x", 33)
+	_, err = Generate(options)
+	assert.Equal(t, ErrExtraDataTooLong, err)
+}
+
+func TestGenerateForks(t *testing.T) {
+	options := Networks["kusd"][MainNetwork]
+	options.Forks = []ForkOpts{{Name: "andromeda", Block: 0}, {Name: "berenike", Block: 100}, {Name: "cassiopeia", Block: 100}}
+	generatedGenesis, err := Generate(options)
+	require.NoError(t, err)
+
+	want := []params.Fork{{Name: "andromeda", Block: big.NewInt(0)}, {Name: "berenike", Block: big.NewInt(100)}, {Name: "cassiopeia", Block: big.NewInt(100)}}
+	assert.Equal(t, want, generatedGenesis.Config.Forks)
+	assert.True(t, generatedGenesis.Config.IsForked("berenike", big.NewInt(100)))
+	assert.False(t, generatedGenesis.Config.IsForked("berenike", big.NewInt(99)))
+
+	// The forks survive the encoding of the genesis
+	encoded, err := generatedGenesis.MarshalJSON()
+	require.NoError(t, err)
+	decoded := new(core.Genesis)
+	require.NoError(t, decoded.UnmarshalJSON(encoded))
+	assert.Equal(t, want, decoded.Config.Forks)
+}
+
+func TestGenerateRejectsInvalidForks(t *testing.T) {
+	testCases := []struct {
+		name  string
+		forks []ForkOpts
+		err   error
+	}{
+		{"out of order", []ForkOpts{{Name: "andromeda", Block: 100}, {Name: "berenike", Block: 99}}, ErrForksOutOfOrder},
+		{"missing name", []ForkOpts{{Name: "", Block: 100}}, ErrEmptyForkName},
+		{"duplicate name", []ForkOpts{{Name: "andromeda", Block: 100}, {Name: "andromeda", Block: 200}}, ErrDuplicateFork},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			options := Networks["kusd"][MainNetwork]
+			options.Forks = tc.forks
+			_, err := Generate(options)
+			require.Error(t, err)
+			assert.True(t, strings.HasPrefix(err.Error(), tc.err.Error()), "have %v, want %v", err, tc.err)
+		})
+	}
+}
+
+func TestGenerateDeploysContractsVersion(t *testing.T) {
+	for _, version := range ContractsVersions() {
+		t.Run(version, func(t *testing.T) {
+			options := Networks["kusd"][MainNetwork]
+			options.ContractsVersion = version
+			generatedGenesis, err := Generate(options)
+			require.NoError(t, err)
+
+			code := common.FromHex(validatorMgrCode[version])
+			deployed := false
+			for _, account := range generatedGenesis.Alloc {
+				if len(account.Code) > 0 && bytes.Contains(code, account.Code) {
+					deployed = true
+				}
+			}
+			assert.True(t, deployed, "validator manager code of version %s not in the alloc", version)
+		})
+	}
+}
+
+func TestGenerateRejectsUnknownContractsVersion(t *testing.T) {
+	options := Networks["kusd"][MainNetwork]
+	options.ContractsVersion = "0.0.1"
+	_, err := Generate(options)
+	assert.Error(t, err)
+}
+
+func TestGenerateRegistersOracles(t *testing.T) {
+	oracles := []string{
+		"0x049ec8777b4806eff0Bb6039551690D8f650B25a",
+		"0x902f069aF381a650B7F18Ff28ffdAd0f11eb425b",
+	}
+	options := Networks["kusd"][MainNetwork]
+	dataFeed := *options.DataFeedSystem
+	dataFeed.MaxNumOracles = 3
+	dataFeed.Price = PriceOpts{SyncFrequency: 300, UpdatePeriod: 20}
+	dataFeed.Oracles = oracles
+	options.DataFeedSystem = &dataFeed
+
+	generatedGenesis, err := Generate(options)
+	require.NoError(t, err)
+
+	// Query the oracle manager on the state of the genesis
+	db := kcoindb.NewMemDatabase()
+	block := generatedGenesis.ToBlock(db)
+	statedb, err := state.New(block.Root(), state.NewDatabase(db))
+	require.NoError(t, err)
+	managerABI, err := abi.JSON(strings.NewReader(oracle.OracleMgrABI))
+	require.NoError(t, err)
+	call := func(result interface{}, method string, args ...interface{}) {
+		input, err := managerABI.Pack(method, args...)
+		require.NoError(t, err)
+		output, _, err := runtime.Call(ProxiedOracleMgr.address, input, &runtime.Config{State: statedb})
+		require.NoError(t, err, method)
+		require.NoError(t, managerABI.Unpack(result, method, output), method)
+	}
+
+	for method, want := range map[string]int64{"maxNumOracles": 3, "syncFrequency": 300, "updatePeriod": 20, "getOracleCount": 2} {
+		value := new(big.Int)
+		call(&value, method)
+		assert.Equal(t, big.NewInt(want), value, method)
+	}
+	for i, addr := range oracles {
+		var registered common.Address
+		call(&registered, "getOracleAtIndex", big.NewInt(int64(i)))
+		assert.Equal(t, common.HexToAddress(addr), registered)
+
+		var isOracle bool
+		call(&isOracle, "isOracle", common.HexToAddress(addr))
+		assert.True(t, isOracle, "oracle %s not registered", addr)
+	}
+	var isOracle bool
+	call(&isOracle, "isOracle", common.HexToAddress("0x6ad6b24C43A622d58e2959474E3912ba94DFD957"))
+	assert.False(t, isOracle)
+}
+
+func TestGenerateRejectsInvalidOracles(t *testing.T) {
+	testCases := []struct {
+		name     string
+		dataFeed DataFeedSystemOpts
+	}{
+		{"no max number of oracles", DataFeedSystemOpts{Price: PriceOpts{SyncFrequency: 600, UpdatePeriod: 30}}},
+		{"update period beyond the sync frequency", DataFeedSystemOpts{MaxNumOracles: 1, Price: PriceOpts{SyncFrequency: 600, UpdatePeriod: 601}}},
+		{"more oracles than allowed", DataFeedSystemOpts{MaxNumOracles: 1, Oracles: []string{"0x049ec8777b4806eff0Bb6039551690D8f650B25a", "0x902f069aF381a650B7F18Ff28ffdAd0f11eb425b"}}},
+		{"duplicate oracle", DataFeedSystemOpts{MaxNumOracles: 2, Oracles: []string{"0x049ec8777b4806eff0Bb6039551690D8f650B25a", "0x049ec8777b4806eff0bb6039551690d8f650b25a"}}},
+		{"invalid oracle address", DataFeedSystemOpts{MaxNumOracles: 1, Oracles: []string{"0x049ec8"}}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			options := Networks["kusd"][MainNetwork]
+			dataFeed := tc.dataFeed
+			options.DataFeedSystem = &dataFeed
+			_, err := Generate(options)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestGeneratePrefundsBalancesBeyondUint64(t *testing.T) {
+	options := Networks["kusd"][MainNetwork]
+	// 10 * 2^64 coins, which doesn't fit in 64 bits even before the conversion to wei
+	coins := new(big.Int).Mul(new(big.Int).Lsh(common.Big1, 64), big.NewInt(10))
+	addr := common.HexToAddress("0x0c4e7a8b2d1f3e5a6b7c8d9e0f1a2b3c4d5e6f70")
+	options.PrefundedAccounts = append(append([]PrefundedAccount{}, options.PrefundedAccounts...), PrefundedAccount{
+		Address: addr.Hex(),
+		Balance: coins,
+	})
+
+	genesis, err := Generate(options)
+	require.NoError(t, err)
+
+	want, _ := new(big.Int).SetString("184467440737095516160000000000000000000", 10)
+	require.Contains(t, genesis.Alloc, addr)
+	assert.Equal(t, want, genesis.Alloc[addr].Balance)
+}
+
+func TestGenerateWithoutPrefundedAccounts(t *testing.T) {
+	options := Networks["kusd"][MainNetwork]
+	options.PrefundedAccounts = nil
+	_, err := Generate(options)
+	assert.Equal(t, ErrEmptyPrefundedAccounts, err)
+
+	// only the genesis validator is funded
+	options.AllowEmptyPrefund = true
+	genesis, err := Generate(options)
+	require.NoError(t, err)
+
+	validator := common.HexToAddress(options.Consensus.Validators[0].Address)
+	require.Contains(t, genesis.Alloc, validator)
+	assert.Equal(t, new(big.Int).Mul(big.NewInt(validatorPrefund), big.NewInt(params.Kcoin)), genesis.Alloc[validator].Balance)
+	for _, account := range Networks["kusd"][MainNetwork].PrefundedAccounts {
+		if addr := common.HexToAddress(account.Address); addr != validator {
+			assert.NotContains(t, genesis.Alloc, addr)
+		}
+	}
+
+	// explicit prefunded accounts are kept
+	options.PrefundedAccounts = Networks["kusd"][MainNetwork].PrefundedAccounts
+	genesis, err = Generate(options)
+	require.NoError(t, err)
+	assert.Equal(t, new(big.Int).Mul(big.NewInt(10000), big.NewInt(params.Kcoin)), genesis.Alloc[common.HexToAddress("0x6D5E05684c737D42F313d5B82A88090136e831F8")].Balance)
+}
+
+func TestGenerateRejectsInvalidPrefundedBalances(t *testing.T) {
+	testCases := []struct {
+		name    string
+		balance *big.Int
+		err     error
+	}{
+		{"missing balance", nil, ErrInvalidBalanceInPrefundedAccounts},
+		{"negative balance", big.NewInt(-1), ErrInvalidBalanceInPrefundedAccounts},
+		{"total supply beyond 256 bits", new(big.Int).Lsh(common.Big1, 256), ErrTotalSupplyOverflow},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			options := Networks["kusd"][MainNetwork]
+			options.PrefundedAccounts = append(append([]PrefundedAccount{}, options.PrefundedAccounts...), PrefundedAccount{
+				Address: "0x0c4e7a8b2d1f3e5a6b7c8d9e0f1a2b3c4d5e6f70",
+				Balance: tc.balance,
+			})
+			_, err := Generate(options)
+			assert.Equal(t, tc.err, err)
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package genesis
 
-import "github.com/kowala-tech/kcoin/client/knode/currency"
+import (
+	"math/big"
+
+	"github.com/kowala-tech/kcoin/client/knode/currency"
+)
 
 /*
 LiveCurrencies is the list of currencies that currently have main nets, and
@@ -78,19 +82,19 @@ var Networks = map[string]map[string]Options{
 			PrefundedAccounts: []PrefundedAccount{
 				{
 					Address: "0x6D5E05684c737D42F313d5B82A88090136e831F8",
-					Balance: 10000,
+					Balance: big.NewInt(10000),
 				},
 				{
 					Address: "0x049ec8777b4806eff0Bb6039551690D8f650B25a",
-					Balance: 10,
+					Balance: big.NewInt(10),
 				},
 				{
 					Address: "0x902f069aF381a650B7F18Ff28ffdAd0f11eb425b",
-					Balance: 10,
+					Balance: big.NewInt(10),
 				},
 				{
 					Address: "0x6ad6b24C43A622d58e2959474E3912ba94DFD957",
-					Balance: 10,
+					Balance: big.NewInt(10),
 				},
 			},
 		},
@@ -148,27 +152,27 @@ var Networks = map[string]map[string]Options{
 			PrefundedAccounts: []PrefundedAccount{
 				{
 					Address: "0xf861e10641952a42f9c527a43ab77c3030ee2c8f",
-					Balance: 50,
+					Balance: big.NewInt(50),
 				},
 				{
 					Address: "0x7dd43075b89c129bcd2cca1e2d680a6f3f30b5d9",
-					Balance: 50,
+					Balance: big.NewInt(50),
 				},
 				{
 					Address: "0xa1d4755112491db5ddf0e10b9253b5a0f6783759",
-					Balance: 50,
+					Balance: big.NewInt(50),
 				},
 				{
 					Address: "0x2429f4aa5cf9d23fea0961780ffb4ff8916a26a0",
-					Balance: 1000000,
+					Balance: big.NewInt(1000000),
 				},
 				{
 					Address: "0x45880e0ab20b1ca0391e8fe871fa035e58edada9",
-					Balance: 1000000,
+					Balance: big.NewInt(1000000),
 				},
 				{
 					Address: "0xdac38f0e18ef8bd32aaae695f82e37e14a75a74b",
-					Balance: 1000000,
+					Balance: big.NewInt(1000000),
 				},
 			},
 		},
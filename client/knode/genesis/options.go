@@ -16,6 +16,10 @@ const (
 	OtherNetwork = "other"
 
 	KonsensusConsensus = "konsensus"
+
+	// validatorPrefund is the balance in whole coins of the genesis validators
+	// of networks without prefunded accounts.
+	validatorPrefund = 10
 )
 
 var (
@@ -35,11 +39,23 @@ var (
 	ErrInvalidFreezePeriod               = errors.New("freeze period is invalid")
 	ErrEmptyWalletAddressValidator       = errors.New("wallet address of genesis validator is mandatory")
 	ErrInvalidWalletAddressValidator     = errors.New("wallet address of genesis validator is invalid")
+	ErrEmptyPrefundedAccounts            = errors.New("prefunded accounts are mandatory")
 	ErrInvalidAddressInPrefundedAccounts = errors.New("address in prefunded accounts is invalid")
+	ErrInvalidBalanceInPrefundedAccounts = errors.New("balance in prefunded accounts is missing or negative")
+	ErrTotalSupplyOverflow               = errors.New("total supply of the prefunded accounts exceeds 256 bits")
 	ErrInvalidContractsOwnerAddress      = errors.New("address used for smart contracts is invalid")
 	ErrInvalidNetwork                    = errors.New("invalid Network, use main, test or other")
 	ErrInvalidConsensusEngine            = errors.New("invalid consensus engine")
+	ErrInvalidContractsVersion           = errors.New("invalid contracts version")
+	ErrEmptyMaxNumOracles                = errors.New("max number of oracles is mandatory")
+	ErrInvalidOracleUpdatePeriod         = errors.New("oracle update period must be positive and not exceed the sync frequency")
+	ErrTooManyOracles                    = errors.New("more oracles than the max number of oracles")
+	ErrDuplicateOracle                   = errors.New("duplicate oracle")
 	ErrInvalidAddress                    = errors.New("Invalid address")
+	ErrExtraDataTooLong                  = fmt.Errorf("extra data exceeds %d bytes", extraDataLength)
+	ErrEmptyForkName                     = errors.New("fork name is mandatory")
+	ErrDuplicateFork                     = errors.New("duplicate fork")
+	ErrForksOutOfOrder                   = errors.New("fork activation blocks are out of order")
 )
 
 type Options struct {
@@ -51,7 +67,15 @@ type Options struct {
 	StabilityContract *StabilityContractOpts
 	DataFeedSystem    *DataFeedSystemOpts
 	PrefundedAccounts []PrefundedAccount
+	AllowEmptyPrefund bool `json:",omitempty"` // fund the genesis validators only if there are no prefunded accounts
 	ExtraData         string
+	ContractsVersion  string     `json:",omitempty"` // version of the embedded core contracts, latest if empty
+	Forks             []ForkOpts `json:",omitempty"` // protocol changes in activation order
+}
+
+type ForkOpts struct {
+	Name  string
+	Block uint64
 }
 
 type StabilityContractOpts struct {
@@ -99,11 +123,12 @@ type PriceOpts struct {
 type DataFeedSystemOpts struct {
 	MaxNumOracles uint64
 	Price         PriceOpts
+	Oracles       []string `json:",omitempty"` // oracles authorized from the genesis
 }
 
 type PrefundedAccount struct {
 	Address string
-	Balance uint64
+	Balance *big.Int // in whole coins
 }
 
 type Validator struct {
@@ -134,6 +159,7 @@ type validPriceOpts struct {
 type validOracleMgrOpts struct {
 	maxNumOracles    *big.Int
 	price            validPriceOpts
+	oracles          []common.Address
 	validatorMgrAddr common.Address
 	owner            common.Address
 }
@@ -186,6 +212,8 @@ type validGenesisOptions struct {
 	miningToken       *validMiningTokenOpts
 	sysvars           *validSystemVarsOpts
 	stability         *validStabilityContractOpts
+	contractsVersion  string
+	forks             []params.Fork
 	ExtraData         string
 }
 
@@ -203,6 +231,23 @@ func validateOptions(options Options) (*validGenesisOptions, error) {
 		}
 	}
 
+	contractsVersion := DefaultContractsVersion
+	if options.ContractsVersion != "" {
+		contractsVersion, err = mapContractsVersion(options.ContractsVersion)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(options.ExtraData) > extraDataLength {
+		return nil, ErrExtraDataTooLong
+	}
+
+	forks, err := mapForks(options.Forks)
+	if err != nil {
+		return nil, err
+	}
+
 	// sysvars
 	initialPrice := new(big.Int)
 	new(big.Float).Mul(new(big.Float).SetFloat64(options.SystemVars.InitialPrice), big.NewFloat(params.Kcoin)).Int(initialPrice)
@@ -249,6 +294,29 @@ func validateOptions(options Options) (*validGenesisOptions, error) {
 	maxNumOracles := new(big.Int).SetUint64(options.DataFeedSystem.MaxNumOracles)
 	syncFrequency := new(big.Int).SetUint64(options.DataFeedSystem.Price.SyncFrequency)
 	updatePeriod := new(big.Int).SetUint64(options.DataFeedSystem.Price.UpdatePeriod)
+	if maxNumOracles.Sign() == 0 {
+		return nil, ErrEmptyMaxNumOracles
+	}
+	if syncFrequency.Sign() > 0 && (updatePeriod.Sign() == 0 || updatePeriod.Cmp(syncFrequency) > 0) {
+		return nil, ErrInvalidOracleUpdatePeriod
+	}
+
+	oracles := make([]common.Address, 0, len(options.DataFeedSystem.Oracles))
+	seenOracles := make(map[common.Address]bool)
+	for _, oracle := range options.DataFeedSystem.Oracles {
+		addr, err := getAddress(oracle)
+		if err != nil {
+			return nil, err
+		}
+		if seenOracles[*addr] {
+			return nil, fmt.Errorf("%v:%s", ErrDuplicateOracle, oracle)
+		}
+		seenOracles[*addr] = true
+		oracles = append(oracles, *addr)
+	}
+	if uint64(len(oracles)) > options.DataFeedSystem.MaxNumOracles {
+		return nil, ErrTooManyOracles
+	}
 
 	// mining tokens
 	decimals := new(big.Int).Exp(common.Big1, new(big.Int).SetUint64(options.Consensus.MiningToken.Decimals), nil)
@@ -267,7 +335,20 @@ func validateOptions(options Options) (*validGenesisOptions, error) {
 	}
 
 	// prefund accounts
-	mintedAmount, validPrefundedAccounts, err := mapPrefundedAccounts(options.PrefundedAccounts)
+	prefundedAccounts := options.PrefundedAccounts
+	if len(prefundedAccounts) == 0 && options.AllowEmptyPrefund {
+		// networks funded by a faucet still need gas for the validators
+		for _, validator := range options.Consensus.Validators {
+			prefundedAccounts = append(prefundedAccounts, PrefundedAccount{
+				Address: validator.Address,
+				Balance: new(big.Int).SetUint64(validatorPrefund),
+			})
+		}
+	}
+	if len(prefundedAccounts) == 0 {
+		return nil, ErrEmptyPrefundedAccounts
+	}
+	mintedAmount, validPrefundedAccounts, err := mapPrefundedAccounts(prefundedAccounts)
 	if err != nil {
 		return nil, err
 	}
@@ -298,6 +379,7 @@ func validateOptions(options Options) (*validGenesisOptions, error) {
 				syncFrequency: syncFrequency,
 				updatePeriod:  updatePeriod,
 			},
+			oracles: oracles,
 		},
 		miningToken: &validMiningTokenOpts{
 			name:     options.Consensus.MiningToken.Name,
@@ -310,6 +392,8 @@ func validateOptions(options Options) (*validGenesisOptions, error) {
 			minDeposit: minDeposit,
 		},
 		prefundedAccounts: validPrefundedAccounts,
+		contractsVersion:  contractsVersion,
+		forks:             forks,
 		ExtraData:         options.ExtraData,
 	}, nil
 }
@@ -339,6 +423,38 @@ func mapConsensusEngine(consensus string) (string, error) {
 	return consensus, nil
 }
 
+func mapContractsVersion(version string) (string, error) {
+	if _, ok := validatorMgrCode[version]; !ok {
+		return "", fmt.Errorf("%v:%s", ErrInvalidContractsVersion, version)
+	}
+
+	return version, nil
+}
+
+// mapForks checks the forks are named uniquely and activated in order.
+func mapForks(opts []ForkOpts) ([]params.Fork, error) {
+	if len(opts) == 0 {
+		return nil, nil
+	}
+	forks := make([]params.Fork, 0, len(opts))
+	seen := make(map[string]bool)
+	for i, fork := range opts {
+		if fork.Name == "" {
+			return nil, ErrEmptyForkName
+		}
+		if seen[fork.Name] {
+			return nil, fmt.Errorf("%v:%s", ErrDuplicateFork, fork.Name)
+		}
+		seen[fork.Name] = true
+
+		if i > 0 && fork.Block < opts[i-1].Block {
+			return nil, ErrForksOutOfOrder
+		}
+		forks = append(forks, params.Fork{Name: fork.Name, Block: new(big.Int).SetUint64(fork.Block)})
+	}
+	return forks, nil
+}
+
 func mapWalletAddress(a string) (*common.Address, error) {
 	stringAddr := a
 
@@ -360,6 +476,13 @@ func mapWalletAddress(a string) (*common.Address, error) {
 	return &address, nil
 }
 
+// PrefundedSupply returns the sum of the balances of the prefunded accounts in
+// wei, which makes up the total supply of the genesis.
+func PrefundedSupply(accounts []PrefundedAccount) (*big.Int, error) {
+	supply, _, err := mapPrefundedAccounts(accounts)
+	return supply, err
+}
+
 func mapPrefundedAccounts(accounts []PrefundedAccount) (*big.Int, []*validPrefundedAccount, error) {
 	var validAccounts []*validPrefundedAccount
 
@@ -370,7 +493,11 @@ func mapPrefundedAccounts(accounts []PrefundedAccount) (*big.Int, []*validPrefun
 			return nil, nil, ErrInvalidAddressInPrefundedAccounts
 		}
 
-		balance := new(big.Int).Mul(new(big.Int).SetUint64(a.Balance), new(big.Int).SetUint64(params.Kcoin))
+		if a.Balance == nil || a.Balance.Sign() < 0 {
+			return nil, nil, ErrInvalidBalanceInPrefundedAccounts
+		}
+
+		balance := new(big.Int).Mul(a.Balance, new(big.Int).SetUint64(params.Kcoin))
 		mintedAmount.Add(mintedAmount, balance)
 
 		validAccount := &validPrefundedAccount{
@@ -381,5 +508,10 @@ func mapPrefundedAccounts(accounts []PrefundedAccount) (*big.Int, []*validPrefun
 		validAccounts = append(validAccounts, validAccount)
 	}
 
+	// the total supply is kept in a uint256 by the system vars contract
+	if mintedAmount.BitLen() > 256 {
+		return nil, nil, ErrTotalSupplyOverflow
+	}
+
 	return mintedAmount, validAccounts, nil
 }
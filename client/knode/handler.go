@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"math/big"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -33,6 +34,14 @@ const (
 	// txChanSize is the size of channel listening to NewTxsEvent.
 	// The number is referenced from the size of tx pool.
 	txChanSize = 4096
+
+	// voteRelayChanSize is the size of the queue of votes waiting to be relayed.
+	// Votes arriving while the queue is full are dropped.
+	voteRelayChanSize = 256
+
+	// maxVoteRelayDistance is the maximum distance between the height a relayed
+	// vote is for and the next block on top of the local chain head.
+	maxVoteRelayDistance = 2
 )
 
 // errIncompatibleConfig is returned if the requested protocols and configs are
@@ -58,6 +67,14 @@ type ProtocolManager struct {
 	fetcher    *fetcher.Fetcher
 	validator  validator.Validator
 	peers      *peerSet
+	whitelist  map[uint64]common.Hash // block hashes peers must agree with
+	disk       *diskMonitor           // pauses block and transaction imports while the disk is nearly full (nil if disabled)
+
+	broadcastPeers map[discover.NodeID]struct{} // peers local transactions are exclusively sent to
+
+	consensusGossip bool             // whether votes are relayed while not validating
+	voters          votersReader     // validator set relayed votes are checked against (nil relays nothing)
+	relayCh         chan *types.Vote // votes waiting to be relayed
 
 	SubProtocols []p2p.Protocol
 
@@ -80,20 +97,29 @@ type ProtocolManager struct {
 
 // NewProtocolManager returns a new kowala sub protocol manager. The Kowala sub protocol manages peers capable
 // with the kowala network.
-func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, networkID uint64, mux *event.TypeMux, txpool txPool, engine consensus.Engine, blockchain *core.BlockChain, chaindb kcoindb.Database, validator validator.Validator) (*ProtocolManager, error) {
+func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, networkID uint64, mux *event.TypeMux, txpool txPool, engine consensus.Engine, blockchain *core.BlockChain, chaindb kcoindb.Database, validator validator.Validator, whitelist map[uint64]common.Hash, broadcastPeers []discover.NodeID, consensusGossip bool) (*ProtocolManager, error) {
 	// Create the protocol manager with the base fields
 	manager := &ProtocolManager{
-		networkID:   networkID,
-		eventMux:    mux,
-		txpool:      txpool,
-		blockchain:  blockchain,
-		validator:   validator,
-		chainconfig: config,
-		peers:       newPeerSet(),
-		newPeerCh:   make(chan *peer),
-		noMorePeers: make(chan struct{}),
-		txsyncCh:    make(chan *txsync),
-		quitSync:    make(chan struct{}),
+		networkID:       networkID,
+		eventMux:        mux,
+		txpool:          txpool,
+		blockchain:      blockchain,
+		validator:       validator,
+		whitelist:       whitelist,
+		chainconfig:     config,
+		peers:           newPeerSet(),
+		consensusGossip: consensusGossip,
+		relayCh:         make(chan *types.Vote, voteRelayChanSize),
+		newPeerCh:       make(chan *peer),
+		noMorePeers:     make(chan struct{}),
+		txsyncCh:        make(chan *txsync),
+		quitSync:        make(chan struct{}),
+	}
+	if len(broadcastPeers) > 0 {
+		manager.broadcastPeers = make(map[discover.NodeID]struct{}, len(broadcastPeers))
+		for _, id := range broadcastPeers {
+			manager.broadcastPeers[id] = struct{}{}
+		}
 	}
 	// Figure out whether to allow fast sync or not
 	if mode == downloader.FastSync && blockchain.CurrentBlock().NumberU64() > 0 {
@@ -201,6 +227,10 @@ func (pm *ProtocolManager) Start(maxPeers int) {
 		pm.voteSub = pm.eventMux.Subscribe(core.NewVoteEvent{})
 		go pm.voteBroadcastLoop()
 	}
+	if pm.consensusGossip {
+		// relay votes received while not validating
+		go pm.voteRelayLoop()
+	}
 
 	// start sync handlers
 	go pm.syncer()
@@ -241,6 +271,20 @@ func (pm *ProtocolManager) newPeer(pv int, p *p2p.Peer, rw p2p.MsgReadWriter) *p
 	return newPeer(pv, p, newMeteredMsgWriter(rw))
 }
 
+// checkWhitelist returns an error if the header is at a whitelisted height but
+// belongs to a different chain than the whitelisted block.
+func (pm *ProtocolManager) checkWhitelist(header *types.Header) error {
+	want, ok := pm.whitelist[header.Number.Uint64()]
+	if !ok {
+		return nil
+	}
+	if hash := header.Hash(); hash != want {
+		return fmt.Errorf("whitelist block mismatch: have %x, want %x", hash, want)
+	}
+	log.Debug("Whitelist block verified", "number", header.Number, "hash", want)
+	return nil
+}
+
 // handle is the callback invoked to manage the life cycle of an eth peer. When
 // this function terminates, the peer is disconnected.
 func (pm *ProtocolManager) handle(p *peer) error {
@@ -279,6 +323,13 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	// after this will be sent via broadcasts.
 	pm.syncTransactions(p)
 
+	// If we have any explicit whitelist block hashes, request them
+	for number := range pm.whitelist {
+		if err := p.RequestHeadersByNumber(number, 1, 0, false); err != nil {
+			return err
+		}
+	}
+
 	// main loop. handle incoming messages.
 	for {
 		if err := pm.handleMsg(p); err != nil {
@@ -404,11 +455,19 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 
 		filter := len(headers) == 1
 		if filter {
-
-			// Irrelevant of the fork checks, send the header to the fetcher just in case
+			// If it's a whitelisted block, validate against the set
+			if err := pm.checkWhitelist(headers[0]); err != nil {
+				p.Log().Info("Whitelist mismatch, dropping peer", "number", headers[0].Number, "hash", headers[0].Hash(), "err", err)
+				return err
+			}
+		}
+		// Irrelevant of the fork checks, send the headers to the fetcher just in case,
+		// single ones and ranges it requested to catch up are kept by it
+		delivered := len(headers)
+		if delivered > 0 {
 			headers = pm.fetcher.FilterHeaders(p.id, headers, time.Now())
 		}
-		if len(headers) > 0 || !filter {
+		if len(headers) > 0 || (!filter && len(headers) == delivered) {
 			err := pm.downloader.DeliverHeaders(p.id, headers)
 			if err != nil {
 				log.Debug("Failed to deliver headers", "err", err)
@@ -569,8 +628,11 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 				unknown = append(unknown, block)
 			}
 		}
+		if pm.disk.Full() {
+			break
+		}
 		for _, block := range unknown {
-			pm.fetcher.Notify(p.id, block.Hash, block.Number, time.Now(), p.RequestOneHeader, p.RequestBodies)
+			pm.fetcher.Notify(p.id, block.Hash, block.Number, time.Now(), p.RequestOneHeader, p.RequestHeaderRange, p.RequestBodies)
 		}
 
 	case msg.Code == NewBlockMsg:
@@ -586,7 +648,9 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 
 		// Mark the peer as owning the block and schedule it for import
 		p.MarkBlock(block.Hash())
-		pm.fetcher.Enqueue(p.id, block)
+		if !pm.disk.Full() {
+			pm.fetcher.Enqueue(p.id, block)
+		}
 
 		if _, peerBlockNumber := p.Head(); block.Number().Cmp(peerBlockNumber) > 0 {
 			p.SetHead(block.Hash(), block.Number())
@@ -600,8 +664,9 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		}
 
 	case msg.Code == TxMsg:
-		// Transactions arrived, make sure we have a valid and fresh chain to handle them
-		if atomic.LoadUint32(&pm.acceptTxs) == 0 {
+		// Transactions arrived, make sure we have a valid and fresh chain and
+		// enough disk space to handle them
+		if atomic.LoadUint32(&pm.acceptTxs) == 0 || pm.disk.Full() {
 			break
 		}
 		// Transactions can be processed, parse all of them and deliver to the pool
@@ -637,7 +702,8 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		}
 
 	case msg.Code == VoteMsg:
-		if !pm.validator.Validating() {
+		validating := pm.validator.Validating()
+		if !validating && !pm.consensusGossip {
 			break
 		}
 		// Retrieve and decode the propagated vote
@@ -648,6 +714,10 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 
 		p.MarkVote(vote.Hash())
 
+		if !validating {
+			pm.relayVote(&vote)
+			break
+		}
 		if err := pm.validator.AddVote(&vote); err != nil {
 			// ignore
 			break
@@ -711,6 +781,9 @@ func (pm *ProtocolManager) BroadcastTxs(txs types.Transactions) {
 	// Broadcast transactions to a batch of peers not knowing about it
 	for _, tx := range txs {
 		peers := pm.peers.PeersWithoutTx(tx.Hash())
+		if pm.isPrivateTx(tx) {
+			peers = pm.filterBroadcastPeers(peers)
+		}
 		for _, peer := range peers {
 			txset[peer] = append(txset[peer], tx)
 		}
@@ -722,6 +795,30 @@ func (pm *ProtocolManager) BroadcastTxs(txs types.Transactions) {
 	}
 }
 
+// isPrivateTx reports whether the transaction may only be relayed to the
+// configured broadcast peers, which is the case for local transactions.
+func (pm *ProtocolManager) isPrivateTx(tx *types.Transaction) bool {
+	return len(pm.broadcastPeers) > 0 && pm.txpool.IsLocal(tx)
+}
+
+// isBroadcastPeer reports whether private transactions may be sent to the peer.
+func (pm *ProtocolManager) isBroadcastPeer(p *peer) bool {
+	_, ok := pm.broadcastPeers[p.ID()]
+	return ok
+}
+
+// filterBroadcastPeers returns the subset of peers private transactions may be
+// sent to.
+func (pm *ProtocolManager) filterBroadcastPeers(peers []*peer) []*peer {
+	filtered := peers[:0]
+	for _, p := range peers {
+		if pm.isBroadcastPeer(p) {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
+
 // Mined broadcast loop
 func (pm *ProtocolManager) minedBroadcastLoop() {
 	// automatically stops if unsubscribe
@@ -763,6 +860,49 @@ func (pm *ProtocolManager) voteBroadcastLoop() {
 	}
 }
 
+// relayVote queues a vote received while not validating to be forwarded to the
+// peers that don't know about it yet. Votes with an invalid signature, from
+// signers outside of the validator set or for heights away from the chain head
+// are dropped, as are votes arriving while the relay queue is full.
+func (pm *ProtocolManager) relayVote(vote *types.Vote) {
+	signer, err := types.VoteSender(types.NewAndromedaSigner(pm.chainconfig.ChainID), vote)
+	if err != nil {
+		log.Debug("Dropping vote with invalid signature", "hash", vote.Hash(), "err", err)
+		return
+	}
+	if pm.voters == nil {
+		return
+	}
+	if voters := pm.voters.Voters(); voters == nil || !voters.Contains(signer) {
+		log.Debug("Dropping vote from non-validator", "hash", vote.Hash(), "signer", signer)
+		return
+	}
+	next := new(big.Int).Add(pm.blockchain.CurrentBlock().Number(), common.Big1)
+	if dist := new(big.Int).Sub(vote.BlockNumber(), next); dist.CmpAbs(big.NewInt(maxVoteRelayDistance)) > 0 {
+		log.Debug("Dropping vote away from the chain head", "hash", vote.Hash(), "number", vote.BlockNumber(), "next", next)
+		return
+	}
+	select {
+	case pm.relayCh <- vote:
+	default:
+		log.Debug("Dropping vote, relay queue full", "hash", vote.Hash())
+	}
+}
+
+// Vote relay loop
+func (pm *ProtocolManager) voteRelayLoop() {
+	for {
+		select {
+		case vote := <-pm.relayCh:
+			for _, peer := range pm.peers.PeersWithoutVote(vote.Hash()) {
+				peer.SendVote(vote)
+			}
+		case <-pm.quitSync:
+			return
+		}
+	}
+}
+
 func (pm *ProtocolManager) txBroadcastLoop() {
 	for {
 		select {
@@ -0,0 +1,327 @@
+package knode
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/consensus/konsensus"
+	"github.com/kowala-tech/kcoin/client/core"
+	"github.com/kowala-tech/kcoin/client/core/types"
+	"github.com/kowala-tech/kcoin/client/core/vm"
+	"github.com/kowala-tech/kcoin/client/crypto"
+	"github.com/kowala-tech/kcoin/client/event"
+	"github.com/kowala-tech/kcoin/client/kcoindb"
+	"github.com/kowala-tech/kcoin/client/knode/validator"
+	"github.com/kowala-tech/kcoin/client/p2p"
+	"github.com/kowala-tech/kcoin/client/p2p/discover"
+	"github.com/kowala-tech/kcoin/client/params"
+	"github.com/kowala-tech/kcoin/client/rlp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtocolManagerCheckWhitelist(t *testing.T) {
+	canonical := &types.Header{Number: big.NewInt(100), Extra: []byte("canonical")}
+	fork := &types.Header{Number: big.NewInt(100), Extra: []byte("fork")}
+	other := &types.Header{Number: big.NewInt(101), Extra: []byte("fork")}
+
+	pm := &ProtocolManager{whitelist: map[uint64]common.Hash{100: canonical.Hash()}}
+
+	assert.NoError(t, pm.checkWhitelist(canonical), "agreeing chain")
+	assert.Error(t, pm.checkWhitelist(fork), "disagreeing chain")
+	assert.NoError(t, pm.checkWhitelist(other), "height not whitelisted")
+
+	pm = &ProtocolManager{}
+	assert.NoError(t, pm.checkWhitelist(fork), "no whitelist")
+}
+
+// testTxPool is a transaction pool serving a fixed set of pending transactions,
+// some of which are considered local.
+type testTxPool struct {
+	pending map[common.Address]types.Transactions
+	locals  map[common.Hash]bool
+}
+
+func (p *testTxPool) AddRemotes([]*types.Transaction) []error { return nil }
+func (p *testTxPool) IsLocal(tx *types.Transaction) bool      { return p.locals[tx.Hash()] }
+func (p *testTxPool) Pending() (map[common.Address]types.Transactions, error) {
+	return p.pending, nil
+}
+func (p *testTxPool) SubscribeNewTxsEvent(chan<- core.NewTxsEvent) event.Subscription {
+	return nil
+}
+
+// newBroadcastTest creates a protocol manager connected to three peers, only the
+// first of which is configured as broadcast peer, along with a local and a
+// remote transaction in its pool.
+func newBroadcastTest() (pm *ProtocolManager, peers []*peer, local, remote *types.Transaction) {
+	local = types.NewTransaction(0, common.Address{1}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	remote = types.NewTransaction(0, common.Address{2}, big.NewInt(1), 21000, big.NewInt(1), nil)
+
+	pool := &testTxPool{
+		pending: map[common.Address]types.Transactions{
+			{1}: {local},
+			{2}: {remote},
+		},
+		locals: map[common.Hash]bool{local.Hash(): true},
+	}
+	peers = make([]*peer, 3)
+	ids := make([]discover.NodeID, len(peers))
+	for i := range peers {
+		ids[i][0] = byte(i + 1)
+		peers[i] = newPeer(1, p2p.NewPeer(ids[i], "test", nil), nil)
+	}
+	pm = &ProtocolManager{
+		txpool:         pool,
+		peers:          newPeerSet(),
+		broadcastPeers: map[discover.NodeID]struct{}{ids[0]: {}},
+	}
+	for _, p := range peers {
+		pm.peers.peers[p.id] = p
+	}
+	return pm, peers, local, remote
+}
+
+// queuedTxs drains the transactions queued for broadcast to the peer.
+func queuedTxs(p *peer) map[common.Hash]bool {
+	txs := make(map[common.Hash]bool)
+	for {
+		select {
+		case batch := <-p.queuedTxs:
+			for _, tx := range batch {
+				txs[tx.Hash()] = true
+			}
+		default:
+			return txs
+		}
+	}
+}
+
+func TestBroadcastTxsToBroadcastPeers(t *testing.T) {
+	pm, peers, local, remote := newBroadcastTest()
+	pm.BroadcastTxs(types.Transactions{local, remote})
+
+	assert.Equal(t, map[common.Hash]bool{local.Hash(): true, remote.Hash(): true}, queuedTxs(peers[0]), "broadcast peer")
+	for _, p := range peers[1:] {
+		assert.Equal(t, map[common.Hash]bool{remote.Hash(): true}, queuedTxs(p), "other peer")
+	}
+}
+
+func TestBroadcastTxsWithoutBroadcastPeers(t *testing.T) {
+	pm, peers, local, remote := newBroadcastTest()
+	pm.broadcastPeers = nil
+	pm.BroadcastTxs(types.Transactions{local, remote})
+
+	for _, p := range peers {
+		assert.Equal(t, map[common.Hash]bool{local.Hash(): true, remote.Hash(): true}, queuedTxs(p))
+	}
+}
+
+func TestSyncTransactionsToBroadcastPeers(t *testing.T) {
+	pm, peers, _, remote := newBroadcastTest()
+	pm.txsyncCh = make(chan *txsync, 1)
+
+	pm.syncTransactions(peers[0])
+	sync := <-pm.txsyncCh
+	assert.Len(t, sync.txs, 2, "broadcast peer")
+
+	pm.syncTransactions(peers[1])
+	sync = <-pm.txsyncCh
+	assert.Equal(t, []*types.Transaction{remote}, sync.txs, "other peer")
+}
+
+// voteValidator is a validator that only records the votes it's handed.
+type voteValidator struct {
+	validator.Validator
+	validating bool
+	votes      []*types.Vote
+}
+
+func (v *voteValidator) Validating() bool { return v.validating }
+func (v *voteValidator) AddVote(vote *types.Vote) error {
+	v.votes = append(v.votes, vote)
+	return nil
+}
+
+// testMsgRW is a message stream that serves queued messages and records the
+// codes of the ones written to it.
+type testMsgRW struct {
+	in      []p2p.Msg
+	written []uint64
+}
+
+func (rw *testMsgRW) ReadMsg() (p2p.Msg, error) {
+	if len(rw.in) == 0 {
+		return p2p.Msg{}, errors.New("no more messages")
+	}
+	msg := rw.in[0]
+	rw.in = rw.in[1:]
+	return msg, nil
+}
+
+func (rw *testMsgRW) WriteMsg(msg p2p.Msg) error {
+	rw.written = append(rw.written, msg.Code)
+	return msg.Discard()
+}
+
+// testVoters is a fixed validator set.
+type testVoters struct {
+	voters types.Voters
+}
+
+func (v *testVoters) Voters() types.Voters { return v.voters }
+
+// voteTest configures the vote delivered by handleVote.
+type voteTest struct {
+	validating bool
+	gossip     bool
+	number     int64 // Height the vote is for, on top of a genesis only chain
+	nonVoter   bool  // Whether the vote is signed by a key outside the validator set
+}
+
+// handleVote delivers a signed vote from the first of three peers to a
+// protocol manager, returning the protocol manager, the validator and the
+// streams of all peers.
+func handleVote(t *testing.T, test voteTest) (*ProtocolManager, *voteValidator, *types.Vote, []*testMsgRW) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	voterKey := key
+	if test.nonVoter {
+		voterKey, err = crypto.GenerateKey()
+		require.NoError(t, err)
+	}
+	voters, err := types.NewVoters([]*types.Voter{types.NewVoter(crypto.PubkeyToAddress(voterKey.PublicKey), big.NewInt(1), big.NewInt(0))})
+	require.NoError(t, err)
+	vote, err := types.SignVote(types.NewVote(big.NewInt(test.number), common.Hash{1}, 0, types.PreVote), types.NewAndromedaSigner(params.TestChainConfig.ChainID), key)
+	require.NoError(t, err)
+
+	db := kcoindb.NewMemDatabase()
+	(&core.Genesis{Config: params.TestChainConfig}).MustCommit(db)
+	chain, err := core.NewBlockChain(db, nil, params.TestChainConfig, konsensus.NewFaker(), vm.Config{})
+	require.NoError(t, err)
+	defer chain.Stop()
+
+	val := &voteValidator{validating: test.validating}
+	pm := &ProtocolManager{
+		chainconfig:     params.TestChainConfig,
+		blockchain:      chain,
+		validator:       val,
+		peers:           newPeerSet(),
+		consensusGossip: test.gossip,
+		voters:          &testVoters{voters: voters},
+		relayCh:         make(chan *types.Vote, voteRelayChanSize),
+		quitSync:        make(chan struct{}),
+	}
+	rws := make([]*testMsgRW, 3)
+	peers := make([]*peer, len(rws))
+	for i := range rws {
+		var id discover.NodeID
+		id[0] = byte(i + 1)
+		rws[i] = new(testMsgRW)
+		peers[i] = newPeer(1, p2p.NewPeer(id, "test", nil), rws[i])
+		pm.peers.peers[peers[i].id] = peers[i]
+	}
+	size, payload, err := rlp.EncodeToReader(vote)
+	require.NoError(t, err)
+	rws[0].in = []p2p.Msg{{Code: VoteMsg, Size: uint32(size), Payload: payload}}
+
+	require.NoError(t, pm.handleMsg(peers[0]))
+	return pm, val, vote, rws
+}
+
+func TestHandleVoteQueuedWithGossip(t *testing.T) {
+	pm, val, vote, rws := handleVote(t, voteTest{gossip: true, number: 1})
+
+	assert.Empty(t, val.votes, "not validating")
+	if assert.Len(t, pm.relayCh, 1) {
+		assert.Equal(t, vote.Hash(), (<-pm.relayCh).Hash())
+	}
+	// Votes are sent by the relay loop
+	for _, rw := range rws {
+		assert.Empty(t, rw.written)
+	}
+}
+
+func TestHandleVoteNotRelayedWithoutGossip(t *testing.T) {
+	pm, val, _, _ := handleVote(t, voteTest{number: 1})
+
+	assert.Empty(t, val.votes, "not validating")
+	assert.Empty(t, pm.relayCh)
+}
+
+func TestHandleVoteNotRelayedFromNonValidator(t *testing.T) {
+	pm, _, _, _ := handleVote(t, voteTest{gossip: true, number: 1, nonVoter: true})
+	assert.Empty(t, pm.relayCh)
+}
+
+func TestHandleVoteNotRelayedAwayFromHead(t *testing.T) {
+	pm, _, _, _ := handleVote(t, voteTest{gossip: true, number: 1 + maxVoteRelayDistance})
+	assert.Len(t, pm.relayCh, 1, "within distance")
+
+	pm, _, _, _ = handleVote(t, voteTest{gossip: true, number: 2 + maxVoteRelayDistance})
+	assert.Empty(t, pm.relayCh, "too far ahead")
+}
+
+func TestHandleVoteDroppedWhileRelayQueueFull(t *testing.T) {
+	pm, _, vote, _ := handleVote(t, voteTest{gossip: true, number: 1})
+	for len(pm.relayCh) < cap(pm.relayCh) {
+		pm.relayCh <- vote
+	}
+
+	// A full queue doesn't block the peer handler
+	pm.relayVote(vote)
+	assert.Len(t, pm.relayCh, voteRelayChanSize)
+}
+
+func TestHandleVoteValidating(t *testing.T) {
+	for _, gossip := range []bool{false, true} {
+		pm, val, vote, rws := handleVote(t, voteTest{validating: true, gossip: gossip, number: 1})
+
+		if assert.Len(t, val.votes, 1, "gossip %v", gossip) {
+			assert.Equal(t, vote.Hash(), val.votes[0].Hash())
+		}
+		// Validators broadcast votes once accepted by the voting system
+		assert.Empty(t, pm.relayCh, "gossip %v", gossip)
+		for _, rw := range rws {
+			assert.Empty(t, rw.written, "gossip %v", gossip)
+		}
+	}
+}
+
+func TestVoteRelayLoop(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	vote, err := types.SignVote(types.NewVote(big.NewInt(1), common.Hash{1}, 0, types.PreVote), types.NewAndromedaSigner(params.TestChainConfig.ChainID), key)
+	require.NoError(t, err)
+
+	pm := &ProtocolManager{
+		peers:    newPeerSet(),
+		relayCh:  make(chan *types.Vote),
+		quitSync: make(chan struct{}),
+	}
+	rws := make([]*testMsgRW, 2)
+	peers := make([]*peer, len(rws))
+	for i := range rws {
+		var id discover.NodeID
+		id[0] = byte(i + 1)
+		rws[i] = new(testMsgRW)
+		peers[i] = newPeer(1, p2p.NewPeer(id, "test", nil), rws[i])
+		pm.peers.peers[peers[i].id] = peers[i]
+	}
+	peers[0].MarkVote(vote.Hash())
+
+	done := make(chan struct{})
+	go func() {
+		pm.voteRelayLoop()
+		close(done)
+	}()
+	pm.relayCh <- vote
+	pm.relayCh <- vote // Only received once the first vote has been relayed
+	close(pm.quitSync)
+	<-done
+
+	assert.Empty(t, rws[0].written, "peer knowing the vote")
+	assert.Equal(t, []uint64{VoteMsg}, rws[1].written, "other peer")
+}
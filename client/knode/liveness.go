@@ -0,0 +1,88 @@
+package knode
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kowala-tech/kcoin/client/core"
+	"github.com/kowala-tech/kcoin/client/event"
+	"github.com/kowala-tech/kcoin/client/log"
+)
+
+// livenessWatchdog follows the chain head and reports a stall whenever no new
+// block is imported within the timeout. While the stall lasts the error is
+// repeated every timeout period.
+type livenessWatchdog struct {
+	chain   chainHeadSubscriber
+	timeout time.Duration
+	stalled int32 // Flag whether block production is currently stalled (atomic)
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newLivenessWatchdog(chain chainHeadSubscriber, timeout time.Duration) *livenessWatchdog {
+	return &livenessWatchdog{
+		chain:   chain,
+		timeout: timeout,
+		quit:    make(chan struct{}),
+	}
+}
+
+// start begins following the chain head.
+func (w *livenessWatchdog) start() {
+	headCh := make(chan core.ChainHeadEvent, 10)
+	sub := w.chain.SubscribeChainHeadEvent(headCh)
+
+	w.wg.Add(1)
+	go w.loop(headCh, sub)
+}
+
+// stop terminates the watchdog.
+func (w *livenessWatchdog) stop() {
+	close(w.quit)
+	w.wg.Wait()
+}
+
+// Stalled reports whether block production is currently considered stalled.
+func (w *livenessWatchdog) Stalled() bool {
+	return atomic.LoadInt32(&w.stalled) == 1
+}
+
+func (w *livenessWatchdog) loop(headCh chan core.ChainHeadEvent, sub event.Subscription) {
+	defer w.wg.Done()
+	defer sub.Unsubscribe()
+
+	last := time.Now()
+	timer := time.NewTimer(w.timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case ev := <-headCh:
+			if atomic.CompareAndSwapInt32(&w.stalled, 1, 0) {
+				livenessStalledGauge.Update(0)
+				log.Info("Block production resumed", "number", ev.Block.Number(), "stalled", time.Since(last))
+			}
+			last = time.Now()
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(w.timeout)
+
+		case <-timer.C:
+			if atomic.CompareAndSwapInt32(&w.stalled, 0, 1) {
+				livenessStalledGauge.Update(1)
+				livenessStallCounter.Inc(1)
+			}
+			log.Error("Block production stalled", "since", last, "timeout", w.timeout)
+			timer.Reset(w.timeout)
+
+		case <-sub.Err():
+			return
+		case <-w.quit:
+			return
+		}
+	}
+}
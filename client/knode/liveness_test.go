@@ -0,0 +1,42 @@
+package knode
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/kowala-tech/kcoin/client/core"
+	"github.com/kowala-tech/kcoin/client/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLivenessWatchdog(t *testing.T) {
+	chain := new(testChainHeads)
+	watchdog := newLivenessWatchdog(chain, 50*time.Millisecond)
+	watchdog.start()
+	defer watchdog.stop()
+
+	// Blocks arriving within the timeout keep the watchdog quiet
+	for i := int64(1); i <= 5; i++ {
+		time.Sleep(20 * time.Millisecond)
+		chain.feed.Send(core.ChainHeadEvent{Block: types.NewBlockWithHeader(&types.Header{Number: big.NewInt(i)})})
+		assert.False(t, watchdog.Stalled(), "block %d", i)
+	}
+
+	// A stalled feed fires the watchdog
+	waitLiveness(t, watchdog, true)
+
+	// A new block clears the stall
+	chain.feed.Send(core.ChainHeadEvent{Block: types.NewBlockWithHeader(&types.Header{Number: big.NewInt(6)})})
+	waitLiveness(t, watchdog, false)
+}
+
+// waitLiveness waits for the watchdog to report the given stall state.
+func waitLiveness(t *testing.T, watchdog *livenessWatchdog, stalled bool) {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if watchdog.Stalled() == stalled {
+			return
+		}
+	}
+	t.Fatalf("watchdog stall state mismatch: have %v, want %v", !stalled, stalled)
+}
@@ -40,6 +40,27 @@ var (
 	miscOutTrafficMeter       = metrics.NewRegisteredMeter("eth/misc/out/traffic", nil)
 )
 
+var (
+	// livenessStalledGauge is 1 while no block has been imported within the
+	// liveness timeout, and 0 otherwise
+	livenessStalledGauge = metrics.NewRegisteredGauge("knode/liveness/stalled", nil)
+
+	// livenessStallCounter counts the stalls detected by the liveness watchdog
+	livenessStallCounter = metrics.NewRegisteredCounter("knode/liveness/stalls", nil)
+
+	// diskFullGauge is 1 while the free disk space is below the minimum, and 0
+	// otherwise
+	diskFullGauge = metrics.NewRegisteredGauge("knode/disk/full", nil)
+
+	// oraclePriceAgeGauge is the number of seconds since the oracles last
+	// submitted a price, once one was submitted after startup
+	oraclePriceAgeGauge = metrics.NewRegisteredGauge("knode/oracle/age", nil)
+
+	// oraclePriceStaleGauge is 1 while the last price submitted by the oracles
+	// is older than the configured maximum age, and 0 otherwise
+	oraclePriceStaleGauge = metrics.NewRegisteredGauge("knode/oracle/stale", nil)
+)
+
 // meteredMsgReadWriter is a wrapper around a p2p.MsgReadWriter, capable of
 // accumulating the above defined metrics based on the data stream contents.
 type meteredMsgReadWriter struct {
@@ -335,6 +335,13 @@ func (p *peer) RequestOneHeader(hash common.Hash) error {
 	return p2p.Send(p.rw, GetBlockHeadersMsg, &getBlockHeadersData{Origin: hashOrNumber{Hash: hash}, Amount: uint64(1), Skip: uint64(0), Reverse: false})
 }
 
+// RequestHeaderRange is a wrapper around the header query functions to fetch a
+// range of consecutive headers. It is used solely by the fetcher.
+func (p *peer) RequestHeaderRange(origin uint64, amount int) error {
+	p.Log().Debug("Fetching header range", "count", amount, "fromnum", origin)
+	return p2p.Send(p.rw, GetBlockHeadersMsg, &getBlockHeadersData{Origin: hashOrNumber{Number: origin}, Amount: uint64(amount), Skip: uint64(0), Reverse: false})
+}
+
 // RequestHeadersByHash fetches a batch of blocks' headers corresponding to the
 // specified header query, based on the hash of an origin block.
 func (p *peer) RequestHeadersByHash(origin common.Hash, amount int, skip int, reverse bool) error {
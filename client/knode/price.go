@@ -0,0 +1,183 @@
+package knode
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/core"
+	"github.com/kowala-tech/kcoin/client/core/types"
+	"github.com/kowala-tech/kcoin/client/event"
+	"github.com/kowala-tech/kcoin/client/log"
+)
+
+// errNoPrice is returned if the oracles did not submit any price yet.
+var errNoPrice = errors.New("no price submitted by the oracles")
+
+// priceCheckInterval is the interval at which the age of the price is checked.
+const priceCheckInterval = time.Minute
+
+// priceChain is the part of the blockchain the price tracker follows.
+type priceChain interface {
+	chainHeadSubscriber
+	CurrentBlock() *types.Block
+}
+
+// priceSource provides the prices submitted by the oracles at the head of the
+// chain.
+type priceSource interface {
+	LatestPrice() (*big.Int, uint64, error)
+}
+
+// priceTracker follows the chain head and records the last price submitted by
+// the oracles along with the time of the block that included it. If a maximum
+// age is set, a warning is logged at every check while the price is older.
+// The block which included the price found at startup is unknown, so that
+// price is considered stale until the oracles submit a new one.
+type priceTracker struct {
+	chain  priceChain
+	source priceSource
+	maxAge time.Duration // maximum age of the price before it's stale (0 = unlimited)
+
+	loaded  bool      // whether the price found at startup was loaded
+	count   uint64    // number of prices submitted at the last update
+	price   *big.Int  // last price submitted
+	updated time.Time // time of the block which included the last price (zero = unknown)
+	stale   bool      // whether the price was stale at the last check
+	lock    sync.RWMutex
+
+	checkInterval time.Duration
+	log           log.Logger
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newPriceTracker(chain priceChain, source priceSource, maxAge time.Duration) *priceTracker {
+	return &priceTracker{
+		chain:         chain,
+		source:        source,
+		maxAge:        maxAge,
+		checkInterval: priceCheckInterval,
+		log:           log.New(),
+		quit:          make(chan struct{}),
+	}
+}
+
+// start loads the current price and begins following the chain head.
+func (t *priceTracker) start() {
+	t.update(t.chain.CurrentBlock())
+
+	headCh := make(chan core.ChainHeadEvent, 10)
+	sub := t.chain.SubscribeChainHeadEvent(headCh)
+
+	t.wg.Add(1)
+	go t.loop(headCh, sub)
+}
+
+// stop terminates the tracker.
+func (t *priceTracker) stop() {
+	close(t.quit)
+	t.wg.Wait()
+}
+
+func (t *priceTracker) loop(headCh chan core.ChainHeadEvent, sub event.Subscription) {
+	defer t.wg.Done()
+	defer sub.Unsubscribe()
+
+	var checkCh <-chan time.Time
+	if t.maxAge > 0 {
+		ticker := time.NewTicker(t.checkInterval)
+		defer ticker.Stop()
+		checkCh = ticker.C
+	}
+
+	for {
+		select {
+		case ev := <-headCh:
+			t.update(ev.Block)
+		case <-checkCh:
+			t.check()
+		case <-sub.Err():
+			return
+		case <-t.quit:
+			return
+		}
+	}
+}
+
+// update reloads the last price after the given block was applied and records
+// the block time if a new price was submitted. The first price loaded is kept
+// without a time, as it may have been submitted by any earlier block.
+func (t *priceTracker) update(block *types.Block) {
+	price, count, err := t.source.LatestPrice()
+	if err != nil {
+		t.log.Debug("Failed to load the oracle price", "number", block.Number(), "err", err)
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.loaded && count == t.count {
+		return
+	}
+	updated := time.Unix(block.Time().Int64(), 0)
+	if !t.loaded {
+		t.loaded, updated = true, time.Time{}
+	}
+	if count == 0 {
+		return
+	}
+	t.log.Debug("Oracle price updated", "number", block.Number(), "price", price)
+	t.count, t.price, t.updated = count, price, updated
+}
+
+// check reports the age of the price and warns if it's older than the maximum
+// age.
+func (t *priceTracker) check() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.price == nil {
+		return
+	}
+	known := !t.updated.IsZero()
+	var age time.Duration
+	if known {
+		age = time.Since(t.updated)
+		oraclePriceAgeGauge.Update(int64(age / time.Second))
+	}
+
+	if !known || age > t.maxAge {
+		if !t.stale {
+			t.stale = true
+			oraclePriceStaleGauge.Update(1)
+		}
+		if !known {
+			t.log.Warn("Oracle price is stale", "updated", "before startup", "maxage", t.maxAge)
+			return
+		}
+		t.log.Warn("Oracle price is stale", "updated", t.updated, "age", common.PrettyDuration(age), "maxage", t.maxAge)
+		return
+	}
+	if t.stale {
+		t.stale = false
+		oraclePriceStaleGauge.Update(0)
+		t.log.Info("Oracle price is up to date again", "updated", t.updated)
+	}
+}
+
+// Latest returns the last price submitted by the oracles and the time it was
+// included in the chain, which is zero for a price submitted before startup.
+func (t *priceTracker) Latest() (*big.Int, time.Time, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.price == nil {
+		return nil, time.Time{}, errNoPrice
+	}
+	return new(big.Int).Set(t.price), t.updated, nil
+}
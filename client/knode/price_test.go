@@ -0,0 +1,162 @@
+package knode
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kowala-tech/kcoin/client/core/types"
+	"github.com/kowala-tech/kcoin/client/log"
+	"github.com/kowala-tech/kcoin/client/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testPriceChain struct {
+	testChainHeads
+	head *types.Block
+}
+
+func (c *testPriceChain) CurrentBlock() *types.Block {
+	return c.head
+}
+
+type testPriceSource struct {
+	price *big.Int
+	count uint64
+}
+
+func (s *testPriceSource) LatestPrice() (*big.Int, uint64, error) {
+	return s.price, s.count, nil
+}
+
+func newTestPriceBlock(number, time int64) *types.Block {
+	return types.NewBlockWithHeader(&types.Header{Number: big.NewInt(number), Time: big.NewInt(time)})
+}
+
+func TestPriceTracker(t *testing.T) {
+	chain := &testPriceChain{head: newTestPriceBlock(1, 100)}
+	source := new(testPriceSource)
+	tracker := newPriceTracker(chain, source, 0)
+	tracker.start()
+	defer tracker.stop()
+
+	_, _, err := tracker.Latest()
+	assert.Equal(t, errNoPrice, err)
+
+	steps := []struct {
+		price   int64
+		count   uint64
+		time    int64
+		updated int64
+	}{
+		{price: 2, count: 1, time: 110, updated: 110},
+		{price: 2, count: 1, time: 120, updated: 110},
+		{price: 2, count: 2, time: 130, updated: 130},
+		{price: 3, count: 3, time: 140, updated: 140},
+	}
+	for i, step := range steps {
+		source.price, source.count = big.NewInt(step.price), step.count
+		tracker.update(newTestPriceBlock(int64(i+2), step.time))
+
+		price, updated, err := tracker.Latest()
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(step.price), price, "step %d", i)
+		assert.Equal(t, time.Unix(step.updated, 0), updated, "step %d", i)
+	}
+}
+
+// testPriceLog records the messages logged by a price tracker.
+type testPriceLog struct {
+	lock     sync.Mutex
+	messages []string
+}
+
+func (l *testPriceLog) handler() log.Handler {
+	return log.FuncHandler(func(r *log.Record) error {
+		l.lock.Lock()
+		defer l.lock.Unlock()
+		l.messages = append(l.messages, r.Msg)
+		return nil
+	})
+}
+
+func (l *testPriceLog) count(msg string) int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	n := 0
+	for _, m := range l.messages {
+		if m == msg {
+			n++
+		}
+	}
+	return n
+}
+
+func TestPriceTrackerStaleness(t *testing.T) {
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = false }()
+
+	oldAge, oldStale := oraclePriceAgeGauge, oraclePriceStaleGauge
+	defer func() { oraclePriceAgeGauge, oraclePriceStaleGauge = oldAge, oldStale }()
+	oraclePriceAgeGauge, oraclePriceStaleGauge = metrics.NewGauge(), metrics.NewGauge()
+
+	now := time.Now()
+	chain := &testPriceChain{head: newTestPriceBlock(1, now.Add(-30*time.Minute).Unix())}
+	source := &testPriceSource{price: big.NewInt(2), count: 1}
+	tracker := newPriceTracker(chain, source, time.Hour)
+	logs := new(testPriceLog)
+	tracker.log = log.New()
+	tracker.log.SetHandler(logs.handler())
+	tracker.update(chain.head)
+
+	// The price found at startup is stale, as its age is unknown
+	tracker.check()
+	assert.Equal(t, int64(1), oraclePriceStaleGauge.Value())
+	assert.Equal(t, 1, logs.count("Oracle price is stale"))
+
+	// A price younger than the maximum age is fine
+	source.count++
+	tracker.update(newTestPriceBlock(2, now.Add(-30*time.Minute).Unix()))
+	tracker.check()
+	assert.Equal(t, int64(0), oraclePriceStaleGauge.Value())
+	assert.InDelta(t, int64(30*60), oraclePriceAgeGauge.Value(), 5)
+	assert.Equal(t, 1, logs.count("Oracle price is stale"))
+	assert.Equal(t, 1, logs.count("Oracle price is up to date again"))
+
+	// Crossing the maximum age flags the price as stale and warns at every check
+	tracker.updated = now.Add(-2 * time.Hour)
+	tracker.check()
+	tracker.check()
+	assert.Equal(t, int64(1), oraclePriceStaleGauge.Value())
+	assert.InDelta(t, int64(2*60*60), oraclePriceAgeGauge.Value(), 5)
+	assert.Equal(t, 3, logs.count("Oracle price is stale"))
+
+	// A new price clears the warning
+	source.count++
+	tracker.update(newTestPriceBlock(3, now.Unix()))
+	tracker.check()
+	assert.Equal(t, int64(0), oraclePriceStaleGauge.Value())
+	assert.Equal(t, 3, logs.count("Oracle price is stale"))
+	assert.Equal(t, 2, logs.count("Oracle price is up to date again"))
+}
+
+func TestPriceTrackerPeriodicCheck(t *testing.T) {
+	chain := &testPriceChain{head: newTestPriceBlock(1, time.Now().Add(-time.Hour).Unix())}
+	tracker := newPriceTracker(chain, &testPriceSource{price: big.NewInt(2), count: 1}, time.Minute)
+	tracker.checkInterval = 10 * time.Millisecond
+	logs := new(testPriceLog)
+	tracker.log = log.New()
+	tracker.log.SetHandler(logs.handler())
+	tracker.start()
+	defer tracker.stop()
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if logs.count("Oracle price is stale") >= 2 {
+			return
+		}
+	}
+	t.Fatalf("stale price warnings mismatch: have %d, want at least 2", logs.count("Oracle price is stale"))
+}
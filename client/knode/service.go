@@ -74,6 +74,12 @@ type Kowala struct {
 	validator validator.Validator // consensus validator
 
 	consensus *consensus.Consensus
+	voters    *votersTracker    // notifies changes of the validator set
+	liveness  *livenessWatchdog // reports stalled block production (nil if disabled)
+	disk      *diskMonitor      // pauses imports while the disk is nearly full (nil if disabled)
+	evidence  *evidencePool     // collects the evidence of double signing validators
+	uptime    *uptimeTracker    // measures the participation of the validators
+	prices    *priceTracker     // follows the price submitted by the oracles
 
 	bindingFuncs []BindingConstructor // binding constructors (in dependency order)
 	contracts    map[reflect.Type]bindings.Binding
@@ -98,6 +104,9 @@ func New(ctx *node.ServiceContext, config *Config) (*Kowala, error) {
 	if !config.SyncMode.IsValid() {
 		return nil, fmt.Errorf("invalid sync mode %d", config.SyncMode)
 	}
+	if err := config.Konsensus.Validate(); err != nil {
+		return nil, err
+	}
 	chainDb, err := CreateDB(ctx, config, "chaindata")
 	if err != nil {
 		return nil, err
@@ -106,6 +115,13 @@ func New(ctx *node.ServiceContext, config *Config) (*Kowala, error) {
 	if _, ok := genesisErr.(*params.ConfigCompatError); genesisErr != nil && !ok {
 		return nil, genesisErr
 	}
+	if config.ChainID != nil {
+		if core.IsPublicGenesisHash(genesisHash) {
+			return nil, fmt.Errorf("can't override the chain ID of the public network with genesis %x", genesisHash)
+		}
+		log.Warn("Overriding the chain ID of the genesis", "genesis", chainConfig.ChainID, "chainid", config.ChainID)
+		chainConfig = chainConfig.WithChainID(config.ChainID)
+	}
 	log.Info("Initialised chain configuration", "config", chainConfig)
 
 	kcoin := &Kowala{
@@ -151,6 +167,11 @@ func New(ctx *node.ServiceContext, config *Config) (*Kowala, error) {
 	if err != nil {
 		return nil, err
 	}
+	if config.VerifyState {
+		if err := kcoin.blockchain.VerifyState(); err != nil {
+			return nil, fmt.Errorf("head state verification failed, resync the node: %v", err)
+		}
+	}
 
 	for _, constructor := range kcoin.bindingFuncs {
 		contract, err := constructor(NewContractBackend(kcoin.apiBackend), kcoin.chainConfig.ChainID)
@@ -178,6 +199,16 @@ func New(ctx *node.ServiceContext, config *Config) (*Kowala, error) {
 	if err := kcoin.Contract(&kcoin.consensus); err != nil {
 		return nil, err
 	}
+	kcoin.voters = newVotersTracker(kcoin.blockchain, kcoin.consensus)
+	kcoin.evidence = newEvidencePool(types.NewAndromedaSigner(chainConfig.ChainID), kcoin.voters)
+	kcoin.uptime = newUptimeTracker(kcoin.blockchain, kcoin.consensus, types.NewAndromedaSigner(chainConfig.ChainID))
+	kcoin.prices = newPriceTracker(kcoin.blockchain, oracleMgr, config.PriceMaxAge)
+	if config.LivenessTimeout > 0 {
+		kcoin.liveness = newLivenessWatchdog(kcoin.blockchain, config.LivenessTimeout)
+	}
+	if path := ctx.ResolvePath("chaindata"); config.DiskMinFree > 0 && path != "" {
+		kcoin.disk = newDiskMonitor(path, config.DiskMinFree*1024*1024, freeDiskSpace, diskCheckInterval)
+	}
 
 	// Rewind the chain in case of an incompatible config upgrade.
 	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
@@ -198,12 +229,20 @@ func New(ctx *node.ServiceContext, config *Config) (*Kowala, error) {
 	}
 	kcoin.apiBackend.gpo = gasprice.NewOracle(kcoin.apiBackend, gpoParams)
 
-	kcoin.validator = validator.New(kcoin, kcoin.consensus, kcoin.chainConfig, kcoin.EventMux(), kcoin.engine, vmConfig)
+	kcoin.validator = validator.New(kcoin, kcoin.consensus, kcoin.chainConfig, &config.Konsensus, kcoin.EventMux(), kcoin.engine, vmConfig)
 	kcoin.validator.SetExtra(makeExtraData(config.ExtraData))
+	kcoin.validator.SetRandomExtra(config.RandomExtraData)
+	kcoin.validator.SetEmptyBlocks(config.EmptyBlocks, config.EmptyBlocksTimeout)
+	kcoin.validator.SetMaxBlockTxs(config.MaxBlockTxs)
+	kcoin.validator.SetPriorityAddresses(config.PriorityAddresses)
 
-	if kcoin.protocolManager, err = NewProtocolManager(kcoin.chainConfig, config.SyncMode, config.NetworkId, kcoin.eventMux, kcoin.txPool, kcoin.engine, kcoin.blockchain, chainDb, kcoin.validator); err != nil {
+	if kcoin.protocolManager, err = NewProtocolManager(kcoin.chainConfig, config.SyncMode, config.NetworkId, kcoin.eventMux, kcoin.txPool, kcoin.engine, kcoin.blockchain, chainDb, kcoin.validator, config.Whitelist, config.TxBroadcastPeers, config.ConsensusGossip); err != nil {
 		return nil, err
 	}
+	kcoin.protocolManager.voters = kcoin.voters
+	if kcoin.disk != nil {
+		kcoin.disk.attach(kcoin.protocolManager, kcoin.validator)
+	}
 
 	kcoin.serverPool = newServerPool(chainDb, kcoin.shutdownChan, new(sync.WaitGroup))
 
@@ -241,7 +280,7 @@ func CreateDB(ctx *node.ServiceContext, config *Config, name string) (kcoindb.Da
 
 // CreateConsensusEngine creates the required type of consensus engine instance for an Kowala service
 func CreateConsensusEngine(ctx *node.ServiceContext, config *Config, chainConfig *params.ChainConfig, db kcoindb.Database) engine.Engine {
-	engine := konsensus.New(&params.KonsensusConfig{})
+	engine := konsensus.New(&config.Konsensus)
 	return engine
 }
 
@@ -270,11 +309,31 @@ func (s *Kowala) APIs() []rpc.API {
 			Version:   "1.0",
 			Service:   NewPrivateValidatorAPI(s),
 			Public:    false,
+		}, {
+			Namespace: "validator",
+			Version:   "1.0",
+			Service:   NewPublicValidatorAPI(s),
+			Public:    true,
+		}, {
+			Namespace: "consensus",
+			Version:   "1.0",
+			Service:   NewPublicConsensusAPI(s),
+			Public:    true,
+		}, {
+			Namespace: "currency",
+			Version:   "1.0",
+			Service:   NewPublicCurrencyAPI(s),
+			Public:    true,
 		}, {
 			Namespace: "mtoken",
 			Version:   "1.0",
 			Service:   NewPublicTokenAPI(s.accountManager, s.consensus, s.chainConfig.ChainID),
 			Public:    false,
+		}, {
+			Namespace: "oracle",
+			Version:   "1.0",
+			Service:   NewPrivateOracleAPI(s),
+			Public:    false,
 		}, {
 			Namespace: "eth",
 			Version:   "1.0",
@@ -419,6 +478,13 @@ func (s *Kowala) Consensus() *consensus.Consensus    { return s.consensus }
 func (s *Kowala) APIBackend() *KowalaAPIBackend      { return s.apiBackend }
 func (s *Kowala) ChainConfig() *params.ChainConfig   { return s.chainConfig }
 
+// SubscribeValidatorSetChangedEvent registers a subscription of
+// ValidatorSetChangedEvent, posted whenever an applied block changes the
+// validator set.
+func (s *Kowala) SubscribeValidatorSetChangedEvent(ch chan<- core.ValidatorSetChangedEvent) event.Subscription {
+	return s.voters.SubscribeValidatorSetChangedEvent(ch)
+}
+
 func (s *Kowala) Contract(contract interface{}) error {
 	element := reflect.ValueOf(contract).Elem()
 	if c, ok := s.contracts[element.Type()]; ok {
@@ -455,6 +521,18 @@ func (s *Kowala) Start(srvr *p2p.Server) error {
 	// Start the bloom bits servicing goroutines
 	s.startBloomHandlers()
 
+	// Start following the validator set
+	s.voters.start()
+	s.evidence.start(s.eventMux)
+	s.uptime.start()
+	s.prices.start()
+	if s.liveness != nil {
+		s.liveness.start()
+	}
+	if s.disk != nil {
+		s.disk.start()
+	}
+
 	// Start the RPC service
 	s.netRPCService = kcoinapi.NewPublicNetAPI(srvr, s.NetVersion())
 
@@ -497,6 +575,16 @@ func (s *Kowala) Stop() error {
 	// otherwise it might not be able to finish an election and
 	// could be punished
 	s.StopValidating()
+	s.voters.stop()
+	s.evidence.stop()
+	s.uptime.stop()
+	s.prices.stop()
+	if s.liveness != nil {
+		s.liveness.stop()
+	}
+	if s.disk != nil {
+		s.disk.stop()
+	}
 	s.bloomIndexer.Close()
 	s.blockchain.Stop()
 	s.protocolManager.Stop()
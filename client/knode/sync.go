@@ -31,7 +31,12 @@ func (pm *ProtocolManager) syncTransactions(p *peer) {
 	var txs types.Transactions
 	pending, _ := pm.txpool.Pending()
 	for _, batch := range pending {
-		txs = append(txs, batch...)
+		for _, tx := range batch {
+			if pm.isPrivateTx(tx) && !pm.isBroadcastPeer(p) {
+				continue
+			}
+			txs = append(txs, tx)
+		}
 	}
 	if len(txs) == 0 {
 		return
@@ -146,6 +151,10 @@ func (pm *ProtocolManager) syncer() {
 
 // synchronise tries to sync up our local block chain with a remote peer.
 func (pm *ProtocolManager) synchronise(peer *peer) {
+	// Don't import any blocks while the disk is nearly full
+	if pm.disk.Full() {
+		return
+	}
 	// Short circuit if no peers are available
 	if peer == nil {
 		// @NOTE (rgeraldes) we are using the forced sync to allow the validator to start the validation independently
@@ -0,0 +1,169 @@
+package knode
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+
+	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/core"
+	"github.com/kowala-tech/kcoin/client/core/types"
+	"github.com/kowala-tech/kcoin/client/event"
+	"github.com/kowala-tech/kcoin/client/log"
+)
+
+// uptimeWindow is the number of recent blocks the validator participation is
+// measured over.
+const uptimeWindow = 1000
+
+// validatorUptime is the participation of a validator over the recent blocks.
+type validatorUptime struct {
+	Address  common.Address `json:"address"`
+	Signed   uint64         `json:"signed"`   // blocks the validator pre-committed
+	Expected uint64         `json:"expected"` // blocks the validator was part of the set for
+	Uptime   float64        `json:"uptime"`   // percentage of expected blocks signed
+}
+
+// uptimeRecord holds the participation in the commit of a single block.
+type uptimeRecord struct {
+	number   uint64
+	expected []common.Address
+	signed   map[common.Address]bool
+}
+
+// uptimeTracker follows the chain head and records, for every block committed
+// on chain, which validators pre-committed it out of the ones expected to.
+type uptimeTracker struct {
+	chain  chainHeadSubscriber
+	source votersSource
+	signer types.Signer
+	window int
+
+	mu      sync.RWMutex
+	records []*uptimeRecord // oldest first
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newUptimeTracker(chain chainHeadSubscriber, source votersSource, signer types.Signer) *uptimeTracker {
+	return &uptimeTracker{
+		chain:  chain,
+		source: source,
+		signer: signer,
+		window: uptimeWindow,
+		quit:   make(chan struct{}),
+	}
+}
+
+// start begins following the chain head.
+func (t *uptimeTracker) start() {
+	headCh := make(chan core.ChainHeadEvent, 10)
+	sub := t.chain.SubscribeChainHeadEvent(headCh)
+
+	t.wg.Add(1)
+	go t.loop(headCh, sub)
+}
+
+// stop terminates the tracker.
+func (t *uptimeTracker) stop() {
+	close(t.quit)
+	t.wg.Wait()
+}
+
+func (t *uptimeTracker) loop(headCh chan core.ChainHeadEvent, sub event.Subscription) {
+	defer t.wg.Done()
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-headCh:
+			t.update(ev.Block)
+		case <-sub.Err():
+			return
+		case <-t.quit:
+			return
+		}
+	}
+}
+
+// update records the participation in the commit of the parent of the given
+// block, as recorded by the block itself. The genesis block isn't voted on.
+func (t *uptimeTracker) update(block *types.Block) {
+	commit := block.LastCommit()
+	if block.NumberU64() < 2 || commit == nil {
+		return
+	}
+	voters, err := t.source.Validators()
+	if err != nil {
+		log.Debug("Failed to load the validator set", "number", block.Number(), "err", err)
+		return
+	}
+	t.record(block.NumberU64()-1, voters, commit.Commits())
+}
+
+// record adds the participation of the voters in a block commit, given the
+// pre-commits it was committed with.
+func (t *uptimeTracker) record(number uint64, voters types.Voters, precommits []*types.Vote) {
+	rec := &uptimeRecord{
+		number:   number,
+		expected: make([]common.Address, voters.Len()),
+		signed:   make(map[common.Address]bool),
+	}
+	for i := range rec.expected {
+		rec.expected[i] = voters.At(i).Address()
+	}
+	for _, vote := range precommits {
+		if vote.BlockNumber().Uint64() != number {
+			continue
+		}
+		address, err := types.VoteSender(t.signer, vote)
+		if err != nil {
+			log.Debug("Ignoring pre-commit with invalid signature", "number", number, "err", err)
+			continue
+		}
+		if voters.Contains(address) {
+			rec.signed[address] = true
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.records = append(t.records, rec)
+	if len(t.records) > t.window {
+		t.records = t.records[len(t.records)-t.window:]
+	}
+}
+
+// uptime returns the participation of every validator expected to sign any
+// of the recorded blocks, sorted by address.
+func (t *uptimeTracker) uptime() []validatorUptime {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	stats := make(map[common.Address]*validatorUptime)
+	for _, rec := range t.records {
+		for _, address := range rec.expected {
+			stat := stats[address]
+			if stat == nil {
+				stat = &validatorUptime{Address: address}
+				stats[address] = stat
+			}
+			stat.Expected++
+			if rec.signed[address] {
+				stat.Signed++
+			}
+		}
+	}
+
+	list := make([]validatorUptime, 0, len(stats))
+	for _, stat := range stats {
+		stat.Uptime = float64(stat.Signed) * 100 / float64(stat.Expected)
+		list = append(list, *stat)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return bytes.Compare(list[i].Address[:], list[j].Address[:]) < 0
+	})
+	return list
+}
@@ -0,0 +1,127 @@
+package knode
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/core/types"
+	"github.com/kowala-tech/kcoin/client/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixedVotersSource struct {
+	voters types.Voters
+}
+
+func (s fixedVotersSource) Validators() (types.Voters, error) {
+	return s.voters, nil
+}
+
+// uptimeTest holds the keys of a set of validators signing pre-commits.
+type uptimeTest struct {
+	t     *testing.T
+	keys  []*ecdsa.PrivateKey
+	addrs []common.Address
+}
+
+func newUptimeTest(t *testing.T, n int) *uptimeTest {
+	ut := &uptimeTest{t: t}
+	for i := 0; i < n; i++ {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		ut.keys = append(ut.keys, key)
+		ut.addrs = append(ut.addrs, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	return ut
+}
+
+// voters returns the set made of the validators with the given indexes.
+func (ut *uptimeTest) voters(indexes ...int) types.Voters {
+	var list []*types.Voter
+	for _, i := range indexes {
+		list = append(list, types.NewVoter(ut.addrs[i], big.NewInt(1), big.NewInt(0)))
+	}
+	voters, err := types.NewVoters(list)
+	require.NoError(ut.t, err)
+	return voters
+}
+
+// precommits returns the pre-commits of the validators with the given indexes
+// for a block number.
+func (ut *uptimeTest) precommits(number uint64, indexes ...int) []*types.Vote {
+	var votes []*types.Vote
+	for _, i := range indexes {
+		vote := types.NewVote(new(big.Int).SetUint64(number), common.Hash{byte(number)}, 0, types.PreCommit)
+		signed, err := types.SignVote(vote, testVoteSigner, ut.keys[i])
+		require.NoError(ut.t, err)
+		votes = append(votes, signed)
+	}
+	return votes
+}
+
+func (ut *uptimeTest) uptimes(tracker *uptimeTracker) map[common.Address]validatorUptime {
+	uptimes := make(map[common.Address]validatorUptime)
+	for _, uptime := range tracker.uptime() {
+		uptimes[uptime.Address] = uptime
+	}
+	return uptimes
+}
+
+func TestUptimeTrackerRecord(t *testing.T) {
+	ut := newUptimeTest(t, 4)
+	tracker := newUptimeTracker(nil, nil, testVoteSigner)
+
+	voters := ut.voters(0, 1, 2)
+	tracker.record(1, voters, ut.precommits(1, 0, 1))
+	tracker.record(2, voters, ut.precommits(2, 0, 1))
+	tracker.record(3, voters, ut.precommits(3, 0))
+	// Pre-commits for another block and from outside the set don't count
+	tracker.record(4, voters, append(ut.precommits(4, 0), append(ut.precommits(3, 2), ut.precommits(4, 3)...)...))
+	// The last validator joins the set
+	tracker.record(5, ut.voters(0, 1, 2, 3), ut.precommits(5, 1, 3))
+
+	assert.Equal(t, map[common.Address]validatorUptime{
+		ut.addrs[0]: {Address: ut.addrs[0], Signed: 4, Expected: 5, Uptime: 80},
+		ut.addrs[1]: {Address: ut.addrs[1], Signed: 3, Expected: 5, Uptime: 60},
+		ut.addrs[2]: {Address: ut.addrs[2], Signed: 0, Expected: 5, Uptime: 0},
+		ut.addrs[3]: {Address: ut.addrs[3], Signed: 1, Expected: 1, Uptime: 100},
+	}, ut.uptimes(tracker))
+}
+
+func TestUptimeTrackerWindow(t *testing.T) {
+	ut := newUptimeTest(t, 2)
+	tracker := newUptimeTracker(nil, nil, testVoteSigner)
+	tracker.window = 2
+
+	voters := ut.voters(0, 1)
+	tracker.record(1, voters, ut.precommits(1, 0))
+	tracker.record(2, voters, ut.precommits(2, 0, 1))
+	tracker.record(3, voters, ut.precommits(3, 1))
+
+	assert.Equal(t, map[common.Address]validatorUptime{
+		ut.addrs[0]: {Address: ut.addrs[0], Signed: 1, Expected: 2, Uptime: 50},
+		ut.addrs[1]: {Address: ut.addrs[1], Signed: 2, Expected: 2, Uptime: 100},
+	}, ut.uptimes(tracker))
+}
+
+func TestUptimeTrackerUpdate(t *testing.T) {
+	ut := newUptimeTest(t, 2)
+	tracker := newUptimeTracker(nil, fixedVotersSource{ut.voters(0, 1)}, testVoteSigner)
+	child := func(number uint64, precommits []*types.Vote) *types.Block {
+		header := &types.Header{Number: new(big.Int).SetUint64(number)}
+		return types.NewBlock(header, nil, nil, &types.Commit{PreCommits: precommits, FirstPreCommit: precommits[0]})
+	}
+
+	// The genesis block isn't voted on
+	tracker.update(child(1, ut.precommits(0, 0, 1)))
+	// Every other block is accounted from the commit of its child
+	tracker.update(child(2, ut.precommits(1, 1)))
+
+	assert.Equal(t, map[common.Address]validatorUptime{
+		ut.addrs[0]: {Address: ut.addrs[0], Signed: 0, Expected: 1, Uptime: 0},
+		ut.addrs[1]: {Address: ut.addrs[1], Signed: 1, Expected: 1, Uptime: 100},
+	}, ut.uptimes(tracker))
+}
@@ -18,6 +18,9 @@ type VotingState struct {
 
 	voters         types.Voters
 	votersChecksum [32]byte
+	votersRounds   uint64 // rounds since the voters set was formed
+
+	proposer common.Address // expected proposer of the current round
 
 	proposal       *types.Proposal
 	block          *types.Block
@@ -46,18 +49,21 @@ func NewVotingTables(eventMux *event.TypeMux, voters types.Voters) (VotingTables
 	majorityFunc := func(winnerBlock common.Hash) {
 		go eventMux.Post(core.NewMajorityEvent{Winner: winnerBlock})
 	}
+	doubleSignFunc := func(evidence *types.DuplicateVoteEvidence) {
+		go eventMux.Post(core.DoubleSignEvent{Evidence: evidence})
+	}
 
 	var err error
 	tables := VotingTables{}
 
 	// prevote
-	tables[types.PreVote], err = core.NewVotingTable(types.PreVote, voters, majorityFunc)
+	tables[types.PreVote], err = core.NewVotingTable(types.PreVote, voters, majorityFunc, doubleSignFunc)
 	if err != nil {
 		return tables, err
 	}
 
 	// precommit
-	tables[types.PreCommit], err = core.NewVotingTable(types.PreCommit, voters, majorityFunc)
+	tables[types.PreCommit], err = core.NewVotingTable(types.PreCommit, voters, majorityFunc, doubleSignFunc)
 	if err != nil {
 		return tables, err
 	}
@@ -128,6 +134,35 @@ func (vs *VotingSystem) Leader(round uint64, voteType types.VoteType) (common.Ha
 	return votingTable.Leader(), nil
 }
 
+// Votes returns the votes received in every round of the election.
+func (vs *VotingSystem) Votes() []*types.Vote {
+	var votes []*types.Vote
+	for _, tables := range vs.votesPerRound {
+		for _, table := range tables {
+			if table != nil {
+				votes = append(votes, table.Votes()...)
+			}
+		}
+	}
+	return votes
+}
+
+// BlockVotes returns the votes of the given type received in a round for the
+// given block.
+func (vs *VotingSystem) BlockVotes(round uint64, voteType types.VoteType, hash common.Hash) []*types.Vote {
+	table, err := vs.getVoteSet(round, voteType)
+	if err != nil || table == nil {
+		return nil
+	}
+	var votes []*types.Vote
+	for _, vote := range table.Votes() {
+		if vote.BlockHash() == hash {
+			votes = append(votes, vote)
+		}
+	}
+	return votes
+}
+
 func (vs *VotingSystem) getVoteSet(round uint64, voteType types.VoteType) (core.VotingTable, error) {
 	votingTables, ok := vs.votesPerRound[round]
 	if !ok {
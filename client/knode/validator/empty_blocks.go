@@ -0,0 +1,71 @@
+package validator
+
+import (
+	"fmt"
+	"time"
+)
+
+// EmptyBlockPolicy represents when the validator proposes blocks that carry no
+// transactions.
+type EmptyBlockPolicy int
+
+const (
+	EmptyBlocksAlways  EmptyBlockPolicy = iota // Propose empty blocks whenever it's the node's turn
+	EmptyBlocksNever                           // Propose blocks only if there are pending transactions
+	EmptyBlocksTimeout                         // Propose empty blocks only after a period without transactions
+)
+
+func (policy EmptyBlockPolicy) IsValid() bool {
+	return policy >= EmptyBlocksAlways && policy <= EmptyBlocksTimeout
+}
+
+// String implements the stringer interface.
+func (policy EmptyBlockPolicy) String() string {
+	switch policy {
+	case EmptyBlocksAlways:
+		return "always"
+	case EmptyBlocksNever:
+		return "never"
+	case EmptyBlocksTimeout:
+		return "timeout"
+	default:
+		return "unknown"
+	}
+}
+
+func (policy EmptyBlockPolicy) MarshalText() ([]byte, error) {
+	if !policy.IsValid() {
+		return nil, fmt.Errorf("unknown empty block policy %d", policy)
+	}
+	return []byte(policy.String()), nil
+}
+
+func (policy *EmptyBlockPolicy) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "always":
+		*policy = EmptyBlocksAlways
+	case "never":
+		*policy = EmptyBlocksNever
+	case "timeout":
+		*policy = EmptyBlocksTimeout
+	default:
+		return fmt.Errorf(`unknown empty block policy %q, want "always", "never" or "timeout"`, text)
+	}
+	return nil
+}
+
+// seal reports whether a block should be proposed given the number of pending
+// transactions and the time elapsed since the last block was sealed.
+func (policy EmptyBlockPolicy) seal(pending int, idle, timeout time.Duration) bool {
+	if pending > 0 {
+		return true
+	}
+	switch policy {
+	case EmptyBlocksNever:
+		return false
+	case EmptyBlocksTimeout:
+		return idle >= timeout
+	default:
+		return true
+	}
+}
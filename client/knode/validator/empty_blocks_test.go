@@ -0,0 +1,48 @@
+package validator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmptyBlockPolicy_Seal(t *testing.T) {
+	const timeout = 30 * time.Second
+
+	testCases := []struct {
+		policy  EmptyBlockPolicy
+		pending int
+		idle    time.Duration
+		seal    bool
+	}{
+		{EmptyBlocksAlways, 0, 0, true},
+		{EmptyBlocksAlways, 5, 0, true},
+		{EmptyBlocksNever, 0, 0, false},
+		{EmptyBlocksNever, 0, time.Hour, false},
+		{EmptyBlocksNever, 1, 0, true},
+		{EmptyBlocksTimeout, 0, timeout - time.Second, false},
+		{EmptyBlocksTimeout, 0, timeout, true},
+		{EmptyBlocksTimeout, 3, 0, true},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.seal, tc.policy.seal(tc.pending, tc.idle, timeout), "policy %v, pending %d, idle %v", tc.policy, tc.pending, tc.idle)
+	}
+}
+
+func TestEmptyBlockPolicy_Text(t *testing.T) {
+	for _, policy := range []EmptyBlockPolicy{EmptyBlocksAlways, EmptyBlocksNever, EmptyBlocksTimeout} {
+		text, err := policy.MarshalText()
+		require.NoError(t, err)
+
+		var decoded EmptyBlockPolicy
+		require.NoError(t, decoded.UnmarshalText(text))
+		assert.Equal(t, policy, decoded)
+	}
+
+	var policy EmptyBlockPolicy
+	assert.Error(t, policy.UnmarshalText([]byte("sometimes")))
+	_, err := EmptyBlockPolicy(42).MarshalText()
+	assert.Error(t, err)
+}
@@ -0,0 +1,15 @@
+package validator
+
+import (
+	"github.com/kowala-tech/kcoin/client/metrics"
+)
+
+var (
+	// proposeLatencyHistogram tracks how long the local node takes to assemble,
+	// sign and broadcast a block proposal, in milliseconds
+	proposeLatencyHistogram = metrics.NewRegisteredHistogram("validator/propose/latency", nil, metrics.NewExpDecaySample(1028, 0.015))
+
+	// missedProposalCounter counts the rounds in which the local node was the
+	// expected proposer but the round ended without a block authored by it
+	missedProposalCounter = metrics.NewRegisteredCounter("validator/propose/missed", nil)
+)
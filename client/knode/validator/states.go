@@ -13,7 +13,6 @@ import (
 	"github.com/kowala-tech/kcoin/client/core/state"
 	"github.com/kowala-tech/kcoin/client/core/types"
 	"github.com/kowala-tech/kcoin/client/log"
-	"github.com/kowala-tech/kcoin/client/params"
 
 	"github.com/davecgh/go-spew/spew"
 )
@@ -93,8 +92,9 @@ func (val *validator) newElectionState() stateFn {
 	if err := val.init(); err != nil {
 		return nil
 	}
+	val.enterStep("newElection")
 
-	<-time.NewTimer(val.start.Sub(time.Now())).C
+	val.waitForNextHeight()
 
 	// @NOTE (rgeraldes) - wait for txs - sync genesis validators, round zero for the first block only.
 	if val.blockNumber.Cmp(big.NewInt(1)) == 0 {
@@ -111,10 +111,27 @@ func (val *validator) newElectionState() stateFn {
 	return val.newRoundState
 }
 
+// waitForNextHeight ends the commit phase of the previous height, waiting for
+// the start of the next one. The wait is bounded by the commit timeout so that a
+// start time pushed into the future, like by a parent block with a skewed clock,
+// doesn't halt the validator. A start time in the past needs no wait at all, so
+// the timeout has no effect there; it doesn't bound writing the committed block.
+func (val *validator) waitForNextHeight() {
+	wait := val.start.Sub(time.Now())
+	if timeout := val.timing.CommitDuration(); timeout > 0 && wait > timeout {
+		log.Warn("Commit timeout expired before the next height", "start", val.start, "timeout", timeout)
+		wait = timeout
+	}
+	<-time.NewTimer(wait).C
+}
+
 func (val *validator) newRoundState() stateFn {
 	log.Info("Starting a new voting round", "start time", val.start, "block number", val.blockNumber, "round", val.round)
 
+	val.votersMu.Lock()
 	val.voters.NextProposer()
+	val.votersRounds++
+	val.votersMu.Unlock()
 
 	if val.round != 0 {
 		val.round++
@@ -125,13 +142,24 @@ func (val *validator) newRoundState() stateFn {
 		parent := val.chain.CurrentBlock()
 		val.makeCurrent(parent)
 	}
+	val.enterStep("newRound")
 
 	return val.newProposalState
 }
 
 func (val *validator) newProposalState() stateFn {
+	val.votersMu.Lock()
 	proposer := val.voters.NextProposer()
+	val.votersMu.Unlock()
+	val.proposer = proposer.Address()
+	val.enterStep("propose")
 	if proposer.Address() == val.walletAccount.Account().Address {
+		if !val.shouldPropose() {
+			log.Info("Skipping the proposal of an empty block", "policy", val.emptyBlocks)
+			// holding back an empty block on purpose isn't a missed turn
+			val.proposer = common.Address{}
+			return val.preVoteState
+		}
 		log.Info("Proposing a new block")
 		val.propose()
 	} else {
@@ -142,7 +170,7 @@ func (val *validator) newProposalState() stateFn {
 }
 
 func (val *validator) waitForProposal() {
-	timeout := time.Duration(params.ProposeDuration+val.round*params.ProposeDeltaDuration) * time.Millisecond
+	timeout := val.timing.ProposeDurationAt(val.round)
 	select {
 	case block := <-val.blockCh:
 		val.block = block
@@ -154,6 +182,7 @@ func (val *validator) waitForProposal() {
 
 func (val *validator) preVoteState() stateFn {
 	log.Info("Pre vote sub-election")
+	val.enterStep("preVote")
 	val.preVote()
 
 	return val.preVoteWaitState
@@ -161,7 +190,8 @@ func (val *validator) preVoteState() stateFn {
 
 func (val *validator) preVoteWaitState() stateFn {
 	log.Info("Waiting for a majority in the pre-vote sub-election")
-	timeout := time.Duration(params.PreVoteDuration+val.round*params.PreVoteDeltaDuration) * time.Millisecond
+	val.enterStep("preVoteWait")
+	timeout := val.timing.PreVoteDurationAt(val.round)
 
 	select {
 	case <-val.majority.Chan():
@@ -176,6 +206,7 @@ func (val *validator) preVoteWaitState() stateFn {
 
 func (val *validator) preCommitState() stateFn {
 	log.Info("Pre commit sub-election")
+	val.enterStep("preCommit")
 	val.preCommit()
 
 	return val.preCommitWaitState
@@ -183,7 +214,8 @@ func (val *validator) preCommitState() stateFn {
 
 func (val *validator) preCommitWaitState() stateFn {
 	log.Info("Waiting for a majority in the pre-commit sub-election")
-	timeout := time.Duration(params.PreCommitDuration+val.round+params.PreCommitDeltaDuration) * time.Millisecond
+	val.enterStep("preCommitWait")
+	timeout := val.timing.PreCommitDurationAt(val.round)
 	defer val.majority.Unsubscribe()
 
 	select {
@@ -191,18 +223,63 @@ func (val *validator) preCommitWaitState() stateFn {
 		log.Info("There's a majority in the pre-commit sub-election!", "event", spew.Sdump(event))
 		if val.block == nil || bytes.Equal(val.block.Hash().Bytes(), common.Hash{}.Bytes()) {
 			log.Debug("No one block wins!")
+			val.checkProposal(nil)
 			return val.newRoundState
 		}
 		return val.commitState
 	case <-time.After(timeout):
 		log.Info("Timeout expired", "duration", timeout)
+		val.checkProposal(nil)
 		return val.newRoundState
 	}
 }
 
+// checkProposal compares the expected proposer of the round against the author
+// of the block that ended it (nil if none) and reports a missed turn if the
+// local node was due to propose but didn't get its block through.
+func (val *validator) checkProposal(block *types.Block) {
+	local := val.walletAccount.Account().Address
+	if val.proposer != local {
+		return
+	}
+	if block != nil && block.Coinbase() == local {
+		return
+	}
+	missedProposalCounter.Inc(1)
+	log.Warn("Missed block proposal", "number", val.blockNumber, "round", val.round)
+}
+
 func (val *validator) commitState() stateFn {
 	log.Info("Commit state")
+	val.enterStep("commit")
+
+	if !val.waitWhilePaused() {
+		return nil
+	}
+	if err := val.commitBlock(); err != nil {
+		log.Error("Failed writing block to chain", "err", err)
+		return nil
+	}
+
+	// election state updates
+	val.commitRound = int(val.round)
+	val.checkProposal(val.block)
 
+	voter, err := val.consensus.IsValidator(val.walletAccount.Account().Address)
+	if err != nil {
+		log.Crit("Failed to verify if the validator is a voter", "err", err)
+	}
+	if !voter {
+		log.Info(fmt.Sprintf("Logging out. Account %q is not a validator", val.walletAccount.Account().Address.String()))
+		return val.loggedOutState
+	}
+
+	return val.newElectionState
+}
+
+// commitBlock writes the committed block to the chain and announces it. Blocks
+// are final once committed, so the block is announced as finalized as well.
+func (val *validator) commitBlock() error {
 	blockHash := val.block.Hash()
 
 	// update block hash since it is now available and not when
@@ -216,11 +293,13 @@ func (val *validator) commitState() stateFn {
 		log.BlockHash = blockHash
 	}
 
-	_, err := val.chain.WriteBlockWithState(val.block, val.work.receipts, val.work.state)
-	if err != nil {
-		log.Error("Failed writing block to chain", "err", err)
-		return nil
+	if _, err := val.chain.WriteBlockWithState(val.block, val.work.receipts, val.work.state); err != nil {
+		return err
+	}
+	if val.voters != nil {
+		val.storeValidators(val.block.NumberU64())
 	}
+	val.storeBlockVotes()
 
 	// Broadcast the block and announce chain insertion event
 	go val.eventMux.Post(core.NewMinedBlockEvent{Block: val.block})
@@ -230,21 +309,10 @@ func (val *validator) commitState() stateFn {
 	)
 	events = append(events, core.ChainEvent{Block: val.block, Hash: val.block.Hash(), Logs: logs})
 	events = append(events, core.ChainHeadEvent{Block: val.block})
+	events = append(events, core.ChainFinalizedEvent{Block: val.block})
 	val.chain.PostChainEvents(events, logs)
 
-	// election state updates
-	val.commitRound = int(val.round)
-
-	voter, err := val.consensus.IsValidator(val.walletAccount.Account().Address)
-	if err != nil {
-		log.Crit("Failed to verify if the validator is a voter", "err", err)
-	}
-	if !voter {
-		log.Info(fmt.Sprintf("Logging out. Account %q is not a validator", val.walletAccount.Account().Address.String()))
-		return val.loggedOutState
-	}
-
-	return val.newElectionState
+	return nil
 }
 
 func (val *validator) loggedOutState() stateFn {
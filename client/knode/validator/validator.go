@@ -2,6 +2,7 @@ package validator
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
 	"math/big"
@@ -15,6 +16,7 @@ import (
 	engine "github.com/kowala-tech/kcoin/client/consensus"
 	"github.com/kowala-tech/kcoin/client/contracts/bindings/consensus"
 	"github.com/kowala-tech/kcoin/client/core"
+	"github.com/kowala-tech/kcoin/client/core/rawdb"
 	"github.com/kowala-tech/kcoin/client/core/state"
 	"github.com/kowala-tech/kcoin/client/core/types"
 	"github.com/kowala-tech/kcoin/client/core/vm"
@@ -32,10 +34,14 @@ var (
 	ErrCantAddBlockFragmentNotValidating = errors.New("can't add block fragment, not validating")
 	ErrIsNotRunning                      = errors.New("validator is not running")
 	ErrIsRunning                         = errors.New("validator is running, cannot change its parameters")
+	ErrExtraDataTooLong                  = fmt.Errorf("extra data exceeds the %d bytes limit", params.MaximumExtraDataSize)
+	ErrUnbondingNotAcknowledged          = errors.New("validator deregistered but the unbonding period was not acknowledged")
+	ErrNotValidating                     = errors.New("validator is not validating")
 )
 
 var (
 	txConfirmationTimeout = 10 * time.Second
+	pausedRecheckInterval = time.Second // period between two checks whether block commits are still paused
 )
 
 // Backend wraps all methods required for mining.
@@ -51,12 +57,36 @@ type Validator interface {
 	Start(walletAccount accounts.WalletAccount, deposit *big.Int)
 	Stop() error
 	SetExtra(extra []byte) error
+	SetRandomExtra(random bool)
+	SetEmptyBlocks(policy EmptyBlockPolicy, timeout time.Duration)
+	SetMaxBlockTxs(max uint64)
+	SetPriorityAddresses(addrs []common.Address)
+	SetPaused(paused func() bool)
 	SetCoinbase(walletAccount accounts.WalletAccount) error
 	SetDeposit(deposit *big.Int) error
 	Pending() (*types.Block, *state.StateDB)
 	PendingBlock() *types.Block
 	Deposits(address *common.Address) ([]*types.Deposit, error)
 	RedeemDeposits() error
+	Exit() ([]*types.Deposit, error)
+	Weights() ([]*types.Voter, uint64, error)
+	RoundState() (*RoundState, error)
+}
+
+// RoundState is a snapshot of the election the validator is taking part in.
+type RoundState struct {
+	BlockNumber *big.Int
+	Round       uint64
+	Step        string
+	Proposer    common.Address
+	Votes       []types.AddressVote
+}
+
+// electionExiter is the subset of the consensus binding required to leave the
+// election.
+type electionExiter interface {
+	Leave(walletAccount accounts.WalletAccount) (common.Hash, error)
+	Deposits(addr common.Address) ([]*types.Deposit, error)
 }
 
 type Service interface {
@@ -82,12 +112,36 @@ type validator struct {
 	backend  Backend
 	chain    *core.BlockChain
 	config   *params.ChainConfig
+	timing   *params.KonsensusConfig // block time and step timeouts
 	engine   engine.Engine
 	vmConfig vm.Config
 
 	walletAccount accounts.WalletAccount
 
+	extra       []byte       // extra data included in proposed blocks
+	randomExtra bool         // whether to fill the extra data with random bytes instead
+	extraMu     sync.RWMutex // protects the extra data settings
+
+	emptyBlocks        EmptyBlockPolicy // when to propose blocks without transactions
+	emptyBlocksTimeout time.Duration    // period without transactions before proposing an empty block
+	emptyBlocksMu      sync.RWMutex     // protects the empty block settings
+
+	maxBlockTxs uint64 // maximum number of transactions per proposed block (0 = unlimited), accessed atomically
+
+	priority   map[common.Address]struct{} // accounts whose transactions are included first
+	priorityMu sync.RWMutex                // protects priority
+
+	paused   func() bool   // reports whether block commits must wait (optional)
+	quit     chan struct{} // closed when the validator stops, releasing a paused commit
+	pausedMu sync.RWMutex  // protects paused and quit
+
 	consensus *consensus.Consensus // consensus binding
+	db        kcoindb.Database     // chain database holding the persisted validator sets
+
+	votersMu sync.RWMutex // protects the voters weights and rounds
+
+	roundState RoundState   // election state as of the last step transition, without votes
+	roundMu    sync.RWMutex // protects the round state
 
 	// sync
 	canStart    int32 // can start indicates whether we can start the validation operation
@@ -102,11 +156,13 @@ type validator struct {
 }
 
 // New returns a new consensus validator
-func New(backend Backend, consensus *consensus.Consensus, config *params.ChainConfig, eventMux *event.TypeMux, engine engine.Engine, vmConfig vm.Config) *validator {
+func New(backend Backend, consensus *consensus.Consensus, config *params.ChainConfig, timing *params.KonsensusConfig, eventMux *event.TypeMux, engine engine.Engine, vmConfig vm.Config) *validator {
 	validator := &validator{
 		config:    config,
+		timing:    timing,
 		backend:   backend,
 		chain:     backend.BlockChain(),
+		db:        backend.ChainDb(),
 		engine:    engine,
 		consensus: consensus,
 		eventMux:  eventMux,
@@ -153,6 +209,10 @@ func (val *validator) Start(walletAccount accounts.WalletAccount, deposit *big.I
 		return
 	}
 
+	val.pausedMu.Lock()
+	val.quit = make(chan struct{})
+	val.pausedMu.Unlock()
+
 	go val.run()
 }
 
@@ -196,6 +256,15 @@ func (val *validator) Stop() error {
 	log.Info("Stopping consensus validator")
 
 	val.leave()
+
+	// a paused commit would never see the deregistration, release it
+	val.pausedMu.Lock()
+	if val.quit != nil {
+		close(val.quit)
+		val.quit = nil
+	}
+	val.pausedMu.Unlock()
+
 	val.wg.Wait() // waits until the validator is no longer registered as a voter.
 
 	atomic.StoreInt32(&val.shouldStart, 0)
@@ -203,7 +272,140 @@ func (val *validator) Stop() error {
 	return nil
 }
 
-func (val *validator) SetExtra(extra []byte) error { return nil }
+func (val *validator) SetExtra(extra []byte) error {
+	if uint64(len(extra)) > params.MaximumExtraDataSize {
+		return ErrExtraDataTooLong
+	}
+	val.extraMu.Lock()
+	defer val.extraMu.Unlock()
+
+	val.extra = common.CopyBytes(extra)
+	return nil
+}
+
+// SetRandomExtra toggles whether proposed blocks carry random extra data
+// instead of the one configured through SetExtra.
+func (val *validator) SetRandomExtra(random bool) {
+	val.extraMu.Lock()
+	defer val.extraMu.Unlock()
+
+	val.randomExtra = random
+}
+
+// blockExtra returns the extra data to include in a newly created block.
+func (val *validator) blockExtra() []byte {
+	val.extraMu.RLock()
+	defer val.extraMu.RUnlock()
+
+	if !val.randomExtra {
+		return common.CopyBytes(val.extra)
+	}
+	extra := make([]byte, params.MaximumExtraDataSize)
+	if _, err := rand.Read(extra); err != nil {
+		log.Warn("Failed to generate random extra data", "err", err)
+		return common.CopyBytes(val.extra)
+	}
+	return extra
+}
+
+// SetEmptyBlocks sets the policy deciding whether the validator proposes blocks
+// without transactions. The timeout only applies to EmptyBlocksTimeout.
+func (val *validator) SetEmptyBlocks(policy EmptyBlockPolicy, timeout time.Duration) {
+	val.emptyBlocksMu.Lock()
+	defer val.emptyBlocksMu.Unlock()
+
+	val.emptyBlocks = policy
+	val.emptyBlocksTimeout = timeout
+}
+
+// SetMaxBlockTxs caps the number of transactions included in proposed blocks,
+// regardless of the gas left. Zero removes the limit.
+func (val *validator) SetMaxBlockTxs(max uint64) {
+	atomic.StoreUint64(&val.maxBlockTxs, max)
+}
+
+// SetPriorityAddresses sets the accounts whose transactions are included in
+// proposed blocks ahead of any other, regardless of their gas price.
+func (val *validator) SetPriorityAddresses(addrs []common.Address) {
+	priority := make(map[common.Address]struct{}, len(addrs))
+	for _, addr := range addrs {
+		priority[addr] = struct{}{}
+	}
+
+	val.priorityMu.Lock()
+	defer val.priorityMu.Unlock()
+
+	val.priority = priority
+}
+
+// SetPaused sets the check reporting whether committed blocks must be held back
+// instead of written to the chain, like while the disk is nearly full. A nil
+// check never pauses the commits.
+func (val *validator) SetPaused(paused func() bool) {
+	val.pausedMu.Lock()
+	defer val.pausedMu.Unlock()
+
+	val.paused = paused
+}
+
+// waitWhilePaused blocks for as long as block commits are paused. It returns
+// false if the validator stopped in the meantime, in which case the block must
+// not be committed.
+func (val *validator) waitWhilePaused() bool {
+	val.pausedMu.RLock()
+	paused, quit := val.paused, val.quit
+	val.pausedMu.RUnlock()
+
+	if paused == nil || !paused() {
+		return true
+	}
+	log.Warn("Block commit paused", "number", val.blockNumber)
+
+	ticker := time.NewTicker(pausedRecheckInterval)
+	defer ticker.Stop()
+
+	for paused() {
+		select {
+		case <-ticker.C:
+		case <-quit:
+			log.Info("Validator stopped while the block commit was paused", "number", val.blockNumber)
+			return false
+		}
+	}
+	log.Info("Block commit resumed", "number", val.blockNumber)
+	return true
+}
+
+// orderTransactions arranges the pending transactions for block assembly,
+// placing the ones sent by priority accounts first.
+func (val *validator) orderTransactions(ordering core.TxOrdering, pending map[common.Address]types.Transactions) core.OrderedTransactions {
+	val.priorityMu.RLock()
+	priority := val.priority
+	val.priorityMu.RUnlock()
+
+	if len(priority) == 0 {
+		return ordering.Order(val.signer, pending)
+	}
+	return ordering.Prioritize(val.signer, pending, func(addr common.Address) bool {
+		_, ok := priority[addr]
+		return ok
+	})
+}
+
+// shouldPropose applies the empty block policy to the current pending
+// transactions and the time elapsed since the head block.
+func (val *validator) shouldPropose() bool {
+	val.emptyBlocksMu.RLock()
+	defer val.emptyBlocksMu.RUnlock()
+
+	if val.emptyBlocks == EmptyBlocksAlways {
+		return true
+	}
+	pending, _ := val.backend.TxPool().Stats()
+	idle := time.Since(time.Unix(val.chain.CurrentBlock().Time().Int64(), 0))
+
+	return val.emptyBlocks.seal(pending, idle, val.emptyBlocksTimeout)
+}
 
 func (val *validator) Validating() bool {
 	return atomic.LoadInt32(&val.validating) > 0
@@ -251,8 +453,10 @@ func (val *validator) restoreLastCommit() {
 		log.Crit("Failed to access the voters checksum", "err", err)
 	}
 
-	if err := val.updateValidators(checksum, true); err != nil {
-		log.Crit("Failed to update the validator set", "err", err)
+	if !val.restoreValidators(checksum) {
+		if err := val.updateValidators(checksum, true); err != nil {
+			log.Crit("Failed to update the validator set", "err", err)
+		}
 	}
 
 	currentBlock := val.chain.CurrentBlock()
@@ -276,9 +480,10 @@ func (val *validator) init() error {
 	}
 
 	start := time.Unix(parent.Time().Int64(), 0)
-	val.start = start.Add(time.Duration(params.BlockTime) * time.Millisecond)
+	val.start = start.Add(val.timing.BlockDuration())
 	val.blockNumber = parent.Number().Add(parent.Number(), big.NewInt(1))
 	val.round = 0
+	val.applyVotersForks()
 
 	val.proposal = nil
 	val.block = nil
@@ -288,11 +493,14 @@ func (val *validator) init() error {
 	val.lockedBlock = nil
 	val.commitRound = -1
 
-	val.votingSystem, err = NewVotingSystem(val.eventMux, val.blockNumber, val.voters)
+	votingSystem, err := NewVotingSystem(val.eventMux, val.blockNumber, val.voters)
 	if err != nil {
 		log.Error("Failed to create voting system", "err", err)
 		return nil
 	}
+	val.handleMutex.Lock()
+	val.votingSystem = votingSystem
+	val.handleMutex.Unlock()
 
 	val.blockCh = make(chan *types.Block)
 	val.majority = val.eventMux.Subscribe(core.NewMajorityEvent{})
@@ -340,12 +548,18 @@ func (val *validator) AddVote(vote *types.Vote) error {
 	return nil
 }
 
-func (val *validator) commitTransactions(mux *event.TypeMux, txs *types.TransactionsByPriceAndNonce, bc *core.BlockChain, coinbase common.Address) {
+func (val *validator) commitTransactions(mux *event.TypeMux, txs core.OrderedTransactions, bc *core.BlockChain, coinbase common.Address) {
 	gp := new(core.GasPool).AddGas(val.header.GasLimit)
+	maxTxs := atomic.LoadUint64(&val.maxBlockTxs)
 
 	var coalescedLogs []*types.Log
 
 	for {
+		// Stop once the block is at the transaction count limit
+		if maxTxs > 0 && uint64(val.tcount) >= maxTxs {
+			log.Trace("Transaction limit reached for current block", "limit", maxTxs)
+			break
+		}
 		// Retrieve the next transaction and abort if all done
 		tx := txs.Peek()
 		if tx == nil {
@@ -433,6 +647,7 @@ func (val *validator) leave() {
 	receipt, err := tx.WaitMinedWithTimeout(val.backend, txHash, txConfirmationTimeout)
 	if err != nil {
 		log.Error("Failed to verify the voter deregistration", "err", err)
+		return
 	}
 	if receipt.Status == types.ReceiptStatusFailed {
 		log.Error("Failed to deregister validator - receipt status failed")
@@ -464,6 +679,7 @@ func (val *validator) createBlock() *types.Block {
 		GasLimit:       core.CalcGasLimit(parent),
 		Time:           big.NewInt(tstamp),
 		ValidatorsHash: val.voters.Hash(),
+		Extra:          val.blockExtra(),
 	}
 	val.header = header
 
@@ -493,7 +709,7 @@ func (val *validator) createBlock() *types.Block {
 		log.Crit("Failed to fetch pending transactions", "err", err)
 	}
 
-	txs := types.NewTransactionsByPriceAndNonce(val.signer, pending)
+	txs := val.orderTransactions(val.backend.TxPool().Ordering(), pending)
 	val.commitTransactions(val.eventMux, txs, val.chain, val.walletAccount.Account().Address)
 
 	// Create the new block to seal with the consensus engine
@@ -506,6 +722,11 @@ func (val *validator) createBlock() *types.Block {
 }
 
 func (val *validator) propose() {
+	start := time.Now()
+	defer func() {
+		proposeLatencyHistogram.Update(int64(time.Since(start) / time.Millisecond))
+	}()
+
 	block := val.createProposalBlock()
 
 	lockedRound := 1
@@ -535,7 +756,6 @@ func (val *validator) propose() {
 			Data:        fragments.Get(int(i)),
 		})
 	}
-
 }
 
 func (val *validator) preVote() {
@@ -721,12 +941,97 @@ func (val *validator) updateValidators(checksum [32]byte, genesis bool) error {
 		log.Debug("voting. updating a list of validators", "was", "nil", "now", validators.Len())
 	}
 
+	val.votersMu.Lock()
 	val.voters = validators
 	val.votersChecksum = checksum
+	val.votersRounds = 0
+	val.votersMu.Unlock()
 
 	return nil
 }
 
+// applyVotersForks switches the validator set to the proposer election active
+// at the current block number.
+func (val *validator) applyVotersForks() {
+	if !val.config.IsForked(params.NormalizedVotersFork, val.blockNumber) {
+		return
+	}
+	val.votersMu.Lock()
+	val.voters = types.NormalizeVoters(val.voters)
+	val.votersMu.Unlock()
+}
+
+// restoreValidators loads the validator set persisted for the head of the
+// chain, so a restarted validator carries on with the proposer weights it had
+// instead of recomputing the set with fresh ones. It reports whether a set was
+// restored, which is only the case if it matches the given contract checksum.
+func (val *validator) restoreValidators(checksum [32]byte) bool {
+	number := val.chain.CurrentBlock().NumberU64()
+	stored := rawdb.ReadValidatorSet(val.db, number)
+	if stored == nil || stored.Checksum != checksum {
+		return false
+	}
+	list := make([]*types.Voter, len(stored.Voters))
+	weights := make([]*big.Int, len(stored.Voters))
+	for i, entry := range stored.Voters {
+		list[i] = types.NewVoter(entry.Address, entry.Deposit, nil)
+		weights[i] = entry.Weight
+	}
+	voters, err := types.NewValidatorSetWithWeights(list, weights)
+	if err != nil {
+		log.Warn("Failed to restore the validator set", "number", number, "err", err)
+		return false
+	}
+	log.Info("Restored the validator set", "number", number, "validators", voters.Len(), "rounds", stored.Rounds)
+
+	val.votersMu.Lock()
+	val.voters = voters
+	val.votersChecksum = checksum
+	val.votersRounds = stored.Rounds
+	val.votersMu.Unlock()
+
+	return true
+}
+
+// storeValidators persists the current validator set and proposer weights as
+// the ones in force after the given block, dropping the set of its parent.
+func (val *validator) storeValidators(number uint64) {
+	val.votersMu.RLock()
+	set := &rawdb.ValidatorSet{
+		Checksum: val.votersChecksum,
+		Rounds:   val.votersRounds,
+		Voters:   make([]rawdb.ValidatorSetEntry, val.voters.Len()),
+	}
+	for i, weight := range val.voters.Weights() {
+		voter := val.voters.At(i)
+		set.Voters[i] = rawdb.ValidatorSetEntry{Address: voter.Address(), Deposit: voter.Deposit(), Weight: weight}
+	}
+	val.votersMu.RUnlock()
+
+	rawdb.WriteValidatorSet(val.db, number, set)
+	if number > 0 {
+		rawdb.DeleteValidatorSet(val.db, number-1)
+	}
+}
+
+// storeBlockVotes persists the votes of the current round for the block being
+// committed, so the commit can be inspected later on.
+func (val *validator) storeBlockVotes() {
+	val.handleMutex.Lock()
+	system := val.votingSystem
+	val.handleMutex.Unlock()
+	if system == nil {
+		return
+	}
+	hash := val.block.Hash()
+	votes := &rawdb.BlockVotes{
+		Round:      val.round,
+		PreVotes:   system.BlockVotes(val.round, types.PreVote, hash),
+		PreCommits: system.BlockVotes(val.round, types.PreCommit, hash),
+	}
+	rawdb.WriteBlockVotes(val.db, hash, val.block.NumberU64(), votes)
+}
+
 func (val *validator) Deposits(address *common.Address) ([]*types.Deposit, error) {
 	if address != nil {
 		return val.consensus.Deposits(*address)
@@ -758,3 +1063,138 @@ func (val *validator) RedeemDeposits() error {
 
 	return nil
 }
+
+// Exit deregisters the validator from the election, waits for the network to
+// acknowledge the unbonding period of its deposits and stops the local
+// validation. It returns the deposits being unbonded.
+func (val *validator) Exit() ([]*types.Deposit, error) {
+	return val.exit(val.consensus, val.backend)
+}
+
+func (val *validator) exit(election electionExiter, backend tx.Backend) ([]*types.Deposit, error) {
+	if !val.Validating() {
+		return nil, ErrIsNotRunning
+	}
+
+	txHash, err := election.Leave(val.walletAccount)
+	if err != nil {
+		return nil, err
+	}
+	receipt, err := tx.WaitMinedWithTimeout(backend, txHash, txConfirmationTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if receipt.Status == types.ReceiptStatusFailed {
+		return nil, fmt.Errorf("Failed to deregister validator - receipt status failed")
+	}
+
+	// the deregistration starts the unbonding period of the deposits
+	deposits, err := election.Deposits(val.walletAccount.Account().Address)
+	if err != nil {
+		return nil, err
+	}
+	unbonding := false
+	for _, deposit := range deposits {
+		if deposit.AvailableAtTimeUnix() != 0 {
+			unbonding = true
+			break
+		}
+	}
+	if !unbonding {
+		return nil, ErrUnbondingNotAcknowledged
+	}
+
+	// the state machine logs out once it notices the deregistration
+	val.wg.Wait()
+	atomic.StoreInt32(&val.shouldStart, 0)
+	atomic.StoreInt32(&val.validating, 0)
+	log.Info("Validator exited the election", "deposits", len(deposits))
+
+	return deposits, nil
+}
+
+// Weights returns a snapshot of the current voters along with their proposer
+// weights and the number of rounds elapsed since the set was formed.
+func (val *validator) Weights() ([]*types.Voter, uint64, error) {
+	val.votersMu.RLock()
+	defer val.votersMu.RUnlock()
+
+	if val.voters == nil {
+		return nil, 0, ErrIsNotRunning
+	}
+
+	weights := val.voters.Weights()
+	voters := make([]*types.Voter, len(weights))
+	for i, weight := range weights {
+		voter := val.voters.At(i)
+		voters[i] = types.NewVoter(voter.Address(), new(big.Int).Set(voter.Deposit()), weight)
+	}
+
+	return voters, val.votersRounds, nil
+}
+
+// enterStep records the election state as the state machine moves into the
+// given step, so that it can be inspected from other goroutines.
+func (val *validator) enterStep(step string) {
+	state := RoundState{
+		Round:    val.round,
+		Step:     step,
+		Proposer: val.proposer,
+	}
+	if val.blockNumber != nil {
+		state.BlockNumber = new(big.Int).Set(val.blockNumber)
+	}
+	val.roundMu.Lock()
+	val.roundState = state
+	val.roundMu.Unlock()
+}
+
+// RoundState returns the current height, round and step of the election along
+// with the votes received so far.
+func (val *validator) RoundState() (*RoundState, error) {
+	if !val.Validating() {
+		return nil, ErrNotValidating
+	}
+	val.roundMu.RLock()
+	state := val.roundState
+	val.roundMu.RUnlock()
+
+	val.handleMutex.Lock()
+	var votes []*types.Vote
+	if val.votingSystem != nil {
+		votes = val.votingSystem.Votes()
+	}
+	val.handleMutex.Unlock()
+
+	for _, vote := range votes {
+		addressVote, err := types.NewAddressVote(val.signer, vote)
+		if err != nil {
+			log.Debug("Failed to recover the vote sender", "hash", vote.Hash(), "err", err)
+			continue
+		}
+		state.Votes = append(state.Votes, addressVote)
+	}
+	return &state, nil
+}
+
+// SimulateProposers runs the proposer election over a copy of the given voters
+// for the given number of rounds and returns how many times each voter would
+// propose, in set order. The given voters are left untouched.
+func SimulateProposers(voters []*types.Voter, rounds uint64) ([]uint64, error) {
+	simulated := make([]*types.Voter, len(voters))
+	index := make(map[common.Address]int, len(voters))
+	for i, voter := range voters {
+		simulated[i] = types.NewVoter(voter.Address(), voter.Deposit(), new(big.Int).Set(voter.Weight()))
+		index[voter.Address()] = i
+	}
+	set, err := types.NewVoters(simulated)
+	if err != nil {
+		return nil, err
+	}
+
+	proposals := make([]uint64, len(voters))
+	for round := uint64(0); round < rounds; round++ {
+		proposals[index[set.NextProposer().Address()]]++
+	}
+	return proposals, nil
+}
@@ -0,0 +1,685 @@
+package validator
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kowala-tech/kcoin/client/accounts"
+	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/consensus/konsensus"
+	"github.com/kowala-tech/kcoin/client/core"
+	"github.com/kowala-tech/kcoin/client/core/rawdb"
+	"github.com/kowala-tech/kcoin/client/core/types"
+	"github.com/kowala-tech/kcoin/client/core/vm"
+	"github.com/kowala-tech/kcoin/client/crypto"
+	"github.com/kowala-tech/kcoin/client/event"
+	"github.com/kowala-tech/kcoin/client/kcoindb"
+	"github.com/kowala-tech/kcoin/client/metrics"
+	"github.com/kowala-tech/kcoin/client/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidator_BlockExtraUsesConfiguredExtra(t *testing.T) {
+	val := &validator{}
+	require.NoError(t, val.SetExtra([]byte("kcoin")))
+
+	assert.Equal(t, []byte("kcoin"), val.blockExtra())
+	assert.Equal(t, ErrExtraDataTooLong, val.SetExtra(make([]byte, params.MaximumExtraDataSize+1)))
+}
+
+func TestValidator_BlockExtraRandom(t *testing.T) {
+	val := &validator{}
+	require.NoError(t, val.SetExtra([]byte("kcoin")))
+	val.SetRandomExtra(true)
+
+	seen := make(map[string]bool)
+	for i := 0; i < 10; i++ {
+		extra := val.blockExtra()
+		assert.True(t, uint64(len(extra)) <= params.MaximumExtraDataSize)
+		assert.False(t, seen[string(extra)], "extra data repeated across blocks")
+		seen[string(extra)] = true
+	}
+}
+
+type testWalletAccount struct {
+	accounts.Wallet
+	account accounts.Account
+}
+
+func (wa *testWalletAccount) Account() accounts.Account { return wa.account }
+
+func (wa *testWalletAccount) SignProposal(account accounts.Account, proposal *types.Proposal, chainID *big.Int) (*types.Proposal, error) {
+	return proposal, nil
+}
+
+type testElection struct {
+	left     bool
+	txHash   common.Hash
+	deposits []*types.Deposit
+}
+
+func (e *testElection) Leave(walletAccount accounts.WalletAccount) (common.Hash, error) {
+	e.left = true
+	return e.txHash, nil
+}
+
+func (e *testElection) Deposits(addr common.Address) ([]*types.Deposit, error) {
+	if !e.left {
+		return nil, errors.New("deposits requested before leaving")
+	}
+	return e.deposits, nil
+}
+
+type testReceipts struct {
+	receipts map[common.Hash]*types.Receipt
+}
+
+func (b *testReceipts) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return b.receipts[txHash], nil
+}
+
+func newExitingValidator() *validator {
+	return &validator{
+		walletAccount: &testWalletAccount{account: accounts.Account{Address: common.HexToAddress("0x01")}},
+		shouldStart:   1,
+		validating:    1,
+	}
+}
+
+func TestValidator_Exit(t *testing.T) {
+	val := newExitingValidator()
+	txHash := common.HexToHash("0x02")
+	election := &testElection{
+		txHash:   txHash,
+		deposits: []*types.Deposit{types.NewDeposit(big.NewInt(100), time.Now().Unix())},
+	}
+	receipts := &testReceipts{map[common.Hash]*types.Receipt{txHash: {Status: types.ReceiptStatusSuccessful}}}
+
+	deposits, err := val.exit(election, receipts)
+	require.NoError(t, err)
+
+	assert.True(t, election.left)
+	assert.Equal(t, election.deposits, deposits)
+	assert.False(t, val.Validating())
+	assert.Equal(t, int32(0), atomic.LoadInt32(&val.shouldStart))
+}
+
+func TestValidator_ExitFailures(t *testing.T) {
+	txHash := common.HexToHash("0x02")
+
+	t.Run("not validating", func(t *testing.T) {
+		val := newExitingValidator()
+		val.validating = 0
+		election := &testElection{txHash: txHash}
+
+		_, err := val.exit(election, &testReceipts{})
+		assert.Equal(t, ErrIsNotRunning, err)
+		assert.False(t, election.left)
+	})
+
+	t.Run("deregistration failed", func(t *testing.T) {
+		val := newExitingValidator()
+		receipts := &testReceipts{map[common.Hash]*types.Receipt{txHash: {Status: types.ReceiptStatusFailed}}}
+
+		_, err := val.exit(&testElection{txHash: txHash}, receipts)
+		assert.Error(t, err)
+		assert.True(t, val.Validating())
+	})
+
+	t.Run("unbonding not acknowledged", func(t *testing.T) {
+		val := newExitingValidator()
+		election := &testElection{
+			txHash:   txHash,
+			deposits: []*types.Deposit{types.NewDeposit(big.NewInt(100), 0)},
+		}
+		receipts := &testReceipts{map[common.Hash]*types.Receipt{txHash: {Status: types.ReceiptStatusSuccessful}}}
+
+		_, err := val.exit(election, receipts)
+		assert.Equal(t, ErrUnbondingNotAcknowledged, err)
+		assert.True(t, val.Validating())
+	})
+}
+
+func TestValidator_Weights(t *testing.T) {
+	val := &validator{}
+	_, _, err := val.Weights()
+	assert.Equal(t, ErrIsNotRunning, err)
+
+	voters, err := types.NewVoters([]*types.Voter{
+		types.NewVoter(common.HexToAddress("0x01"), big.NewInt(100), big.NewInt(0)),
+		types.NewVoter(common.HexToAddress("0x02"), big.NewInt(200), big.NewInt(0)),
+		types.NewVoter(common.HexToAddress("0x03"), big.NewInt(300), big.NewInt(0)),
+	})
+	require.NoError(t, err)
+	val.voters = voters
+
+	const rounds = 5
+	for i := 0; i < rounds; i++ {
+		val.newRoundState()
+	}
+
+	dump, dumpRounds, err := val.Weights()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(rounds), dumpRounds)
+	require.Len(t, dump, voters.Len())
+	for i, voter := range dump {
+		assert.Equal(t, voters.At(i).Address(), voter.Address())
+		assert.Equal(t, voters.At(i).Deposit(), voter.Deposit())
+		assert.Equal(t, voters.At(i).Weight(), voter.Weight())
+	}
+
+	// the dump is a snapshot of the internal state
+	dump[0].Weight().SetInt64(-1)
+	assert.NotEqual(t, big.NewInt(-1), voters.At(0).Weight())
+}
+
+func TestValidator_WaitWhilePaused(t *testing.T) {
+	defer func(interval time.Duration) { pausedRecheckInterval = interval }(pausedRecheckInterval)
+	pausedRecheckInterval = 10 * time.Millisecond
+
+	val := &validator{}
+	assert.True(t, val.waitWhilePaused()) // no check set
+
+	var paused int32 = 1
+	val.SetPaused(func() bool { return atomic.LoadInt32(&paused) == 1 })
+	time.AfterFunc(100*time.Millisecond, func() { atomic.StoreInt32(&paused, 0) })
+
+	begin := time.Now()
+	assert.True(t, val.waitWhilePaused())
+	elapsed := time.Since(begin)
+	assert.True(t, elapsed >= 100*time.Millisecond, "resumed after %v while paused", elapsed)
+}
+
+func TestValidator_WaitWhilePausedStopped(t *testing.T) {
+	quit := make(chan struct{})
+	val := &validator{quit: quit}
+	val.SetPaused(func() bool { return true })
+	time.AfterFunc(50*time.Millisecond, func() { close(quit) })
+
+	done := make(chan bool)
+	go func() { done <- val.waitWhilePaused() }()
+	select {
+	case resumed := <-done:
+		assert.False(t, resumed, "commit resumed after the validator stopped")
+	case <-time.After(5 * time.Second):
+		t.Fatal("paused commit not released by the validator stop")
+	}
+}
+
+func TestValidator_NormalizedVotersFork(t *testing.T) {
+	voters, err := types.NewVoters([]*types.Voter{
+		types.NewVoter(common.HexToAddress("0x01"), big.NewInt(100), big.NewInt(0)),
+		types.NewVoter(common.HexToAddress("0x02"), big.NewInt(200), big.NewInt(0)),
+	})
+	require.NoError(t, err)
+
+	config := *params.TestChainConfig
+	config.Forks = []params.Fork{{Name: params.NormalizedVotersFork, Block: big.NewInt(2)}}
+	val := &validator{config: &config, voters: voters}
+
+	// the proposer gives up its own deposit before the fork
+	val.blockNumber = big.NewInt(1)
+	val.applyVotersForks()
+	val.newRoundState()
+	assert.Equal(t, []*big.Int{big.NewInt(100), big.NewInt(0)}, voters.Weights())
+
+	// and the total deposit from the fork on, with the weights centered
+	val.blockNumber = big.NewInt(2)
+	val.applyVotersForks()
+	val.newRoundState()
+	assert.Equal(t, []*big.Int{big.NewInt(-150), big.NewInt(150)}, voters.Weights())
+}
+
+func TestSimulateProposers(t *testing.T) {
+	const rounds = 6000
+	const tolerance = 0.01
+
+	testCases := []struct {
+		name     string
+		deposits []int64
+	}{
+		{"equal deposits", []int64{100, 100, 100, 100}},
+		{"unequal deposits", []int64{100, 200}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var total int64
+			voters := make([]*types.Voter, len(tc.deposits))
+			for i, deposit := range tc.deposits {
+				voters[i] = types.NewVoter(common.BigToAddress(big.NewInt(int64(i+1))), big.NewInt(deposit), big.NewInt(0))
+				total += deposit
+			}
+
+			proposals, err := SimulateProposers(voters, rounds)
+			require.NoError(t, err)
+			require.Len(t, proposals, len(voters))
+
+			for i, deposit := range tc.deposits {
+				expected := float64(deposit) / float64(total)
+				assert.InDelta(t, expected, float64(proposals[i])/rounds, tolerance, "validator %d", i)
+				assert.Equal(t, big.NewInt(0), voters[i].Weight(), "simulation changed the voter weights")
+			}
+		})
+	}
+}
+
+func TestValidator_ProposeRecordsLatency(t *testing.T) {
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = false }()
+
+	oldHistogram := proposeLatencyHistogram
+	defer func() { proposeLatencyHistogram = oldHistogram }()
+	proposeLatencyHistogram = metrics.NewHistogram(metrics.NewExpDecaySample(1028, 0.015))
+
+	eventMux := new(event.TypeMux)
+	defer eventMux.Stop()
+	proposals := eventMux.Subscribe(core.NewProposalEvent{})
+
+	val := &validator{
+		walletAccount: &testWalletAccount{account: accounts.Account{Address: common.HexToAddress("0x01")}},
+		config:        params.TestChainConfig,
+		eventMux:      eventMux,
+	}
+	val.blockNumber = big.NewInt(1)
+	val.lockedBlock = types.NewBlock(&types.Header{Number: big.NewInt(1)}, nil, nil, nil)
+
+	go val.propose()
+
+	select {
+	case <-proposals.Chan():
+	case <-time.After(time.Second):
+		t.Fatal("proposal not broadcast")
+	}
+	// the observation is recorded once all fragments are broadcast
+	for i := 0; i < 100 && proposeLatencyHistogram.Count() == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, int64(1), proposeLatencyHistogram.Count())
+}
+
+func TestValidator_CheckProposalCountsMissedTurns(t *testing.T) {
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = false }()
+
+	oldCounter := missedProposalCounter
+	defer func() { missedProposalCounter = oldCounter }()
+
+	local := common.HexToAddress("0x01")
+	remote := common.HexToAddress("0x02")
+	blockBy := func(author common.Address) *types.Block {
+		return types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), Coinbase: author})
+	}
+
+	testCases := []struct {
+		name     string
+		proposer common.Address
+		block    *types.Block
+		missed   int64
+	}{
+		{"local turn without a block", local, nil, 1},
+		{"local turn won by another author", local, blockBy(remote), 1},
+		{"local turn with own block", local, blockBy(local), 0},
+		{"remote turn without a block", remote, nil, 0},
+		{"remote turn with its block", remote, blockBy(remote), 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			missedProposalCounter = metrics.NewCounter()
+
+			val := &validator{
+				walletAccount: &testWalletAccount{account: accounts.Account{Address: local}},
+			}
+			val.blockNumber = big.NewInt(1)
+			val.proposer = tc.proposer
+
+			val.checkProposal(tc.block)
+			assert.Equal(t, tc.missed, missedProposalCounter.Count())
+		})
+	}
+}
+
+func TestValidator_RoundState(t *testing.T) {
+	val := &validator{signer: types.NewAndromedaSigner(params.TestChainConfig.ChainID)}
+	_, err := val.RoundState()
+	assert.Equal(t, ErrNotValidating, err)
+
+	keys := make([]*ecdsa.PrivateKey, 2)
+	members := make([]*types.Voter, len(keys))
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		members[i] = types.NewVoter(crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(100), big.NewInt(0))
+	}
+	voters, err := types.NewVoters(members)
+	require.NoError(t, err)
+
+	eventMux := new(event.TypeMux)
+	defer eventMux.Stop()
+
+	val.blockNumber = big.NewInt(7)
+	val.round = 2
+	val.proposer = members[1].Address()
+	val.votingSystem, err = NewVotingSystem(eventMux, val.blockNumber, voters)
+	require.NoError(t, err)
+	atomic.StoreInt32(&val.validating, 1)
+
+	val.enterStep("preVoteWait")
+
+	block := common.HexToHash("0xb10c")
+	vote, err := types.SignVote(types.NewVote(val.blockNumber, block, 0, types.PreVote), val.signer, keys[0])
+	require.NoError(t, err)
+	require.NoError(t, val.AddVote(vote))
+
+	// the state machine moving on doesn't alter the snapshot being read
+	val.round = 3
+
+	state, err := val.RoundState()
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(7), state.BlockNumber)
+	assert.Equal(t, uint64(2), state.Round)
+	assert.Equal(t, "preVoteWait", state.Step)
+	assert.Equal(t, members[1].Address(), state.Proposer)
+	require.Len(t, state.Votes, 1)
+	assert.Equal(t, members[0].Address(), state.Votes[0].Address())
+	assert.Equal(t, block, state.Votes[0].Vote().BlockHash())
+	assert.Equal(t, types.PreVote, state.Votes[0].Vote().Type())
+}
+
+func TestValidator_CommitTimeoutBoundsNextHeightWait(t *testing.T) {
+	timing := &params.KonsensusConfig{BlockTime: 100, CommitTimeout: 100}
+	require.NoError(t, timing.Validate())
+
+	testCases := []struct {
+		name     string
+		timing   *params.KonsensusConfig
+		parent   time.Duration // Parent block time relative to now
+		min, max time.Duration
+	}{
+		{"parent in the past", timing, -time.Second, 0, 40 * time.Millisecond},
+		{"parent just committed", timing, 0, 100 * time.Millisecond, 400 * time.Millisecond},
+		{"parent in the future", timing, time.Hour, 100 * time.Millisecond, 400 * time.Millisecond},
+		{"unbounded wait", &params.KonsensusConfig{BlockTime: 100}, 0, 100 * time.Millisecond, 400 * time.Millisecond},
+		{"unbounded wait, parent in the past", &params.KonsensusConfig{BlockTime: 100}, -time.Second, 0, 40 * time.Millisecond},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// The next height starts a block time after the parent, like on init
+			val := &validator{timing: tc.timing}
+			val.start = time.Now().Add(tc.parent).Add(tc.timing.BlockDuration())
+
+			begin := time.Now()
+			val.waitForNextHeight()
+			elapsed := time.Since(begin)
+
+			assert.True(t, elapsed >= tc.min, "waited %v, want at least %v", elapsed, tc.min)
+			assert.True(t, elapsed < tc.max, "waited %v, want less than %v", elapsed, tc.max)
+		})
+	}
+}
+
+// newTestBlockValidator returns a validator ready to assemble a block on top
+// of a fresh chain in which the given accounts are funded.
+func newTestBlockValidator(t *testing.T, funded ...common.Address) (*validator, *core.BlockChain) {
+	alloc := make(core.GenesisAlloc)
+	for _, addr := range funded {
+		alloc[addr] = core.GenesisAccount{Balance: big.NewInt(1000000000000000000)}
+	}
+	db := kcoindb.NewMemDatabase()
+	genesis := core.Genesis{
+		Config:   params.TestChainConfig,
+		GasLimit: params.GenesisGasLimit,
+		Alloc:    alloc,
+	}
+	genesisBlock := genesis.MustCommit(db)
+	chain, err := core.NewBlockChain(db, nil, genesis.Config, konsensus.NewFaker(), vm.Config{})
+	require.NoError(t, err)
+
+	statedb, err := chain.StateAt(genesisBlock.Root())
+	require.NoError(t, err)
+
+	val := &validator{
+		config: genesis.Config,
+		signer: types.NewAndromedaSigner(genesis.Config.ChainID),
+		db:     db,
+	}
+	val.work = &work{
+		state: statedb,
+		header: &types.Header{
+			ParentHash: genesisBlock.Hash(),
+			Number:     big.NewInt(1),
+			GasLimit:   genesisBlock.GasLimit(),
+			Time:       big.NewInt(time.Now().Unix()),
+		},
+	}
+	return val, chain
+}
+
+func TestValidator_CommitBlockAnnouncesFinality(t *testing.T) {
+	val, chain := newTestBlockValidator(t)
+	defer chain.Stop()
+
+	val.chain = chain
+	val.eventMux = new(event.TypeMux)
+	defer val.eventMux.Stop()
+	val.work.header.Root = val.work.state.IntermediateRoot(false)
+	val.block = types.NewBlock(val.work.header, nil, nil, nil)
+
+	finalized := make(chan core.ChainFinalizedEvent, 1)
+	sub := chain.SubscribeChainFinalizedEvent(finalized)
+	defer sub.Unsubscribe()
+
+	require.NoError(t, val.commitBlock())
+	assert.Equal(t, val.block.Hash(), chain.CurrentBlock().Hash())
+
+	select {
+	case ev := <-finalized:
+		assert.Equal(t, val.block.Hash(), ev.Block.Hash())
+	case <-time.After(time.Second):
+		t.Fatal("no finalized event on commit")
+	}
+}
+
+func TestValidator_CommitBlockStoresVotes(t *testing.T) {
+	val, chain := newTestBlockValidator(t)
+	defer chain.Stop()
+
+	val.chain = chain
+	val.eventMux = new(event.TypeMux)
+	defer val.eventMux.Stop()
+	val.work.header.Root = val.work.state.IntermediateRoot(false)
+	val.block = types.NewBlock(val.work.header, nil, nil, nil)
+
+	keys := make([]*ecdsa.PrivateKey, 3)
+	members := make([]*types.Voter, len(keys))
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		members[i] = types.NewVoter(crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(100), big.NewInt(0))
+	}
+	voters, err := types.NewVoters(members)
+	require.NoError(t, err)
+	val.votingSystem, err = NewVotingSystem(val.eventMux, val.block.Number(), voters)
+	require.NoError(t, err)
+
+	addVote := func(key *ecdsa.PrivateKey, hash common.Hash, voteType types.VoteType) {
+		vote, err := types.SignVote(types.NewVote(val.block.Number(), hash, 0, voteType), val.signer, key)
+		require.NoError(t, err)
+		addressVote, err := types.NewAddressVote(val.signer, vote)
+		require.NoError(t, err)
+		require.NoError(t, val.votingSystem.Add(addressVote))
+	}
+	for _, key := range keys {
+		addVote(key, val.block.Hash(), types.PreVote)
+	}
+	addVote(keys[0], val.block.Hash(), types.PreCommit)
+	addVote(keys[1], val.block.Hash(), types.PreCommit)
+	addVote(keys[2], common.Hash{}, types.PreCommit)
+
+	require.NoError(t, val.commitBlock())
+
+	stored := rawdb.ReadBlockVotes(val.db, val.block.Hash(), val.block.NumberU64())
+	require.NotNil(t, stored)
+	assert.Equal(t, uint64(0), stored.Round)
+
+	signers := func(votes []*types.Vote) map[common.Address]bool {
+		addrs := make(map[common.Address]bool)
+		for _, vote := range votes {
+			assert.Equal(t, val.block.Hash(), vote.BlockHash())
+			addressVote, err := types.NewAddressVote(val.signer, vote)
+			require.NoError(t, err)
+			addrs[addressVote.Address()] = true
+		}
+		return addrs
+	}
+	assert.Equal(t, map[common.Address]bool{members[0].Address(): true, members[1].Address(): true, members[2].Address(): true}, signers(stored.PreVotes))
+	assert.Equal(t, map[common.Address]bool{members[0].Address(): true, members[1].Address(): true}, signers(stored.PreCommits))
+}
+
+func TestValidator_RestartRestoresValidatorSet(t *testing.T) {
+	val, chain := newTestBlockValidator(t)
+	defer chain.Stop()
+
+	val.chain = chain
+	val.eventMux = new(event.TypeMux)
+	defer val.eventMux.Stop()
+
+	voters, err := types.NewVoters([]*types.Voter{
+		types.NewVoter(common.HexToAddress("0x01"), big.NewInt(100), big.NewInt(0)),
+		types.NewVoter(common.HexToAddress("0x02"), big.NewInt(200), big.NewInt(0)),
+		types.NewVoter(common.HexToAddress("0x03"), big.NewInt(300), big.NewInt(0)),
+	})
+	require.NoError(t, err)
+	val.voters = voters
+	val.votersChecksum = types.VotersChecksum{0x01}
+	for i := 0; i < 4; i++ {
+		val.newRoundState()
+	}
+	val.work.header.Root = val.work.state.IntermediateRoot(false)
+	val.block = types.NewBlock(val.work.header, nil, nil, nil)
+	require.NoError(t, val.commitBlock())
+
+	want, wantRounds, err := val.Weights()
+	require.NoError(t, err)
+
+	// a restarted validator has no consensus binding to recompute the set
+	// from, so it must come from the database
+	restarted := &validator{chain: chain, db: val.db}
+	require.True(t, restarted.restoreValidators(val.votersChecksum))
+
+	have, haveRounds, err := restarted.Weights()
+	require.NoError(t, err)
+	assert.Equal(t, wantRounds, haveRounds)
+	require.Len(t, have, len(want))
+	for i := range want {
+		assert.Equal(t, want[i].Address(), have[i].Address())
+		assert.Equal(t, want[i].Deposit(), have[i].Deposit())
+		assert.Equal(t, want[i].Weight(), have[i].Weight())
+	}
+	assert.Equal(t, val.voters.NextProposer().Address(), restarted.voters.NextProposer().Address())
+
+	// a set that changed in the contract since it was stored isn't restored
+	assert.False(t, (&validator{chain: chain, db: val.db}).restoreValidators(types.VotersChecksum{0x02}))
+}
+
+func TestValidator_CommitTransactionsStopsAtMaxBlockTxs(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+
+	val, chain := newTestBlockValidator(t, sender)
+	defer chain.Stop()
+
+	txs := make(map[common.Address]types.Transactions)
+	for nonce := uint64(0); nonce < 5; nonce++ {
+		tx, err := types.SignTx(types.NewTransaction(nonce, common.Address{0x01}, big.NewInt(1), params.TxGas, big.NewInt(1), nil), val.signer, key)
+		require.NoError(t, err)
+		txs[sender] = append(txs[sender], tx)
+	}
+
+	val.SetMaxBlockTxs(3)
+	val.commitTransactions(new(event.TypeMux), types.NewTransactionsByPriceAndNonce(val.signer, txs), chain, common.Address{})
+
+	assert.Equal(t, 3, val.tcount)
+	assert.Len(t, val.txs, 3)
+	assert.True(t, val.header.GasUsed+params.TxGas <= val.header.GasLimit, "block should have gas left for more transactions")
+}
+
+func TestValidator_PriorityAddressesIncludedFirst(t *testing.T) {
+	priorityKey, _ := crypto.GenerateKey()
+	otherKey, _ := crypto.GenerateKey()
+	prioritySender := crypto.PubkeyToAddress(priorityKey.PublicKey)
+	otherSender := crypto.PubkeyToAddress(otherKey.PublicKey)
+
+	val, chain := newTestBlockValidator(t, prioritySender, otherSender)
+	defer chain.Stop()
+
+	sign := func(key *ecdsa.PrivateKey, nonce uint64, price int64) *types.Transaction {
+		tx, err := types.SignTx(types.NewTransaction(nonce, common.Address{0x01}, big.NewInt(1), params.TxGas, big.NewInt(price), nil), val.signer, key)
+		require.NoError(t, err)
+		return tx
+	}
+	pending := map[common.Address]types.Transactions{
+		prioritySender: {sign(priorityKey, 0, 1), sign(priorityKey, 1, 1)},
+		otherSender:    {sign(otherKey, 0, 100)},
+	}
+
+	val.SetPriorityAddresses([]common.Address{prioritySender})
+	val.commitTransactions(new(event.TypeMux), val.orderTransactions(core.PriceOrdering, pending), chain, common.Address{})
+
+	require.Len(t, val.txs, 3)
+	for i, want := range []common.Address{prioritySender, prioritySender, otherSender} {
+		from, err := types.TxSender(val.signer, val.txs[i])
+		require.NoError(t, err)
+		assert.Equal(t, want, from, "transaction %d", i)
+	}
+}
+
+// keyWalletAccount signs votes with a private key.
+type keyWalletAccount struct {
+	testWalletAccount
+	key *ecdsa.PrivateKey
+}
+
+func (wa *keyWalletAccount) SignVote(account accounts.Account, vote *types.Vote, chainID *big.Int) (*types.Vote, error) {
+	return types.SignVote(vote, types.NewAndromedaSigner(chainID), wa.key)
+}
+
+func TestValidator_SingleValidatorCommitsWithoutWaiting(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	voters, err := types.NewVoters([]*types.Voter{types.NewVoter(address, big.NewInt(100), big.NewInt(0))})
+	require.NoError(t, err)
+
+	eventMux := new(event.TypeMux)
+	defer eventMux.Stop()
+
+	val := &validator{
+		walletAccount: &keyWalletAccount{testWalletAccount{account: accounts.Account{Address: address}}, key},
+		config:        params.TestChainConfig,
+		signer:        types.NewAndromedaSigner(params.TestChainConfig.ChainID),
+		timing:        &params.KonsensusConfig{PreVoteTimeout: 10000, PreCommitTimeout: 10000},
+		eventMux:      eventMux,
+		blockNumber:   big.NewInt(1),
+		block:         types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1)}),
+	}
+	val.votingSystem, err = NewVotingSystem(eventMux, val.blockNumber, voters)
+	require.NoError(t, err)
+	val.majority = eventMux.Subscribe(core.NewMajorityEvent{})
+
+	begin := time.Now()
+	state := val.preVoteState
+	for i := 0; i < 4; i++ {
+		state = state()
+	}
+	elapsed := time.Since(begin)
+
+	assert.Equal(t, reflect.ValueOf(val.commitState).Pointer(), reflect.ValueOf(state).Pointer(), "block not committed")
+	assert.Equal(t, val.block, val.lockedBlock)
+	assert.True(t, elapsed < time.Second, "waited %v for the step timeouts", elapsed)
+}
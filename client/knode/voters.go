@@ -0,0 +1,142 @@
+package knode
+
+import (
+	"sync"
+
+	"github.com/kowala-tech/kcoin/client/core"
+	"github.com/kowala-tech/kcoin/client/core/types"
+	"github.com/kowala-tech/kcoin/client/event"
+	"github.com/kowala-tech/kcoin/client/log"
+)
+
+// chainHeadSubscriber is the part of the blockchain the voters tracker follows.
+type chainHeadSubscriber interface {
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+}
+
+// votersSource provides the validator set at the head of the chain.
+type votersSource interface {
+	Validators() (types.Voters, error)
+}
+
+// votersReader provides the last known validator set.
+type votersReader interface {
+	Voters() types.Voters
+}
+
+// votersTracker follows the chain head and notifies its subscribers whenever
+// an applied block changes the validator set, either because a validator
+// joined or left or because its deposit changed.
+type votersTracker struct {
+	chain  chainHeadSubscriber
+	source votersSource
+
+	voters   types.Voters
+	votersMu sync.RWMutex // Protects the voters
+
+	feed  event.Feed
+	scope event.SubscriptionScope
+	quit  chan struct{}
+	wg    sync.WaitGroup
+}
+
+func newVotersTracker(chain chainHeadSubscriber, source votersSource) *votersTracker {
+	return &votersTracker{
+		chain:  chain,
+		source: source,
+		quit:   make(chan struct{}),
+	}
+}
+
+// start loads the current validator set and begins following the chain head.
+func (t *votersTracker) start() {
+	voters, err := t.source.Validators()
+	if err != nil {
+		log.Debug("Failed to load the validator set", "err", err)
+	}
+	t.votersMu.Lock()
+	t.voters = voters
+	t.votersMu.Unlock()
+
+	headCh := make(chan core.ChainHeadEvent, 10)
+	sub := t.chain.SubscribeChainHeadEvent(headCh)
+
+	t.wg.Add(1)
+	go t.loop(headCh, sub)
+}
+
+// stop terminates the tracker and all the subscriptions to it.
+func (t *votersTracker) stop() {
+	close(t.quit)
+	t.wg.Wait()
+	t.scope.Close()
+}
+
+func (t *votersTracker) loop(headCh chan core.ChainHeadEvent, sub event.Subscription) {
+	defer t.wg.Done()
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-headCh:
+			t.update(ev.Block)
+		case <-sub.Err():
+			return
+		case <-t.quit:
+			return
+		}
+	}
+}
+
+// update reloads the validator set after the given block was applied and
+// notifies the subscribers if it changed.
+func (t *votersTracker) update(block *types.Block) {
+	voters, err := t.source.Validators()
+	if err != nil {
+		log.Debug("Failed to load the validator set", "number", block.Number(), "err", err)
+		return
+	}
+	if sameVoters(t.voters, voters) {
+		return
+	}
+	log.Info("Validator set changed", "number", block.Number(), "hash", block.Hash(), "validators", voters.Len())
+
+	t.votersMu.Lock()
+	prev := t.voters
+	t.voters = voters
+	t.votersMu.Unlock()
+	t.feed.Send(core.ValidatorSetChangedEvent{Block: block, Old: prev, New: voters})
+}
+
+// Voters returns the validator set at the head of the chain, or nil if it
+// couldn't be loaded.
+func (t *votersTracker) Voters() types.Voters {
+	t.votersMu.RLock()
+	defer t.votersMu.RUnlock()
+
+	return t.voters
+}
+
+// SubscribeValidatorSetChangedEvent registers a subscription of
+// ValidatorSetChangedEvent.
+func (t *votersTracker) SubscribeValidatorSetChangedEvent(ch chan<- core.ValidatorSetChangedEvent) event.Subscription {
+	return t.scope.Track(t.feed.Subscribe(ch))
+}
+
+// sameVoters reports whether both sets hold the same validators, in the same
+// order and with the same deposits.
+func sameVoters(a, b types.Voters) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if a.Len() != b.Len() {
+		return false
+	}
+	for i := 0; i < a.Len(); i++ {
+		va, vb := a.At(i), b.At(i)
+		if va.Address() != vb.Address() || va.Deposit().Cmp(vb.Deposit()) != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package knode
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/core"
+	"github.com/kowala-tech/kcoin/client/core/types"
+	"github.com/kowala-tech/kcoin/client/event"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testChainHeads struct {
+	feed event.Feed
+}
+
+func (c *testChainHeads) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return c.feed.Subscribe(ch)
+}
+
+type testVotersSource struct {
+	voters chan types.Voters
+}
+
+func (s *testVotersSource) Validators() (types.Voters, error) {
+	return <-s.voters, nil
+}
+
+func newTestVoters(t *testing.T, deposits map[byte]int64) types.Voters {
+	var list []*types.Voter
+	for i := byte(0); i < 0xff; i++ {
+		if deposit, ok := deposits[i]; ok {
+			list = append(list, types.NewVoter(common.Address{i}, big.NewInt(deposit), big.NewInt(0)))
+		}
+	}
+	voters, err := types.NewVoters(list)
+	require.NoError(t, err)
+	return voters
+}
+
+func TestVotersTracker(t *testing.T) {
+	chain := new(testChainHeads)
+	source := &testVotersSource{voters: make(chan types.Voters, 1)}
+	tracker := newVotersTracker(chain, source)
+
+	initial := newTestVoters(t, map[byte]int64{1: 100})
+	source.voters <- initial
+	tracker.start()
+	defer tracker.stop()
+
+	changes := make(chan core.ValidatorSetChangedEvent, 1)
+	sub := tracker.SubscribeValidatorSetChangedEvent(changes)
+	defer sub.Unsubscribe()
+
+	joined := newTestVoters(t, map[byte]int64{1: 100, 2: 50})
+	redeposited := newTestVoters(t, map[byte]int64{1: 100, 2: 80})
+	steps := []struct {
+		voters  types.Voters
+		changed bool
+		prev    types.Voters
+	}{
+		{newTestVoters(t, map[byte]int64{1: 100}), false, nil},
+		{joined, true, initial},
+		{redeposited, true, joined},
+		{newTestVoters(t, map[byte]int64{1: 100, 2: 80}), false, nil},
+	}
+	for i, step := range steps {
+		block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(int64(i + 1))})
+		source.voters <- step.voters
+		chain.feed.Send(core.ChainHeadEvent{Block: block})
+
+		select {
+		case ev := <-changes:
+			require.True(t, step.changed, "step %d: unexpected change event", i)
+			assert.Equal(t, block, ev.Block)
+			assert.Equal(t, step.prev, ev.Old)
+			assert.Equal(t, step.voters, ev.New)
+		case <-time.After(100 * time.Millisecond):
+			require.False(t, step.changed, "step %d: missing change event", i)
+		}
+	}
+}
@@ -12,7 +12,6 @@ import (
 	"strings"
 	"time"
 
-	prometheusmetrics "github.com/kowala-tech/go-metrics-prometheus"
 	"github.com/kowala-tech/kcoin/client/log"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -62,8 +61,8 @@ func CollectProcessMetrics(refresh time.Duration, promAddr, promSubSys string) {
 	go func() {
 		prometheusRegistry := prometheus.DefaultGatherer
 		metricsRegistry := DefaultRegistry
-		pClient := prometheusmetrics.NewPrometheusProvider(metricsRegistry, "eth", promSubSys, (prometheusRegistry).(*prometheus.Registry), refresh)
-		go pClient.UpdatePrometheusMetrics()
+		exporter := newPrometheusExporter(metricsRegistry, "eth", promSubSys, (prometheusRegistry).(*prometheus.Registry))
+		go exporter.loop(refresh)
 
 		log.Info("Starting Prometheus metrics", "address", promAddr, "subsystem", promSubSys)
 		http.Handle("/metrics", promhttp.HandlerFor(prometheusRegistry, promhttp.HandlerOpts{}))
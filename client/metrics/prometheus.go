@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// prometheusQuantiles are the quantiles histograms are summarized with.
+var prometheusQuantiles = []float64{0.5, 0.75, 0.95, 0.99, 0.999}
+
+// prometheusExporter mirrors the metrics of a registry into Prometheus gauges,
+// and histograms into summaries, applying the given namespace and subsystem to
+// all produced series.
+type prometheusExporter struct {
+	registry  Registry
+	namespace string
+	subsystem string
+	gatherer  prometheus.Registerer
+	gauges    map[string]prometheus.Gauge
+	summaries map[string]*prometheusSummary
+}
+
+// newPrometheusExporter creates an exporter from the given metrics registry into
+// the given Prometheus one.
+func newPrometheusExporter(r Registry, namespace, subsystem string, gatherer prometheus.Registerer) *prometheusExporter {
+	return &prometheusExporter{
+		registry:  r,
+		namespace: namespace,
+		subsystem: subsystem,
+		gatherer:  gatherer,
+		gauges:    make(map[string]prometheus.Gauge),
+		summaries: make(map[string]*prometheusSummary),
+	}
+}
+
+// loop periodically updates the Prometheus series from the metrics registry.
+func (e *prometheusExporter) loop(refresh time.Duration) {
+	for range time.Tick(refresh) {
+		e.update()
+	}
+}
+
+// update refreshes every Prometheus series with the current metric values.
+func (e *prometheusExporter) update() {
+	e.registry.Each(func(name string, i interface{}) {
+		switch metric := i.(type) {
+		case Counter:
+			e.set(name, float64(metric.Count()))
+		case Gauge:
+			e.set(name, float64(metric.Value()))
+		case GaugeFloat64:
+			e.set(name, metric.Value())
+		case Histogram:
+			e.summarize(name, metric.Snapshot())
+		case Meter:
+			e.set(name, metric.Snapshot().Rate1())
+		case Timer:
+			e.set(name, metric.Snapshot().Rate1())
+		}
+	})
+}
+
+// set updates the Prometheus gauge of a metric, registering it on first use.
+func (e *prometheusExporter) set(name string, value float64) {
+	key := fmt.Sprintf("%s_%s_%s", e.namespace, e.subsystem, name)
+	g, ok := e.gauges[key]
+	if !ok {
+		g = prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: flattenPrometheusKey(e.namespace),
+			Subsystem: flattenPrometheusKey(e.subsystem),
+			Name:      flattenPrometheusKey(name),
+			Help:      name,
+		})
+		e.gatherer.MustRegister(g)
+		e.gauges[key] = g
+	}
+	g.Set(value)
+}
+
+// summarize updates the Prometheus summary of a histogram, registering it on
+// first use.
+func (e *prometheusExporter) summarize(name string, h Histogram) {
+	key := fmt.Sprintf("%s_%s_%s", e.namespace, e.subsystem, name)
+	s, ok := e.summaries[key]
+	if !ok {
+		fqName := prometheus.BuildFQName(flattenPrometheusKey(e.namespace), flattenPrometheusKey(e.subsystem), flattenPrometheusKey(name))
+		s = &prometheusSummary{desc: prometheus.NewDesc(fqName, name, nil, nil)}
+		e.gatherer.MustRegister(s)
+		e.summaries[key] = s
+	}
+	quantiles := make(map[float64]float64, len(prometheusQuantiles))
+	for i, value := range h.Percentiles(prometheusQuantiles) {
+		quantiles[prometheusQuantiles[i]] = value
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.count, s.sum, s.quantiles = uint64(h.Count()), float64(h.Sum()), quantiles
+}
+
+// prometheusSummary is a Prometheus collector exposing the last snapshot of a
+// histogram as a summary.
+type prometheusSummary struct {
+	desc      *prometheus.Desc
+	count     uint64
+	sum       float64
+	quantiles map[float64]float64
+	lock      sync.Mutex
+}
+
+// Describe implements prometheus.Collector.
+func (s *prometheusSummary) Describe(ch chan<- *prometheus.Desc) {
+	ch <- s.desc
+}
+
+// Collect implements prometheus.Collector.
+func (s *prometheusSummary) Collect(ch chan<- prometheus.Metric) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	ch <- prometheus.MustNewConstSummary(s.desc, s.count, s.sum, s.quantiles)
+}
+
+// flattenPrometheusKey replaces the characters not allowed in Prometheus metric
+// names with underscores.
+func flattenPrometheusKey(key string) string {
+	return strings.NewReplacer(" ", "_", ".", "_", "-", "_", "=", "_", "/", "_").Replace(key)
+}
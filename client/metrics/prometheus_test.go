@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestPrometheusExporter(t *testing.T) {
+	r := NewRegistry()
+	NewRegisteredCounter("txpool/queued/noncegap", r).Inc(3)
+	NewRegisteredGauge("txpool/pending", r).Update(47)
+	histogram := NewRegisteredHistogram("knode/votes/delay", r, NewUniformSample(100))
+	for _, value := range []int64{10, 20, 30, 40} {
+		histogram.Update(value)
+	}
+
+	gatherer := prometheus.NewRegistry()
+	newPrometheusExporter(r, "eth", "node", gatherer).update()
+
+	families, err := gatherer.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	values := make(map[string]float64)
+	for _, family := range families {
+		if summary := family.GetMetric()[0].GetSummary(); summary != nil {
+			// histograms are summarized rather than reduced to a single value
+			if have, want := summary.GetSampleCount(), uint64(4); have != want {
+				t.Errorf("%s: sample count mismatch: have %d, want %d", family.GetName(), have, want)
+			}
+			if have, want := summary.GetSampleSum(), float64(100); have != want {
+				t.Errorf("%s: sample sum mismatch: have %v, want %v", family.GetName(), have, want)
+			}
+			for _, quantile := range summary.GetQuantile() {
+				values[fmt.Sprintf("%s{%v}", family.GetName(), quantile.GetQuantile())] = quantile.GetValue()
+			}
+			continue
+		}
+		values[family.GetName()] = family.GetMetric()[0].GetGauge().GetValue()
+	}
+	want := map[string]float64{
+		"eth_node_txpool_queued_noncegap":  3,
+		"eth_node_txpool_pending":          47,
+		"eth_node_knode_votes_delay{0.5}":  25,
+		"eth_node_knode_votes_delay{0.99}": 40,
+	}
+	for name, value := range want {
+		if have, ok := values[name]; !ok || have != value {
+			t.Errorf("%s: value mismatch: have %v (exported %v), want %v", name, have, ok, value)
+		}
+	}
+}
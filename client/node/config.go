@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/kowala-tech/kcoin/client/accounts"
 	"github.com/kowala-tech/kcoin/client/accounts/keystore"
@@ -25,6 +26,8 @@ const (
 	datadirStaticNodes     = "static-nodes.json"  // Path within the datadir to the static node list
 	datadirTrustedNodes    = "trusted-nodes.json" // Path within the datadir to the trusted node list
 	datadirNodeDatabase    = "nodes"              // Path within the datadir to store the node infos
+	datadirLock            = "LOCK"               // Path within the instance directory to the datadir lock
+	datadirPID             = "PID"                // Path within the instance directory to the lock holder's process ID
 )
 
 // Config represents a small collection of configuration values to fine tune the
@@ -66,6 +69,11 @@ type Config struct {
 	// scrypt KDF at the expense of security.
 	UseLightweightKDF bool `toml:",omitempty"`
 
+	// ScryptN and ScryptP override the scrypt cost parameters of the key store.
+	// When zero, the standard or lightweight presets are used.
+	ScryptN int `toml:",omitempty"`
+	ScryptP int `toml:",omitempty"`
+
 	// NoUSB disables hardware wallet monitoring and connectivity.
 	NoUSB bool `toml:",omitempty"`
 
@@ -103,6 +111,28 @@ type Config struct {
 	// exposed.
 	HTTPModules []string `toml:",omitempty"`
 
+	// HTTPTLSCert and HTTPTLSKey are the PEM encoded certificate and private key
+	// files to serve the HTTP RPC interface over TLS with. Both need to be set to
+	// enable TLS, if neither is the interface is served over plain HTTP.
+	HTTPTLSCert string `toml:",omitempty"`
+	HTTPTLSKey  string `toml:",omitempty"`
+
+	// AuthSecretFile is the path of a file holding the hex encoded 32 byte secret
+	// HTTP and websocket RPC requests need to carry a bearer token signed with. If
+	// empty, RPC requests are not authenticated.
+	AuthSecretFile string `toml:",omitempty"`
+
+	// RPCRateLimit is the number of requests per second each client (by IP address)
+	// may issue to the HTTP and websocket RPC interfaces, zero leaving them
+	// unlimited. RPCMethodRateLimits overrides it for individual methods, such as
+	// expensive ones like kcoin_getLogs.
+	RPCRateLimit        float64            `toml:",omitempty"`
+	RPCMethodRateLimits map[string]float64 `toml:",omitempty"`
+
+	// RPCBatchLimit is the maximum number of requests a single JSON-RPC batch sent
+	// to the HTTP and websocket RPC interfaces may hold. Zero leaves it unbounded.
+	RPCBatchLimit int `toml:",omitempty"`
+
 	// WSHost is the host interface on which to start the websocket RPC server. If
 	// this field is empty, no websocket API endpoint will be started.
 	WSHost string `toml:",omitempty"`
@@ -129,6 +159,15 @@ type Config struct {
 	// private APIs to untrusted users is a major security risk.
 	WSExposeAll bool `toml:",omitempty"`
 
+	// RPCDisabledModules is a list of API modules never exposed via the HTTP and
+	// websocket RPC interfaces, regardless of HTTPModules, WSModules and
+	// WSExposeAll. The IPC interface is not affected.
+	RPCDisabledModules []string `toml:",omitempty"`
+
+	// ShutdownTimeout bounds how long the node waits for its services to stop
+	// before giving up on them. Zero waits indefinitely.
+	ShutdownTimeout time.Duration `toml:",omitempty"`
+
 	// Logger is a custom logger to use with the p2p.Server.
 	Logger log.Logger `toml:",omitempty"`
 }
@@ -178,6 +217,23 @@ func DefaultIPCEndpoint(clientIdentifier string) string {
 	return config.IPCEndpoint()
 }
 
+// AuthSecret loads the secret RPC requests are authenticated with, returning nil
+// if authentication is disabled.
+func (c *Config) AuthSecret() ([]byte, error) {
+	if c.AuthSecretFile == "" {
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(c.AuthSecretFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read RPC auth secret: %v", err)
+	}
+	secret := common.FromHex(strings.TrimSpace(string(data)))
+	if len(secret) != 32 {
+		return nil, fmt.Errorf("invalid RPC auth secret in %s: want 32 hex encoded bytes", c.AuthSecretFile)
+	}
+	return secret, nil
+}
+
 // HTTPEndpoint resolves an HTTP endpoint based on the configured host interface
 // and port parameters.
 func (c *Config) HTTPEndpoint() string {
@@ -356,6 +412,33 @@ func (c *Config) parsePersistentNodes(path string) []*discover.Node {
 	return nodes
 }
 
+// Bounds of the configurable scrypt parameters. The upper limit of N keeps the
+// memory needed per key derivation (128 * r * N bytes) at 4GB.
+const (
+	MinScryptN = 1 << 10
+	MaxScryptN = 1 << 22
+	MaxScryptP = 64
+)
+
+// ValidateScryptN checks that n is a power of two within the supported bounds.
+func ValidateScryptN(n int) error {
+	if n < MinScryptN || n > MaxScryptN {
+		return fmt.Errorf("scrypt N %d out of range [%d, %d]", n, MinScryptN, MaxScryptN)
+	}
+	if n&(n-1) != 0 {
+		return fmt.Errorf("scrypt N %d is not a power of two", n)
+	}
+	return nil
+}
+
+// ValidateScryptP checks that p is within the supported bounds.
+func ValidateScryptP(p int) error {
+	if p < 1 || p > MaxScryptP {
+		return fmt.Errorf("scrypt P %d out of range [1, %d]", p, MaxScryptP)
+	}
+	return nil
+}
+
 // AccountConfig determines the settings for scrypt and keydirectory
 func (c *Config) AccountConfig() (int, int, string, error) {
 	scryptN := keystore.StandardScryptN
@@ -364,6 +447,18 @@ func (c *Config) AccountConfig() (int, int, string, error) {
 		scryptN = keystore.LightScryptN
 		scryptP = keystore.LightScryptP
 	}
+	if c.ScryptN != 0 {
+		if err := ValidateScryptN(c.ScryptN); err != nil {
+			return 0, 0, "", err
+		}
+		scryptN = c.ScryptN
+	}
+	if c.ScryptP != 0 {
+		if err := ValidateScryptP(c.ScryptP); err != nil {
+			return 0, 0, "", err
+		}
+		scryptP = c.ScryptP
+	}
 
 	var (
 		keydir string
@@ -6,8 +6,10 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 
+	"github.com/kowala-tech/kcoin/client/accounts/keystore"
 	"github.com/kowala-tech/kcoin/client/crypto"
 	"github.com/kowala-tech/kcoin/client/p2p"
 )
@@ -77,6 +79,45 @@ func TestIPCPathResolution(t *testing.T) {
 	}
 }
 
+// Tests that explicit scrypt parameters override the KDF presets and that
+// invalid ones are rejected.
+func TestAccountConfigScrypt(t *testing.T) {
+	var tests = []struct {
+		LightKDF         bool
+		ScryptN, ScryptP int
+		N, P             int
+		Fail             bool
+	}{
+		{false, 0, 0, keystore.StandardScryptN, keystore.StandardScryptP, false},
+		{true, 0, 0, keystore.LightScryptN, keystore.LightScryptP, false},
+		{false, 1 << 14, 0, 1 << 14, keystore.StandardScryptP, false},
+		{true, 0, 2, keystore.LightScryptN, 2, false},
+		{true, 1 << 16, 4, 1 << 16, 4, false},
+		{false, 3000, 0, 0, 0, true},
+		{false, 1 << 8, 0, 0, 0, true},
+		{false, 1 << 24, 0, 0, 0, true},
+		{false, 0, -1, 0, 0, true},
+		{false, 0, MaxScryptP + 1, 0, 0, true},
+	}
+	for i, test := range tests {
+		config := &Config{UseLightweightKDF: test.LightKDF, ScryptN: test.ScryptN, ScryptP: test.ScryptP}
+		n, p, _, err := config.AccountConfig()
+		if test.Fail {
+			if err == nil {
+				t.Errorf("test %d: expected error, got N=%d P=%d", i, n, p)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+			continue
+		}
+		if n != test.N || p != test.P {
+			t.Errorf("test %d: scrypt parameters mismatch: have N=%d P=%d, want N=%d P=%d", i, n, p, test.N, test.P)
+		}
+	}
+}
+
 // Tests that node keys can be correctly created, persisted, loaded and/or made
 // ephemeral.
 func TestNodeKeyPersistency(t *testing.T) {
@@ -132,3 +173,28 @@ func TestNodeKeyPersistency(t *testing.T) {
 		t.Fatalf("ephemeral node key persisted to disk")
 	}
 }
+
+// Tests that the RPC authentication secret is loaded and validated.
+func TestConfigAuthSecret(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if secret, err := (&Config{}).AuthSecret(); secret != nil || err != nil {
+		t.Fatalf("secret without file: have %x, %v, want nil, nil", secret, err)
+	}
+	valid := filepath.Join(dir, "valid")
+	ioutil.WriteFile(valid, []byte("0x"+strings.Repeat("ab", 32)+"\n"), 0600)
+	if secret, err := (&Config{AuthSecretFile: valid}).AuthSecret(); err != nil || !bytes.Equal(secret, bytes.Repeat([]byte{0xab}, 32)) {
+		t.Fatalf("valid secret mismatch: have %x, %v", secret, err)
+	}
+	short := filepath.Join(dir, "short")
+	ioutil.WriteFile(short, []byte("abcd"), 0600)
+	for _, file := range []string{short, filepath.Join(dir, "missing")} {
+		if _, err := (&Config{AuthSecretFile: file}).AuthSecret(); err == nil {
+			t.Errorf("secret %s: expected error", filepath.Base(file))
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package node
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/kowala-tech/kcoin/client/kcoindb"
+	"github.com/kowala-tech/kcoin/client/log"
+)
+
+// minDatabaseCache is the smallest cache in megabytes a database is opened with.
+const minDatabaseCache = 16
+
+// openLDBDatabase opens the LevelDB database at the given path, retrying with a
+// smaller cache if the open fails for lack of memory.
+//
+// The retry is best-effort. LevelDB only allocates the write buffers when
+// opening, sized from the cache, and it does so through the Go runtime, which
+// aborts the process instead of returning an error when out of memory. Only
+// the ENOMEM errors of the system calls made while opening are retried, which
+// a smaller cache makes less likely but doesn't rule out.
+func openLDBDatabase(path string, cache, handles int) (kcoindb.Database, error) {
+	return openDatabase(func(cache int) (kcoindb.Database, error) {
+		db, err := kcoindb.NewLDBDatabase(path, cache, handles)
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	}, cache)
+}
+
+// openDatabase opens a database with the given cache in megabytes, halving the
+// cache down to minDatabaseCache as long as the open fails for lack of memory.
+func openDatabase(open func(cache int) (kcoindb.Database, error), cache int) (kcoindb.Database, error) {
+	for {
+		db, err := open(cache)
+		if err == nil || !isOutOfMemory(err) || cache <= minDatabaseCache {
+			return db, err
+		}
+		reduced := cache / 2
+		if reduced < minDatabaseCache {
+			reduced = minDatabaseCache
+		}
+		log.Warn("Not enough memory to open the database, reducing the cache", "cache", cache, "reduced", reduced, "err", err)
+		cache = reduced
+	}
+}
+
+// isOutOfMemory returns whether err was caused by the system running out of
+// memory.
+func isOutOfMemory(err error) bool {
+	for {
+		switch e := err.(type) {
+		case *os.PathError:
+			err = e.Err
+		case *os.SyscallError:
+			err = e.Err
+		case syscall.Errno:
+			return e == syscall.ENOMEM
+		default:
+			return false
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package node
+
+import (
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+
+	"github.com/kowala-tech/kcoin/client/kcoindb"
+)
+
+func TestOpenDatabaseReducesCache(t *testing.T) {
+	oom := &os.PathError{Op: "open", Path: "chaindata/000001.log", Err: syscall.ENOMEM}
+
+	// the cache is halved until the database fits in memory
+	var caches []int
+	db, err := openDatabase(func(cache int) (kcoindb.Database, error) {
+		caches = append(caches, cache)
+		if cache > 100 {
+			return nil, oom
+		}
+		return kcoindb.NewMemDatabase(), nil
+	}, 1024)
+	if err != nil || db == nil {
+		t.Fatalf("database not opened with a reduced cache: %v", err)
+	}
+	if want := []int{1024, 512, 256, 128, 64}; !reflect.DeepEqual(caches, want) {
+		t.Fatalf("cache mismatch: have %v, want %v", caches, want)
+	}
+
+	// the open fails below the minimum cache
+	caches = nil
+	if _, err := openDatabase(func(cache int) (kcoindb.Database, error) {
+		caches = append(caches, cache)
+		return nil, oom
+	}, 50); err != oom {
+		t.Fatalf("error mismatch: have %v, want %v", err, oom)
+	}
+	if want := []int{50, 25, minDatabaseCache}; !reflect.DeepEqual(caches, want) {
+		t.Fatalf("cache mismatch: have %v, want %v", caches, want)
+	}
+
+	// other failures aren't retried
+	caches = nil
+	failure := &os.PathError{Op: "open", Path: "chaindata/LOCK", Err: syscall.EAGAIN}
+	if _, err := openDatabase(func(cache int) (kcoindb.Database, error) {
+		caches = append(caches, cache)
+		return nil, failure
+	}, 1024); err != failure {
+		t.Fatalf("error mismatch: have %v, want %v", err, failure)
+	}
+	if len(caches) != 1 {
+		t.Fatalf("open retried after a failure unrelated to memory: %v", caches)
+	}
+}
@@ -12,6 +12,9 @@ var (
 	ErrNodeStopped    = errors.New("node not started")
 	ErrNodeRunning    = errors.New("node already running")
 	ErrServiceUnknown = errors.New("unknown service")
+	ErrStopTimeout    = errors.New("service did not stop in time")
+
+	ErrHTTPTLSIncomplete = errors.New("HTTP-RPC TLS requires both a certificate and a key file")
 
 	datadirInUseErrnos = map[uint]bool{11: true, 32: true, 35: true}
 )
@@ -23,6 +26,23 @@ func convertFileLockError(err error) error {
 	return err
 }
 
+// DatadirUsedError is returned during Node startup if the datadir is locked by
+// another running instance.
+type DatadirUsedError struct {
+	PID int
+}
+
+// Error generates a textual representation of the datadir in use error.
+func (e *DatadirUsedError) Error() string {
+	return fmt.Sprintf("datadir already in use by PID %d", e.PID)
+}
+
+// Unwrap returns ErrDatadirUsed, so that errors.Is matches both forms of the
+// datadir in use error.
+func (e *DatadirUsedError) Unwrap() error {
+	return ErrDatadirUsed
+}
+
 // DuplicateServiceError is returned during Node startup if a registered service
 // constructor returns a service of the same type that was already started.
 type DuplicateServiceError struct {
@@ -3,12 +3,15 @@ package node
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/kowala-tech/kcoin/client/accounts"
 	"github.com/kowala-tech/kcoin/client/event"
@@ -222,14 +225,49 @@ func (n *Node) openDataDir() error {
 	}
 	// Lock the instance directory to prevent concurrent use by another instance as well as
 	// accidental use of the instance directory as a database.
-	release, _, err := flock.New(filepath.Join(instdir, "LOCK"))
+	release, _, err := flock.New(filepath.Join(instdir, datadirLock))
 	if err != nil {
-		return convertFileLockError(err)
+		return datadirLockError(instdir, convertFileLockError(err))
+	}
+	// Record the process holding the lock so other instances can report it.
+	pid := []byte(strconv.Itoa(os.Getpid()))
+	if err := ioutil.WriteFile(filepath.Join(instdir, datadirPID), pid, 0644); err != nil {
+		release.Release()
+		return err
 	}
 	n.instanceDirLock = release
 	return nil
 }
 
+// closeDataDir releases the instance directory lock taken by openDataDir.
+func (n *Node) closeDataDir() {
+	if n.instanceDirLock == nil {
+		return
+	}
+	os.Remove(filepath.Join(n.config.DataDir, n.config.name(), datadirPID))
+	if err := n.instanceDirLock.Release(); err != nil {
+		n.log.Error("Can't release datadir lock", "err", err)
+	}
+	n.instanceDirLock = nil
+}
+
+// datadirLockError converts a datadir in use failure into a DatadirUsedError
+// if the process holding the lock recorded its ID in the instance directory.
+func datadirLockError(instdir string, err error) error {
+	if err != ErrDatadirUsed {
+		return err
+	}
+	blob, rerr := ioutil.ReadFile(filepath.Join(instdir, datadirPID))
+	if rerr != nil {
+		return err
+	}
+	pid, perr := strconv.Atoi(strings.TrimSpace(string(blob)))
+	if perr != nil {
+		return err
+	}
+	return &DatadirUsedError{PID: pid}
+}
+
 // startRPC is a helper method to start all the various RPC endpoint during node
 // startup. It's not meant to be called at any time afterwards as it makes certain
 // assumptions about the state of the node.
@@ -263,6 +301,27 @@ func (n *Node) startRPC(services map[reflect.Type]Service) error {
 	return nil
 }
 
+// removeDisabledAPIs returns the given APIs without the ones whose namespace is
+// disabled.
+func removeDisabledAPIs(apis []rpc.API, disabled []string) []rpc.API {
+	if len(disabled) == 0 {
+		return apis
+	}
+	skip := make(map[string]bool)
+	for _, namespace := range disabled {
+		skip[namespace] = true
+	}
+	var enabled []rpc.API
+	for _, api := range apis {
+		if skip[api.Namespace] {
+			log.Debug("Skipping disabled RPC module", "namespace", api.Namespace)
+			continue
+		}
+		enabled = append(enabled, api)
+	}
+	return enabled
+}
+
 // startInProc initializes an in-process RPC endpoint.
 func (n *Node) startInProc(apis []rpc.API) error {
 	// Register all the APIs exposed by the services
@@ -314,17 +373,35 @@ func (n *Node) stopIPC() {
 	}
 }
 
-// startHTTP initializes and starts the HTTP RPC endpoint.
+// startHTTP initializes and starts the HTTP RPC endpoint, leaving out the
+// disabled modules.
 func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors []string, vhosts []string) error {
 	// Short circuit if the HTTP endpoint isn't being exposed
 	if endpoint == "" {
 		return nil
 	}
-	listener, handler, err := rpc.StartHTTPEndpoint(endpoint, apis, modules, cors, vhosts)
+	apis = removeDisabledAPIs(apis, n.config.RPCDisabledModules)
+	secret, err := n.config.AuthSecret()
+	if err != nil {
+		return err
+	}
+	var (
+		listener net.Listener
+		handler  *rpc.Server
+		limits   = rpc.RateLimits{Rate: n.config.RPCRateLimit, Methods: n.config.RPCMethodRateLimits}
+	)
+	switch cert, key := n.config.HTTPTLSCert, n.config.HTTPTLSKey; {
+	case cert != "" && key != "":
+		listener, handler, err = rpc.StartHTTPSEndpoint(endpoint, apis, modules, cors, vhosts, secret, limits, n.config.RPCBatchLimit, cert, key)
+	case cert != "" || key != "":
+		return ErrHTTPTLSIncomplete
+	default:
+		listener, handler, err = rpc.StartHTTPEndpoint(endpoint, apis, modules, cors, vhosts, secret, limits, n.config.RPCBatchLimit)
+	}
 	if err != nil {
 		return err
 	}
-	n.log.Info("HTTP endpoint opened", "url", fmt.Sprintf("http://%s", endpoint), "cors", strings.Join(cors, ","), "vhosts", strings.Join(vhosts, ","))
+	n.log.Info("HTTP endpoint opened", "url", fmt.Sprintf("%s://%s", n.httpScheme(), endpoint), "cors", strings.Join(cors, ","), "vhosts", strings.Join(vhosts, ","))
 	// All listeners booted successfully
 	n.httpEndpoint = endpoint
 	n.httpListener = listener
@@ -339,7 +416,7 @@ func (n *Node) stopHTTP() {
 		n.httpListener.Close()
 		n.httpListener = nil
 
-		n.log.Info("HTTP endpoint closed", "url", fmt.Sprintf("http://%s", n.httpEndpoint))
+		n.log.Info("HTTP endpoint closed", "url", fmt.Sprintf("%s://%s", n.httpScheme(), n.httpEndpoint))
 	}
 	if n.httpHandler != nil {
 		n.httpHandler.Stop()
@@ -347,13 +424,28 @@ func (n *Node) stopHTTP() {
 	}
 }
 
-// startWS initializes and starts the websocket RPC endpoint.
+// httpScheme returns the URL scheme the HTTP RPC endpoint is served with.
+func (n *Node) httpScheme() string {
+	if n.config.HTTPTLSCert != "" && n.config.HTTPTLSKey != "" {
+		return "https"
+	}
+	return "http"
+}
+
+// startWS initializes and starts the websocket RPC endpoint, leaving out the
+// disabled modules.
 func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrigins []string, exposeAll bool) error {
 	// Short circuit if the WS endpoint isn't being exposed
 	if endpoint == "" {
 		return nil
 	}
-	listener, handler, err := rpc.StartWSEndpoint(endpoint, apis, modules, wsOrigins, exposeAll)
+	apis = removeDisabledAPIs(apis, n.config.RPCDisabledModules)
+	secret, err := n.config.AuthSecret()
+	if err != nil {
+		return err
+	}
+	limits := rpc.RateLimits{Rate: n.config.RPCRateLimit, Methods: n.config.RPCMethodRateLimits}
+	listener, handler, err := rpc.StartWSEndpoint(endpoint, apis, modules, wsOrigins, exposeAll, secret, limits, n.config.RPCBatchLimit)
 	if err != nil {
 		return err
 	}
@@ -397,24 +489,14 @@ func (n *Node) Stop() error {
 	n.stopIPC()
 	n.rpcAPIs = nil
 	failure := &StopError{
-		Services: make(map[reflect.Type]error),
-	}
-	for kind, service := range n.services {
-		if err := service.Stop(); err != nil {
-			failure.Services[kind] = err
-		}
+		Services: n.stopServices(),
 	}
 	n.server.Stop()
 	n.services = nil
 	n.server = nil
 
 	// Release instance directory lock.
-	if n.instanceDirLock != nil {
-		if err := n.instanceDirLock.Release(); err != nil {
-			n.log.Error("Can't release datadir lock", "err", err)
-		}
-		n.instanceDirLock = nil
-	}
+	n.closeDataDir()
 
 	// unblock n.Wait
 	close(n.stop)
@@ -434,6 +516,60 @@ func (n *Node) Stop() error {
 	return nil
 }
 
+// stopServices terminates all the running services, giving up on the ones still
+// running once the configured shutdown timeout expires. It returns the errors
+// of the services that failed to stop cleanly or in time.
+func (n *Node) stopServices() map[reflect.Type]error {
+	var (
+		services = n.services
+		done     = make(chan struct{})
+
+		lock     sync.Mutex
+		failures = make(map[reflect.Type]error)
+		pending  = make(map[reflect.Type]struct{}, len(services))
+	)
+	for kind := range services {
+		pending[kind] = struct{}{}
+	}
+	go func() {
+		defer close(done)
+		for kind, service := range services {
+			err := service.Stop()
+
+			lock.Lock()
+			delete(pending, kind)
+			if err != nil {
+				failures[kind] = err
+			}
+			lock.Unlock()
+		}
+	}()
+
+	var timeout <-chan time.Time
+	if n.config.ShutdownTimeout > 0 {
+		timer := time.NewTimer(n.config.ShutdownTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-done:
+	case <-timeout:
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	result := make(map[reflect.Type]error, len(failures)+len(pending))
+	for kind, err := range failures {
+		result[kind] = err
+	}
+	for kind := range pending {
+		n.log.Error("Service did not stop in time", "service", kind, "timeout", n.config.ShutdownTimeout)
+		result[kind] = ErrStopTimeout
+	}
+	return result
+}
+
 // Wait blocks the thread until the node is stopped. If the node is not running
 // at the time of invocation, the method immediately returns.
 func (n *Node) Wait() {
@@ -554,7 +690,7 @@ func (n *Node) OpenDatabase(name string, cache, handles int) (kcoindb.Database,
 	if n.config.DataDir == "" {
 		return kcoindb.NewMemDatabase(), nil
 	}
-	return kcoindb.NewLDBDatabase(n.config.resolvePath(name), cache, handles)
+	return openLDBDatabase(n.config.resolvePath(name), cache, handles)
 }
 
 // ResolvePath returns the absolute path of a resource in the instance directory.
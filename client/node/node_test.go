@@ -1,10 +1,21 @@
 package node
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
 	"errors"
 	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -75,15 +86,51 @@ func TestNodeUsedDataDir(t *testing.T) {
 	if err := original.Start(); err != nil {
 		t.Fatalf("failed to start original protocol stack: %v", err)
 	}
-	defer original.Stop()
 
 	// Create a second node based on the same data directory and ensure failure
 	duplicate, err := New(&Config{DataDir: dir})
 	if err != nil {
 		t.Fatalf("failed to create duplicate protocol stack: %v", err)
 	}
-	if err := duplicate.Start(); err != ErrDatadirUsed {
-		t.Fatalf("duplicate datadir failure mismatch: have %v, want %v", err, ErrDatadirUsed)
+	want := &DatadirUsedError{PID: os.Getpid()}
+	if err := duplicate.Start(); !reflect.DeepEqual(err, want) {
+		t.Fatalf("duplicate datadir failure mismatch: have %v, want %v", err, want)
+	}
+	if err := original.Stop(); err != nil {
+		t.Fatalf("failed to stop original protocol stack: %v", err)
+	}
+
+	// The lock is released along with the original node
+	if err := duplicate.Start(); err != nil {
+		t.Fatalf("failed to start duplicate protocol stack: %v", err)
+	}
+	duplicate.Stop()
+}
+
+// Tests that the datadir in use error falls back to ErrDatadirUsed if the lock
+// holder's process ID is unknown.
+func TestDatadirLockError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary data directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := datadirLockError(dir, ErrDatadirUsed); err != ErrDatadirUsed {
+		t.Fatalf("missing PID file error mismatch: have %v, want %v", err, ErrDatadirUsed)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, datadirPID), []byte("1234\n"), 0644); err != nil {
+		t.Fatalf("failed to write PID file: %v", err)
+	}
+	err = datadirLockError(dir, ErrDatadirUsed)
+	if err.Error() != "datadir already in use by PID 1234" {
+		t.Fatalf("datadir in use error mismatch: have %v", err)
+	}
+	if !errors.Is(err, ErrDatadirUsed) {
+		t.Fatalf("datadir in use error %v doesn't match %v", err, ErrDatadirUsed)
+	}
+	if err := datadirLockError(dir, ErrNodeStopped); err != ErrNodeStopped {
+		t.Fatalf("unrelated error mismatch: have %v, want %v", err, ErrNodeStopped)
 	}
 }
 
@@ -557,3 +604,222 @@ func TestAPIGather(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the shutdown timeout gives up on services that do not terminate,
+// reporting them as failed without waiting for them.
+func TestServiceStopTimeout(t *testing.T) {
+	config := testNodeConfig()
+	config.ShutdownTimeout = 50 * time.Millisecond
+
+	stack, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	release := make(chan struct{})
+	defer close(release)
+
+	if err := stack.Register(InstrumentedServiceMakerA(NewInstrumentedService)); err != nil {
+		t.Fatalf("service registration failed: %v", err)
+	}
+	if err := stack.Register(InstrumentedServiceMakerB(func(*ServiceContext) (Service, error) {
+		return &InstrumentedService{stopHook: func() { <-release }}, nil
+	})); err != nil {
+		t.Fatalf("hanging service registration failed: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start protocol stack: %v", err)
+	}
+
+	start := time.Now()
+	err = stack.Stop()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("shutdown took %v despite the timeout", elapsed)
+	}
+	failure, ok := err.(*StopError)
+	if !ok {
+		t.Fatalf("termination failure mismatch: have %v, want StopError", err)
+	}
+	hanging := reflect.TypeOf(&InstrumentedServiceB{})
+	if failure.Services[hanging] != ErrStopTimeout {
+		t.Fatalf("hanging service failure mismatch: have %v, want %v", failure.Services[hanging], ErrStopTimeout)
+	}
+}
+
+// writeTestCertificate generates a self-signed TLS certificate for 127.0.0.1,
+// returning the PEM files it was written to.
+func writeTestCertificate(t *testing.T, dir string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatalf("failed to write certificate: %v", err)
+	}
+	if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	return certFile, keyFile
+}
+
+// Tests that the HTTP RPC endpoint is served over TLS if configured to.
+func TestHTTPTLSEndpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := testNodeConfig()
+	config.HTTPHost = "127.0.0.1"
+	config.HTTPTLSCert, config.HTTPTLSKey = writeTestCertificate(t, dir)
+
+	stack, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start protocol stack: %v", err)
+	}
+	defer stack.Stop()
+
+	cert, _ := ioutil.ReadFile(config.HTTPTLSCert)
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(cert)
+
+	addr := stack.httpListener.Addr().String()
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	resp, err := client.Post("https://"+addr, "application/json", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"rpc_modules"}`))
+	if err != nil {
+		t.Fatalf("failed to query HTTPS endpoint: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("HTTPS status mismatch: have %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	// Plaintext requests must not be served
+	if resp, err := http.Post("http://"+addr, "application/json", strings.NewReader(`{}`)); err == nil {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			t.Fatalf("plaintext request served by HTTPS endpoint")
+		}
+	}
+}
+
+// Tests that disabling every module given to the HTTP RPC endpoint exposes
+// none of them, instead of falling back to all the public ones.
+func TestHTTPDisabledModules(t *testing.T) {
+	for _, modules := range [][]string{{"debug"}, {"admin", "debug", "web3"}} {
+		config := testNodeConfig()
+		config.HTTPHost = "127.0.0.1"
+		config.HTTPModules = modules
+		config.RPCDisabledModules = modules
+
+		stack, err := New(config)
+		if err != nil {
+			t.Fatalf("failed to create protocol stack: %v", err)
+		}
+		if err := stack.Start(); err != nil {
+			t.Fatalf("failed to start protocol stack: %v", err)
+		}
+		client, err := rpc.Dial("http://" + stack.httpListener.Addr().String())
+		if err != nil {
+			t.Fatalf("failed to dial HTTP endpoint: %v", err)
+		}
+		exposed, err := client.SupportedModules()
+		if err != nil {
+			t.Fatalf("failed to retrieve the modules: %v", err)
+		}
+		for _, module := range []string{"admin", "debug", "web3"} {
+			if _, ok := exposed[module]; ok {
+				t.Errorf("modules %v: module %q exposed", modules, module)
+			}
+		}
+		client.Close()
+		stack.Stop()
+	}
+}
+
+// Tests that the endpoints started through the admin API leave out the disabled
+// modules as well.
+func TestAdminStartDisabledModules(t *testing.T) {
+	config := testNodeConfig()
+	config.RPCDisabledModules = []string{"debug"}
+
+	stack, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start protocol stack: %v", err)
+	}
+	defer stack.Stop()
+
+	var (
+		admin   = NewPrivateAdminAPI(stack)
+		host    = "127.0.0.1"
+		port    = 0
+		origins = "*"
+		modules = "admin,debug,web3"
+	)
+	if _, err := admin.StartRPC(&host, &port, nil, &modules, nil); err != nil {
+		t.Fatalf("failed to start HTTP endpoint: %v", err)
+	}
+	if _, err := admin.StartWS(&host, &port, &origins, &modules); err != nil {
+		t.Fatalf("failed to start WebSocket endpoint: %v", err)
+	}
+	for _, url := range []string{"http://" + stack.httpListener.Addr().String(), "ws://" + stack.wsListener.Addr().String()} {
+		client, err := rpc.Dial(url)
+		if err != nil {
+			t.Fatalf("failed to dial %s: %v", url, err)
+		}
+		exposed, err := client.SupportedModules()
+		client.Close()
+		if err != nil {
+			t.Fatalf("failed to retrieve the modules of %s: %v", url, err)
+		}
+		if _, ok := exposed["debug"]; ok {
+			t.Errorf("%s: disabled module exposed", url)
+		}
+		if _, ok := exposed["admin"]; !ok {
+			t.Errorf("%s: enabled module not exposed", url)
+		}
+	}
+}
+
+// Tests that the HTTP RPC endpoint refuses to start with only half of the TLS
+// configuration.
+func TestHTTPTLSIncomplete(t *testing.T) {
+	for _, files := range [][2]string{{"cert.pem", ""}, {"", "key.pem"}} {
+		config := testNodeConfig()
+		config.HTTPHost = "127.0.0.1"
+		config.HTTPTLSCert, config.HTTPTLSKey = files[0], files[1]
+
+		stack, err := New(config)
+		if err != nil {
+			t.Fatalf("failed to create protocol stack: %v", err)
+		}
+		if err := stack.Start(); err != ErrHTTPTLSIncomplete {
+			stack.Stop()
+			t.Fatalf("start error mismatch: have %v, want %v", err, ErrHTTPTLSIncomplete)
+		}
+	}
+}
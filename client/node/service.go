@@ -27,7 +27,7 @@ func (ctx *ServiceContext) OpenDatabase(name string, cache int, handles int) (kc
 	if ctx.config.DataDir == "" {
 		return kcoindb.NewMemDatabase(), nil
 	}
-	db, err := kcoindb.NewLDBDatabase(ctx.config.resolvePath(name), cache, handles)
+	db, err := openLDBDatabase(ctx.config.resolvePath(name), cache, handles)
 	if err != nil {
 		return nil, err
 	}
@@ -242,6 +242,7 @@ var (
 	errAlreadyConnected = errors.New("already connected")
 	errRecentlyDialed   = errors.New("recently dialed")
 	errNotWhitelisted   = errors.New("not contained in netrestrict whitelist")
+	errDialThrottled    = errors.New("too many connection attempts")
 )
 
 func (s *dialstate) checkDial(n *discover.Node, peers map[discover.NodeID]*Peer) error {
@@ -330,6 +331,11 @@ type dialError struct {
 
 // dial performs the actual connection attempt.
 func (t *dialTask) dial(srv *Server, dest *discover.Node) error {
+	// Static nodes are always redialed, others are subject to the throttle.
+	if t.flags&staticDialedConn == 0 && !srv.outboundThrottle.allow(dest.IP, time.Now()) {
+		egressThrottledCounter.Inc(1)
+		return errDialThrottled
+	}
 	fd, err := srv.Dialer.Dial(dest)
 	if err != nil {
 		log.Trace("Dial error", "task", t, "err", err)
@@ -0,0 +1,120 @@
+package dnsdisc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/kowala-tech/kcoin/client/p2p/discover"
+)
+
+// maxEntries caps the number of entries retrieved from a single tree, so a
+// misconfigured or hostile domain can't keep the resolver busy forever.
+const maxEntries = 1000
+
+// Resolver is a DNS resolver that can look up TXT records.
+type Resolver interface {
+	LookupTXT(ctx context.Context, domain string) ([]string, error)
+}
+
+// Client resolves enode lists published as DNS trees.
+type Client struct {
+	resolver Resolver
+}
+
+// NewClient creates a client using the given resolver, or the system one if
+// nil is passed.
+func NewClient(resolver Resolver) *Client {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return &Client{resolver: resolver}
+}
+
+// Resolve retrieves the tree referenced by the given enrtree URL, returning all
+// the nodes it contains. The root signature and every entry hash is verified,
+// and each leaf is validated the same way bootnode URLs are.
+func (c *Client) Resolve(ctx context.Context, rawurl string) ([]*discover.Node, error) {
+	key, domain, err := ParseURL(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	root, err := c.resolveRoot(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	rootEntry, err := parseRoot(root, key)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		nodes   []*discover.Node
+		queue   = []string{rootEntry.hash}
+		visited = make(map[string]bool)
+	)
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+		if visited[hash] {
+			continue
+		}
+		if visited[hash] = true; len(visited) > maxEntries {
+			return nil, fmt.Errorf("tree at %s exceeds %d entries", domain, maxEntries)
+		}
+		entry, err := c.resolveEntry(ctx, domain, hash)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case strings.HasPrefix(entry, branchPrefix):
+			children, err := parseBranch(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid branch %s.%s: %v", hash, domain, err)
+			}
+			queue = append(queue, children...)
+		case strings.HasPrefix(entry, leafPrefix):
+			node, err := discover.ParseNode(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid node %s.%s: %v", hash, domain, err)
+			}
+			if node.Incomplete() {
+				return nil, fmt.Errorf("invalid node %s.%s: missing IP address", hash, domain)
+			}
+			nodes = append(nodes, node)
+		default:
+			return nil, fmt.Errorf("%v at %s.%s", errUnknownEntry, hash, domain)
+		}
+	}
+	return nodes, nil
+}
+
+// resolveRoot retrieves the root entry of the tree at domain.
+func (c *Client) resolveRoot(ctx context.Context, domain string) (string, error) {
+	txts, err := c.resolver.LookupTXT(ctx, domain)
+	if err != nil {
+		return "", err
+	}
+	for _, txt := range txts {
+		if strings.HasPrefix(txt, rootPrefix) {
+			return txt, nil
+		}
+	}
+	return "", errNoRoot
+}
+
+// resolveEntry retrieves the entry with the given hash, checking that the
+// content actually hashes to it.
+func (c *Client) resolveEntry(ctx context.Context, domain, hash string) (string, error) {
+	name := hash + "." + domain
+	txts, err := c.resolver.LookupTXT(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	for _, txt := range txts {
+		if entryHash(txt) == hash {
+			return txt, nil
+		}
+	}
+	return "", fmt.Errorf("%v at %s", errHashMismatch, name)
+}
@@ -0,0 +1,131 @@
+package dnsdisc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/kowala-tech/kcoin/client/crypto"
+	"github.com/kowala-tech/kcoin/client/p2p/discover"
+)
+
+var testKey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+
+// mapResolver is a Resolver serving TXT records from a map.
+type mapResolver map[string]string
+
+func (mr mapResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if record, ok := mr[name]; ok {
+		return []string{record}, nil
+	}
+	return nil, fmt.Errorf("no such host %s", name)
+}
+
+func testNodes(t *testing.T, n int) []*discover.Node {
+	nodes := make([]*discover.Node, n)
+	for i := range nodes {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes[i] = discover.NewNode(discover.PubkeyID(&key.PublicKey), net.IP{10, 0, byte(i >> 8), byte(i)}, 30303, 30303)
+	}
+	return nodes
+}
+
+func TestURL(t *testing.T) {
+	url := URL(&testKey.PublicKey, "nodes.example.org")
+	key, domain, err := ParseURL(url)
+	if err != nil {
+		t.Fatalf("failed to parse %q: %v", url, err)
+	}
+	if domain != "nodes.example.org" {
+		t.Errorf("domain mismatch: have %q, want %q", domain, "nodes.example.org")
+	}
+	if key.X.Cmp(testKey.X) != 0 || key.Y.Cmp(testKey.Y) != 0 {
+		t.Errorf("public key mismatch")
+	}
+	for _, bad := range []string{
+		"enode://nodes.example.org",
+		"enrtree://nodes.example.org",
+		"enrtree://AAAA@nodes.example.org",
+	} {
+		if _, _, err := ParseURL(bad); err == nil {
+			t.Errorf("no error for invalid URL %q", bad)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	for _, size := range []int{0, 1, maxChildren, maxChildren + 1, 200} {
+		nodes := testNodes(t, size)
+		tree, err := MakeTree(1, nodes, testKey)
+		if err != nil {
+			t.Fatalf("%d nodes: failed to make tree: %v", size, err)
+		}
+		client := NewClient(mapResolver(tree.ToTXT("nodes.example.org")))
+		have, err := client.Resolve(context.Background(), URL(&testKey.PublicKey, "nodes.example.org"))
+		if err != nil {
+			t.Fatalf("%d nodes: failed to resolve: %v", size, err)
+		}
+		if len(have) != len(nodes) {
+			t.Fatalf("%d nodes: resolved %d nodes", size, len(have))
+		}
+		want := make(map[discover.NodeID]string)
+		for _, n := range nodes {
+			want[n.ID] = n.String()
+		}
+		for _, n := range have {
+			if want[n.ID] != n.String() {
+				t.Errorf("%d nodes: unexpected node %v", size, n)
+			}
+		}
+	}
+}
+
+func TestResolveWrongKey(t *testing.T) {
+	tree, err := MakeTree(1, testNodes(t, 3), testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _ := crypto.GenerateKey()
+	client := NewClient(mapResolver(tree.ToTXT("nodes.example.org")))
+	if _, err := client.Resolve(context.Background(), URL(&other.PublicKey, "nodes.example.org")); err != errInvalidSig {
+		t.Fatalf("error mismatch: have %v, want %v", err, errInvalidSig)
+	}
+}
+
+func TestResolveTamperedEntry(t *testing.T) {
+	nodes := testNodes(t, 3)
+	tree, err := MakeTree(1, nodes, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := tree.ToTXT("nodes.example.org")
+	for name, record := range records {
+		if record == nodes[0].String() {
+			records[name] = testNodes(t, 1)[0].String()
+		}
+	}
+	client := NewClient(mapResolver(records))
+	_, err = client.Resolve(context.Background(), URL(&testKey.PublicKey, "nodes.example.org"))
+	if err == nil || !strings.Contains(err.Error(), errHashMismatch.Error()) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errHashMismatch)
+	}
+}
+
+func TestResolveInvalidLeaf(t *testing.T) {
+	// A leaf without an IP address passes the hash checks but must be
+	// rejected like an incomplete bootnode.
+	leaf := "enode://" + testNodes(t, 1)[0].ID.String()
+	tree := &Tree{entries: make(map[string]string)}
+	if err := tree.sign(tree.add(branchPrefix+tree.add(leaf)), 1, testKey); err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient(mapResolver(tree.ToTXT("nodes.example.org")))
+	if _, err := client.Resolve(context.Background(), URL(&testKey.PublicKey, "nodes.example.org")); err == nil {
+		t.Fatal("no error for incomplete node")
+	}
+}
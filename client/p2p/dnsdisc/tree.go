@@ -0,0 +1,218 @@
+// Package dnsdisc implements node discovery via enode lists published as a
+// signed tree of DNS TXT records.
+//
+// A tree is referenced by a URL of the form
+//
+//	enrtree://<base32 compressed public key>@<domain>
+//
+// The TXT record at the domain itself holds the signed root entry, which
+// points at the hash of the topmost branch:
+//
+//	enrtree-root:v1 e=<hash> seq=<sequence number> sig=<signature>
+//
+// Every other entry lives at <hash>.<domain>, where the hash is the base32
+// encoding of the first 16 bytes of the keccak256 hash of the entry. Entries
+// are either branches listing the hashes of their children, or enode URLs
+// naming a single node:
+//
+//	enrtree-branch:<hash>,<hash>,...
+//	enode://<hex node id>@10.3.58.6:22334
+//
+// Since the root is signed and every entry is referenced by its hash, the
+// whole tree is authenticated by the key in the URL. Link subtrees referring
+// to other domains are not supported.
+package dnsdisc
+
+import (
+	"crypto/ecdsa"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/kowala-tech/kcoin/client/crypto"
+	"github.com/kowala-tech/kcoin/client/p2p/discover"
+)
+
+const (
+	rootPrefix   = "enrtree-root:v1"
+	branchPrefix = "enrtree-branch:"
+	leafPrefix   = "enode://"
+	urlScheme    = "enrtree"
+
+	maxChildren = 13 // maximum number of hashes in a branch entry
+	hashLength  = 16 // number of keccak256 bytes used for entry hashes
+)
+
+var b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+var (
+	errInvalidURL      = errors.New("invalid enrtree URL")
+	errInvalidSig      = errors.New("invalid root signature")
+	errNoRoot          = errors.New("no enrtree root found")
+	errHashMismatch    = errors.New("entry hash mismatch")
+	errUnknownEntry    = errors.New("unknown entry type")
+	errInvalidChild    = errors.New("invalid child hash")
+	errTooManyChildren = errors.New("too many children in branch")
+)
+
+// Tree is a signed tree of enode URLs, ready to be published in DNS.
+type Tree struct {
+	root    string            // signed root entry
+	entries map[string]string // all other entries, keyed by hash
+}
+
+// MakeTree creates a tree containing the given nodes and signs its root with
+// key. The sequence number must be increased whenever the tree is updated.
+func MakeTree(seq uint, nodes []*discover.Node, key *ecdsa.PrivateKey) (*Tree, error) {
+	t := &Tree{entries: make(map[string]string)}
+
+	hashes := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		if n.Incomplete() {
+			return nil, fmt.Errorf("incomplete node %v", n)
+		}
+		hashes = append(hashes, t.add(n.String()))
+	}
+	// Group the hashes into branches until a single one is left at the top
+	for {
+		var parents []string
+		for len(hashes) > 0 {
+			size := len(hashes)
+			if size > maxChildren {
+				size = maxChildren
+			}
+			parents = append(parents, t.add(branchPrefix+strings.Join(hashes[:size], ",")))
+			hashes = hashes[size:]
+		}
+		if len(parents) == 0 {
+			parents = append(parents, t.add(branchPrefix))
+		}
+		if hashes = parents; len(hashes) == 1 {
+			break
+		}
+	}
+	if err := t.sign(hashes[0], seq, key); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// sign sets the root entry of the tree to point at hash, signed with key.
+func (t *Tree) sign(hash string, seq uint, key *ecdsa.PrivateKey) error {
+	body := fmt.Sprintf("%s e=%s seq=%d", rootPrefix, hash, seq)
+	sig, err := crypto.Sign(crypto.Keccak256([]byte(body)), key)
+	if err != nil {
+		return err
+	}
+	t.root = body + " sig=" + base64.RawURLEncoding.EncodeToString(sig)
+	return nil
+}
+
+// add inserts an entry into the tree, returning its hash.
+func (t *Tree) add(entry string) string {
+	hash := entryHash(entry)
+	t.entries[hash] = entry
+	return hash
+}
+
+// ToTXT returns the TXT records of the tree, keyed by the fully qualified name
+// they must be published under.
+func (t *Tree) ToTXT(domain string) map[string]string {
+	records := map[string]string{domain: t.root}
+	for hash, entry := range t.entries {
+		records[hash+"."+domain] = entry
+	}
+	return records
+}
+
+// URL returns the enrtree URL referencing a tree signed by key under domain.
+func URL(key *ecdsa.PublicKey, domain string) string {
+	u := url.URL{Scheme: urlScheme, User: url.User(b32.EncodeToString(crypto.CompressPubkey(key))), Host: domain}
+	return u.String()
+}
+
+// ParseURL parses an enrtree URL, returning the public key the tree must be
+// signed with and the domain holding its root.
+func ParseURL(rawurl string) (*ecdsa.PublicKey, string, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, "", err
+	}
+	if u.Scheme != urlScheme || u.User == nil || u.Host == "" {
+		return nil, "", errInvalidURL
+	}
+	keybytes, err := b32.DecodeString(strings.ToUpper(u.User.Username()))
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid public key: %v", err)
+	}
+	key, err := crypto.DecompressPubkey(keybytes)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid public key: %v", err)
+	}
+	return key, u.Host, nil
+}
+
+// entryHash computes the hash an entry is published under.
+func entryHash(entry string) string {
+	return b32.EncodeToString(crypto.Keccak256([]byte(entry))[:hashLength])
+}
+
+// rootEntry is a parsed and verified root entry.
+type rootEntry struct {
+	hash string
+	seq  uint64
+}
+
+// parseRoot parses a root entry, verifying its signature against key.
+func parseRoot(entry string, key *ecdsa.PublicKey) (*rootEntry, error) {
+	fields := strings.Fields(entry)
+	if len(fields) != 4 || fields[0] != rootPrefix ||
+		!strings.HasPrefix(fields[1], "e=") || !strings.HasPrefix(fields[2], "seq=") || !strings.HasPrefix(fields[3], "sig=") {
+		return nil, fmt.Errorf("invalid root entry %q", entry)
+	}
+	hash := strings.TrimPrefix(fields[1], "e=")
+	if !isHash(hash) {
+		return nil, errInvalidChild
+	}
+	seq, err := strconv.ParseUint(strings.TrimPrefix(fields[2], "seq="), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid root sequence number: %v", err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(fields[3], "sig="))
+	if err != nil || len(sig) != 65 {
+		return nil, errInvalidSig
+	}
+	body := strings.Join(fields[:3], " ")
+	if !crypto.VerifySignature(crypto.CompressPubkey(key), crypto.Keccak256([]byte(body)), sig[:64]) {
+		return nil, errInvalidSig
+	}
+	return &rootEntry{hash: hash, seq: seq}, nil
+}
+
+// parseBranch parses the child hashes of a branch entry.
+func parseBranch(entry string) ([]string, error) {
+	list := strings.TrimPrefix(entry, branchPrefix)
+	if list == "" {
+		return nil, nil
+	}
+	children := strings.Split(list, ",")
+	if len(children) > maxChildren {
+		return nil, errTooManyChildren
+	}
+	for _, child := range children {
+		if !isHash(child) {
+			return nil, errInvalidChild
+		}
+	}
+	return children, nil
+}
+
+// isHash reports whether s is a well formed entry hash.
+func isHash(s string) bool {
+	b, err := b32.DecodeString(s)
+	return err == nil && len(b) == hashLength
+}
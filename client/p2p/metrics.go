@@ -3,9 +3,11 @@
 package p2p
 
 import (
+	"fmt"
 	"net"
 
 	"github.com/kowala-tech/kcoin/client/metrics"
+	"github.com/kowala-tech/kcoin/client/p2p/discover"
 )
 
 var (
@@ -13,6 +15,10 @@ var (
 	ingressTrafficMeter = metrics.NewRegisteredMeter("p2p/InboundTraffic", nil)
 	egressConnectMeter  = metrics.NewRegisteredMeter("p2p/OutboundConnects", nil)
 	egressTrafficMeter  = metrics.NewRegisteredMeter("p2p/OutboundTraffic", nil)
+
+	ingressRestrictedCounter = metrics.NewRegisteredCounter("p2p/InboundRestricted", nil) // Dropped due to NetRestrict
+	ingressThrottledCounter  = metrics.NewRegisteredCounter("p2p/InboundThrottled", nil)  // Dropped due to the per-IP throttle
+	egressThrottledCounter   = metrics.NewRegisteredCounter("p2p/OutboundThrottled", nil) // Skipped due to the per-IP throttle
 )
 
 // meteredConn is a wrapper around a net.Conn that meters both the
@@ -53,3 +59,85 @@ func (c *meteredConn) Write(b []byte) (n int, err error) {
 	egressTrafficMeter.Mark(int64(n))
 	return
 }
+
+// msgMeter counts the messages and bytes transferred in one direction.
+type msgMeter struct {
+	packets metrics.Counter
+	traffic metrics.Counter
+}
+
+// newMsgMeter creates (or retrieves) the counters registered under the prefix.
+func newMsgMeter(prefix string) msgMeter {
+	return msgMeter{
+		packets: metrics.GetOrRegisterCounter(prefix+"/packets", nil),
+		traffic: metrics.GetOrRegisterCounter(prefix+"/traffic", nil),
+	}
+}
+
+// mark accounts for a single message of the given payload size.
+func (m msgMeter) mark(size uint32) {
+	m.packets.Inc(1)
+	m.traffic.Inc(int64(size))
+}
+
+// meteredMsgReadWriter is a wrapper around a protocol MsgReadWriter, counting the
+// messages and bytes exchanged both per protocol and per peer.
+type meteredMsgReadWriter struct {
+	MsgReadWriter
+	in, out         msgMeter // Aggregate counters of the protocol
+	peerIn, peerOut msgMeter // Counters of the protocol with this particular peer
+}
+
+// newMeteredMsgReadWriter wraps a protocol MsgReadWriter with message metering.
+// If the metrics system is disabled, this function returns the original object.
+func newMeteredMsgReadWriter(rw MsgReadWriter, id discover.NodeID, protocol string) MsgReadWriter {
+	if !metrics.Enabled {
+		return rw
+	}
+	prefix, peer := "p2p/"+protocol, peerMetricsPrefix(id, protocol)
+	return &meteredMsgReadWriter{
+		MsgReadWriter: rw,
+		in:            newMsgMeter(prefix + "/in"),
+		out:           newMsgMeter(prefix + "/out"),
+		peerIn:        newMsgMeter(peer + "/in"),
+		peerOut:       newMsgMeter(peer + "/out"),
+	}
+}
+
+// ReadMsg delegates a message read to the wrapped reader, counting it as inbound.
+func (rw *meteredMsgReadWriter) ReadMsg() (Msg, error) {
+	msg, err := rw.MsgReadWriter.ReadMsg()
+	if err == nil {
+		rw.in.mark(msg.Size)
+		rw.peerIn.mark(msg.Size)
+	}
+	return msg, err
+}
+
+// WriteMsg delegates a message write to the wrapped writer, counting it as
+// outbound if it succeeded.
+func (rw *meteredMsgReadWriter) WriteMsg(msg Msg) error {
+	size := msg.Size
+	if err := rw.MsgReadWriter.WriteMsg(msg); err != nil {
+		return err
+	}
+	rw.out.mark(size)
+	rw.peerOut.mark(size)
+	return nil
+}
+
+// peerMetricsPrefix returns the name prefix of the message counters of a single
+// peer and protocol.
+func peerMetricsPrefix(id discover.NodeID, protocol string) string {
+	return fmt.Sprintf("p2p/peers/%x/%s", id[:8], protocol)
+}
+
+// unregisterPeerMetrics removes the message counters of a disconnected peer, so
+// they don't accumulate in the registry.
+func unregisterPeerMetrics(id discover.NodeID, protocol string) {
+	prefix := peerMetricsPrefix(id, protocol)
+	for _, dir := range []string{"/in", "/out"} {
+		metrics.DefaultRegistry.Unregister(prefix + dir + "/packets")
+		metrics.DefaultRegistry.Unregister(prefix + dir + "/traffic")
+	}
+}
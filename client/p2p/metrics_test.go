@@ -0,0 +1,94 @@
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kowala-tech/kcoin/client/metrics"
+)
+
+// counterValue returns the current value of a registered counter.
+func counterValue(t *testing.T, name string) int64 {
+	counter, ok := metrics.DefaultRegistry.Get(name).(metrics.Counter)
+	if !ok {
+		t.Fatalf("counter %s not registered", name)
+	}
+	return counter.Count()
+}
+
+func TestPeerMessageMetrics(t *testing.T) {
+	defer func(enabled bool) { metrics.Enabled = enabled }(metrics.Enabled)
+	metrics.Enabled = true
+
+	var (
+		received = make(chan struct{})
+		release  = make(chan struct{})
+	)
+	proto := Protocol{
+		Name:   "metered",
+		Length: 5,
+		Run: func(peer *Peer, rw MsgReadWriter) error {
+			for i := 0; i < 3; i++ {
+				msg, err := rw.ReadMsg()
+				if err != nil {
+					return err
+				}
+				msg.Discard()
+			}
+			if err := SendItems(rw, 1, "foo"); err != nil {
+				return err
+			}
+			if err := SendItems(rw, 2, "bar", "baz"); err != nil {
+				return err
+			}
+			close(received)
+			<-release
+			return nil
+		},
+	}
+	closer, rw, peer, errc := testPeer([]Protocol{proto})
+	defer closer()
+
+	for i := uint(0); i < 3; i++ {
+		if err := Send(rw, baseProtocolLength+1, []uint{i}); err != nil {
+			t.Fatalf("failed to send message %d: %v", i, err)
+		}
+	}
+	if err := ExpectMsg(rw, baseProtocolLength+1, []string{"foo"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExpectMsg(rw, baseProtocolLength+2, []string{"bar", "baz"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-received:
+	case <-time.After(2 * time.Second):
+		t.Fatal("protocol did not process the messages")
+	}
+	prefix := peerMetricsPrefix(peer.ID(), "metered")
+	for name, want := range map[string]int64{
+		"p2p/metered/in/packets":  3,
+		"p2p/metered/out/packets": 2,
+		prefix + "/in/packets":    3,
+		prefix + "/out/packets":   2,
+		prefix + "/in/traffic":    3 * 2, // RLP lists of a single small integer
+		prefix + "/out/traffic":   5 + 9, // ["foo"] and ["bar", "baz"]
+	} {
+		if have := counterValue(t, name); have != want {
+			t.Errorf("counter %s mismatch: have %d, want %d", name, have, want)
+		}
+	}
+	// Per-peer counters should be dropped once the peer disconnects
+	close(release)
+	select {
+	case <-errc:
+	case <-time.After(2 * time.Second):
+		t.Fatal("peer did not terminate")
+	}
+	if metrics.DefaultRegistry.Get(prefix+"/in/packets") != nil {
+		t.Error("per-peer counters retained after disconnect")
+	}
+	if metrics.DefaultRegistry.Get("p2p/metered/in/packets") == nil {
+		t.Error("aggregate counters dropped after disconnect")
+	}
+}
@@ -39,6 +39,8 @@ type Interface interface {
 //
 //     "" or "none"         return nil
 //     "extip:77.12.33.4"   will assume the local machine is reachable on the given IP
+//     "extip:2001:db8::1"  the same for an IPv6 address, which may also be given
+//                          in brackets as "extip:[2001:db8::1]"
 //     "any"                uses the first auto-detected mechanism
 //     "upnp"               uses the Universal Plug and Play protocol
 //     "pmp"                uses NAT-PMP with an auto-detected gateway address
@@ -48,11 +50,11 @@ func Parse(spec string) (Interface, error) {
 		parts = strings.SplitN(spec, ":", 2)
 		mech  = strings.ToLower(parts[0])
 		ip    net.IP
+		err   error
 	)
 	if len(parts) > 1 {
-		ip = net.ParseIP(parts[1])
-		if ip == nil {
-			return nil, errors.New("invalid IP address")
+		if ip, err = parseIP(parts[1]); err != nil {
+			return nil, err
 		}
 	}
 	switch mech {
@@ -64,6 +66,9 @@ func Parse(spec string) (Interface, error) {
 		if ip == nil {
 			return nil, errors.New("missing IP address")
 		}
+		if ip.IsUnspecified() || ip.IsMulticast() {
+			return nil, fmt.Errorf("invalid external IP address %v (unspecified/multicast)", ip)
+		}
 		return ExtIP(ip), nil
 	case "upnp":
 		return UPnP(), nil
@@ -74,6 +79,23 @@ func Parse(spec string) (Interface, error) {
 	}
 }
 
+// parseIP parses the address given to a mechanism. IPv6 addresses may be
+// enclosed in brackets, IPv4 ones are returned in their 4 byte form.
+func parseIP(s string) (net.IP, error) {
+	addr := s
+	if strings.HasPrefix(addr, "[") && strings.HasSuffix(addr, "]") {
+		addr = addr[1 : len(addr)-1]
+	}
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address %q", s)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	return ip, nil
+}
+
 const (
 	mapTimeout        = 20 * time.Minute
 	mapUpdateInterval = 15 * time.Minute
@@ -120,6 +142,15 @@ func ExtIP(ip net.IP) Interface {
 	return extIP(ip)
 }
 
+// StaticIP returns the address of an ExtIP mechanism, or nil for any other
+// mechanism, whose external address needs to be discovered.
+func StaticIP(m Interface) net.IP {
+	if ip, ok := m.(extIP); ok {
+		return net.IP(ip)
+	}
+	return nil
+}
+
 type extIP net.IP
 
 func (n extIP) ExternalIP() (net.IP, error) { return net.IP(n), nil }
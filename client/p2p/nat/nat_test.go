@@ -45,3 +45,45 @@ func TestAutoDiscRace(t *testing.T) {
 		}
 	}
 }
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec string
+		ip   net.IP // expected address of an ExtIP mechanism
+		err  bool
+	}{
+		{spec: "extip:77.12.33.4", ip: net.IP{77, 12, 33, 4}},
+		{spec: "EXTIP:77.12.33.4", ip: net.IP{77, 12, 33, 4}},
+		{spec: "extip:2001:db8::1", ip: net.ParseIP("2001:db8::1")},
+		{spec: "extip:[2001:db8::1]", ip: net.ParseIP("2001:db8::1")},
+		{spec: "ip:::ffff:77.12.33.4", ip: net.IP{77, 12, 33, 4}},
+		{spec: "extip", err: true},
+		{spec: "extip:", err: true},
+		{spec: "extip:garbage", err: true},
+		{spec: "extip:77.12.33", err: true},
+		{spec: "extip:[77.12.33.4", err: true},
+		{spec: "extip:2001:db8::1:22334", err: true},
+		{spec: "extip:[2001:db8::1]:22334", err: true},
+		{spec: "extip:::", err: true},
+		{spec: "extip:ff02::1", err: true},
+		{spec: "pmp:[2001:db8::1]"},
+		{spec: "pmp:garbage", err: true},
+		{spec: "none"},
+	}
+	for _, tt := range tests {
+		m, err := Parse(tt.spec)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%q: no error", tt.spec)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.spec, err)
+			continue
+		}
+		if ip := StaticIP(m); !ip.Equal(tt.ip) || (ip != nil && ip.To4() != nil && len(ip) != net.IPv4len) {
+			t.Errorf("%q: address mismatch: have %v, want %v", tt.spec, ip, tt.ip)
+		}
+	}
+}
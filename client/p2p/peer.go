@@ -12,6 +12,7 @@ import (
 	"github.com/kowala-tech/kcoin/client/common/mclock"
 	"github.com/kowala-tech/kcoin/client/event"
 	"github.com/kowala-tech/kcoin/client/log"
+	"github.com/kowala-tech/kcoin/client/metrics"
 	"github.com/kowala-tech/kcoin/client/p2p/discover"
 	"github.com/kowala-tech/kcoin/client/rlp"
 )
@@ -216,6 +217,12 @@ loop:
 	close(p.closed)
 	p.rw.close(reason)
 	p.wg.Wait()
+
+	if metrics.Enabled {
+		for _, proto := range p.running {
+			unregisterPeerMetrics(p.ID(), proto.Name)
+		}
+	}
 	return remoteRequested, err
 }
 
@@ -327,7 +334,7 @@ func (p *Peer) startProtocols(writeStart <-chan struct{}, writeErr chan<- error)
 		proto.closed = p.closed
 		proto.wstart = writeStart
 		proto.werr = writeErr
-		var rw MsgReadWriter = proto
+		rw := newMeteredMsgReadWriter(proto, p.ID(), proto.Name)
 		if p.events != nil {
 			rw = newMsgEventer(rw, p.events, p.ID(), proto.Name)
 		}
@@ -400,12 +407,16 @@ func (rw *protoRW) ReadMsg() (Msg, error) {
 // peer. Sub-protocol independent fields are contained and initialized here, with
 // protocol specifics delegated to all connected sub-protocols.
 type PeerInfo struct {
-	ID      string   `json:"id"`   // Unique node identifier (also the encryption key)
-	Name    string   `json:"name"` // Name of the node, including client type, version, OS, custom data
-	Caps    []string `json:"caps"` // Sum-protocols advertised by this particular peer
-	Network struct {
+	ID         string   `json:"id"`         // Unique node identifier (also the encryption key)
+	Enode      string   `json:"enode"`      // Node URL built from the identifier and the remote endpoint
+	Name       string   `json:"name"`       // Name of the node, including client type, version, OS, custom data
+	Caps       []string `json:"caps"`       // Sum-protocols advertised by this particular peer
+	Negotiated []string `json:"negotiated"` // Sub-protocols running on the connection, as name/version
+	Uptime     uint64   `json:"uptime"`     // Seconds since the connection was established
+	Network    struct {
 		LocalAddress  string `json:"localAddress"`  // Local endpoint of the TCP data connection
 		RemoteAddress string `json:"remoteAddress"` // Remote endpoint of the TCP data connection
+		RemoteIP      string `json:"remoteIP"`      // IP address of the remote endpoint
 		Inbound       bool   `json:"inbound"`
 		Trusted       bool   `json:"trusted"`
 		Static        bool   `json:"static"`
@@ -423,12 +434,20 @@ func (p *Peer) Info() *PeerInfo {
 	// Assemble the generic peer metadata
 	info := &PeerInfo{
 		ID:        p.ID().String(),
+		Enode:     (&discover.Node{ID: p.ID()}).String(),
 		Name:      p.Name(),
 		Caps:      caps,
+		Uptime:    uint64(time.Duration(mclock.Now()-p.created) / time.Second),
 		Protocols: make(map[string]interface{}),
 	}
 	info.Network.LocalAddress = p.LocalAddr().String()
 	info.Network.RemoteAddress = p.RemoteAddr().String()
+	if addr, ok := p.RemoteAddr().(*net.TCPAddr); ok {
+		// The port of inbound connections is the ephemeral one they were
+		// made from, not necessarily the one the peer listens on.
+		info.Enode = discover.NewNode(p.ID(), addr.IP, uint16(addr.Port), uint16(addr.Port)).String()
+		info.Network.RemoteIP = addr.IP.String()
+	}
 	info.Network.Inbound = p.rw.is(inboundConn)
 	info.Network.Trusted = p.rw.is(trustedConn)
 	info.Network.Static = p.rw.is(staticDialedConn)
@@ -444,6 +463,8 @@ func (p *Peer) Info() *PeerInfo {
 			}
 		}
 		info.Protocols[proto.Name] = protoInfo
+		info.Negotiated = append(info.Negotiated, fmt.Sprintf("%s/%d", proto.Name, proto.Version))
 	}
+	sort.Strings(info.Negotiated)
 	return info
 }
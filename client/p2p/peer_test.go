@@ -8,6 +8,8 @@ import (
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/kowala-tech/kcoin/client/common/mclock"
 )
 
 var discard = Protocol{
@@ -199,6 +201,45 @@ func TestNewPeer(t *testing.T) {
 	p.Disconnect(DiscAlreadyConnected) // Should not hang
 }
 
+// remoteAddrConn is a connection reporting a fixed remote address.
+type remoteAddrConn struct {
+	net.Conn
+	remote net.Addr
+}
+
+func (c remoteAddrConn) RemoteAddr() net.Addr { return c.remote }
+
+func TestPeerInfo(t *testing.T) {
+	pipe, _ := net.Pipe()
+	id := randomID()
+	c := &conn{
+		fd:    remoteAddrConn{pipe, &net.TCPAddr{IP: net.IP{10, 0, 0, 1}, Port: 30303}},
+		id:    id,
+		caps:  []Cap{{"foo", 2}, {"bar", 3}, {"baz", 1}},
+		flags: inboundConn,
+	}
+	protos := []Protocol{{Name: "foo", Version: 2, Length: 1}, {Name: "bar", Version: 3, Length: 1}}
+	p := newPeer(c, protos)
+	p.created -= mclock.AbsTime(5 * time.Second)
+
+	info := p.Info()
+	if want := fmt.Sprintf("enode://%x@10.0.0.1:30303", id[:]); info.Enode != want {
+		t.Errorf("enode mismatch: got %q, want %q", info.Enode, want)
+	}
+	if info.Network.RemoteIP != "10.0.0.1" {
+		t.Errorf("remote IP mismatch: got %q, want %q", info.Network.RemoteIP, "10.0.0.1")
+	}
+	if !info.Network.Inbound {
+		t.Error("inbound connection not reported")
+	}
+	if want := []string{"bar/3", "foo/2"}; !reflect.DeepEqual(info.Negotiated, want) {
+		t.Errorf("negotiated protocols mismatch: got %v, want %v", info.Negotiated, want)
+	}
+	if info.Uptime < 5 {
+		t.Errorf("uptime too low: got %d, want at least 5", info.Uptime)
+	}
+}
+
 func TestMatchProtocols(t *testing.T) {
 	tests := []struct {
 		Remote []Cap
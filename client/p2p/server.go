@@ -2,6 +2,7 @@
 package p2p
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"errors"
 	"fmt"
@@ -15,6 +16,7 @@ import (
 	"github.com/kowala-tech/kcoin/client/log"
 	"github.com/kowala-tech/kcoin/client/p2p/discover"
 	"github.com/kowala-tech/kcoin/client/p2p/discv5"
+	"github.com/kowala-tech/kcoin/client/p2p/dnsdisc"
 	"github.com/kowala-tech/kcoin/client/p2p/nat"
 	"github.com/kowala-tech/kcoin/client/p2p/netutil"
 )
@@ -22,6 +24,9 @@ import (
 const (
 	defaultDialTimeout = 15 * time.Second
 
+	// Maximum time allowed for resolving the DNS discovery trees on startup.
+	dnsResolveTimeout = 10 * time.Second
+
 	// Connectivity defaults.
 	maxActiveDialTasks     = 16
 	defaultMaxPendingPeers = 50
@@ -51,6 +56,15 @@ type Config struct {
 	// Zero defaults to preset values.
 	MaxPendingPeers int `toml:",omitempty"`
 
+	// ConnThrottleWindow is the window over which the connection attempts
+	// to and from a single IP are rate limited. The IPs of trusted nodes are
+	// exempt. Zero disables the limit.
+	ConnThrottleWindow time.Duration `toml:",omitempty"`
+
+	// ConnThrottleAttempts is the number of connection attempts allowed per
+	// IP within the throttle window. Zero defaults to a preset value.
+	ConnThrottleAttempts int `toml:",omitempty"`
+
 	// DialRatio controls the ratio of inbound to dialed connections.
 	// Example: a DialRatio of 2 allows 1/2 of connections to be dialed.
 	// Setting DialRatio to zero defaults it to 3.
@@ -80,6 +94,10 @@ type Config struct {
 	// protocol.
 	BootstrapNodesV5 []*discv5.Node `toml:",omitempty"`
 
+	// DNSDiscovery is a list of enrtree:// URLs of DNS node trees, whose
+	// nodes are resolved on startup and added to the bootstrap nodes.
+	DNSDiscovery []string `toml:",omitempty"`
+
 	// Static nodes are used as pre-configured connections which are always
 	// maintained and re-connected on disconnects.
 	StaticNodes []*discover.Node
@@ -162,6 +180,10 @@ type Server struct {
 	loopWG        sync.WaitGroup // loop, listenLoop
 	peerFeed      event.Feed
 	log           log.Logger
+	dnsResolver   dnsdisc.Resolver // resolver used for DNSDiscovery, nil for the system one
+
+	inboundThrottle  *connThrottle // limits accepted connections per IP, nil if disabled
+	outboundThrottle *connThrottle // limits dynamic dials per IP, nil if disabled
 }
 
 type peerOpFunc func(map[discover.NodeID]*Peer)
@@ -311,11 +333,16 @@ func (srv *Server) makeSelf(listener net.Listener, ntab discoverTable) *discover
 		if listener == nil {
 			return &discover.Node{IP: net.ParseIP("0.0.0.0"), ID: discover.PubkeyID(&srv.PrivateKey.PublicKey)}
 		}
-		// Otherwise inject the listener address too
+		// Otherwise inject the listener address too, announcing the
+		// external IP instead if it's configured statically
 		addr := listener.Addr().(*net.TCPAddr)
+		ip := addr.IP
+		if ext := nat.StaticIP(srv.NAT); ext != nil {
+			ip = ext
+		}
 		return &discover.Node{
 			ID:  discover.PubkeyID(&srv.PrivateKey.PublicKey),
-			IP:  addr.IP,
+			IP:  ip,
 			TCP: uint16(addr.Port),
 		}
 	}
@@ -400,6 +427,15 @@ func (srv *Server) Start() (err error) {
 	srv.peerOp = make(chan peerOpFunc)
 	srv.peerOpDone = make(chan struct{})
 
+	var trustedIPs []net.IP
+	for _, n := range srv.TrustedNodes {
+		if !n.Incomplete() {
+			trustedIPs = append(trustedIPs, n.IP)
+		}
+	}
+	srv.inboundThrottle = newConnThrottle(srv.ConnThrottleWindow, srv.ConnThrottleAttempts, trustedIPs)
+	srv.outboundThrottle = newConnThrottle(srv.ConnThrottleWindow, srv.ConnThrottleAttempts, trustedIPs)
+
 	var (
 		conn      *net.UDPConn
 		sconn     *sharedUDPConn
@@ -433,6 +469,8 @@ func (srv *Server) Start() (err error) {
 		sconn = &sharedUDPConn{conn, unhandled}
 	}
 
+	bootnodes := srv.bootstrapNodes()
+
 	// node table
 	if !srv.NoDiscovery {
 		cfg := discover.Config{
@@ -440,7 +478,7 @@ func (srv *Server) Start() (err error) {
 			AnnounceAddr: realaddr,
 			NodeDBPath:   srv.NodeDatabase,
 			NetRestrict:  srv.NetRestrict,
-			Bootnodes:    srv.BootstrapNodes,
+			Bootnodes:    bootnodes,
 			Unhandled:    unhandled,
 		}
 		ntab, err := discover.ListenUDP(conn, cfg)
@@ -470,7 +508,7 @@ func (srv *Server) Start() (err error) {
 	}
 
 	dynPeers := srv.maxDialedConns()
-	dialer := newDialState(srv.StaticNodes, srv.BootstrapNodes, srv.ntab, dynPeers, srv.NetRestrict)
+	dialer := newDialState(srv.StaticNodes, bootnodes, srv.ntab, dynPeers, srv.NetRestrict)
 
 	// handshake
 	srv.ourHandshake = &protoHandshake{Version: baseProtocolVersion, Name: srv.Name, ID: discover.PubkeyID(&srv.PrivateKey.PublicKey)}
@@ -707,6 +745,43 @@ func (srv *Server) maxInboundConns() int {
 	return srv.MaxPeers - srv.maxDialedConns()
 }
 
+// bootstrapNodes returns the configured bootstrap nodes extended with the
+// nodes of all DNS discovery trees. Trees failing to resolve are skipped, so
+// a DNS outage leaves the node with its static bootnodes only.
+func (srv *Server) bootstrapNodes() []*discover.Node {
+	if len(srv.DNSDiscovery) == 0 {
+		return srv.BootstrapNodes
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dnsResolveTimeout)
+	defer cancel()
+
+	var (
+		client = dnsdisc.NewClient(srv.dnsResolver)
+		nodes  = append([]*discover.Node{}, srv.BootstrapNodes...)
+		seen   = make(map[discover.NodeID]bool)
+	)
+	for _, n := range nodes {
+		seen[n.ID] = true
+	}
+	for _, url := range srv.DNSDiscovery {
+		resolved, err := client.Resolve(ctx, url)
+		if err != nil {
+			srv.log.Warn("Failed to resolve DNS discovery tree", "url", url, "err", err)
+			continue
+		}
+		added := 0
+		for _, n := range resolved {
+			if !seen[n.ID] {
+				seen[n.ID] = true
+				nodes = append(nodes, n)
+				added++
+			}
+		}
+		srv.log.Info("Resolved DNS discovery tree", "url", url, "nodes", added)
+	}
+	return nodes
+}
+
 func (srv *Server) maxDialedConns() int {
 	if srv.NoDiscovery || srv.NoDial {
 		return 0
@@ -760,13 +835,23 @@ func (srv *Server) listenLoop() {
 		// Reject connections that do not match NetRestrict.
 		if srv.NetRestrict != nil {
 			if tcp, ok := fd.RemoteAddr().(*net.TCPAddr); ok && !srv.NetRestrict.Contains(tcp.IP) {
-				srv.log.Debug("Rejected conn (not whitelisted in NetRestrict)", "addr", fd.RemoteAddr())
+				srv.log.Debug("Rejected conn (not whitelisted in NetRestrict)", "addr", fd.RemoteAddr(), "ip", tcp.IP)
+				ingressRestrictedCounter.Inc(1)
 				fd.Close()
 				slots <- struct{}{}
 				continue
 			}
 		}
 
+		// Reject hosts attempting to connect more often than the throttle allows.
+		if tcp, ok := fd.RemoteAddr().(*net.TCPAddr); ok && !srv.inboundThrottle.allow(tcp.IP, time.Now()) {
+			srv.log.Debug("Rejected conn (too many connection attempts)", "addr", fd.RemoteAddr(), "ip", tcp.IP)
+			ingressThrottledCounter.Inc(1)
+			fd.Close()
+			slots <- struct{}{}
+			continue
+		}
+
 		fd = newMeteredConn(fd, true)
 		srv.log.Trace("Accepted connection", "addr", fd.RemoteAddr())
 		go func() {
@@ -1,11 +1,16 @@
 package p2p
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"errors"
+	"fmt"
+	"io"
 	"math/rand"
 	"net"
 	"reflect"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -13,6 +18,8 @@ import (
 	"github.com/kowala-tech/kcoin/client/crypto/sha3"
 	"github.com/kowala-tech/kcoin/client/log"
 	"github.com/kowala-tech/kcoin/client/p2p/discover"
+	"github.com/kowala-tech/kcoin/client/p2p/dnsdisc"
+	"github.com/kowala-tech/kcoin/client/p2p/nat"
 )
 
 func init() {
@@ -471,6 +478,123 @@ func (c *setupTransport) ReadMsg() (Msg, error) {
 	panic("ReadMsg called on setupTransport")
 }
 
+// Tests that connections from an IP beyond the throttle limit are rejected
+// before the handshake.
+func TestServerInboundThrottle(t *testing.T) {
+	var handshakes int32
+	srv := &Server{
+		Config: Config{
+			Name:                 "test",
+			MaxPeers:             10,
+			ListenAddr:           "127.0.0.1:0",
+			PrivateKey:           newkey(),
+			NoDiscovery:          true,
+			ConnThrottleWindow:   time.Minute,
+			ConnThrottleAttempts: 1,
+		},
+		newTransport: func(fd net.Conn) transport {
+			atomic.AddInt32(&handshakes, 1)
+			return newTestTransport(randomID(), fd)
+		},
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start server: %v", err)
+	}
+	defer srv.Stop()
+
+	first, err := net.DialTimeout("tcp", srv.ListenAddr, 5*time.Second)
+	if err != nil {
+		t.Fatalf("could not dial: %v", err)
+	}
+	defer first.Close()
+
+	second, err := net.DialTimeout("tcp", srv.ListenAddr, 5*time.Second)
+	if err != nil {
+		t.Fatalf("could not dial: %v", err)
+	}
+	defer second.Close()
+
+	// The throttled connection is closed right away
+	second.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := second.Read(make([]byte, 1)); err != io.EOF {
+		t.Fatalf("throttled connection not closed: %v", err)
+	}
+	if n := atomic.LoadInt32(&handshakes); n != 1 {
+		t.Fatalf("handshake count mismatch: have %d, want 1", n)
+	}
+}
+
+// Tests that a statically configured external IP is announced when discovery
+// is disabled.
+func TestServerSelfExtIP(t *testing.T) {
+	ext := net.ParseIP("2001:db8::1")
+	srv := &Server{
+		Config: Config{
+			Name:        "test",
+			MaxPeers:    10,
+			ListenAddr:  "127.0.0.1:0",
+			PrivateKey:  newkey(),
+			NoDiscovery: true,
+			NAT:         nat.ExtIP(ext),
+		},
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start server: %v", err)
+	}
+	defer srv.Stop()
+
+	self := srv.Self()
+	if !self.IP.Equal(ext) {
+		t.Fatalf("announced IP mismatch: have %v, want %v", self.IP, ext)
+	}
+	port := srv.listener.Addr().(*net.TCPAddr).Port
+	if want := fmt.Sprintf("[2001:db8::1]:%d", port); !strings.Contains(self.String(), "@"+want) {
+		t.Fatalf("announced URL %q doesn't contain %q", self.String(), want)
+	}
+}
+
+// mapResolver is a dnsdisc.Resolver serving TXT records from a map.
+type mapResolver map[string]string
+
+func (mr mapResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if record, ok := mr[name]; ok {
+		return []string{record}, nil
+	}
+	return nil, fmt.Errorf("no such host %s", name)
+}
+
+// Tests that the nodes of DNS discovery trees are merged into the bootstrap
+// nodes, skipping duplicates and trees that fail to resolve.
+func TestServerDNSDiscovery(t *testing.T) {
+	var (
+		static = discover.NewNode(randomID(), net.IP{10, 0, 0, 1}, 30303, 30303)
+		dns    = discover.NewNode(randomID(), net.IP{10, 0, 0, 2}, 30303, 30303)
+		key    = newkey()
+	)
+	tree, err := dnsdisc.MakeTree(1, []*discover.Node{static, dns}, key)
+	if err != nil {
+		t.Fatalf("failed to make tree: %v", err)
+	}
+	srv := &Server{
+		Config: Config{
+			BootstrapNodes: []*discover.Node{static},
+			DNSDiscovery: []string{
+				dnsdisc.URL(&key.PublicKey, "nodes.example.org"),
+				dnsdisc.URL(&key.PublicKey, "missing.example.org"),
+			},
+		},
+		dnsResolver: mapResolver(tree.ToTXT("nodes.example.org")),
+		log:         log.New(),
+	}
+	nodes := srv.bootstrapNodes()
+	if len(nodes) != 2 || nodes[0] != static || nodes[1].ID != dns.ID {
+		t.Fatalf("bootstrap nodes mismatch: have %v, want %v", nodes, []*discover.Node{static, dns})
+	}
+	if len(srv.BootstrapNodes) != 1 {
+		t.Fatalf("configured bootstrap nodes modified: %v", srv.BootstrapNodes)
+	}
+}
+
 func newkey() *ecdsa.PrivateKey {
 	key, err := crypto.GenerateKey()
 	if err != nil {
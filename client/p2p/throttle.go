@@ -0,0 +1,90 @@
+package p2p
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// defaultConnThrottleAttempts is the number of connection attempts allowed per
+// remote IP within the throttle window if not configured otherwise.
+const defaultConnThrottleAttempts = 3
+
+// connThrottle limits the number of connection attempts per remote IP within a
+// time window, so a single host churning connections can't monopolize the
+// pending handshake slots.
+type connThrottle struct {
+	window   time.Duration
+	attempts int
+	exempt   map[string]bool // IPs never throttled, e.g. those of trusted nodes
+
+	mu      sync.Mutex
+	entries map[string]*throttleEntry
+	swept   time.Time // last time expired entries were dropped
+}
+
+// throttleEntry counts the attempts of a single IP in its current window.
+type throttleEntry struct {
+	start time.Time
+	count int
+}
+
+// newConnThrottle creates a throttle allowing the given number of attempts per
+// IP within window. It returns nil if window is zero, which allows everything.
+func newConnThrottle(window time.Duration, attempts int, exempt []net.IP) *connThrottle {
+	if window <= 0 {
+		return nil
+	}
+	if attempts <= 0 {
+		attempts = defaultConnThrottleAttempts
+	}
+	t := &connThrottle{
+		window:   window,
+		attempts: attempts,
+		exempt:   make(map[string]bool, len(exempt)),
+		entries:  make(map[string]*throttleEntry),
+	}
+	for _, ip := range exempt {
+		t.exempt[ip.String()] = true
+	}
+	return t
+}
+
+// allow records a connection attempt from ip at the given time, reporting
+// whether it's within the limit. Rejected attempts don't count against the
+// limit, so a host can connect again once its window is over.
+func (t *connThrottle) allow(ip net.IP, now time.Time) bool {
+	if t == nil {
+		return true
+	}
+	key := ip.String()
+	if t.exempt[key] {
+		return true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if now.Sub(t.swept) >= t.window {
+		t.sweep(now)
+	}
+	entry := t.entries[key]
+	if entry == nil || now.Sub(entry.start) >= t.window {
+		t.entries[key] = &throttleEntry{start: now, count: 1}
+		return true
+	}
+	if entry.count >= t.attempts {
+		return false
+	}
+	entry.count++
+	return true
+}
+
+// sweep drops the entries whose window is over.
+func (t *connThrottle) sweep(now time.Time) {
+	for key, entry := range t.entries {
+		if now.Sub(entry.start) >= t.window {
+			delete(t.entries, key)
+		}
+	}
+	t.swept = now
+}
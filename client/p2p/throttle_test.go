@@ -0,0 +1,64 @@
+package p2p
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestConnThrottle(t *testing.T) {
+	var (
+		start   = time.Now()
+		ip      = net.IP{10, 0, 0, 1}
+		other   = net.IP{10, 0, 0, 2}
+		trusted = net.IP{10, 0, 0, 3}
+	)
+	throttle := newConnThrottle(time.Minute, 2, []net.IP{trusted})
+
+	tests := []struct {
+		ip    net.IP
+		after time.Duration
+		allow bool
+	}{
+		{ip, 0, true},
+		{ip, time.Second, true},
+		{ip, 2 * time.Second, false}, // over the limit
+		{other, 2 * time.Second, true},
+		{trusted, 2 * time.Second, true},
+		{trusted, 2 * time.Second, true},
+		{trusted, 2 * time.Second, true}, // exempt
+		{ip, 59 * time.Second, false},
+		{ip, time.Minute, true}, // new window
+		{ip, time.Minute, true},
+		{ip, time.Minute, false},
+	}
+	for i, tt := range tests {
+		if allow := throttle.allow(tt.ip, start.Add(tt.after)); allow != tt.allow {
+			t.Errorf("attempt %d: %v after %v: allowed %t, want %t", i, tt.ip, tt.after, allow, tt.allow)
+		}
+	}
+}
+
+func TestConnThrottleSweep(t *testing.T) {
+	start := time.Now()
+	throttle := newConnThrottle(time.Minute, 1, nil)
+	for i := 0; i < 10; i++ {
+		throttle.allow(net.IP{10, 0, 0, byte(i)}, start)
+	}
+	throttle.allow(net.IP{10, 0, 1, 0}, start.Add(2*time.Minute))
+	if len(throttle.entries) != 1 {
+		t.Fatalf("expired entries not swept: have %d entries, want 1", len(throttle.entries))
+	}
+}
+
+func TestConnThrottleDisabled(t *testing.T) {
+	throttle := newConnThrottle(0, 1, nil)
+	if throttle != nil {
+		t.Fatal("throttle created with a zero window")
+	}
+	for i := 0; i < 10; i++ {
+		if !throttle.allow(net.IP{10, 0, 0, 1}, time.Now()) {
+			t.Fatal("disabled throttle rejected an attempt")
+		}
+	}
+}
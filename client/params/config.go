@@ -3,6 +3,7 @@ package params
 import (
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/kowala-tech/kcoin/client/common"
 )
@@ -33,11 +34,22 @@ var (
 	// means that all fields must be set at all times. This forces
 	// anyone adding flags to the config to also have to set these
 	// fields.
-	AllKonsensusProtocolChanges = &ChainConfig{big.NewInt(2), new(KonsensusConfig)}
-	TestChainConfig             = &ChainConfig{big.NewInt(1), new(KonsensusConfig)}
+	AllKonsensusProtocolChanges = &ChainConfig{big.NewInt(2), new(KonsensusConfig), nil}
+	TestChainConfig             = &ChainConfig{big.NewInt(1), new(KonsensusConfig), nil}
 	TestRules                   = TestChainConfig.Rules(new(big.Int))
 )
 
+// IsPublicChainID reports whether the chain ID identifies one of the public
+// Kowala networks.
+func IsPublicChainID(chainID *big.Int) bool {
+	for _, config := range []*ChainConfig{MainnetChainConfig, TestnetChainConfig} {
+		if config.ChainID.Cmp(chainID) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // ChainConfig is the core config which determines the blockchain settings.
 //
 // ChainConfig is stored in the database on a per block basis. This means
@@ -48,16 +60,115 @@ type ChainConfig struct {
 
 	// Various consensus engines
 	Konsensus *KonsensusConfig `json:"konsensus,omitempty"`
+
+	Forks []Fork `json:"forks,omitempty"` // Protocol changes in activation order
+}
+
+// Fork is a named protocol change activated from a block number onwards.
+type Fork struct {
+	Name  string   `json:"name"`
+	Block *big.Int `json:"block"`
+}
+
+// NormalizedVotersFork is the name of the fork from which proposers are elected
+// with weights kept bounded, as by types.NewNormalizedVoters.
+const NormalizedVotersFork = "normalizedVoters"
+
+// ForkBlock returns the activation block of the named fork, or nil if the fork
+// isn't scheduled.
+func (c *ChainConfig) ForkBlock(name string) *big.Int {
+	for _, fork := range c.Forks {
+		if fork.Name == name {
+			return fork.Block
+		}
+	}
+	return nil
+}
+
+// IsForked returns whether the named fork is active at the given block number.
+func (c *ChainConfig) IsForked(name string, num *big.Int) bool {
+	return isForked(c.ForkBlock(name), num)
 }
 
 // KonsensusConfig is the consensus engine configs for proof-of-stake based sealing.
-type KonsensusConfig struct{}
+// Zero values fall back to the protocol defaults.
+type KonsensusConfig struct {
+	BlockTime        uint64 `json:"blockTime,omitempty"`        // Target time between blocks in milliseconds
+	ProposeTimeout   uint64 `json:"proposeTimeout,omitempty"`   // Base timeout of the propose step in milliseconds
+	PreVoteTimeout   uint64 `json:"preVoteTimeout,omitempty"`   // Base timeout of the pre-vote step in milliseconds
+	PreCommitTimeout uint64 `json:"preCommitTimeout,omitempty"` // Base timeout of the pre-commit step in milliseconds
+	CommitTimeout    uint64 `json:"commitTimeout,omitempty"`    // Maximum wait after a commit for the next height in milliseconds (0 = unbounded)
+}
+
+// BlockDuration returns the target time between blocks.
+func (c *KonsensusConfig) BlockDuration() time.Duration {
+	if c == nil || c.BlockTime == 0 {
+		return time.Duration(BlockTime) * time.Millisecond
+	}
+	return time.Duration(c.BlockTime) * time.Millisecond
+}
+
+// ProposeDurationAt returns the timeout of the propose step in the given round.
+func (c *KonsensusConfig) ProposeDurationAt(round uint64) time.Duration {
+	base := ProposeDuration
+	if c != nil && c.ProposeTimeout != 0 {
+		base = c.ProposeTimeout
+	}
+	return time.Duration(base+round*ProposeDeltaDuration) * time.Millisecond
+}
+
+// PreVoteDurationAt returns the timeout of the pre-vote step in the given round.
+func (c *KonsensusConfig) PreVoteDurationAt(round uint64) time.Duration {
+	base := PreVoteDuration
+	if c != nil && c.PreVoteTimeout != 0 {
+		base = c.PreVoteTimeout
+	}
+	return time.Duration(base+round*PreVoteDeltaDuration) * time.Millisecond
+}
+
+// PreCommitDurationAt returns the timeout of the pre-commit step in the given round.
+// Unlike the other steps, the delta is added once and each round only adds a
+// millisecond, the schedule validators have always run the pre-commit step with.
+func (c *KonsensusConfig) PreCommitDurationAt(round uint64) time.Duration {
+	base := PreCommitDuration
+	if c != nil && c.PreCommitTimeout != 0 {
+		base = c.PreCommitTimeout
+	}
+	return time.Duration(base+round+PreCommitDeltaDuration) * time.Millisecond
+}
+
+// CommitDuration returns the maximum time the commit phase waits for the start
+// of the next height, or zero if the wait is unbounded.
+func (c *KonsensusConfig) CommitDuration() time.Duration {
+	if c == nil {
+		return 0
+	}
+	return time.Duration(c.CommitTimeout) * time.Millisecond
+}
+
+// Validate checks that the commit timeout, if set, isn't shorter than the block
+// time. A shorter one would start the next height before the block time of the
+// previous block has elapsed.
+func (c *KonsensusConfig) Validate() error {
+	if timeout := c.CommitDuration(); timeout > 0 && timeout < c.BlockDuration() {
+		return fmt.Errorf("commit timeout %v shorter than the block time %v", timeout, c.BlockDuration())
+	}
+	return nil
+}
 
 // String implements the stringer interface, returning the consensus engine details.
 func (c *KonsensusConfig) String() string {
 	return "konsensus"
 }
 
+// WithChainID returns a copy of the configuration identifying the chain with
+// another chain ID.
+func (c *ChainConfig) WithChainID(chainID *big.Int) *ChainConfig {
+	cpy := *c
+	cpy.ChainID = new(big.Int).Set(chainID)
+	return &cpy
+}
+
 // String implements the fmt.Stringer interface.
 func (c *ChainConfig) String() string {
 	var engine interface{}
@@ -102,9 +213,32 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if !configNumEqual(c.ChainID, newcfg.ChainID) {
 		return newCompatError("Chain ID", c.ChainID, newcfg.ChainID)
 	}
+	for _, forks := range [][]Fork{c.Forks, newcfg.Forks} {
+		for _, fork := range forks {
+			stored, new := c.ForkBlock(fork.Name), newcfg.ForkBlock(fork.Name)
+			if isForkIncompatible(stored, new, head) {
+				return newCompatError(fork.Name+" fork block", stored, new)
+			}
+		}
+	}
 	return nil
 }
 
+// isForkIncompatible returns true if a fork scheduled at s1 cannot be rescheduled
+// to block s2 because head is already past the fork.
+func isForkIncompatible(s1, s2, head *big.Int) bool {
+	return (isForked(s1, head) || isForked(s2, head)) && !configNumEqual(s1, s2)
+}
+
+// isForked returns whether a fork scheduled at block s is active at the given
+// head block.
+func isForked(s, head *big.Int) bool {
+	if s == nil || head == nil {
+		return false
+	}
+	return s.Cmp(head) <= 0
+}
+
 func configNumEqual(x, y *big.Int) bool {
 	if x == nil {
 		return y == nil
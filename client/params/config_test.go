@@ -4,6 +4,7 @@ import (
 	"math/big"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestCheckCompatible(t *testing.T) {
@@ -28,6 +29,34 @@ func TestCheckCompatible(t *testing.T) {
 				RewindTo:     0,
 			},
 		},
+		{
+			stored:  &ChainConfig{ChainID: big.NewInt(1), Forks: []Fork{{"andromeda", big.NewInt(10)}}},
+			new:     &ChainConfig{ChainID: big.NewInt(1), Forks: []Fork{{"andromeda", big.NewInt(20)}}},
+			head:    9,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{ChainID: big.NewInt(1), Forks: []Fork{{"andromeda", big.NewInt(10)}}},
+			new:    &ChainConfig{ChainID: big.NewInt(1), Forks: []Fork{{"andromeda", big.NewInt(20)}}},
+			head:   25,
+			wantErr: &ConfigCompatError{
+				What:         "andromeda fork block",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(20),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored: &ChainConfig{ChainID: big.NewInt(1)},
+			new:    &ChainConfig{ChainID: big.NewInt(1), Forks: []Fork{{"andromeda", big.NewInt(5)}}},
+			head:   5,
+			wantErr: &ConfigCompatError{
+				What:         "andromeda fork block",
+				StoredConfig: nil,
+				NewConfig:    big.NewInt(5),
+				RewindTo:     4,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -37,3 +66,72 @@ func TestCheckCompatible(t *testing.T) {
 		}
 	}
 }
+
+func TestKonsensusConfigTiming(t *testing.T) {
+	var unset *KonsensusConfig
+	if have, want := unset.BlockDuration(), time.Second; have != want {
+		t.Errorf("default block time mismatch: have %v, want %v", have, want)
+	}
+	for round, want := range []time.Duration{225 * time.Millisecond, 226 * time.Millisecond, 227 * time.Millisecond} {
+		if have := new(KonsensusConfig).PreCommitDurationAt(uint64(round)); have != want {
+			t.Errorf("default pre-commit timeout of round %d mismatch: have %v, want %v", round, have, want)
+		}
+	}
+
+	c := &KonsensusConfig{BlockTime: 200, ProposeTimeout: 100, PreVoteTimeout: 50, PreCommitTimeout: 40}
+	tests := []struct {
+		name       string
+		have, want time.Duration
+	}{
+		{"block time", c.BlockDuration(), 200 * time.Millisecond},
+		{"propose", c.ProposeDurationAt(0), 100 * time.Millisecond},
+		{"propose round 2", c.ProposeDurationAt(2), 150 * time.Millisecond},
+		{"pre-vote round 1", c.PreVoteDurationAt(1), 75 * time.Millisecond},
+		{"pre-commit round 1", c.PreCommitDurationAt(1), 66 * time.Millisecond},
+		{"commit", (&KonsensusConfig{CommitTimeout: 300}).CommitDuration(), 300 * time.Millisecond},
+		{"unbounded commit", c.CommitDuration(), 0},
+	}
+	for _, test := range tests {
+		if test.have != test.want {
+			t.Errorf("%s: timeout mismatch: have %v, want %v", test.name, test.have, test.want)
+		}
+	}
+}
+
+func TestKonsensusConfigValidate(t *testing.T) {
+	tests := []struct {
+		config *KonsensusConfig
+		valid  bool
+	}{
+		{nil, true},
+		{&KonsensusConfig{}, true},
+		{&KonsensusConfig{CommitTimeout: 1000}, true},
+		{&KonsensusConfig{CommitTimeout: 999}, false},
+		{&KonsensusConfig{BlockTime: 200, CommitTimeout: 200}, true},
+		{&KonsensusConfig{BlockTime: 200, CommitTimeout: 100}, false},
+	}
+	for i, test := range tests {
+		if err := test.config.Validate(); (err == nil) != test.valid {
+			t.Errorf("test %d: validity mismatch: have %v, want valid %v", i, err, test.valid)
+		}
+	}
+}
+
+func TestChainConfigWithChainID(t *testing.T) {
+	c := MainnetChainConfig.WithChainID(big.NewInt(1337))
+	if c.ChainID.Cmp(big.NewInt(1337)) != 0 {
+		t.Errorf("chain ID mismatch: have %v, want 1337", c.ChainID)
+	}
+	if c.Konsensus != MainnetChainConfig.Konsensus {
+		t.Error("consensus configuration not kept")
+	}
+	if MainnetChainConfig.ChainID.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("original configuration modified: chain ID %v", MainnetChainConfig.ChainID)
+	}
+	if IsPublicChainID(c.ChainID) {
+		t.Errorf("chain ID %v reported as public", c.ChainID)
+	}
+	if !IsPublicChainID(TestnetChainConfig.ChainID) {
+		t.Errorf("test network chain ID %v not reported as public", TestnetChainConfig.ChainID)
+	}
+}
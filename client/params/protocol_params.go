@@ -64,4 +64,5 @@ const (
 	PreCommitDuration      uint64 = 200
 	PreCommitDeltaDuration uint64 = 25
 	BlockTime              uint64 = 1000
+	MinimumSafeBlockTime   uint64 = 500 // Block times below this rarely leave room to gather the votes
 )
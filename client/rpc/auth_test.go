@@ -0,0 +1,116 @@
+package rpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"golang.org/x/net/websocket"
+)
+
+var testAuthSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func TestAuthHandler(t *testing.T) {
+	handler := newAuthHandler(testAuthSecret, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	valid, _ := NewAuthToken(testAuthSecret)
+	forged, _ := NewAuthToken([]byte("fedcba9876543210fedcba9876543210"))
+	stale, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{IssuedAt: time.Now().Add(-2 * authTokenMaxAge).Unix()}).SignedString(testAuthSecret)
+	unissued, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{}).SignedString(testAuthSecret)
+
+	tests := []struct {
+		header string
+		code   int
+	}{
+		{"", http.StatusUnauthorized},
+		{valid, http.StatusUnauthorized}, // missing Bearer scheme
+		{"Bearer " + valid, http.StatusOK},
+		{"Bearer " + forged, http.StatusUnauthorized},
+		{"Bearer " + stale, http.StatusUnauthorized},
+		{"Bearer " + unissued, http.StatusUnauthorized},
+		{"Bearer garbage", http.StatusUnauthorized},
+	}
+	for i, tt := range tests {
+		request := httptest.NewRequest(http.MethodPost, "http://url.com", strings.NewReader(""))
+		if tt.header != "" {
+			request.Header.Set("Authorization", tt.header)
+		}
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		if response.Code != tt.code {
+			t.Errorf("test %d: response code mismatch: have %d, want %d", i, response.Code, tt.code)
+		}
+	}
+}
+
+func TestAuthBehindCORS(t *testing.T) {
+	listener, server, err := StartHTTPEndpoint("127.0.0.1:0", nil, nil, []string{"http://example.com"}, []string{"*"}, testAuthSecret, RateLimits{}, 0)
+	if err != nil {
+		t.Fatalf("failed to start HTTP endpoint: %v", err)
+	}
+	defer server.Stop()
+	defer listener.Close()
+
+	url := "http://" + listener.Addr().String()
+
+	// Preflight requests carry no credentials and must be answered regardless
+	preflight, _ := http.NewRequest(http.MethodOptions, url, nil)
+	preflight.Header.Set("Origin", "http://example.com")
+	preflight.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	preflight.Header.Set("Access-Control-Request-Headers", "authorization,content-type")
+	resp, err := http.DefaultClient.Do(preflight)
+	if err != nil {
+		t.Fatalf("preflight request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("preflight status mismatch: have %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if origin := resp.Header.Get("Access-Control-Allow-Origin"); origin != "http://example.com" {
+		t.Errorf("preflight allowed origin mismatch: have %q, want %q", origin, "http://example.com")
+	}
+
+	// Rejected requests carry the CORS headers, so the browser can report them
+	request, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(`{}`))
+	request.Header.Set("Origin", "http://example.com")
+	request.Header.Set("Content-Type", "application/json")
+	resp, err = http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("unauthenticated request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("unauthenticated status mismatch: have %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+	if origin := resp.Header.Get("Access-Control-Allow-Origin"); origin != "http://example.com" {
+		t.Errorf("rejection allowed origin mismatch: have %q, want %q", origin, "http://example.com")
+	}
+}
+
+func TestAuthWebsocketHandshake(t *testing.T) {
+	listener, server, err := StartWSEndpoint("127.0.0.1:0", nil, nil, []string{"*"}, false, testAuthSecret, RateLimits{}, 0)
+	if err != nil {
+		t.Fatalf("failed to start websocket endpoint: %v", err)
+	}
+	defer server.Stop()
+	defer listener.Close()
+
+	config, err := websocket.NewConfig("ws://"+listener.Addr().String(), "http://localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn, err := websocket.DialConfig(config); err == nil {
+		conn.Close()
+		t.Fatal("unauthenticated handshake succeeded")
+	}
+	token, _ := NewAuthToken(testAuthSecret)
+	config.Header = http.Header{"Authorization": {"Bearer " + token}}
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		t.Fatalf("authenticated handshake failed: %v", err)
+	}
+	conn.Close()
+}
@@ -1,13 +1,34 @@
 package rpc
 
 import (
+	"crypto/tls"
+	"fmt"
 	"net"
 
 	"github.com/kowala-tech/kcoin/client/log"
 )
 
-// StartHTTPEndpoint starts the HTTP RPC endpoint, configured with cors/vhosts/modules
-func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []string, vhosts []string) (net.Listener, *Server, error) {
+// StartHTTPEndpoint starts the HTTP RPC endpoint, configured with cors/vhosts/modules.
+// If an authentication secret is given, requests need to carry a bearer token
+// signed with it. Requests exceeding the given rate limits and batches of more
+// than batchLimit requests are rejected, a zero batchLimit leaves batches unbounded.
+func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []string, vhosts []string, authSecret []byte, rateLimits RateLimits, batchLimit int) (net.Listener, *Server, error) {
+	return startHTTPEndpoint(endpoint, apis, modules, cors, vhosts, authSecret, rateLimits, batchLimit, nil)
+}
+
+// StartHTTPSEndpoint starts the HTTP RPC endpoint over TLS, serving the PEM encoded
+// certificate and private key loaded from the given files.
+func StartHTTPSEndpoint(endpoint string, apis []API, modules []string, cors []string, vhosts []string, authSecret []byte, rateLimits RateLimits, batchLimit int, certFile, keyFile string) (net.Listener, *Server, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load TLS certificate: %v", err)
+	}
+	return startHTTPEndpoint(endpoint, apis, modules, cors, vhosts, authSecret, rateLimits, batchLimit, &tls.Config{Certificates: []tls.Certificate{cert}})
+}
+
+// startHTTPEndpoint starts the HTTP RPC endpoint, wrapping the listener with TLS
+// if a configuration is given.
+func startHTTPEndpoint(endpoint string, apis []API, modules []string, cors []string, vhosts []string, authSecret []byte, rateLimits RateLimits, batchLimit int, tlsConfig *tls.Config) (net.Listener, *Server, error) {
 	// Generate the whitelist based on the allowed modules
 	whitelist := make(map[string]bool)
 	for _, module := range modules {
@@ -23,6 +44,10 @@ func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []str
 			log.Debug("HTTP registered", "namespace", api.Namespace)
 		}
 	}
+	if rateLimits.enabled() {
+		handler.limiter = newRateLimiter(rateLimits)
+	}
+	handler.batchLimit = batchLimit
 	// All APIs registered, start the HTTP listener
 	var (
 		listener net.Listener
@@ -31,12 +56,19 @@ func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []str
 	if listener, err = net.Listen("tcp", endpoint); err != nil {
 		return nil, nil, err
 	}
-	go NewHTTPServer(cors, vhosts, handler).Serve(listener)
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+	server := newHTTPServer(cors, vhosts, authSecret, handler)
+	go server.Serve(listener)
 	return listener, handler, err
 }
 
-// StartWSEndpoint starts a websocket endpoint
-func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []string, exposeAll bool) (net.Listener, *Server, error) {
+// StartWSEndpoint starts a websocket endpoint. If an authentication secret is
+// given, the handshake needs to carry a bearer token signed with it. Requests
+// exceeding the given rate limits and batches of more than batchLimit requests
+// are rejected, a zero batchLimit leaves batches unbounded.
+func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []string, exposeAll bool, authSecret []byte, rateLimits RateLimits, batchLimit int) (net.Listener, *Server, error) {
 
 	// Generate the whitelist based on the allowed modules
 	whitelist := make(map[string]bool)
@@ -53,6 +85,10 @@ func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []
 			log.Debug("WebSocket registered", "service", api.Service, "namespace", api.Namespace)
 		}
 	}
+	if rateLimits.enabled() {
+		handler.limiter = newRateLimiter(rateLimits)
+	}
+	handler.batchLimit = batchLimit
 	// All APIs registered, start the HTTP listener
 	var (
 		listener net.Listener
@@ -61,7 +97,11 @@ func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []
 	if listener, err = net.Listen("tcp", endpoint); err != nil {
 		return nil, nil, err
 	}
-	go NewWSServer(wsOrigins, handler).Serve(listener)
+	server := NewWSServer(wsOrigins, handler)
+	if authSecret != nil {
+		server.Handler = newAuthHandler(authSecret, server.Handler)
+	}
+	go server.Serve(listener)
 	return listener, handler, err
 
 }
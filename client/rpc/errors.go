@@ -42,6 +42,22 @@ func (e *callbackError) ErrorCode() int { return -32000 }
 
 func (e *callbackError) Error() string { return e.message }
 
+// batch holds more requests than the server accepts
+type batchLimitError struct{ size, limit int }
+
+func (e *batchLimitError) ErrorCode() int { return -32600 }
+
+func (e *batchLimitError) Error() string {
+	return fmt.Sprintf("batch of %d requests exceeds the limit of %d", e.size, e.limit)
+}
+
+// client exceeded its request rate limit
+type rateLimitError struct{ method string }
+
+func (e *rateLimitError) ErrorCode() int { return -32005 }
+
+func (e *rateLimitError) Error() string { return fmt.Sprintf("rate limit exceeded for %s", e.method) }
+
 // issued when a request is received after the server is issued to stop.
 type shutdownError struct{}
 
@@ -146,8 +146,21 @@ func (t *httpReadWriteNopCloser) Close() error {
 //
 // Deprecated: Server implements http.Handler
 func NewHTTPServer(cors []string, vhosts []string, srv *Server) *http.Server {
+	return newHTTPServer(cors, vhosts, nil, srv)
+}
+
+// newHTTPServer creates a new HTTP RPC server around an API provider. If an
+// authentication secret is given, requests need to carry a bearer token signed
+// with it. The check runs within the CORS handler, so CORS preflight requests,
+// which never carry credentials, are answered and rejections carry the CORS
+// headers the browser needs to read them.
+func newHTTPServer(cors []string, vhosts []string, authSecret []byte, srv *Server) *http.Server {
+	var handler http.Handler = srv
+	if authSecret != nil {
+		handler = newAuthHandler(authSecret, handler)
+	}
 	// Wrap the CORS-handler within a host-handler
-	handler := newCorsHandler(srv, cors)
+	handler = newCorsHandler(handler, cors)
 	handler = newVHostHandler(vhosts, handler)
 	return &http.Server{
 		Handler:      handler,
@@ -201,10 +214,10 @@ func validateRequest(r *http.Request) (int, error) {
 	return 0, nil
 }
 
-func newCorsHandler(srv *Server, allowedOrigins []string) http.Handler {
+func newCorsHandler(next http.Handler, allowedOrigins []string) http.Handler {
 	// disable CORS support if user has not specified a custom CORS configuration
 	if len(allowedOrigins) == 0 {
-		return srv
+		return next
 	}
 	c := cors.New(cors.Options{
 		AllowedOrigins: allowedOrigins,
@@ -212,7 +225,7 @@ func newCorsHandler(srv *Server, allowedOrigins []string) http.Handler {
 		MaxAge:         600,
 		AllowedHeaders: []string{"*"},
 	})
-	return c.Handler(srv)
+	return c.Handler(next)
 }
 
 // virtualHostHandler is a handler which validates the Host-header of incoming requests.
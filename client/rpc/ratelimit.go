@@ -0,0 +1,111 @@
+package rpc
+
+import (
+	"context"
+	"math"
+	"net"
+	"sync"
+	"time"
+)
+
+// rateLimitSweepInterval is how often the buckets of idle clients are dropped.
+const rateLimitSweepInterval = time.Minute
+
+// RateLimits configures how many requests per second each client of an HTTP or
+// websocket RPC endpoint may issue. Clients are told apart by their IP address,
+// a zero rate leaves requests unlimited.
+type RateLimits struct {
+	Rate    float64            // Requests per second allowed for methods without a limit of their own
+	Methods map[string]float64 // Requests per second allowed per method name (e.g. kcoin_getLogs)
+}
+
+// enabled reports whether any limit is configured.
+func (l RateLimits) enabled() bool {
+	if l.Rate > 0 {
+		return true
+	}
+	for _, rate := range l.Methods {
+		if rate > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenBucket is the allowance of a single client, refilled at the limit's rate
+// up to a burst of one second worth of requests.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// take refills the bucket and attempts to consume a token from it.
+func (b *tokenBucket) take(rate float64, now time.Time) bool {
+	b.tokens = math.Min(math.Max(rate, 1), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// rateLimiter tracks the request allowance of the clients of an RPC server.
+type rateLimiter struct {
+	limits RateLimits
+
+	buckets map[string]*tokenBucket // Allowances keyed by client and limited method
+	swept   time.Time               // Last time idle buckets were dropped
+	lock    sync.Mutex
+}
+
+// newRateLimiter creates a limiter enforcing the given limits.
+func newRateLimiter(limits RateLimits) *rateLimiter {
+	return &rateLimiter{
+		limits:  limits,
+		buckets: make(map[string]*tokenBucket),
+		swept:   time.Now(),
+	}
+}
+
+// allow reports whether client may call method, consuming from its allowance.
+// Methods with a limit of their own are accounted separately from the rest.
+func (l *rateLimiter) allow(client, method string) bool {
+	rate, key := l.limits.Rate, client
+	if methodRate, ok := l.limits.Methods[method]; ok {
+		rate, key = methodRate, client+" "+method
+	}
+	if rate <= 0 {
+		return true
+	}
+	now := time.Now()
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if now.Sub(l.swept) > rateLimitSweepInterval {
+		for key, bucket := range l.buckets {
+			if now.Sub(bucket.last) > rateLimitSweepInterval {
+				delete(l.buckets, key)
+			}
+		}
+		l.swept = now
+	}
+	bucket := l.buckets[key]
+	if bucket == nil {
+		bucket = &tokenBucket{tokens: math.Max(rate, 1), last: now}
+		l.buckets[key] = bucket
+	}
+	return bucket.take(rate, now)
+}
+
+// clientAddr returns the IP address of the client issuing the request served
+// with the given context.
+func clientAddr(ctx context.Context) string {
+	remote, _ := ctx.Value("remote").(string)
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		return host
+	}
+	return remote
+}
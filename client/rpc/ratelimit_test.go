@@ -0,0 +1,81 @@
+package rpc
+
+import (
+	"testing"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(RateLimits{
+		Rate:    2,
+		Methods: map[string]float64{"test_echo": 1, "test_rets": 0},
+	})
+	tests := []struct {
+		client, method string
+		allow          bool
+	}{
+		// Methods without a limit of their own share the default allowance
+		{"10.0.0.1", "test_noArgsRets", true},
+		{"10.0.0.1", "test_sleep", true},
+		{"10.0.0.1", "test_noArgsRets", false},
+		// Methods with a limit are accounted separately
+		{"10.0.0.1", "test_echo", true},
+		{"10.0.0.1", "test_echo", false},
+		// A zero method limit exempts the method
+		{"10.0.0.1", "test_rets", true},
+		{"10.0.0.1", "test_rets", true},
+		{"10.0.0.1", "test_rets", true},
+		// Other clients have allowances of their own
+		{"10.0.0.2", "test_noArgsRets", true},
+		{"10.0.0.2", "test_echo", true},
+	}
+	for i, tt := range tests {
+		if allow := limiter.allow(tt.client, tt.method); allow != tt.allow {
+			t.Errorf("test %d: %s calling %s: allowed %v, want %v", i, tt.client, tt.method, allow, tt.allow)
+		}
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	if (RateLimits{}).enabled() {
+		t.Error("empty limits reported as enabled")
+	}
+	if (RateLimits{Methods: map[string]float64{"test_echo": 0}}).enabled() {
+		t.Error("exemption only limits reported as enabled")
+	}
+	if !(RateLimits{Methods: map[string]float64{"test_echo": 1}}).enabled() {
+		t.Error("method limits reported as disabled")
+	}
+}
+
+// Tests that requests over the limit are answered with a JSON-RPC error.
+func TestRateLimitedHTTPRequests(t *testing.T) {
+	apis := []API{{Namespace: "test", Version: "1.0", Service: new(Service), Public: true}}
+	listener, server, err := StartHTTPEndpoint("127.0.0.1:0", apis, nil, nil, []string{"*"}, nil, RateLimits{Methods: map[string]float64{"test_echo": 1}}, 0)
+	if err != nil {
+		t.Fatalf("failed to start HTTP endpoint: %v", err)
+	}
+	defer server.Stop()
+	defer listener.Close()
+
+	client, err := DialHTTP("http://" + listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial HTTP endpoint: %v", err)
+	}
+	defer client.Close()
+
+	var result Result
+	if err := client.Call(&result, "test_echo", "hello", 1, &Args{"world"}); err != nil {
+		t.Fatalf("first request failed: %v", err)
+	}
+	err = client.Call(&result, "test_echo", "hello", 2, &Args{"world"})
+	if err == nil {
+		t.Fatal("request over the limit succeeded")
+	}
+	if rpcErr, ok := err.(Error); !ok || rpcErr.ErrorCode() != -32005 {
+		t.Fatalf("unexpected error for request over the limit: %v", err)
+	}
+	// Methods without a limit are not affected
+	if err := client.Call(nil, "test_noArgsRets"); err != nil {
+		t.Fatalf("unlimited request failed: %v", err)
+	}
+}
@@ -145,6 +145,14 @@ func (s *Server) serveRequest(ctx context.Context, codec ServerCodec, singleShot
 	// test if the server is ordered to stop
 	for atomic.LoadInt32(&s.run) == 1 {
 		reqs, batch, err := s.readRequest(codec)
+		if _, ok := err.(*batchLimitError); ok {
+			// Reject oversized batches as a whole, but keep serving the connection
+			codec.Write(codec.CreateErrorResponse(nil, err))
+			if singleShot {
+				return nil
+			}
+			continue
+		}
 		if err != nil {
 			// If a parsing error occurred, send an error
 			if err.Error() != "EOF" {
@@ -261,6 +269,13 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 		return codec.CreateErrorResponse(&req.id, &invalidParamsError{"Expected subscription id as first argument"}), nil
 	}
 
+	if s.limiter != nil {
+		method := req.svcname + serviceMethodSeparator + formatName(req.callb.method.Name)
+		if !s.limiter.allow(clientAddr(ctx), method) {
+			return codec.CreateErrorResponse(&req.id, &rateLimitError{method}), nil
+		}
+	}
+
 	if req.callb.isSubscribe {
 		subid, err := s.createSubscription(ctx, codec, req)
 		if err != nil {
@@ -363,6 +378,9 @@ func (s *Server) readRequest(codec ServerCodec) ([]*serverRequest, bool, Error)
 	if err != nil {
 		return nil, batch, err
 	}
+	if batch && s.batchLimit > 0 && len(reqs) > s.batchLimit {
+		return nil, batch, &batchLimitError{len(reqs), s.batchLimit}
+	}
 
 	requests := make([]*serverRequest, len(reqs))
 
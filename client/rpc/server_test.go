@@ -144,3 +144,49 @@ func TestServerMethodExecution(t *testing.T) {
 func TestServerMethodWithCtx(t *testing.T) {
 	testServerMethodExecution(t, "echoWithCtx")
 }
+
+// Tests that batches over the limit are rejected as a whole without dropping
+// the connection, while smaller ones are served.
+func TestServerBatchLimit(t *testing.T) {
+	server := NewServer()
+	server.batchLimit = 2
+	if err := server.RegisterName("test", new(Service)); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+
+	go server.ServeCodec(NewJSONCodec(serverConn), OptionMethodInvocation)
+
+	out := json.NewEncoder(clientConn)
+	in := json.NewDecoder(clientConn)
+
+	batch := func(n int) []map[string]interface{} {
+		requests := make([]map[string]interface{}, n)
+		for i := range requests {
+			requests[i] = map[string]interface{}{"id": i, "method": "test_rets", "jsonrpc": "2.0"}
+		}
+		return requests
+	}
+	if err := out.Encode(batch(3)); err != nil {
+		t.Fatal(err)
+	}
+	var rejected jsonErrResponse
+	if err := in.Decode(&rejected); err != nil {
+		t.Fatalf("failed to decode response to oversized batch: %v", err)
+	}
+	if rejected.Error.Code != -32600 {
+		t.Fatalf("oversized batch error code mismatch: have %d, want %d", rejected.Error.Code, -32600)
+	}
+
+	if err := out.Encode(batch(2)); err != nil {
+		t.Fatal(err)
+	}
+	var responses []jsonSuccessResponse
+	if err := in.Decode(&responses); err != nil {
+		t.Fatalf("failed to decode response to batch within the limit: %v", err)
+	}
+	if len(responses) != 2 {
+		t.Fatalf("response count mismatch: have %d, want 2", len(responses))
+	}
+}
@@ -53,7 +53,9 @@ type subscriptions map[string]*callback  // collection of subscription callbacks
 
 // Server represents a RPC server
 type Server struct {
-	services serviceRegistry
+	services   serviceRegistry
+	limiter    *rateLimiter // Request rate limiter, nil if requests are unlimited
+	batchLimit int          // Maximum number of requests in a batch, zero if unlimited
 
 	run      int32
 	codecsMu sync.Mutex
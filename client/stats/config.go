@@ -7,7 +7,8 @@ import (
 )
 
 type Config struct {
-	URL string `toml:",omitempty"`
+	URL      string `toml:",omitempty"`
+	Insecure bool   `toml:",omitempty"` // Skip the verification of TLS server certificates
 }
 
 func (config *Config) GetURL() string {
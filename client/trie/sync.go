@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/kowala-tech/kcoin/client/common"
+	"github.com/kowala-tech/kcoin/client/crypto"
 	"github.com/kowala-tech/kcoin/client/kcoindb"
 	"gopkg.in/karalabe/cookiejar.v2/collections/prque"
 )
@@ -211,6 +212,60 @@ func (s *Sync) Commit(dbw kcoindb.Putter) (int, error) {
 	return written, nil
 }
 
+// Retrieved returns the data content of all nodes that have already been
+// downloaded, but cannot be committed yet as some of their children are still
+// missing. Together with the persisted database content, these are enough to
+// resume the sync without fetching the nodes again.
+func (s *Sync) Retrieved() [][]byte {
+	blobs := make([][]byte, 0, len(s.requests))
+	for _, req := range s.requests {
+		if req.data != nil {
+			blobs = append(blobs, req.data)
+		}
+	}
+	return blobs
+}
+
+// Restore injects previously retrieved node data (see Retrieved) into the
+// scheduler, walking down from the currently missing nodes so that every blob
+// is processed only once its parent is known. Blobs not referenced by the trie
+// are ignored. The number of restored nodes is returned.
+func (s *Sync) Restore(blobs [][]byte) (int, error) {
+	known := make(map[common.Hash][]byte, len(blobs))
+	for _, blob := range blobs {
+		known[crypto.Keccak256Hash(blob)] = blob
+	}
+	restored := 0
+	for len(known) > 0 {
+		var (
+			results []SyncResult
+			missing []common.Hash
+			prios   []float32
+		)
+		for !s.queue.Empty() {
+			item, prio := s.queue.Pop()
+			hash := item.(common.Hash)
+			if data, ok := known[hash]; ok {
+				results = append(results, SyncResult{Hash: hash, Data: data})
+				delete(known, hash)
+				continue
+			}
+			missing, prios = append(missing, hash), append(prios, prio)
+		}
+		for i, hash := range missing {
+			s.queue.Push(hash, prios[i])
+		}
+		if len(results) == 0 {
+			break
+		}
+		if _, index, err := s.Process(results); err != nil {
+			return restored + index, err
+		}
+		restored += len(results)
+	}
+	return restored, nil
+}
+
 // Pending returns the number of state entries currently pending for download.
 func (s *Sync) Pending() int {
 	return len(s.requests)
@@ -47,7 +47,7 @@ func (builder *KcoinNodeBuilder) NodeSpec() *NodeSpec {
 	portMapping := make(map[int32]int32, 0)
 
 	if builder.validate {
-		cmd = append(cmd, "--validate")
+		cmd = append(cmd, "--validate", "--validate.confirm")
 	}
 	if builder.rpcPort != nil {
 		cmd = append(cmd, "--rpc")
@@ -3,6 +3,7 @@ package impl
 import (
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"regexp"
 	"strconv"
 	"strings"
@@ -327,15 +328,15 @@ func (ctx *Context) buildGenesis() error {
 		PrefundedAccounts: ctx.getPrefundedAccounts(baseDeposit, []genesis.PrefundedAccount{
 			{
 				Address: ctx.genesisValidatorAccount.Address.Hex(),
-				Balance: baseDeposit * 100,
+				Balance: new(big.Int).SetUint64(baseDeposit * 100),
 			},
 			{
 				Address: "0x259be75d96876f2ada3d202722523e9cd4dd917d",
-				Balance: baseDeposit * 100,
+				Balance: new(big.Int).SetUint64(baseDeposit * 100),
 			},
 			{
 				Address: ctx.kusdSeederAccount.Address.Hex(),
-				Balance: baseDeposit * 10000,
+				Balance: new(big.Int).SetUint64(baseDeposit * 10000),
 			},
 		}...),
 	})
@@ -386,7 +387,7 @@ func (ctx *Context) getPrefundedAccounts(baseDeposit uint64, accs ...genesis.Pre
 	for _, acc := range ctx.mtokensGovernanceAccounts {
 		governors = append(governors, genesis.PrefundedAccount{
 			Address: acc.Address.Hex(),
-			Balance: baseDeposit * 10000,
+			Balance: new(big.Int).SetUint64(baseDeposit * 10000),
 		})
 	}
 
@@ -117,7 +117,7 @@ func (n *NodeSpecBuilder) WithChainID(chainID string) *NodeSpecBuilder {
 
 //AsValidator sets the node as a validator of blocks.
 func (n *NodeSpecBuilder) AsValidator() *NodeSpecBuilder {
-	n.addCmdArgs([]string{"--validate"})
+	n.addCmdArgs([]string{"--validate", "--validate.confirm"})
 	return n
 }
 
@@ -266,19 +266,19 @@ func (t *testnet) buildGenesis() error {
 		PrefundedAccounts: []genesis.PrefundedAccount{
 			{
 				Address: t.genesisValidatorAccount.Address.Hex(),
-				Balance: baseDeposit * 100,
+				Balance: new(big.Int).SetUint64(baseDeposit * 100),
 			},
 			{
 				Address: "0x259be75d96876f2ada3d202722523e9cd4dd917d",
-				Balance: baseDeposit * 100,
+				Balance: new(big.Int).SetUint64(baseDeposit * 100),
 			},
 			{
 				Address: t.seederAccount.Address.Hex(),
-				Balance: 1000000000000000000,
+				Balance: new(big.Int).SetUint64(1000000000000000000),
 			},
 			{
 				Address: t.mtokensSeederAccount.Address.Hex(),
-				Balance: baseDeposit * 10000,
+				Balance: new(big.Int).SetUint64(baseDeposit * 10000),
 			},
 		},
 	})